@@ -0,0 +1,76 @@
+package ast
+
+// AccessChain describes an access of the root data that only uses identifiers and literal keys, such as
+// $.a["b"][0].c. It is computed once by ResolveAccessChains, so the chain can be followed without inspecting the
+// nodes and literals it is made of.
+type AccessChain struct {
+	// Steps are the accesses after the root ($), in the order they are applied. A named root, such as $steps, is
+	// the field of the root data with its name, so it is the first step of its chains.
+	Steps []AccessStep
+	// Root is the name of the named root the chain starts at, such as "steps" for $steps, or empty if the chain starts
+	// at $.
+	Root string
+}
+
+// AccessStep is a single access of an AccessChain.
+type AccessStep struct {
+	// Key is the field name for dot notation, and the value of the literal for bracket accesses.
+	Key any
+	// Bracket is true if the key is accessed with brackets.
+	Bracket bool
+}
+
+// ResolveAccessChains sets the Chain of each dot notation and bracket accessor in the tree that is part of an access
+// chain of the root data. Accesses that contain subexpressions, or start at a function call or at the variables
+// ($vars), are left without a chain.
+func ResolveAccessChains(root Node) {
+	Walk(root, VisitorFuncs{
+		// The chain of a node extends the chain of its left node, so the children are resolved first.
+		ExitFunc: func(node Node) {
+			switch n := node.(type) {
+			case *DotNotation:
+				root, steps, isChain := accessChainSteps(n.LeftAccessibleNode)
+				identifier, isIdentifier := n.RightAccessIdentifier.(*Identifier)
+				if isChain && isIdentifier && !identifier.IsRoot() {
+					n.Chain = &AccessChain{Root: root, Steps: append(steps[:len(steps):len(steps)], AccessStep{
+						Key: identifier.IdentifierName,
+					})}
+				}
+			case *BracketAccessor:
+				root, steps, isChain := accessChainSteps(n.LeftNode)
+				literal, isLiteral := n.RightExpression.(ValueLiteral)
+				if isChain && isLiteral {
+					n.Chain = &AccessChain{Root: root, Steps: append(steps[:len(steps):len(steps)], AccessStep{
+						Key:     literal.Value(),
+						Bracket: true,
+					})}
+				}
+			}
+		},
+	})
+}
+
+// accessChainSteps returns the name of the named root and the steps of the access chain that ends at the node, and
+// whether the node is part of an access chain.
+func accessChainSteps(node Node) (string, []AccessStep, bool) {
+	switch n := node.(type) {
+	case *Identifier:
+		switch rootName := n.RootName(); rootName {
+		case VariablesRootName:
+			return "", nil, false
+		case "":
+		default:
+			return rootName, []AccessStep{{Key: rootName}}, true
+		}
+		return "", nil, n.IdentifierName == "$"
+	case *DotNotation:
+		if n.Chain != nil {
+			return n.Chain.Root, n.Chain.Steps, true
+		}
+	case *BracketAccessor:
+		if n.Chain != nil {
+			return n.Chain.Root, n.Chain.Steps, true
+		}
+	}
+	return "", nil, false
+}
@@ -0,0 +1,60 @@
+package ast
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+func TestResolveAccessChains(t *testing.T) {
+	node := parseForWalkTest(t, `$.a["b"][1].c`)
+	ResolveAccessChains(node)
+	dotNotation := node.(*DotNotation)
+	assert.Equals(t, dotNotation.Chain.Steps, []AccessStep{
+		{Key: "a"},
+		{Key: "b", Bracket: true},
+		{Key: int64(1), Bracket: true},
+		{Key: "c"},
+	})
+	// Each node of the chain has the chain up to the node.
+	bracketAccessor := dotNotation.LeftAccessibleNode.(*BracketAccessor)
+	assert.Equals(t, len(bracketAccessor.Chain.Steps), 3)
+}
+
+func TestResolveAccessChains_NotChains(t *testing.T) {
+	testCases := map[string]string{
+		"subexpression key":   `$.a[$.b].c`,
+		"function call root":  `f().a`,
+		"implicit root":       `a.b`,
+		"bracket on function": `f()["a"]`,
+		"variables":           `$vars.a.b`,
+	}
+	for name, expression := range testCases {
+		t.Run(name, func(t *testing.T) {
+			node := parseForWalkTest(t, expression)
+			ResolveAccessChains(node)
+			_, _, isChain := accessChainSteps(node)
+			assert.Equals(t, isChain, false)
+		})
+	}
+}
+
+func TestResolveAccessChains_Nested(t *testing.T) {
+	// The key is not a literal, so the outer access is not a chain, but the key itself is.
+	node := parseForWalkTest(t, `$.a[$.b.c]`)
+	ResolveAccessChains(node)
+	bracketAccessor := node.(*BracketAccessor)
+	assert.Nil(t, bracketAccessor.Chain)
+	assert.Equals(t, bracketAccessor.LeftNode.(*DotNotation).Chain.Steps, []AccessStep{{Key: "a"}})
+	assert.Equals(t, bracketAccessor.RightExpression.(*DotNotation).Chain.Steps, []AccessStep{{Key: "b"}, {Key: "c"}})
+}
+
+func TestResolveAccessChains_NamedRoot(t *testing.T) {
+	node := parseForWalkTest(t, `$steps.a["b"]`)
+	ResolveAccessChains(node)
+	bracketAccessor := node.(*BracketAccessor)
+	assert.Equals(t, bracketAccessor.Chain, &AccessChain{
+		Root:  "steps",
+		Steps: []AccessStep{{Key: "steps"}, {Key: "a"}, {Key: "b", Bracket: true}},
+	})
+}
@@ -0,0 +1,40 @@
+package ast
+
+// Chunks of the node arena start small, so short expressions do not reserve memory for nodes they do not have, and
+// grow up to the maximum size for long expressions.
+const (
+	minArenaChunkSize = 4
+	maxArenaChunkSize = 256
+)
+
+// slab allocates values of a type in chunks, so many values only need a few heap allocations. The values are never
+// moved, so the returned pointers stay valid, and a chunk is freed when none of its values are referenced anymore.
+type slab[T any] struct {
+	chunk []T
+}
+
+// new returns a pointer to a copy of the value, stored in the current chunk.
+func (s *slab[T]) new(value T) *T {
+	if len(s.chunk) == cap(s.chunk) {
+		size := min(max(2*cap(s.chunk), minArenaChunkSize), maxArenaChunkSize)
+		s.chunk = make([]T, 0, size)
+	}
+	s.chunk = append(s.chunk, value)
+	return &s.chunk[len(s.chunk)-1]
+}
+
+// nodeArena allocates the nodes of a parsed expression, so parsing creates far fewer heap objects. The nodes of an
+// expression are stored next to each other, and are released together with the AST.
+type nodeArena struct {
+	stringLiterals   slab[StringLiteral]
+	intLiterals      slab[IntLiteral]
+	floatLiterals    slab[FloatLiteral]
+	booleanLiterals  slab[BooleanLiteral]
+	bracketAccessors slab[BracketAccessor]
+	identifiers      slab[Identifier]
+	dotNotations     slab[DotNotation]
+	functionCalls    slab[FunctionCall]
+	argumentLists    slab[ArgumentList]
+	binaryOperations slab[BinaryOperation]
+	unaryOperations  slab[UnaryOperation]
+}
@@ -0,0 +1,500 @@
+// Package ast is designed to tokenize and parse Arcaflow expressions. The package contains representations of the
+// components of the abstract tree representation of use of the grammar.
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	invalid = "INVALID/MISSING"
+)
+
+// Node represents any node in the abstract syntax tree.
+// Left() and Right() can return nil for any node types that do not
+// have left and right sides.
+type Node interface {
+	String() string
+	// Start returns the position of the first character of the node in the source expression.
+	Start() Position
+	// End returns the position directly after the last character of the node in the source expression.
+	End() Position
+}
+
+// Position is a location in the source expression. Lines and columns start at 1.
+// A zero Position means that the position is not known, for example because
+// the node was not created by the parser.
+type Position struct {
+	Line   int
+	Column int
+	// Offset is the byte offset of the position from the start of the expression, starting at 0. Unlike the line
+	// and column, it is not changed by the position offset of the parser, so it can be used to slice the expression.
+	Offset int
+}
+
+// String returns the position in the line:column format.
+func (p Position) String() string {
+	return strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+}
+
+// IsValid returns true if the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// NodeSpan holds the start and end position of a node. It is embedded in all node types.
+type NodeSpan struct {
+	StartPos Position
+	EndPos   Position
+}
+
+// Start returns the position of the first character of the node.
+func (s NodeSpan) Start() Position {
+	return s.StartPos
+}
+
+// End returns the position directly after the last character of the node.
+func (s NodeSpan) End() Position {
+	return s.EndPos
+}
+
+type BinaryNode interface {
+	Left() Node
+	Right() Node
+}
+
+type NNode interface {
+	NumChildren() int
+	GetChild(int) (Node, error)
+}
+
+// ValueLiteral represents any kind of literals that can be represented
+// by the abstract syntax tree. Examples: ints, strings.
+type ValueLiteral interface {
+	Value() interface{}
+	String() string
+}
+
+// StringLiteral represents a string literal value in the abstract syntax
+// tree.
+type StringLiteral struct {
+	NodeSpan
+	StrValue string
+	// Literal is the literal as written in the expression, including the quotes. It is empty if the node was not
+	// created by the parser.
+	Literal string
+}
+
+// String returns the literal as written in the expression, if it still holds the value. Otherwise, it returns the
+// string surrounded by double quotes.
+func (l *StringLiteral) String() string {
+	quoted := len(l.Literal) >= 2 && l.Literal[0] == l.Literal[len(l.Literal)-1]
+	if quoted && unquoteStringLiteral(l.Literal) == l.StrValue {
+		return l.Literal
+	}
+	return `"` + l.StrValue + `"`
+}
+
+// Value returns the string contained. It can be cast to a string.
+func (l *StringLiteral) Value() interface{} {
+	return l.StrValue
+}
+
+// IntLiteral represents an integer literal value in the abstract syntax
+// tree. Duration and byte size literals, such as 5m30s and 2Gi, are also
+// integer literals, with the value in seconds or bytes, as are numbers with
+// an exponent, such as 1e6, if integer exponents are enabled.
+type IntLiteral struct {
+	NodeSpan
+	IntValue int64
+	// Literal is the literal as written in the expression if it is a
+	// duration, byte size, or number with an exponent, and empty for plain
+	// integers.
+	Literal string
+}
+
+// String returns a string representation of the integer contained, or the
+// literal as written for duration, byte size, and exponent literals.
+func (l *IntLiteral) String() string {
+	if l.Literal != "" {
+		return l.Literal
+	}
+	return strconv.FormatInt(l.IntValue, 10) // Format in base 10
+}
+
+// Value returns the integer contained.
+func (l *IntLiteral) Value() interface{} {
+	return l.IntValue
+}
+
+// FloatLiteral represents a floating point literal value in the abstract syntax
+// tree.
+type FloatLiteral struct {
+	NodeSpan
+	FloatValue float64
+	// Literal is the literal as written in the expression. It is empty if the node was not created by the parser.
+	Literal string
+}
+
+// String returns the literal as written in the expression, if it still holds the value. Otherwise, it returns a
+// string representation of the float contained.
+func (l *FloatLiteral) String() string {
+	if l.Literal != "" {
+		if parsed, err := strconv.ParseFloat(l.Literal, 64); err == nil && parsed == l.FloatValue {
+			return l.Literal
+		}
+	}
+	// 'f' for full float, instead of exponential format.
+	// The third arg, prec, is -1 to give an exact output.
+	// The fourth arg specifies that we're using 64-bit floats.
+	return strconv.FormatFloat(l.FloatValue, 'f', -1, 64)
+}
+
+// Value returns the float contained.
+func (l *FloatLiteral) Value() interface{} {
+	return l.FloatValue
+}
+
+// BooleanLiteral represents a boolean literal value in the abstract syntax
+// tree. true or false
+type BooleanLiteral struct {
+	NodeSpan
+	BooleanValue bool
+}
+
+// String returns a string representation of the boolean contained.
+func (l *BooleanLiteral) String() string {
+	return strconv.FormatBool(l.BooleanValue)
+}
+
+// Value returns the float contained.
+func (l *BooleanLiteral) Value() interface{} {
+	return l.BooleanValue
+}
+
+// BracketAccessor represents a part of the abstract syntax tree that is accessing
+// the value at a key in a map/object, or index of a list.
+// The format is the value to the left, followed by an open/right square bracket, followed
+// by the key, followed by a close/left square bracket.
+type BracketAccessor struct {
+	NodeSpan
+	LeftNode        Node
+	RightExpression Node
+	// Chain is the precomputed access chain ending at this node, or nil if the access is not a chain of literal
+	// accesses of the root data. It is set by ResolveAccessChains.
+	Chain *AccessChain
+}
+
+// Right returns the key.
+func (m *BracketAccessor) Right() Node {
+	return m.RightExpression
+}
+
+// Left returns the node being accessed.
+func (m *BracketAccessor) Left() Node {
+	return m.LeftNode
+}
+
+// String returns the string from the accessed node, followed by '[', followed
+// by the string from the key, followed by ']'.
+func (m *BracketAccessor) String() string {
+	return m.LeftNode.String() + "[" + m.RightExpression.String() + "]"
+}
+
+// Identifier represents a valid identifier in the abstract syntax tree.
+type Identifier struct {
+	NodeSpan
+	IdentifierName string
+}
+
+// String returns the identifier name.
+func (i *Identifier) String() string {
+	return i.IdentifierName
+}
+
+// VariablesRootName is the name of the named root that holds the variables passed when evaluating an expression, as
+// in $vars.name. Unlike other named roots, it is not a field of the root data.
+const VariablesRootName = "vars"
+
+// IsRoot returns true if the identifier accesses the root data, either as $ or as a named root, such as $steps.
+func (i *Identifier) IsRoot() bool {
+	return strings.HasPrefix(i.IdentifierName, "$")
+}
+
+// RootName returns the name of the named root the identifier accesses, such as "steps" for $steps. It returns an
+// empty string for $ and for identifiers that are not roots.
+func (i *Identifier) RootName() string {
+	if !i.IsRoot() {
+		return ""
+	}
+	return i.IdentifierName[1:]
+}
+
+// DotNotation represents the access of an identifier in a node.
+type DotNotation struct {
+	NodeSpan
+	// The identifier on the right of the dot
+	RightAccessIdentifier Node
+	// The expression on the left could be one of several nodes.
+	// I.e. An Identifier, a MapAccessor, or another DotNotation
+	LeftAccessibleNode Node
+	// Chain is the precomputed access chain ending at this node, or nil if the access is not a chain of literal
+	// accesses of the root data. It is set by ResolveAccessChains.
+	Chain *AccessChain
+}
+
+// Right returns the identifier being accessed in the left node.
+func (d *DotNotation) Right() Node {
+	return d.RightAccessIdentifier
+}
+
+// Left returns the left node being accessed.
+func (d *DotNotation) Left() Node {
+	return d.LeftAccessibleNode
+}
+
+// String returns the string representing the left node, followed by '.',
+// followed by the string representing the right identifier.
+func (d *DotNotation) String() string {
+	if d == nil {
+		return invalid
+	}
+	var left, right string
+	if d.LeftAccessibleNode != nil {
+		left = d.LeftAccessibleNode.String()
+	} else {
+		left = invalid
+	}
+	if d.RightAccessIdentifier != nil {
+		right = d.RightAccessIdentifier.String()
+	} else {
+		right = invalid
+	}
+	return left + "." + right
+}
+
+// FunctionCall represents a call to a function with 0 or more parameters.
+type FunctionCall struct {
+	NodeSpan
+	FuncIdentifier *Identifier
+	ArgumentInputs *ArgumentList
+}
+
+// Right returns nil, because an identifier does not branch left and right.
+func (f *FunctionCall) Right() Node {
+	return f.ArgumentInputs
+}
+
+// Left returns nil, because an identifier does not branch left and right.
+func (f *FunctionCall) Left() Node {
+	return f.FuncIdentifier
+}
+
+// String returns the identifier name.
+func (f *FunctionCall) String() string {
+	return f.FuncIdentifier.String() + "(" + f.ArgumentInputs.String() + ")"
+}
+
+// ArgumentList is a list of expressions being used to specify values to input into function parameters.
+type ArgumentList struct {
+	NodeSpan
+	Arguments []Node
+}
+
+func (l *ArgumentList) NumChildren() int {
+	return len(l.Arguments)
+}
+func (l *ArgumentList) GetChild(index int) (Node, error) {
+	if index >= len(l.Arguments) {
+		return nil, fmt.Errorf("index requested is out of bounds. Got %d, expected less than %d",
+			index, len(l.Arguments))
+	}
+	return l.Arguments[index], nil
+}
+
+// String gives a comma-separated list of the arguments
+func (l *ArgumentList) String() string {
+	if len(l.Arguments) == 0 {
+		return ""
+	}
+	result := l.Arguments[0].String()
+	for i := 1; i < len(l.Arguments); i++ {
+		result += ", " + l.Arguments[i].String()
+	}
+	return result
+}
+
+type MathOperationType int
+
+const (
+	Invalid MathOperationType = iota
+	Add
+	Subtract
+	Multiply
+	Divide
+	Modulus
+	Power
+	EqualTo
+	NotEqualTo
+	GreaterThan
+	LessThan
+	GreaterThanEqualTo
+	LessThanEqualTo
+	And
+	Or
+	Not
+)
+
+func (e MathOperationType) String() string {
+	switch e {
+	case Invalid:
+		return "INVALID"
+	case Add:
+		return "+"
+	case Subtract:
+		return "-"
+	case Multiply:
+		return "*"
+	case Divide:
+		return "÷"
+	case Modulus:
+		return "%"
+	case Power:
+		return "^"
+	case EqualTo:
+		return "=="
+	case NotEqualTo:
+		return "!="
+	case GreaterThan:
+		return ">"
+	case LessThan:
+		return "<"
+	case GreaterThanEqualTo:
+		return ">="
+	case LessThanEqualTo:
+		return "<="
+	case And:
+		return "&&"
+	case Or:
+		return "||"
+	case Not:
+		return "!"
+	default:
+		return "ENTRY MISSING"
+	}
+}
+
+// token returns the operator as it is written in expressions.
+func (e MathOperationType) token() string {
+	if e == Divide {
+		return "/"
+	}
+	return e.String()
+}
+
+// precedence returns how strongly the binary operator binds its operands. Operators with a higher precedence are
+// applied first.
+func (e MathOperationType) precedence() int {
+	switch e {
+	case Or:
+		return 1
+	case And:
+		return 2
+	case EqualTo, NotEqualTo, GreaterThan, LessThan, GreaterThanEqualTo, LessThanEqualTo:
+		return 3
+	case Add, Subtract:
+		return 4
+	case Multiply, Divide, Modulus:
+		return 5
+	case Power:
+		return 6
+	default:
+		return 0
+	}
+}
+
+type BinaryOperation struct {
+	NodeSpan
+	LeftNode  Node
+	RightNode Node
+	Operation MathOperationType
+}
+
+func (b *BinaryOperation) Right() Node {
+	return b.RightNode
+}
+
+func (b *BinaryOperation) Left() Node {
+	return b.LeftNode
+}
+
+// String returns the left node, followed by the operator, followed by the right node. Since parentheses are not
+// retained in the tree, the operands are surrounded by parentheses only where they are needed to keep the evaluation
+// order of the tree when the result is parsed again, such as in (a + b) * c.
+func (b *BinaryOperation) String() string {
+	left := b.LeftNode.String()
+	if NeedsParenthesesOnLeft(b.Operation, b.LeftNode) {
+		left = "(" + left + ")"
+	}
+	right := b.RightNode.String()
+	if NeedsParenthesesOnRight(b.Operation, b.RightNode) {
+		right = "(" + right + ")"
+	}
+	return left + " " + b.Operation.token() + " " + right
+}
+
+// NeedsParenthesesOnLeft returns true if the left operand of the operation must be surrounded by parentheses. All
+// binary operators are left-associative, so only operations with a lower precedence need them. A unary operator
+// applies to the rest of the expression, so an operand that ends with one needs them too.
+func NeedsParenthesesOnLeft(operation MathOperationType, operand Node) bool {
+	if binary, isBinary := operand.(*BinaryOperation); isBinary && binary.Operation.precedence() < operation.precedence() {
+		return true
+	}
+	return endsWithUnaryOperation(operand)
+}
+
+// NeedsParenthesesOnRight returns true if the right operand of the operation must be surrounded by parentheses.
+// Operations with the same precedence need them, since the operators are left-associative. The not operator can
+// only be written where a condition of && or || starts.
+func NeedsParenthesesOnRight(operation MathOperationType, operand Node) bool {
+	switch n := operand.(type) {
+	case *BinaryOperation:
+		return n.Operation.precedence() <= operation.precedence()
+	case *UnaryOperation:
+		return n.LeftOperation == Not && operation != And && operation != Or
+	default:
+		return false
+	}
+}
+
+// endsWithUnaryOperation returns true if the string representation of the node ends with a unary operation that is
+// not surrounded by parentheses.
+func endsWithUnaryOperation(node Node) bool {
+	switch n := node.(type) {
+	case *UnaryOperation:
+		return true
+	case *BinaryOperation:
+		return !NeedsParenthesesOnRight(n.Operation, n.RightNode) && endsWithUnaryOperation(n.RightNode)
+	default:
+		return false
+	}
+}
+
+type UnaryOperation struct {
+	NodeSpan
+	LeftOperation MathOperationType
+	RightNode     Node
+}
+
+// String returns the operation, followed by the string representation of the right node. Operations are surrounded
+// by parentheses, since the operator applies to the rest of the expression, which is easily misread, as in -(a + b).
+func (b *UnaryOperation) String() string {
+	switch b.RightNode.(type) {
+	case *BinaryOperation, *UnaryOperation:
+		return b.LeftOperation.token() + "(" + b.RightNode.String() + ")"
+	default:
+		return b.LeftOperation.token() + b.RightNode.String()
+	}
+}
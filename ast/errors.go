@@ -0,0 +1,158 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InvalidTokenError represents an error when the tokenizer doesn't recognise
+// the token pattern. This is often caused by invalid characters, or characters
+// in the wrong order.
+type InvalidTokenError struct {
+	InvalidToken TokenValue
+	// Reason describes why the token is not valid, such as a string that is not closed.
+	Reason string
+	// Snippet is the line of the expression containing the token, followed by a line that marks the token.
+	// It is empty if the source is not known.
+	Snippet string
+}
+
+func (e *InvalidTokenError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+// LocalizedError returns the message of the error like Error does, with each message it is made of, including the
+// reason and the hint, formatted by the localize function. See expressions.LocalizableError.
+func (e *InvalidTokenError) LocalizedError(localize func(format string, args ...any) string) string {
+	errorMsg := localize("Invalid token \"%s\" in %s at line %d:%d",
+		e.InvalidToken.Value, e.InvalidToken.Filename, e.InvalidToken.Line, e.InvalidToken.Column)
+	if e.Reason != "" {
+		errorMsg = localize("%s; %s", errorMsg, localize(e.Reason))
+	}
+	return withSnippetAndHint(localize, errorMsg, e.Snippet, e.Hint())
+}
+
+// Hint returns a short suggestion on how to fix the error.
+func (e *InvalidTokenError) Hint() string {
+	value := e.InvalidToken.Value
+	switch {
+	case strings.HasPrefix(value, `"`) || strings.HasPrefix(value, `'`) || strings.HasPrefix(value, "`"):
+		return "check that the string is closed with a matching quote"
+	case hasLeadingZero(value):
+		return "remove the leading zeros, such as 7 instead of 07"
+	case value != "" && value[0] >= '0' && value[0] <= '9':
+		return "durations use the units d, h, m, and s, such as 1h30m, and byte sizes use units such as 512MB or 2Gi"
+	default:
+		return "check for unsupported characters"
+	}
+}
+
+// NestingDepthError is returned when an expression is nested deeper than the limit of the parser, such as with many
+// nested parentheses or unary operators.
+type NestingDepthError struct {
+	MaxDepth int
+	Filename string
+	// Position is the position of the expression that exceeds the limit.
+	Position Position
+}
+
+func (e *NestingDepthError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+// LocalizedError returns the message of the error like Error does, formatted by the localize function. See
+// expressions.LocalizableError.
+func (e *NestingDepthError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize("Expression nested more than %d levels deep in %s at line %s", e.MaxDepth, e.Filename, e.Position)
+}
+
+// InvalidGrammarError represents when the order of tokens is not valid for
+// the language.
+type InvalidGrammarError struct {
+	FoundToken     *TokenValue
+	ExpectedTokens []TokenID // Nil for end, no expected token
+	// Snippet is the line of the expression containing the found token, followed by a line that marks the token.
+	// If no token was found, the end of the expression is marked. It is empty if the source is not known.
+	Snippet string
+}
+
+func (e *InvalidGrammarError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+// LocalizedError returns the message of the error like Error does, with each message it is made of, including the
+// hint, formatted by the localize function. See expressions.LocalizableError.
+func (e *InvalidGrammarError) LocalizedError(localize func(format string, args ...any) string) string {
+	var found string
+	if e.FoundToken != nil {
+		found = localize("Token %q of ID %q placed in invalid configuration in %q at line %d:%d",
+			e.FoundToken.Value, e.FoundToken.TokenID, e.FoundToken.Filename, e.FoundToken.Line, e.FoundToken.Column)
+	} else {
+		found = localize("Expected token not found")
+	}
+	var expected string
+	switch {
+	case e.ExpectedTokens == nil:
+		expected = localize("expected end of expression.")
+	case len(e.ExpectedTokens) == 0:
+		expected = localize("expected any token.")
+	case len(e.ExpectedTokens) == 1:
+		expected = localize("expected token \"%v\"", e.ExpectedTokens[0])
+	default:
+		expected = localize("expected one of tokens \"%v\"", e.ExpectedTokens)
+	}
+	return withSnippetAndHint(localize, localize("%s; %s", found, expected), e.Snippet, e.Hint())
+}
+
+// Hint returns a short suggestion on how to fix the error.
+func (e *InvalidGrammarError) Hint() string {
+	switch {
+	case e.FoundToken == nil:
+		return "the expression ended unexpectedly; check for a missing value or closing bracket"
+	case e.ExpectedTokens == nil:
+		return "check for a missing operator, or an extra closing bracket or parenthesis"
+	case len(e.ExpectedTokens) == 1 && e.ExpectedTokens[0] == EqualsToken:
+		return "use '==' to compare values"
+	case len(e.ExpectedTokens) == 1 && e.ExpectedTokens[0] == AndToken:
+		return "use '&&' for a logical and"
+	case len(e.ExpectedTokens) == 1 && e.ExpectedTokens[0] == OrToken:
+		return "use '||' for a logical or"
+	case sliceContains(e.ExpectedTokens, IdentifierToken) && e.FoundToken.TokenID != IdentifierToken:
+		return "a value, a reference, or a function call is expected here"
+	default:
+		return ""
+	}
+}
+
+// messageError is an error whose message can be translated, like the errors created by expressions.Errorf.
+type messageError struct {
+	format string
+	args   []any
+}
+
+// errorf creates an error with the message formatted like fmt.Sprintf does, which can be translated.
+func errorf(format string, args ...any) error {
+	return &messageError{format: format, args: args}
+}
+
+func (e *messageError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+// LocalizedError returns the message of the error formatted by the localize function. See
+// expressions.LocalizableError.
+func (e *messageError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize(e.format, e.args...)
+}
+
+// withSnippetAndHint appends the snippet and the hint, if present, to the error message. The hint is formatted by the
+// localize function.
+func withSnippetAndHint(localize func(format string, args ...any) string, errorMsg string, snippet string, hint string) string {
+	if snippet != "" {
+		errorMsg += "\n" + snippet
+	}
+	if hint != "" {
+		errorMsg = localize("%s\nHint: %s", errorMsg, localize(hint))
+	}
+	return errorMsg
+}
@@ -0,0 +1,184 @@
+package ast
+
+// GrammarRules is the grammar of expressions in Backus–Naur form. The operators that are written with two characters,
+// such as &&, are two tokens.
+const GrammarRules = `<root_expression> ::= <or_expression>
+<or_expression> ::= <and_expression> [ "|" "|" <and_expression> ]
+<and_expression> ::= <not_expression> [ "&" "&" <not_expression> ]
+<not_expression> ::= [ "!" ] <comparison_expression>
+<comparison_expression> ::= <add_sub_expression> [ <comparison_operator> <add_sub_expression> ]
+<comparison_operator> ::= ">" | "<" | ">" "=" | "<" "=" | "=" "=" | "!" "="
+<add_sub_expression> ::= <multiply_divide_expression> [ <add_sub_operator> <multiply_divide_expression>]
+<add_sub_operator> ::=  "+" | "-"
+<multiply_divide_expression> ::= <exponents_expression> [ <multiply_divide_operator> <exponents_expression> ]
+<multiply_divide_operator> ::=  "*" | "/" | "%"
+<exponents_expression> ::= <parentheses_expression> [ "^" <parentheses_expression> ]
+<parentheses_expression> ::= <negation_expression> | "(" <root_expression> ")"
+<negation_expression> ::= ["-"] <value_or_access_expression>
+<value_or_access_expression> ::= <literal> | <identifier_or_function> [ <chained_access> ]
+<identifier_or_function> := IdentifierToken | <function_call>
+<function_call> := <function_name> "(" [ <argument_list> ] ")"
+<function_name> := IdentifierToken [ <namespace_separator> <function_name> ]
+<namespace_separator> := "." | ":" ":"
+<chained_access> := <chainable_access> [ <chained_access> ]
+<chainable_access> := <dot_notation> | <bracket_access>
+<dot_notation> := "." IdentifierToken
+<bracket_access> := "[" <root_expression> "]"
+<literal> := IntLiteralToken | StringLiteralToken | RawStringLiteralToken | FloatLiteralToken | BooleanLiteralToken | DurationLiteralToken | ByteSizeLiteralToken
+<argument_list> := <root_expression> [ "," <argument_list> ]
+`
+
+// Grammar describes the syntax of expressions as data, so documentation generators, syntax highlighters, and external
+// validators can be generated from it instead of repeating it. It is encoded with snake_case keys as JSON.
+type Grammar struct {
+	// Tokens are the kinds of tokens Lex returns, except UnknownToken.
+	Tokens []TokenDescription `json:"tokens"`
+	// Keywords are the words that are not identifiers.
+	Keywords []string `json:"keywords"`
+	// Operators are the unary and binary operators, from the lowest to the highest precedence.
+	Operators []OperatorDescription `json:"operators"`
+	// NodeKinds are the types of the nodes of the syntax tree.
+	NodeKinds []NodeKindDescription `json:"node_kinds"`
+	// Rules is the grammar in Backus–Naur form, see GrammarRules.
+	Rules string `json:"rules"`
+}
+
+// TokenDescription describes a kind of token.
+type TokenDescription struct {
+	ID          TokenID `json:"id"`
+	Description string  `json:"description"`
+	// Pattern is a regular expression in the RE2 syntax matching the tokens of this kind.
+	Pattern  string   `json:"pattern"`
+	Examples []string `json:"examples"`
+}
+
+// OperatorDescription describes an operator.
+type OperatorDescription struct {
+	// Symbol is the operator as it is written, such as "&&".
+	Symbol    string            `json:"symbol"`
+	Operation MathOperationType `json:"-"`
+	// Name is the name of the operation, such as "and".
+	Name string `json:"name"`
+	// Unary is true for the prefix operators - and !, which apply to the rest of the expression they precede, so
+	// -a + b is -(a + b).
+	Unary bool `json:"unary"`
+	// Precedence is how strongly a binary operator binds its operands. Operators with a higher precedence are applied
+	// first. All binary operators are left-associative. It is 0 for unary operators.
+	Precedence int `json:"precedence"`
+}
+
+// NodeKindDescription describes a type of node of the syntax tree.
+type NodeKindDescription struct {
+	// Name is the name of the Go type of the node, such as "BinaryOperation".
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// operationNames are the names of the operations in the grammar description.
+var operationNames = map[MathOperationType]string{
+	Add: "add", Subtract: "subtract", Multiply: "multiply", Divide: "divide", Modulus: "modulus", Power: "power",
+	EqualTo: "equal-to", NotEqualTo: "not-equal-to", GreaterThan: "greater-than", LessThan: "less-than",
+	GreaterThanEqualTo: "greater-than-or-equal-to", LessThanEqualTo: "less-than-or-equal-to", And: "and", Or: "or",
+	Not: "not",
+}
+
+// DescribeGrammar returns the description of the grammar of expressions. Each call returns a new value, which the
+// caller may modify.
+func DescribeGrammar() Grammar {
+	operators := []OperatorDescription{
+		{Operation: Not, Unary: true},
+		{Operation: Subtract, Unary: true},
+	}
+	for _, operation := range []MathOperationType{
+		Or, And, EqualTo, NotEqualTo, GreaterThan, LessThan, GreaterThanEqualTo, LessThanEqualTo,
+		Add, Subtract, Multiply, Divide, Modulus, Power,
+	} {
+		operators = append(operators, OperatorDescription{Operation: operation, Precedence: operation.precedence()})
+	}
+	for i := range operators {
+		operators[i].Symbol = operators[i].Operation.token()
+		operators[i].Name = operationNames[operators[i].Operation]
+		if operators[i].Unary && operators[i].Operation == Subtract {
+			operators[i].Name = "negate"
+		}
+	}
+	return Grammar{
+		Tokens:    describeTokens(),
+		Keywords:  []string{"true", "false"},
+		Operators: operators,
+		NodeKinds: []NodeKindDescription{
+			{"StringLiteral", "A string literal, with the escape sequences resolved in StrValue."},
+			{"IntLiteral", "An integer literal, including duration and byte size literals, which are integers."},
+			{"FloatLiteral", "A floating point literal."},
+			{"BooleanLiteral", "The literal true or false."},
+			{"Identifier", "The root ($), a named root such as $steps, a field name, or the name of a function."},
+			{"DotNotation", "The access of a field of the value on the left, as in a.b."},
+			{"BracketAccessor", "The access of a map key or list index of the value on the left, as in a[0]."},
+			{"FunctionCall", "The call of a function with the arguments in an ArgumentList."},
+			{"ArgumentList", "The arguments of a function call."},
+			{"BinaryOperation", "An operator applied to the operands on its left and right, as in a + b."},
+			{"UnaryOperation", "The operator - or ! applied to the rest of the expression, as in -a."},
+		},
+		Rules: GrammarRules,
+	}
+}
+
+// describeTokens returns the descriptions of the kinds of tokens.
+func describeTokens() []TokenDescription {
+	return []TokenDescription{
+		{IdentifierToken, "A field or function name.", `[A-Za-z_][A-Za-z0-9_]*`, []string{"foo", "to_upper"}},
+		{
+			StringLiteralToken,
+			"A string in double or single quotes, in which characters are escaped with a backslash.",
+			`"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'`,
+			[]string{`"hello"`, `'it\'s'`},
+		},
+		{RawStringLiteralToken, "A string in backticks, without escape sequences.", "`[^`\\n]*`", []string{"`a\\b`"}},
+		{IntLiteralToken, "An integer that does not start with 0, or 0.", `0|[1-9][0-9]*`, []string{"0", "42"}},
+		{
+			FloatLiteralToken,
+			"A floating point number with a fraction, an exponent, or both.",
+			`[0-9]+(?:\.[0-9]*(?:[eE][+-]?[0-9]+)?|[eE][+-]?[0-9]+)`,
+			[]string{"1.5", "5.", "1e6", "2.5e-3"},
+		},
+		{
+			DurationLiteralToken,
+			"A duration in days, hours, minutes, and seconds, which is the integer number of seconds.",
+			`[0-9]+(?:d(?:[0-9]+h)?(?:[0-9]+m)?(?:[0-9]+s)?|h(?:[0-9]+m)?(?:[0-9]+s)?|m(?:[0-9]+s)?|s)`,
+			[]string{"5m", "1h30m", "2d"},
+		},
+		{
+			ByteSizeLiteralToken,
+			"A size in bytes with a decimal or binary unit, which is the integer number of bytes.",
+			`[0-9]+(?:[KMGTP]i?B?|B)`,
+			[]string{"512B", "2Gi", "10MB"},
+		},
+		{BooleanLiteralToken, "The boolean true or false.", `true|false`, []string{"true", "false"}},
+		{BracketAccessDelimiterStartToken, "The start of a bracket access.", `\[`, []string{"["}},
+		{BracketAccessDelimiterEndToken, "The end of a bracket access.", `\]`, []string{"]"}},
+		{
+			ParenthesesStartToken, "The start of an argument list or a parenthesized expression.", `\(`,
+			[]string{"("},
+		},
+		{ParenthesesEndToken, "The end of an argument list or a parenthesized expression.", `\)`, []string{")"}},
+		{DotObjectAccessToken, "The dot of a field access.", `\.`, []string{"."}},
+		{RootAccessToken, "The root ($) or a named root, such as $steps.", `\$(?:[A-Za-z_][A-Za-z0-9_]*)?`,
+			[]string{"$", "$steps"}},
+		{CurrentObjectAccessToken, "The current object in a filter, which is reserved.", `@`, []string{"@"}},
+		{EqualsToken, "An equals sign, which is part of the ==, !=, >=, and <= operators.", `=`, []string{"="}},
+		{SelectorToken, "A colon, which separates the namespace of a function as ::.", `:`, []string{":"}},
+		{FilterToken, "The start of a filter, which is reserved.", `\?`, []string{"?"}},
+		{NegationToken, "The minus sign of subtraction and negation.", `-`, []string{"-"}},
+		{AsteriskToken, "The multiplication operator.", `\*`, []string{"*"}},
+		{ListSeparatorToken, "The separator of function arguments.", `,`, []string{","}},
+		{DivideToken, "The division operator.", `/`, []string{"/"}},
+		{GreaterThanToken, "The greater than sign of the comparison operators.", `>`, []string{">"}},
+		{LessThanToken, "The less than sign of the comparison operators.", `<`, []string{"<"}},
+		{PlusToken, "The addition operator.", `\+`, []string{"+"}},
+		{NotToken, "The not operator, and part of the != operator.", `!`, []string{"!"}},
+		{PowerToken, "The power operator.", `\^`, []string{"^"}},
+		{ModulusToken, "The modulus operator.", `%`, []string{"%"}},
+		{AndToken, "One of the two characters of the && operator.", `&`, []string{"&"}},
+		{OrToken, "One of the two characters of the || operator.", `\|`, []string{"|"}},
+	}
+}
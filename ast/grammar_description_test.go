@@ -0,0 +1,56 @@
+package ast
+
+import (
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+func TestDescribeGrammar_Tokens(t *testing.T) {
+	grammar := DescribeGrammar()
+	seen := map[TokenID]bool{}
+	for _, token := range grammar.Tokens {
+		assert.Equals(t, seen[token.ID], false)
+		seen[token.ID] = true
+		pattern := regexp.MustCompile(`^(?:` + token.Pattern + `)$`)
+		for _, example := range token.Examples {
+			t.Run(string(token.ID)+" "+example, func(t *testing.T) {
+				// The examples are lexed as a single token of the kind, and match its pattern.
+				tokens, err := Lex(example)
+				assert.NoError(t, err)
+				assert.Equals(t, len(tokens), 1)
+				assert.Equals(t, tokens[0].ID, token.ID)
+				assert.Equals(t, pattern.MatchString(example), true)
+			})
+		}
+	}
+	assert.Equals(t, seen[UnknownToken], false)
+	assert.Equals(t, len(seen), 30)
+}
+
+func TestDescribeGrammar_Operators(t *testing.T) {
+	grammar := DescribeGrammar()
+	assert.Equals(t, len(grammar.Operators), 16)
+	previousPrecedence := 0
+	for _, operator := range grammar.Operators {
+		assert.Equals(t, operator.Name != "", true)
+		if operator.Unary {
+			assert.Equals(t, operator.Precedence, 0)
+			continue
+		}
+		assert.Equals(t, operator.Precedence >= previousPrecedence, true)
+		previousPrecedence = operator.Precedence
+		// The binary operators parse with their symbol.
+		parser, err := InitParser("a "+operator.Symbol+" b", "")
+		assert.NoError(t, err)
+		root, err := parser.ParseExpression()
+		assert.NoError(t, err)
+		assert.Equals(t, root.(*BinaryOperation).Operation, operator.Operation)
+	}
+
+	encoded, err := json.Marshal(grammar)
+	assert.NoError(t, err)
+	assert.Contains(t, string(encoded), `{"symbol":"||","name":"or","unary":false,"precedence":1}`)
+}
@@ -8,6 +8,41 @@ import (
 	"go.arcalot.io/assert"
 )
 
+// withoutPositions clears the positions of all nodes in the tree, as well as the literals as written, so that parsed
+// trees can be compared with trees constructed in the tests. The positions and literals are tested separately.
+func withoutPositions[T Node](node T) T {
+	Inspect(node, func(node Node) bool {
+		switch n := node.(type) {
+		case *StringLiteral:
+			n.NodeSpan = NodeSpan{}
+			n.Literal = ""
+		case *IntLiteral:
+			n.NodeSpan = NodeSpan{}
+		case *FloatLiteral:
+			n.NodeSpan = NodeSpan{}
+			n.Literal = ""
+		case *BooleanLiteral:
+			n.NodeSpan = NodeSpan{}
+		case *BracketAccessor:
+			n.NodeSpan = NodeSpan{}
+		case *Identifier:
+			n.NodeSpan = NodeSpan{}
+		case *DotNotation:
+			n.NodeSpan = NodeSpan{}
+		case *FunctionCall:
+			n.NodeSpan = NodeSpan{}
+		case *ArgumentList:
+			n.NodeSpan = NodeSpan{}
+		case *BinaryOperation:
+			n.NodeSpan = NodeSpan{}
+		case *UnaryOperation:
+			n.NodeSpan = NodeSpan{}
+		}
+		return true
+	})
+	return node
+}
+
 func TestIdentifierParser(t *testing.T) {
 	identifierName := "abc"
 
@@ -55,14 +90,14 @@ func TestMapAccessParser(t *testing.T) {
 	mapResult, err := p.parseBracketAccess(&Identifier{IdentifierName: "a"})
 
 	assert.NoError(t, err)
-	assert.Equals[Node](t, mapResult.RightExpression, &IntLiteral{IntValue: int64(0)})
+	assert.Equals[Node](t, withoutPositions(mapResult.RightExpression), &IntLiteral{IntValue: int64(0)})
 	assert.InstanceOf[ValueLiteral](t, mapResult.RightExpression)
 	assert.Equals(t, mapResult.RightExpression.(ValueLiteral).Value().(int64), int64(0))
 
 	mapResult, err = p.parseBracketAccess(&Identifier{IdentifierName: "a"})
 
 	assert.NoError(t, err)
-	assert.Equals[Node](t, mapResult.RightExpression, &StringLiteral{StrValue: "a"})
+	assert.Equals[Node](t, withoutPositions(mapResult.RightExpression), &StringLiteral{StrValue: "a"})
 	assert.InstanceOf[ValueLiteral](t, mapResult.RightExpression)
 	assert.Equals(t, mapResult.RightExpression.(ValueLiteral).Value(), "a")
 
@@ -145,7 +180,7 @@ func TestRootVar(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *DotNotation")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestRootStrLiteral(t *testing.T) {
@@ -192,7 +227,7 @@ func TestDotNotation(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *DotNotation")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestMapAccess(t *testing.T) {
@@ -223,7 +258,7 @@ func TestMapAccess(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *BracketAccessor")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestDeepMapAccess(t *testing.T) {
@@ -266,7 +301,7 @@ func TestDeepMapAccess(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *BracketAccessor")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestCompound(t *testing.T) {
@@ -309,24 +344,24 @@ func TestCompound(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *DotNotation")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestAllBracketNotation(t *testing.T) {
 	expression := `$["a"]["b"][0]["c"]`
 
 	level4 := &BracketAccessor{}
-	level4.LeftNode = &Identifier{"$"}
-	level4.RightExpression = &StringLiteral{"a"}
+	level4.LeftNode = &Identifier{IdentifierName: "$"}
+	level4.RightExpression = &StringLiteral{StrValue: "a"}
 	level3 := &BracketAccessor{}
 	level3.LeftNode = level4
-	level3.RightExpression = &StringLiteral{"b"}
+	level3.RightExpression = &StringLiteral{StrValue: "b"}
 	level2 := &BracketAccessor{}
 	level2.LeftNode = level3
-	level2.RightExpression = &IntLiteral{0}
+	level2.RightExpression = &IntLiteral{IntValue: 0}
 	root := &BracketAccessor{}
 	root.LeftNode = level2
-	root.RightExpression = &StringLiteral{"c"}
+	root.RightExpression = &StringLiteral{StrValue: "c"}
 	// Create parser
 	p, err := InitParser(expression, t.Name())
 
@@ -343,7 +378,7 @@ func TestAllBracketNotation(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *BracketAccessor")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestEmptyExpression(t *testing.T) {
@@ -393,7 +428,7 @@ func TestSubExpression(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *BracketAccessor")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 
 func TestParseExpression_Error_TrailingDot(t *testing.T) {
@@ -408,6 +443,18 @@ func TestParseExpression_Error_TrailingDot(t *testing.T) {
 	assert.Contains(t, err.Error(), `expected token "identifier"`)
 }
 
+func TestParseExpression_Error_TrailingOperator(t *testing.T) {
+	// Test that an operator at the end of the input is a grammar error.
+	for _, expression := range []string{"$.a +", "1 *", "2 ^"} {
+		p, err := InitParser(expression, t.Name())
+		assert.NoError(t, err)
+
+		_, err = p.ParseExpression()
+		var grammarErr *InvalidGrammarError
+		assert.Equals(t, errors.As(err, &grammarErr), true)
+	}
+}
+
 func TestParseExpression_Error_BracketAfterLiteral(t *testing.T) {
 	// Test error message for bracket access after literal
 	expression := "0[0]"
@@ -468,7 +515,7 @@ func TestEmptyFunctionExpression(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *FunctionCall")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 func TestOneArgFunctionExpression(t *testing.T) {
 	expression := "funcName($.a)"
@@ -496,7 +543,7 @@ func TestOneArgFunctionExpression(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *FunctionCall")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 func TestMultiArgFunctionExpression(t *testing.T) {
 	expression := `funcName($.a, 5, "test")`
@@ -525,7 +572,7 @@ func TestMultiArgFunctionExpression(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *FunctionCall")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
 }
 func TestChainedFunctionExpression(t *testing.T) {
 	expression := "funcName().a"
@@ -554,7 +601,43 @@ func TestChainedFunctionExpression(t *testing.T) {
 	if !ok {
 		t.Fatalf("Output is not of type *DotNotation")
 	}
-	assert.Equals(t, parsedRoot, root)
+	assert.Equals(t, withoutPositions(parsedRoot), root)
+}
+
+func TestNamespacedFunctionExpression(t *testing.T) {
+	for _, expression := range []string{"math.abs($.a).b", "math::abs($.a).b"} {
+		arg := &DotNotation{
+			LeftAccessibleNode:    &Identifier{IdentifierName: "$"},
+			RightAccessIdentifier: &Identifier{IdentifierName: "a"},
+		}
+		root := &DotNotation{
+			LeftAccessibleNode: &FunctionCall{
+				FuncIdentifier: &Identifier{IdentifierName: "math.abs"},
+				ArgumentInputs: &ArgumentList{Arguments: []Node{arg}},
+			},
+			RightAccessIdentifier: &Identifier{IdentifierName: "b"},
+		}
+
+		p, err := InitParser(expression, t.Name())
+		assert.NoError(t, err)
+		parsedResult, err := p.ParseExpression()
+		assert.NoError(t, err)
+		// The name spans all namespaces, up to the argument list.
+		functionCall := parsedResult.(*DotNotation).LeftAccessibleNode.(*FunctionCall)
+		assert.Equals(t, functionCall.FuncIdentifier.Start(), Position{Line: 1, Column: 1})
+		assert.Equals(t, functionCall.FuncIdentifier.End(), Position{Line: 1, Column: len(expression) - 6, Offset: len(expression) - 7})
+		assert.Equals(t, withoutPositions(parsedResult), Node(root))
+		assert.Equals(t, parsedResult.String(), "math.abs($.a).b")
+	}
+}
+
+func TestNamespacedFunctionExpression_Invalid(t *testing.T) {
+	for _, expression := range []string{"math::abs", "math:abs()", "math.(1)", "$.math.abs()"} {
+		p, err := InitParser(expression, t.Name())
+		assert.NoError(t, err)
+		_, err = p.ParseExpression()
+		assert.Error(t, err)
+	}
 }
 
 func TestExpressionInvalidStart(t *testing.T) {
@@ -633,7 +716,7 @@ func TestExpression_SimpleAdd(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals(t, parsedResult.(*BinaryOperation), root)
+	assert.Equals(t, withoutPositions(parsedResult.(*BinaryOperation)), root)
 }
 
 func TestExpression_ThreeSub(t *testing.T) {
@@ -668,7 +751,7 @@ func TestExpression_ThreeSub(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_MixedAddMultiplicationDivision(t *testing.T) {
@@ -710,7 +793,7 @@ func TestExpression_MixedAddMultiplicationDivision(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_Power(t *testing.T) {
@@ -745,7 +828,7 @@ func TestExpression_Power(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_PowerParentheses(t *testing.T) {
@@ -780,7 +863,7 @@ func TestExpression_PowerParentheses(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_Parentheses(t *testing.T) {
@@ -815,7 +898,7 @@ func TestExpression_Parentheses(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_UnaryNegative(t *testing.T) {
@@ -849,7 +932,7 @@ func TestExpression_UnaryNegative(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_MultiNegationUnary(t *testing.T) {
@@ -873,7 +956,7 @@ func TestExpression_MultiNegationUnary(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*UnaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_MultiNegationUnaryParentheses(t *testing.T) {
@@ -893,7 +976,7 @@ func TestExpression_MultiNegationUnaryParentheses(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*UnaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_MultiNotUnary(t *testing.T) {
@@ -913,7 +996,7 @@ func TestExpression_MultiNotUnary(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*UnaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 // In the binary operator grammar tests, not all operators are tested
@@ -945,7 +1028,7 @@ func TestExpression_SimpleComparison(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_SimpleComparisonTwoToken(t *testing.T) {
@@ -972,7 +1055,7 @@ func TestExpression_SimpleComparisonTwoToken(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_ErrIncorrectEquals(t *testing.T) {
@@ -1028,7 +1111,7 @@ func TestExpression_MixedComparisons(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 func TestExpression_AndLogic(t *testing.T) {
 	expression := "true && false"
@@ -1055,7 +1138,7 @@ func TestExpression_AndLogic(t *testing.T) {
 	assert.NotNil(t, parsedResult)
 
 	assert.InstanceOf[*BinaryOperation](t, parsedResult)
-	assert.Equals[Node](t, parsedResult, root)
+	assert.Equals[Node](t, withoutPositions(parsedResult), root)
 }
 
 func TestExpression_AllTypes(t *testing.T) {
@@ -1282,3 +1365,344 @@ func TestParseString_EscapedStrings(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equals(t, result.StrValue, "'")
 }
+
+func TestParseString_RawStrings(t *testing.T) {
+	// Backslashes and quotes in raw strings are kept as they are.
+	node := withoutPositions(parseForWalkTest(t, "$[`a\\b`] + `\\n\"'`"))
+	assert.Equals[Node](t, node, &BinaryOperation{
+		LeftNode: &BracketAccessor{
+			LeftNode:        &Identifier{IdentifierName: "$"},
+			RightExpression: &StringLiteral{StrValue: `a\b`},
+		},
+		RightNode: &StringLiteral{StrValue: `\n"'`},
+		Operation: Add,
+	})
+	assert.Equals(t, parseForWalkTest(t, "`a\\b\"`").String(), "`a\\b\"`")
+}
+
+func TestFieldNamedLikeLiteral(t *testing.T) {
+	node := withoutPositions(parseForWalkTest(t, `$.true.false == true`))
+	assert.Equals[Node](t, node, &BinaryOperation{
+		LeftNode: &DotNotation{
+			LeftAccessibleNode: &DotNotation{
+				LeftAccessibleNode:    &Identifier{IdentifierName: "$"},
+				RightAccessIdentifier: &Identifier{IdentifierName: "true"},
+			},
+			RightAccessIdentifier: &Identifier{IdentifierName: "false"},
+		},
+		RightNode: &BooleanLiteral{BooleanValue: true},
+		Operation: EqualTo,
+	})
+	// Without a dot, the words are still literals.
+	assert.Equals[Node](t, withoutPositions(parseForWalkTest(t, `false`)), &BooleanLiteral{BooleanValue: false})
+	assert.Equals(t, parseForWalkTest(t, `a.true`).String(), `a.true`)
+}
+
+func TestLiteralString_AsWritten(t *testing.T) {
+	testCases := []string{
+		`$['a']`,
+		`$["a\"b"]`,
+		"f(`a\\b`, 'c')",
+		`1.50 + 2.0`,
+		`1.5e3 * 0.0`,
+		`'don\'t' == "it's"`,
+	}
+	for _, expression := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			node := parseForWalkTest(t, expression)
+			var literals []string
+			Inspect(node, func(node Node) bool {
+				switch n := node.(type) {
+				case *StringLiteral:
+					literals = append(literals, n.String())
+				case *FloatLiteral:
+					literals = append(literals, n.String())
+				}
+				return true
+			})
+			for _, literal := range literals {
+				assert.Contains(t, expression, literal)
+			}
+		})
+	}
+}
+
+func TestLiteralString_RoundTrip(t *testing.T) {
+	assert.Equals(t, parseForWalkTest(t, `$['a']`).String(), `$['a']`)
+	assert.Equals(t, parseForWalkTest(t, `$.a["b\"c"].d`).String(), `$.a["b\"c"].d`)
+}
+
+func TestLiteralString_Changed(t *testing.T) {
+	// Once the value is changed, the literal as written no longer applies.
+	stringLiteral := parseForWalkTest(t, `'a'`).(*StringLiteral)
+	assert.Equals(t, stringLiteral.String(), `'a'`)
+	stringLiteral.StrValue = "b"
+	assert.Equals(t, stringLiteral.String(), `"b"`)
+	floatLiteral := parseForWalkTest(t, `1.50`).(*FloatLiteral)
+	assert.Equals(t, floatLiteral.String(), `1.50`)
+	floatLiteral.FloatValue = 2
+	assert.Equals(t, floatLiteral.String(), `2`)
+	// Nodes constructed without a literal use the normalized form.
+	assert.Equals(t, (&StringLiteral{StrValue: "c"}).String(), `"c"`)
+	assert.Equals(t, (&FloatLiteral{FloatValue: 1.5}).String(), `1.5`)
+}
+
+func TestNodePositions(t *testing.T) {
+	// Leading whitespace must be included in the column numbers.
+	expression := `  f($.a["b"], 1) + -2.5`
+
+	p, err := InitParser(expression, t.Name())
+	assert.NoError(t, err)
+	parsedResult, err := p.ParseExpression()
+	assert.NoError(t, err)
+
+	positions := map[string][2]Position{}
+	Inspect(parsedResult, func(node Node) bool {
+		positions[node.String()] = [2]Position{node.Start(), node.End()}
+		return true
+	})
+	expected := map[string][2]Position{
+		`f($.a["b"], 1) + -2.5`: {{1, 3, 2}, {1, 24, 23}},
+		`f($.a["b"], 1)`:        {{1, 3, 2}, {1, 17, 16}},
+		`f`:                     {{1, 3, 2}, {1, 4, 3}},
+		`$.a["b"], 1`:           {{1, 4, 3}, {1, 17, 16}},
+		`$.a["b"]`:              {{1, 5, 4}, {1, 13, 12}},
+		`$.a`:                   {{1, 5, 4}, {1, 8, 7}},
+		`$`:                     {{1, 5, 4}, {1, 6, 5}},
+		`a`:                     {{1, 7, 6}, {1, 8, 7}},
+		`"b"`:                   {{1, 9, 8}, {1, 12, 11}},
+		`1`:                     {{1, 15, 14}, {1, 16, 15}},
+		`-2.5`:                  {{1, 20, 19}, {1, 24, 23}},
+		`2.5`:                   {{1, 21, 20}, {1, 24, 23}},
+	}
+	assert.Equals(t, positions, expected)
+}
+
+func TestNodePositions_MultiLine(t *testing.T) {
+	expression := "$.a +\n  10"
+
+	p, err := InitParser(expression, t.Name())
+	assert.NoError(t, err)
+	parsedResult, err := p.ParseExpression()
+	assert.NoError(t, err)
+	binaryOperation, ok := parsedResult.(*BinaryOperation)
+	if !ok {
+		t.Fatalf("Output is not of type *BinaryOperation")
+	}
+	assert.Equals(t, binaryOperation.Start(), Position{Line: 1, Column: 1})
+	assert.Equals(t, binaryOperation.End(), Position{Line: 2, Column: 5, Offset: 10})
+	assert.Equals(t, binaryOperation.RightNode.Start(), Position{Line: 2, Column: 3, Offset: 8})
+	assert.Equals(t, binaryOperation.End().String(), "2:5")
+}
+
+func TestErrorSnippet_UnexpectedToken(t *testing.T) {
+	p, err := InitParser(`$.foo )`, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	var grammarErr *InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	assert.Equals(t, grammarErr.Snippet, "$.foo )\n      ^")
+	assert.Contains(t, err.Error(), "expected end of expression.\n$.foo )\n      ^\nHint: ")
+}
+
+func TestErrorSnippet_TokenLength(t *testing.T) {
+	p, err := InitParser("$.a &&\n\t$.b $.c", t.Name())
+	assert.NoError(t, err)
+	p.SetPositionOffset(2, 4)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	var grammarErr *InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	// The tab is kept, so that the marker lines up.
+	assert.Equals(t, grammarErr.Snippet, "\t$.b $.c\n\t    ^")
+	assert.Equals(t, grammarErr.FoundToken.Line, 4)
+
+	p, err = InitParser(`f(1, "abc" "de")`, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	assert.Equals(t, grammarErr.Snippet, "f(1, \"abc\" \"de\")\n           ^~~~")
+}
+
+func TestErrorSnippet_EndOfExpression(t *testing.T) {
+	p, err := InitParser(`$.a[0`, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	var grammarErr *InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	assert.Equals(t, grammarErr.Snippet, "$.a[0\n     ^")
+	assert.Contains(t, grammarErr.Hint(), "ended unexpectedly")
+}
+
+func TestErrorSnippet_Hints(t *testing.T) {
+	hints := map[string]string{
+		`1 = 1`:        "use '=='",
+		`true & false`: "use '&&'",
+		`true | false`: "use '||'",
+		`$.a + )`:      "a value, a reference, or a function call",
+		`$.a $.b`:      "check for a missing operator",
+	}
+	for expression, expectedHint := range hints {
+		p, err := InitParser(expression, t.Name())
+		assert.NoError(t, err)
+		_, err = p.ParseExpression()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Hint: "+expectedHint)
+	}
+}
+
+func TestErrorSnippet_InvalidToken(t *testing.T) {
+	p, err := InitParser(`$.a + #`, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	var tokenErr *InvalidTokenError
+	assert.Equals(t, errors.As(err, &tokenErr), true)
+	assert.Equals(t, tokenErr.Snippet, "$.a + #\n      ^")
+	assert.Contains(t, err.Error(), "Hint: check for unsupported characters")
+}
+
+func TestGrammarError_TokenNotReused(t *testing.T) {
+	p, err := InitParser(`$.a $.b`, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	var grammarErr *InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	// The parser reuses its current token, so advancing must not change the token of the error.
+	assert.NoError(t, p.advanceToken())
+	assert.Equals(t, grammarErr.FoundToken.Value, "$")
+	assert.Equals(t, grammarErr.FoundToken.Column, 5)
+	assert.Equals(t, p.currentToken.Value, ".")
+}
+
+func TestNestingDepth(t *testing.T) {
+	// The root expression is the first level.
+	nested := strings.Repeat("(", DefaultMaxNestingDepth-1) + "1" + strings.Repeat(")", DefaultMaxNestingDepth-1)
+	p, err := InitParser(nested, t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.NoError(t, err)
+
+	for expression, column := range map[string]int{
+		"(" + nested + ")":                   DefaultMaxNestingDepth + 1,
+		strings.Repeat("!", 100000) + "true": DefaultMaxNestingDepth + 1,
+		strings.Repeat("f(", 100000):         2*DefaultMaxNestingDepth + 1,
+	} {
+		p, err = InitParser(expression, t.Name())
+		assert.NoError(t, err)
+		_, err = p.ParseExpression()
+		var depthErr *NestingDepthError
+		assert.Equals(t, errors.As(err, &depthErr), true)
+		assert.Equals(t, depthErr.MaxDepth, DefaultMaxNestingDepth)
+		assert.Equals(t, depthErr.Position, Position{Line: 1, Column: column, Offset: column - 1})
+	}
+
+	p, err = InitParser("-(-1)", t.Name())
+	assert.NoError(t, err)
+	p.SetMaxNestingDepth(2)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "nested more than 2 levels deep")
+}
+
+func BenchmarkParser(b *testing.B) {
+	short := `$.steps.read_kubeconfig.outputs["success"].credentials[f(1, 2.5)] + 5m30s >= 2Gi && !$.flag`
+	long := strings.Repeat(`$.items[0].value * 2 + f($.a, "b") - `, 200) + "1"
+	for name, input := range map[string]string{"short": short, "long": long} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				parser, err := InitParser(input, "workflow.yaml")
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := parser.ParseExpression(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseExpression_IntegerExponents(t *testing.T) {
+	testCases := map[string]Node{
+		"1e6":                    &IntLiteral{IntValue: 1000000, Literal: "1e6"},
+		"25E+2":                  &IntLiteral{IntValue: 2500, Literal: "25E+2"},
+		"120e-1":                 &IntLiteral{IntValue: 12, Literal: "120e-1"},
+		"0e99":                   &IntLiteral{IntValue: 0, Literal: "0e99"},
+		"9e18":                   &IntLiteral{IntValue: 9000000000000000000, Literal: "9e18"},
+		"1e19":                   &FloatLiteral{FloatValue: 1e19},
+		"15e-1":                  &FloatLiteral{FloatValue: 1.5},
+		"1.0e6":                  &FloatLiteral{FloatValue: 1e6},
+		"99999999999999999999e0": &FloatLiteral{FloatValue: 99999999999999999999e0},
+	}
+	for expression, expected := range testCases {
+		p, err := InitParser(expression, t.Name())
+		assert.NoError(t, err)
+		p.SetIntegerExponents(true)
+		node, err := p.ParseExpression()
+		assert.NoError(t, err)
+		assert.Equals(t, withoutPositions(node), expected)
+	}
+	// Without integer exponents, the literals are floats.
+	assert.Equals[Node](t, withoutPositions(parseForWalkTest(t, "1e6")), &FloatLiteral{FloatValue: 1e6})
+}
+
+func TestParseExpression_FloatOutOfRange(t *testing.T) {
+	p, err := InitParser("1.0e999", t.Name())
+	assert.NoError(t, err)
+	_, err = p.ParseExpression()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "float literal 1.0e999 is out of range")
+}
+
+func TestOperationString_MinimalParentheses(t *testing.T) {
+	testCases := map[string]string{
+		`a + b * c`:           `a + b * c`,
+		`(a + b) * c`:         `(a + b) * c`,
+		`((a * b)) + c`:       `a * b + c`,
+		`a - b - c`:           `a - b - c`,
+		`a - (b - c)`:         `a - (b - c)`,
+		`2 ^ 3 ^ 2`:           `2 ^ 3 ^ 2`,
+		`2 ^ (3 ^ 2)`:         `2 ^ (3 ^ 2)`,
+		`a / b % c`:           `a / b % c`,
+		`a * -b`:              `a * -b`,
+		`(-a) + b`:            `(-a) + b`,
+		`(a * -b) + c`:        `(a * -b) + c`,
+		`-(a + b)`:            `-(a + b)`,
+		`1 == (!true)`:        `1 == (!true)`,
+		`a && b || c`:         `a && b || c`,
+		`a && (b || c)`:       `a && (b || c)`,
+		`(!a) || b`:           `(!a) || b`,
+		`a || !b`:             `a || !b`,
+		`$.a[$.b + 1] > f(2)`: `$.a[$.b + 1] > f(2)`,
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			node := parseForWalkTest(t, expression)
+			assert.Equals(t, node.String(), expected)
+			// The printed expression must be parsed into the same tree.
+			assert.Equals(t, withoutPositions(parseForWalkTest(t, node.String())), withoutPositions(node))
+		})
+	}
+}
+
+func TestNodePositions_Offset(t *testing.T) {
+	// The offsets are byte offsets, so they are not affected by the position offset and multibyte characters.
+	expression := " \"é\" +\n  $.a[0]"
+	p, err := InitParser(expression, t.Name())
+	assert.NoError(t, err)
+	p.SetPositionOffset(2, 4)
+	parsedResult, err := p.ParseExpression()
+	assert.NoError(t, err)
+	var sources []string
+	Inspect(parsedResult, func(node Node) bool {
+		sources = append(sources, expression[node.Start().Offset:node.End().Offset])
+		return true
+	})
+	assert.Equals(t, sources, []string{"\"é\" +\n  $.a[0]", `"é"`, `$.a[0]`, `$.a`, `$`, `a`, `0`})
+	assert.Equals(t, parsedResult.(*BinaryOperation).RightNode.Start(), Position{Line: 4, Column: 3, Offset: 10})
+}
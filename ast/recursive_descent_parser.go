@@ -3,37 +3,12 @@ package ast
 import (
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
-/*
-Current grammar in Backus–Naur form:
-<root_expression> ::= <or_expression>
-<or_expression> ::= <and_expression> [ "|" "|" <and_expression> ]
-<and_expression> ::= <not_expression> [ "&" "&" <not_expression> ]
-<not_expression> ::= [ "!" ] <comparison_expression>
-<comparison_expression> ::= <add_sub_expression> [ <comparison_operator> <add_sub_expression> ]
-<comparison_operator> ::= ">" | "<" | ">" "=" | "<" "=" | "=" "=" | "!" "="
-<add_sub_expression> ::= <multiply_divide_expression> [ <add_sub_operator> <multiply_divide_expression>]
-<add_sub_operator> ::=  "+" | "-"
-<multiply_divide_expression> ::= <exponents_expression> [ <multiply_divide_operator> <exponents_expression> ]
-<multiply_divide_operator> ::=  "*" | "/" | "%"
-<exponents_expression> ::= <parentheses_expression> [ "^" <parentheses_expression> ]
-<parentheses_expression> ::= <negation_expression> | "(" <root_expression> ")"
-<negation_expression> ::= ["-"] <value_or_access_expression>
-<value_or_access_expression> ::= <literal> | <identifier_or_function> [ <chained_access> ]
-<identifier_or_function> := IdentifierToken | <function_call>
-<function_call> := IdentifierToken "(" [ <argument_list> ] ")"
-<chained_access> := <chainable_access> [ <chained_access> ]
-<chainable_access> := <dot_notation> | <bracket_access>
-<dot_notation> := "." IdentifierToken
-<bracket_access> := "[" <root_expression> "]"
-<literal> := IntLiteralToken | StringLiteralToken | FloatLiteralToken | BooleanLiteralToken
-<argument_list> := <root_expression> [ "," <argument_list> ]
-
-filtering/querying will be added later if needed.
-*/
+// The grammar of expressions is described in Backus–Naur form by GrammarRules.
 
 // Parser represents the object that handles parsing the grammar for the
 // expression.
@@ -43,30 +18,95 @@ filtering/querying will be added later if needed.
 type Parser struct {
 	t            *tokenizer
 	currentToken *TokenValue
-	atRoot       bool
+	// token holds the value of the current token. It is reused for all tokens, so advancing does not allocate.
+	token TokenValue
+	// arena allocates the nodes of the parsed expression.
+	arena nodeArena
+	// depth is the number of nested expressions being parsed, and maxDepth is the limit of it.
+	depth    int
+	maxDepth int
+	atRoot   bool
+	// integerExponents makes whole numbers written with an exponent but without a fraction, such as 1e6, integers.
+	integerExponents bool
+	// lastTokenEnd is the position directly after the last token the parser advanced past.
+	lastTokenEnd Position
 }
 
 // InitParser initializes the parser with the given raw expression.
 func InitParser(expression string, fileName string) (*Parser, error) {
 	t := initTokenizer(expression, fileName)
-	p := &Parser{t: t}
+	p := &Parser{t: t, maxDepth: DefaultMaxNestingDepth}
 	p.atRoot = true
 
 	return p, nil
 }
 
+// SetPositionOffset sets the offsets that are added to the positions of tokens and nodes. This is useful when the
+// expression is embedded in a larger document. The line offset is added to all lines, while the column offset is
+// only added to the columns of the first line of the expression. Must be called before parsing.
+func (p *Parser) SetPositionOffset(lineOffset int, columnOffset int) {
+	p.t.lineOffset = lineOffset
+	p.t.columnOffset = columnOffset
+}
+
+// DefaultMaxNestingDepth is the default limit of nested expressions, such as parentheses, unary operators, and
+// function arguments, within an expression.
+const DefaultMaxNestingDepth = 100
+
+// SetMaxNestingDepth sets the limit of nested expressions, such as parentheses, unary operators, and function
+// arguments. Parsing an expression that is nested deeper fails with a NestingDepthError, so adversarial input cannot
+// exhaust the stack. Must be called before parsing.
+func (p *Parser) SetMaxNestingDepth(maxDepth int) {
+	p.maxDepth = maxDepth
+}
+
+// SetIntegerExponents sets whether numbers written with an exponent but without a fraction, such as 1e6, are parsed
+// as integers. If enabled, such numbers are integers if their value is whole and fits in an int64, and floats
+// otherwise. If disabled, which is the default, they are always floats. Must be called before parsing.
+func (p *Parser) SetIntegerExponents(enabled bool) {
+	p.integerExponents = enabled
+}
+
 // advanceToken advances to the next token by updating the current token var.
 // Also needed before parsing.
 func (p *Parser) advanceToken() error {
+	if p.currentToken != nil {
+		p.lastTokenEnd = p.currentToken.endPosition()
+	}
 	if p.t.hasNextToken() {
-		newToken, err := p.t.getNext()
-		p.currentToken = newToken
+		err := p.t.next(&p.token)
+		p.currentToken = &p.token
 		return err
 	}
 	p.currentToken = nil
 	return nil
 }
 
+// foundToken returns a copy of the current token for errors, since the current token is overwritten when advancing,
+// or nil if the end of the expression has been reached.
+func (p *Parser) foundToken() *TokenValue {
+	if p.currentToken == nil {
+		return nil
+	}
+	token := *p.currentToken
+	return &token
+}
+
+// currentPosition returns the start position of the current token, or the end of the previous token if the end
+// of the expression has been reached.
+func (p *Parser) currentPosition() Position {
+	if p.currentToken == nil {
+		return p.lastTokenEnd
+	}
+	return p.currentToken.startPosition()
+}
+
+// spanFrom returns the span from the specified start position to the end of the last token the parser advanced
+// past. Call it after advancing past the last token of the node.
+func (p *Parser) spanFrom(start Position) NodeSpan {
+	return NodeSpan{StartPos: start, EndPos: p.lastTokenEnd}
+}
+
 // parseBracketAccess parses a bracket access in the form of a
 // bracket, followed by the key, followed by a closing bracket.
 //
@@ -78,7 +118,7 @@ func (p *Parser) parseBracketAccess(expressionToAccess Node) (*BracketAccessor,
 	// Verify and read in the [
 	if p.currentToken == nil ||
 		p.currentToken.TokenID != BracketAccessDelimiterStartToken {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{IdentifierToken}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{IdentifierToken}}
 	}
 	err := p.advanceToken()
 	if err != nil {
@@ -96,55 +136,142 @@ func (p *Parser) parseBracketAccess(expressionToAccess Node) (*BracketAccessor,
 		return nil, err
 	}
 
-	return &BracketAccessor{LeftNode: expressionToAccess, RightExpression: subExpr}, nil
+	return p.arena.bracketAccessors.new(BracketAccessor{
+		NodeSpan:        p.spanFrom(expressionToAccess.Start()),
+		LeftNode:        expressionToAccess,
+		RightExpression: subExpr,
+	}), nil
 }
 
 func (p *Parser) parseIntLiteral() (*IntLiteral, error) {
 	if p.currentToken.TokenID != IntLiteralToken {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{IntLiteralToken}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{IntLiteralToken}}
 	}
+	start := p.currentPosition()
 	parsedInt, err := strconv.ParseInt(p.currentToken.Value, 10, 0)
 	if err != nil {
 		return nil, err // Should not fail if the parser is set up correctly
 	}
-	literal := &IntLiteral{IntValue: parsedInt}
+	literal := p.arena.intLiterals.new(IntLiteral{IntValue: parsedInt})
+	err = p.advanceToken()
+	if err != nil {
+		return nil, err
+	}
+	literal.NodeSpan = p.spanFrom(start)
+	return literal, nil
+}
+
+// parseUnitLiteral parses a duration or byte size literal into an integer literal.
+func (p *Parser) parseUnitLiteral() (*IntLiteral, error) {
+	start := p.currentPosition()
+	var value int64
+	var err error
+	switch p.currentToken.TokenID {
+	case DurationLiteralToken:
+		value, err = ParseDurationLiteral(p.currentToken.Value)
+	case ByteSizeLiteralToken:
+		value, err = ParseByteSizeLiteral(p.currentToken.Value)
+	default:
+		return nil, &InvalidGrammarError{
+			FoundToken:     p.foundToken(),
+			ExpectedTokens: []TokenID{DurationLiteralToken, ByteSizeLiteralToken},
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	literal := p.arena.intLiterals.new(IntLiteral{IntValue: value, Literal: p.currentToken.Value})
 	err = p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
+	literal.NodeSpan = p.spanFrom(start)
 	return literal, nil
 }
 
 func (p *Parser) parseFloatLiteral() (*FloatLiteral, error) {
 	if p.currentToken.TokenID != FloatLiteralToken {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{FloatLiteralToken}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{FloatLiteralToken}}
 	}
+	start := p.currentPosition()
 	parsedFloat, err := strconv.ParseFloat(p.currentToken.Value, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return nil, errorf("float literal %s is out of range", p.currentToken.Value)
+	}
 	if err != nil {
 		// If this happens, make sure ParseFloat's requirements match the tokenizer's requirements.
 		return nil, fmt.Errorf("bug: could not parse float %s (%w)", p.currentToken.Value, err)
 	}
-	literal := &FloatLiteral{FloatValue: parsedFloat}
+	literal := p.arena.floatLiterals.new(FloatLiteral{FloatValue: parsedFloat, Literal: p.currentToken.Value})
 	err = p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
+	literal.NodeSpan = p.spanFrom(start)
+	return literal, nil
+}
+
+// parseIntegerExponentLiteral parses a float token written with an exponent into an integer literal with the value.
+func (p *Parser) parseIntegerExponentLiteral(value int64) (*IntLiteral, error) {
+	start := p.currentPosition()
+	literal := p.arena.intLiterals.new(IntLiteral{IntValue: value, Literal: p.currentToken.Value})
+	err := p.advanceToken()
+	if err != nil {
+		return nil, err
+	}
+	literal.NodeSpan = p.spanFrom(start)
 	return literal, nil
 }
 
+// integerExponentValue returns the value of a float literal, such as 1e6, as an integer, if integer exponents are
+// enabled, the literal has no fraction, and its value is whole and fits in an int64.
+func (p *Parser) integerExponentValue(literal string) (int64, bool) {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(literal), "e")
+	if !p.integerExponents || !hasExponent || strings.Contains(mantissa, ".") {
+		return 0, false
+	}
+	value, err := strconv.ParseInt(mantissa, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	power, err := strconv.Atoi(exponent)
+	if err != nil {
+		return 0, false
+	}
+	if value == 0 {
+		return 0, true
+	}
+	// Each step either fails or moves the power towards zero, and only a few steps fit in an int64.
+	for ; power < 0; power++ {
+		if value%10 != 0 {
+			return 0, false
+		}
+		value /= 10
+	}
+	for ; power > 0; power-- {
+		if value > math.MaxInt64/10 {
+			return 0, false
+		}
+		value *= 10
+	}
+	return value, true
+}
+
 func (p *Parser) parseBooleanLiteral() (*BooleanLiteral, error) {
 	if p.currentToken.TokenID != BooleanLiteralToken {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{BooleanLiteralToken}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{BooleanLiteralToken}}
 	}
+	start := p.currentPosition()
 	parsedBoolean, err := strconv.ParseBool(p.currentToken.Value)
 	if err != nil {
 		return nil, err // Should not fail if the parser is set up correctly
 	}
-	literal := &BooleanLiteral{BooleanValue: parsedBoolean}
+	literal := p.arena.booleanLiterals.new(BooleanLiteral{BooleanValue: parsedBoolean})
 	err = p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
+	literal.NodeSpan = p.spanFrom(start)
 	return literal, nil
 }
 
@@ -160,23 +287,34 @@ var escapeReplacer = strings.NewReplacer(
 	`\0`, "\000",
 )
 
-func (p *Parser) parseStringLiteral() (*StringLiteral, error) {
-	// The literal token includes the "", so trim the ends off.
-	parsedString := p.currentToken.Value[1 : len(p.currentToken.Value)-1]
-	// Replace escaped characters
-	if p.currentToken.TokenID != RawStringLiteralToken {
-		parsedString = escapeReplacer.Replace(parsedString)
+// unquoteStringLiteral returns the value of a string literal token. Escaped characters are replaced, except in raw
+// strings.
+func unquoteStringLiteral(literal string) string {
+	// The literal token includes the quotes, so trim the ends off.
+	value := literal[1 : len(literal)-1]
+	if literal[0] == '`' {
+		return value
 	}
+	return escapeReplacer.Replace(value)
+}
+
+func (p *Parser) parseStringLiteral() (*StringLiteral, error) {
+	start := p.currentPosition()
 	// Now create the literal itself and advance the token.
-	literal := &StringLiteral{StrValue: parsedString}
+	literal := p.arena.stringLiterals.new(StringLiteral{
+		StrValue: unquoteStringLiteral(p.currentToken.Value),
+		Literal:  p.currentToken.Value,
+	})
 	err := p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
+	literal.NodeSpan = p.spanFrom(start)
 	return literal, nil
 }
 
 func (p *Parser) parseArgs() (*ArgumentList, error) {
+	start := p.currentPosition()
 	// Keep parsing expressions until you hit a comma.
 	argNodes := make([]Node, 0)
 	expectedToken := ParenthesesStartToken
@@ -184,7 +322,7 @@ func (p *Parser) parseArgs() (*ArgumentList, error) {
 		// Check for incomplete scenario.
 		if p.currentToken == nil && i != 0 { // Reached end too early.
 			return nil, &InvalidGrammarError{
-				FoundToken:     p.currentToken,
+				FoundToken:     p.foundToken(),
 				ExpectedTokens: []TokenID{ParenthesesEndToken, ListSeparatorToken},
 			}
 		}
@@ -195,7 +333,7 @@ func (p *Parser) parseArgs() (*ArgumentList, error) {
 			if err != nil {
 				return nil, err
 			}
-			return &ArgumentList{Arguments: argNodes}, nil
+			return p.arena.argumentLists.new(ArgumentList{NodeSpan: p.spanFrom(start), Arguments: argNodes}), nil
 		} else if p.currentToken.TokenID != expectedToken {
 			// The first is preceded by a (, the others are preceded by ,
 			expectedTokens := []TokenID{expectedToken}
@@ -204,7 +342,7 @@ func (p *Parser) parseArgs() (*ArgumentList, error) {
 				expectedTokens = append(expectedTokens, ParenthesesEndToken)
 			}
 			return nil, &InvalidGrammarError{
-				FoundToken:     p.currentToken,
+				FoundToken:     p.foundToken(),
 				ExpectedTokens: expectedTokens,
 			}
 		}
@@ -217,7 +355,7 @@ func (p *Parser) parseArgs() (*ArgumentList, error) {
 		// Check for incomplete scenario.
 		if p.currentToken == nil { // Reached end too early.
 			return nil, &InvalidGrammarError{
-				FoundToken:     p.currentToken,
+				FoundToken:     p.foundToken(),
 				ExpectedTokens: []TokenID{ParenthesesEndToken},
 			}
 		}
@@ -228,7 +366,7 @@ func (p *Parser) parseArgs() (*ArgumentList, error) {
 			if err != nil {
 				return nil, err
 			}
-			return &ArgumentList{Arguments: argNodes}, nil
+			return p.arena.argumentLists.new(ArgumentList{NodeSpan: p.spanFrom(start), Arguments: argNodes}), nil
 		}
 
 		// It should be able to process a whole expression within the arg
@@ -249,35 +387,76 @@ func (p *Parser) parseIdentifier() (*Identifier, error) {
 	// Only accessing one token, the identifier
 	if p.currentToken == nil ||
 		p.currentToken.TokenID != IdentifierToken {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{IdentifierToken}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{IdentifierToken}}
 	}
 
-	parsedIdentifier := &Identifier{IdentifierName: p.currentToken.Value}
+	start := p.currentPosition()
+	parsedIdentifier := p.arena.identifiers.new(Identifier{IdentifierName: p.currentToken.Value})
 	err := p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
+	parsedIdentifier.NodeSpan = p.spanFrom(start)
 	return parsedIdentifier, nil
 }
 
+// parseFieldName parses the identifier that follows a dot. Field names are never literals, so the words true and false
+// are identifiers here, which allows accessing fields with these names.
+func (p *Parser) parseFieldName() (*Identifier, error) {
+	if p.currentToken != nil && p.currentToken.TokenID == BooleanLiteralToken {
+		p.currentToken.TokenID = IdentifierToken
+	}
+	return p.parseIdentifier()
+}
+
 // ParseExpression is the correct entrypoint for parsing an expression.
 // It advances to the first token, and parses the expression.
 func (p *Parser) ParseExpression() (Node, error) {
 	err := p.advanceToken()
 	if err != nil {
-		return nil, err
+		return nil, p.withSnippet(err)
 	}
 
 	node, err := p.parseRootExpression()
 	if err != nil {
-		return nil, err
+		return nil, p.withSnippet(err)
 	} else if p.currentToken != nil {
 		// Reached wrong token. It should be at the end here.
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: nil}
+		return nil, p.withSnippet(&InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: nil})
 	}
 	return node, err
 }
 
+// withSnippet adds the source snippet to token and grammar errors.
+func (p *Parser) withSnippet(err error) error {
+	var tokenErr *InvalidTokenError
+	var grammarErr *InvalidGrammarError
+	switch {
+	case errors.As(err, &tokenErr):
+		tokenErr.Snippet = p.tokenSnippet(&tokenErr.InvalidToken)
+	case errors.As(err, &grammarErr):
+		if grammarErr.FoundToken != nil {
+			grammarErr.Snippet = p.tokenSnippet(grammarErr.FoundToken)
+		} else {
+			// The expression ended early, so mark the end of the expression.
+			grammarErr.Snippet = p.t.snippet(p.lastTokenEnd, 1)
+		}
+	}
+	return err
+}
+
+// tokenSnippet returns the source snippet marking the specified token.
+func (p *Parser) tokenSnippet(token *TokenValue) string {
+	length := 0
+	for _, char := range token.Value {
+		if char == '\n' {
+			break
+		}
+		length++
+	}
+	return p.t.snippet(token.startPosition(), length)
+}
+
 func (p *Parser) parseMathOperator() (MathOperationType, error) {
 	firstToken := p.currentToken.TokenID
 	err := p.advanceToken()
@@ -316,7 +495,7 @@ func (p *Parser) parseMathOperator() (MathOperationType, error) {
 				return EqualTo, nil
 			default:
 				// If you get here, there is a case missing here that is in the outer switch
-				panic(fmt.Errorf("illegal code state hit after token %s", firstToken))
+				return Invalid, fmt.Errorf("bug: illegal code state hit after token %s", firstToken)
 			}
 		} else {
 			// No token, or non-equals token next, so validate as a single token.
@@ -329,15 +508,15 @@ func (p *Parser) parseMathOperator() (MathOperationType, error) {
 				return Not, nil
 			case EqualsToken:
 				// Expected double equals, but got single equals
-				return Invalid, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{EqualsToken}}
+				return Invalid, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{EqualsToken}}
 			default:
 				// If you get here, there is a case missing here that is in the outer switch
-				panic(fmt.Errorf("illegal code state hit after token %s", firstToken))
+				return Invalid, fmt.Errorf("bug: illegal code state hit after token %s", firstToken)
 			}
 		}
 	case AndToken:
 		if p.currentToken == nil || p.currentToken.TokenID != AndToken {
-			return Invalid, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{AndToken}}
+			return Invalid, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{AndToken}}
 		}
 		err := p.advanceToken()
 		if err != nil {
@@ -346,7 +525,7 @@ func (p *Parser) parseMathOperator() (MathOperationType, error) {
 		return And, nil
 	case OrToken:
 		if p.currentToken == nil || p.currentToken.TokenID != OrToken {
-			return Invalid, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{OrToken}}
+			return Invalid, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{OrToken}}
 		}
 		err := p.advanceToken()
 		if err != nil {
@@ -354,7 +533,7 @@ func (p *Parser) parseMathOperator() (MathOperationType, error) {
 		}
 		return Or, nil
 	default:
-		return Invalid, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{
+		return Invalid, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{
 			PlusToken,
 			NegationToken,
 			AsteriskToken,
@@ -376,6 +555,7 @@ func (p *Parser) parseMathOperator() (MathOperationType, error) {
 // If, after parsing the first operand, the operator is not present, then the function returns
 // successfully.
 func (p *Parser) parseBinaryExpression(supportedOperators []TokenID, childNodeParser func() (Node, error)) (Node, error) {
+	start := p.currentPosition()
 	root, err := childNodeParser()
 	if err != nil {
 		return nil, err
@@ -391,11 +571,12 @@ func (p *Parser) parseBinaryExpression(supportedOperators []TokenID, childNodePa
 		if err != nil {
 			return nil, err
 		}
-		root = &BinaryOperation{
+		root = p.arena.binaryOperations.new(BinaryOperation{
+			NodeSpan:  p.spanFrom(start),
 			LeftNode:  root,
 			RightNode: right,
 			Operation: operatorToken,
-		}
+		})
 	}
 	return root, nil
 }
@@ -404,9 +585,10 @@ func (p *Parser) parseBinaryExpression(supportedOperators []TokenID, childNodePa
 // on the right. If the expected token is not there, it continues recursively with childNodeParser.
 func (p *Parser) parseLeftUnaryExpression(supportedOperators []TokenID, childNodeParser func() (Node, error)) (Node, error) {
 	if p.currentToken == nil {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: []TokenID{}}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{}}
 	}
 	if sliceContains(supportedOperators, p.currentToken.TokenID) {
+		start := p.currentPosition()
 		operation, err := p.parseMathOperator()
 		if err != nil {
 			return nil, err
@@ -415,10 +597,11 @@ func (p *Parser) parseLeftUnaryExpression(supportedOperators []TokenID, childNod
 		if err != nil {
 			return nil, err
 		}
-		return &UnaryOperation{
+		return p.arena.unaryOperations.new(UnaryOperation{
+			NodeSpan:      p.spanFrom(start),
 			LeftOperation: operation,
 			RightNode:     subNode,
-		}, nil
+		}), nil
 	}
 	return childNodeParser()
 }
@@ -437,6 +620,18 @@ func (p *Parser) parseLeftUnaryExpression(supportedOperators []TokenID, childNod
 // For more details, see the grammar at the top of this file.
 
 func (p *Parser) parseRootExpression() (Node, error) {
+	// All nested expressions are parsed from the root, so limiting the depth here limits the recursion.
+	p.depth++
+	defer func() {
+		p.depth--
+	}()
+	if p.depth > p.maxDepth {
+		return nil, &NestingDepthError{
+			MaxDepth: p.maxDepth,
+			Filename: p.t.filename,
+			Position: p.currentPosition(),
+		}
+	}
 	// Currently `or` is the first one to call based on the order of operations specified above,
 	// and based on the grammar specified at the top of the file.
 	return p.parseConditionalOr()
@@ -475,7 +670,7 @@ func (p *Parser) parseExponents() (Node, error) {
 func (p *Parser) parseParentheses() (Node, error) {
 	// If parentheses, continue recursing back from the root.
 	// If not parentheses, recurse down into negation.
-	if p.currentToken.TokenID != ParenthesesStartToken {
+	if p.currentToken == nil || p.currentToken.TokenID != ParenthesesStartToken {
 		return p.parseNegationOperation()
 	}
 	err := p.advanceToken() // Go past the parentheses
@@ -497,7 +692,15 @@ func (p *Parser) parseNegationOperation() (Node, error) {
 	return p.parseLeftUnaryExpression([]TokenID{NegationToken}, p.parseValueOrAccessExpression)
 }
 
-var literalTokens = []TokenID{StringLiteralToken, RawStringLiteralToken, IntLiteralToken, BooleanLiteralToken, FloatLiteralToken}
+var literalTokens = []TokenID{
+	StringLiteralToken,
+	RawStringLiteralToken,
+	IntLiteralToken,
+	BooleanLiteralToken,
+	FloatLiteralToken,
+	DurationLiteralToken,
+	ByteSizeLiteralToken,
+}
 var identifierTokens = []TokenID{IdentifierToken, RootAccessToken}
 var validRootValueOrAccessStartTokens = append(literalTokens, identifierTokens...)
 var validValueOrAccessStartTokens = append(validRootValueOrAccessStartTokens, CurrentObjectAccessToken)
@@ -505,10 +708,10 @@ var validValueOrAccessStartTokens = append(validRootValueOrAccessStartTokens, Cu
 // parseValueOrAccessExpression parses a root expression
 func (p *Parser) parseValueOrAccessExpression() (Node, error) {
 	if p.currentToken == nil || !sliceContains(validValueOrAccessStartTokens, p.currentToken.TokenID) {
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: validValueOrAccessStartTokens}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: validValueOrAccessStartTokens}
 	} else if p.atRoot && p.currentToken.TokenID == CurrentObjectAccessToken {
 		// Can't support @/CurrentObjectAccessToken at root
-		return nil, &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: validRootValueOrAccessStartTokens}
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: validRootValueOrAccessStartTokens}
 	}
 	p.atRoot = false // Know when you can reference the current object.
 
@@ -521,8 +724,14 @@ func (p *Parser) parseValueOrAccessExpression() (Node, error) {
 		literalNode, err = p.parseStringLiteral()
 	case IntLiteralToken:
 		literalNode, err = p.parseIntLiteral()
+	case DurationLiteralToken, ByteSizeLiteralToken:
+		literalNode, err = p.parseUnitLiteral()
 	case FloatLiteralToken:
-		literalNode, err = p.parseFloatLiteral()
+		if value, isInteger := p.integerExponentValue(p.currentToken.Value); isInteger {
+			literalNode, err = p.parseIntegerExponentLiteral(value)
+		} else {
+			literalNode, err = p.parseFloatLiteral()
+		}
 	case BooleanLiteralToken:
 		literalNode, err = p.parseBooleanLiteral()
 	default:
@@ -538,11 +747,11 @@ func (p *Parser) parseValueOrAccessExpression() (Node, error) {
 		switch p.currentToken.TokenID {
 		// These are all access start tokens which cannot follow a literal.
 		case ParenthesesStartToken:
-			return nil, fmt.Errorf("an opening parentheses cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
+			return nil, errorf("an opening parentheses cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
 		case DotObjectAccessToken:
-			return nil, fmt.Errorf("dot notation cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
+			return nil, errorf("dot notation cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
 		case BracketAccessDelimiterStartToken:
-			return nil, fmt.Errorf("bracket access cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
+			return nil, errorf("bracket access cannot follow a literal; got %q after %q", p.currentToken.Value, literalNode.String())
 		}
 	}
 	return literalNode, nil
@@ -551,12 +760,19 @@ func (p *Parser) parseValueOrAccessExpression() (Node, error) {
 // Parses the current identifier, parses the arg list if available, then checks for chainable accesses.
 // Expects to be called when the current node is an identifier.
 func (p *Parser) parseIdentifierOrFunction() (Node, error) {
-	firstNode := &Identifier{IdentifierName: p.currentToken.Value}
+	start := p.currentPosition()
+	firstNode := p.arena.identifiers.new(Identifier{IdentifierName: p.currentToken.Value})
 	err := p.advanceToken()
 	if err != nil {
 		return nil, err
 	}
-	chainableNode, err := p.parseFunctionArgs(firstNode)
+	firstNode.NodeSpan = p.spanFrom(start)
+	var chainableNode Node
+	if firstNode.IsRoot() {
+		chainableNode, err = p.parseFunctionArgs(firstNode)
+	} else {
+		chainableNode, err = p.parseNamespacedFunction(firstNode)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -567,6 +783,63 @@ func (p *Parser) parseIdentifierOrFunction() (Node, error) {
 	return p.parseChainedAccess(chainableNode)
 }
 
+// parseNamespacedFunction parses the namespaces and the name of a function that follow the first identifier, such as
+// the ".abs" in "math.abs(x)" or the "::upper" in "str::upper(x)", then parses the function call. Data cannot be
+// called, so dot-separated identifiers are a namespaced function name if they are followed by an argument list. The
+// name of the function is the namespaces and the name separated by dots, regardless of the separator used in the
+// expression. If no argument list follows dot-separated identifiers, they are returned as dot notation.
+func (p *Parser) parseNamespacedFunction(firstNode *Identifier) (Node, error) {
+	segments := []*Identifier{firstNode}
+	usesColons := false
+	for p.currentToken != nil &&
+		(p.currentToken.TokenID == DotObjectAccessToken || p.currentToken.TokenID == SelectorToken) {
+		if p.currentToken.TokenID == SelectorToken {
+			// The separator is "::", so a second ':' must follow.
+			if err := p.advanceToken(); err != nil {
+				return nil, err
+			}
+			if p.currentToken == nil || p.currentToken.TokenID != SelectorToken {
+				return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{SelectorToken}}
+			}
+			usesColons = true
+		}
+		if err := p.advanceToken(); err != nil {
+			return nil, err
+		}
+		segment, err := p.parseFieldName()
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 1 {
+		return p.parseFunctionArgs(firstNode)
+	}
+	if p.currentToken != nil && p.currentToken.TokenID == ParenthesesStartToken {
+		names := make([]string, len(segments))
+		for i, segment := range segments {
+			names[i] = segment.IdentifierName
+		}
+		return p.parseFunctionArgs(p.arena.identifiers.new(Identifier{
+			NodeSpan:       NodeSpan{StartPos: firstNode.Start(), EndPos: segments[len(segments)-1].End()},
+			IdentifierName: strings.Join(names, "."),
+		}))
+	}
+	if usesColons {
+		// Namespaces are only used in function names.
+		return nil, &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: []TokenID{ParenthesesStartToken}}
+	}
+	var currentNode Node = firstNode
+	for _, segment := range segments[1:] {
+		currentNode = p.arena.dotNotations.new(DotNotation{
+			NodeSpan:              NodeSpan{StartPos: firstNode.Start(), EndPos: segment.End()},
+			LeftAccessibleNode:    currentNode,
+			RightAccessIdentifier: segment,
+		})
+	}
+	return currentNode, nil
+}
+
 // parseFunctionArgs parses all parts of a function call that follow the identifier, including the parentheses.
 // If a parameter list is not found, it returns the identifier.
 func (p *Parser) parseFunctionArgs(precedingNode *Identifier) (Node, error) {
@@ -578,10 +851,11 @@ func (p *Parser) parseFunctionArgs(precedingNode *Identifier) (Node, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &FunctionCall{
+	return p.arena.functionCalls.new(FunctionCall{
+		NodeSpan:       p.spanFrom(precedingNode.Start()),
 		FuncIdentifier: precedingNode,
 		ArgumentInputs: argList,
-	}, nil
+	}), nil
 }
 
 // parseChainedAccess parses all the dot notations, map accesses, binary operations, and function calls.
@@ -596,11 +870,15 @@ func (p *Parser) parseChainedAccess(rootNode Node) (Node, error) {
 			if err != nil {
 				return nil, err
 			}
-			accessingIdentifier, err := p.parseIdentifier()
+			accessingIdentifier, err := p.parseFieldName()
 			if err != nil {
 				return nil, err
 			}
-			currentNode = &DotNotation{LeftAccessibleNode: currentNode, RightAccessIdentifier: accessingIdentifier}
+			currentNode = p.arena.dotNotations.new(DotNotation{
+				NodeSpan:              p.spanFrom(currentNode.Start()),
+				LeftAccessibleNode:    currentNode,
+				RightAccessIdentifier: accessingIdentifier,
+			})
 		case BracketAccessDelimiterStartToken:
 			// Bracket notation
 			parsedMapAccess, err := p.parseBracketAccess(currentNode)
@@ -620,7 +898,7 @@ func (p *Parser) parseChainedAccess(rootNode Node) (Node, error) {
 // For use when you know which tokens are required.
 func (p *Parser) eat(validTokens []TokenID) error {
 	if p.currentToken == nil || !sliceContains(validTokens, p.currentToken.TokenID) {
-		return &InvalidGrammarError{FoundToken: p.currentToken, ExpectedTokens: validTokens}
+		return &InvalidGrammarError{FoundToken: p.foundToken(), ExpectedTokens: validTokens}
 	}
 	return p.advanceToken()
 }
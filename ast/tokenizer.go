@@ -0,0 +1,595 @@
+package ast
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenID Represents the name of a type of token that has a pattern.
+type TokenID string
+
+const (
+	// IdentifierToken represents a token with any valid object name.
+	IdentifierToken TokenID = "identifier"
+	// StringLiteralToken represents a token that has a sequence of characters.
+	// Supports the string format used in golang, and will include
+	// the " before and after the contents of the string.
+	// Characters can be escaped the common way with a backslash.
+	StringLiteralToken    TokenID = "string"
+	RawStringLiteralToken TokenID = "raw-string"
+	// IntLiteralToken represents an integer token. Must not start with 0.
+	IntLiteralToken TokenID = "int"
+	// FloatLiteralToken represents a float token.
+	FloatLiteralToken TokenID = "float"
+	// DurationLiteralToken represents a duration, such as 5m30s, which is evaluated to the number of seconds.
+	DurationLiteralToken TokenID = "duration"
+	// ByteSizeLiteralToken represents a byte size, such as 2Gi, which is evaluated to the number of bytes.
+	ByteSizeLiteralToken TokenID = "byte-size"
+	// BooleanLiteralToken represents true or false.
+	BooleanLiteralToken TokenID = "boolean"
+	// BracketAccessDelimiterStartToken represents the token before an object
+	//  access. The '[' in 'obj["key"]'.
+	//nolint:gosec
+	BracketAccessDelimiterStartToken TokenID = "map-delimiter-start"
+	// BracketAccessDelimiterEndToken represents the token before an object
+	// access. The '[' in 'obj["key"]'.
+	//nolint:gosec
+	BracketAccessDelimiterEndToken TokenID = "map-delimiter-end"
+	// ParenthesesStartToken represents the start token of an argument list or a parenthesized expression. '('
+	ParenthesesStartToken TokenID = "parentheses-start"
+	// ParenthesesEndToken represents the closing of the argument list. ')'
+	ParenthesesEndToken TokenID = "parentheses-end"
+	// DotObjectAccessToken represents the '.' token in 'a.b' (dot notation).
+	DotObjectAccessToken TokenID = "object-access"
+	// RootAccessToken represents the token that identifies accessing the
+	// root object, $, or a named root, such as $steps.
+	RootAccessToken TokenID = "root-access"
+	// CurrentObjectAccessToken represents the token, @, that identifies the current
+	// object in a filter.
+	CurrentObjectAccessToken TokenID = "current-object-access"
+	// EqualsToken represents the token that represents a single equals sign.
+	EqualsToken TokenID = "equals-sign"
+	// SelectorToken Represents the ':' character used in selector expressions in bracket
+	// object access.
+	SelectorToken TokenID = "selector"
+	// FilterToken represents the '?' used in filter expressions in bracket object access.
+	FilterToken TokenID = "filter"
+	// NegationToken represents a negation sign '-'.
+	//nolint:gosec
+	NegationToken TokenID = "negation-sign"
+	// AsteriskToken represents a wildcard/multiplication token '*'.
+	AsteriskToken TokenID = "asterisk"
+	// ListSeparatorToken represents a comma in a parameter list
+	ListSeparatorToken TokenID = "list-separator" //nolint:gosec // not a security credential
+	// DivideToken represents the forward slash used to specify division.
+	DivideToken TokenID = "divide"
+	// GreaterThanToken represents a > symbol.
+	GreaterThanToken TokenID = "greater-than"
+	// LessThanToken represents a < symbol.
+	LessThanToken TokenID = "less-than"
+	// PlusToken represents a + symbol.
+	PlusToken TokenID = "plus"
+	// NotToken represents an ! symbol.
+	NotToken TokenID = "not"
+	// PowerToken represents a caret symbol for exponentiation.
+	PowerToken TokenID = "power"
+	// ModulusToken represents a percent symbol for remainder.
+	ModulusToken TokenID = "mod"
+	// AndToken represents logical-and &&
+	AndToken TokenID = "and"
+	// OrToken represents logical-or ||
+	OrToken TokenID = "or"
+	// UnknownToken is a placeholder for when there was an error in the token.
+	UnknownToken TokenID = "error"
+)
+
+// TokenValue represents the token parsed from the expression the tokenizer
+// was initialized with.
+// The line number and column is relative to the beginning of the expression.
+// If part of a greater file, it's recommended that you offset those values to
+// get the line and column within the file to prevent confusion.
+type TokenValue struct {
+	Value    string
+	TokenID  TokenID
+	Filename string
+	Line     int
+	Column   int
+	// Offset is the byte offset of the token from the start of the expression.
+	Offset int
+}
+
+// startPosition returns the position of the first character of the token.
+func (t *TokenValue) startPosition() Position {
+	return Position{Line: t.Line, Column: t.Column, Offset: t.Offset}
+}
+
+// endPosition returns the position directly after the last character of the token.
+func (t *TokenValue) endPosition() Position {
+	line, column := t.Line, t.Column
+	for _, char := range t.Value {
+		if char == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return Position{Line: line, Column: column, Offset: t.Offset + len(t.Value)}
+}
+
+// tokenizer is used for reading tokens of an expression. It scans the source directly, so invalid input is only
+// reported through the returned errors.
+type tokenizer struct {
+	filename string
+	// source is the expression being tokenized, also used to create error snippets.
+	source string
+	// offset is the byte offset of the next character to scan.
+	offset int
+	// line and column are the position of the next character to scan, without the offsets.
+	line   int
+	column int
+	// lineOffset is added to the line numbers of all tokens.
+	lineOffset int
+	// columnOffset is added to the column numbers of the tokens on the first line.
+	columnOffset int
+}
+
+// initTokenizer initializes the tokenizer struct with the given expression.
+func initTokenizer(expression string, sourceName string) *tokenizer {
+	// Need to trim the trailing whitespace first since that can cause unexpected blank tokens.
+	// Leading whitespace is skipped when scanning, which keeps the token positions relative to the original input.
+	return &tokenizer{
+		filename: sourceName,
+		source:   strings.TrimRightFunc(expression, unicode.IsSpace),
+		line:     1,
+		column:   1,
+	}
+}
+
+// hasNextToken Checks to see if it has reached the end of the expression.
+// If it has, it returns false. If there are tokens left, it returns true.
+func (t *tokenizer) hasNextToken() bool {
+	return t.offset < len(t.source)
+}
+
+// getNext gets the next token type and value.
+// If there is no token left, it returns an unknown token and an
+// InvalidTokenError.
+func (t *tokenizer) getNext() (*TokenValue, error) {
+	token := &TokenValue{}
+	err := t.next(token)
+	return token, err
+}
+
+// next scans the next token into the token, so a single token can be reused for all tokens of the expression. The
+// value of the token is a slice of the expression, not a copy.
+// If there is no token left, it sets an unknown token and returns an InvalidTokenError.
+func (t *tokenizer) next(token *TokenValue) error {
+	t.skipWhitespace()
+	line, column := t.position()
+	start := t.offset
+	reason := t.scanToken()
+	*token = TokenValue{t.source[start:t.offset], UnknownToken, t.filename, line, column, start}
+	if reason == "" {
+		token.TokenID = classifyToken(token.Value)
+		if token.TokenID == UnknownToken {
+			reason = invalidTokenReason(token.Value)
+		}
+	}
+	if reason != "" {
+		return &InvalidTokenError{InvalidToken: *token, Reason: reason}
+	}
+	return nil
+}
+
+// skipWhitespace advances past spaces, tabs, and line breaks.
+func (t *tokenizer) skipWhitespace() {
+	for t.offset < len(t.source) {
+		switch t.source[t.offset] {
+		case ' ', '\t', '\n', '\r':
+			t.advance()
+		default:
+			return
+		}
+	}
+}
+
+// scanToken advances past the next token. It returns the reason if the token cannot be valid, such as an
+// unterminated string, or an empty string otherwise.
+func (t *tokenizer) scanToken() string {
+	if t.offset >= len(t.source) {
+		return "the expression ended"
+	}
+	first, _ := utf8.DecodeRuneInString(t.source[t.offset:])
+	switch {
+	case first < utf8.RuneSelf && isDigit(byte(first)):
+		t.scanNumber()
+	case first == '_' || unicode.IsLetter(first):
+		t.scanWord()
+	case first == '"' || first == '\'':
+		return t.scanString(byte(first))
+	case first == '`':
+		return t.scanRawString()
+	case first == '$':
+		// The name of a named root, such as $steps, is part of the root access token.
+		t.advance()
+		if next, _ := utf8.DecodeRuneInString(t.source[t.offset:]); next == '_' || unicode.IsLetter(next) {
+			t.scanWord()
+		}
+	default:
+		t.advance()
+	}
+	return ""
+}
+
+// scanNumber advances past digits, an optional fraction and exponent, and the letters and digits directly following
+// them, such as the units of duration and byte size literals. Whether the result is a valid number is decided when
+// classifying the token.
+func (t *tokenizer) scanNumber() {
+	t.advanceWhile(isDigit)
+	if t.peek(0) == '.' {
+		t.advance()
+		t.advanceWhile(isDigit)
+	}
+	if exponent := t.peek(0); exponent == 'e' || exponent == 'E' {
+		signLength := 0
+		if sign := t.peek(1); sign == '+' || sign == '-' {
+			signLength = 1
+		}
+		if isDigit(t.peek(1 + signLength)) {
+			for i := 0; i <= signLength; i++ {
+				t.advance()
+			}
+			t.advanceWhile(isDigit)
+		}
+	}
+	t.scanWord()
+}
+
+// scanWord advances past letters, digits, and underscores, including non-ASCII letters and digits, so identifiers
+// with such characters are reported as a single invalid token.
+func (t *tokenizer) scanWord() {
+	for t.offset < len(t.source) {
+		char, _ := utf8.DecodeRuneInString(t.source[t.offset:])
+		if char != '_' && !unicode.IsLetter(char) && !unicode.IsDigit(char) {
+			return
+		}
+		t.advance()
+	}
+}
+
+// scanString advances past a string in the quotes, in which characters can be escaped with a backslash. The string
+// must end on the same line.
+func (t *tokenizer) scanString(quote byte) string {
+	t.advance()
+	for t.offset < len(t.source) {
+		switch t.source[t.offset] {
+		case quote:
+			t.advance()
+			return ""
+		case '\n':
+			return "the string is not closed before the end of the line"
+		case '\\':
+			t.advance()
+			if t.offset < len(t.source) && t.source[t.offset] != '\n' {
+				t.advance()
+			}
+		default:
+			t.advance()
+		}
+	}
+	return "the string is not closed before the end of the expression"
+}
+
+// scanRawString advances past a string in backticks, in which characters cannot be escaped.
+func (t *tokenizer) scanRawString() string {
+	t.advance()
+	for t.offset < len(t.source) {
+		if t.source[t.offset] == '`' {
+			t.advance()
+			return ""
+		}
+		t.advance()
+	}
+	return "the string is not closed before the end of the expression"
+}
+
+// advance advances past the next character, and updates the position.
+func (t *tokenizer) advance() {
+	char, size := utf8.DecodeRuneInString(t.source[t.offset:])
+	t.offset += size
+	if char == '\n' {
+		t.line++
+		t.column = 1
+	} else {
+		t.column++
+	}
+}
+
+// advanceWhile advances past the ASCII characters that match.
+func (t *tokenizer) advanceWhile(matches func(byte) bool) {
+	for t.offset < len(t.source) && matches(t.source[t.offset]) {
+		t.advance()
+	}
+}
+
+// peek returns the byte the distance after the next character, or 0 if it is past the end of the source.
+func (t *tokenizer) peek(distance int) byte {
+	if t.offset+distance >= len(t.source) {
+		return 0
+	}
+	return t.source[t.offset+distance]
+}
+
+// position returns the line and column of the next character with the offsets applied.
+func (t *tokenizer) position() (int, int) {
+	column := t.column
+	if t.line == 1 {
+		column += t.columnOffset
+	}
+	return t.line + t.lineOffset, column
+}
+
+// classifyToken returns the ID of the token with the value, or UnknownToken if the value is not a valid token.
+func classifyToken(value string) TokenID {
+	if value == "" {
+		return UnknownToken
+	}
+	first := value[0]
+	switch {
+	case value == "true" || value == "false":
+		return BooleanLiteralToken
+	case isDigit(first):
+		return classifyNumber(value)
+	case isWordCharacter(first):
+		if isWord(value) {
+			return IdentifierToken
+		}
+		return UnknownToken
+	case first == '"' || first == '\'' || first == '`':
+		return classifyString(value)
+	case first == '$' && len(value) > 1:
+		if isWord(value[1:]) {
+			return RootAccessToken
+		}
+		return UnknownToken
+	case len(value) == 1:
+		return classifySymbol(first)
+	default:
+		return UnknownToken
+	}
+}
+
+// classifyNumber returns the ID of a token that starts with a digit.
+func classifyNumber(value string) TokenID {
+	switch {
+	case hasLeadingZero(value):
+		// Integers such as 07 are not valid, so they are not mistaken for octal numbers or identifiers.
+		return UnknownToken
+	case isFloatLiteral(value):
+		return FloatLiteralToken
+	case isIntLiteral(value):
+		return IntLiteralToken
+	case durationPattern.MatchString(value):
+		return DurationLiteralToken
+	case byteSizePattern.MatchString(value):
+		return ByteSizeLiteralToken
+	default:
+		return UnknownToken
+	}
+}
+
+// classifyString returns the ID of a token that starts with a quote. The token must end with the same quote.
+func classifyString(value string) TokenID {
+	quote := value[0]
+	if len(value) < 2 || value[len(value)-1] != quote || strings.ContainsRune(value, '\n') {
+		return UnknownToken
+	}
+	if quote == '`' {
+		return RawStringLiteralToken
+	}
+	return StringLiteralToken
+}
+
+// invalidTokenReason returns why the scanned token is not valid.
+func invalidTokenReason(value string) string {
+	first := value[0]
+	switch {
+	case hasLeadingZero(value):
+		return "leading zeros are not allowed in integers"
+	case isDigit(first):
+		return "not a valid number, duration, or byte size"
+	case first == '"' || first == '\'' || first == '`':
+		return "strings cannot span multiple lines"
+	case first == '$':
+		return "root names can only contain ASCII letters, digits, and underscores"
+	case isWordCharacter(first) || (first >= utf8.RuneSelf && utf8.RuneCountInString(value) > 1):
+		return "identifiers can only contain ASCII letters, digits, and underscores"
+	default:
+		return "unsupported character"
+	}
+}
+
+// classifySymbol returns the ID of a single character token that is not a literal or identifier.
+func classifySymbol(symbol byte) TokenID {
+	switch symbol {
+	case '[':
+		return BracketAccessDelimiterStartToken
+	case ']':
+		return BracketAccessDelimiterEndToken
+	case '(':
+		return ParenthesesStartToken
+	case ')':
+		return ParenthesesEndToken
+	case '.':
+		return DotObjectAccessToken
+	case '$':
+		return RootAccessToken
+	case '@':
+		return CurrentObjectAccessToken
+	case '=':
+		return EqualsToken
+	case ':':
+		return SelectorToken
+	case '?':
+		return FilterToken
+	case '-':
+		return NegationToken
+	case '*':
+		return AsteriskToken
+	case ',':
+		return ListSeparatorToken
+	case '/':
+		return DivideToken
+	case '>':
+		return GreaterThanToken
+	case '<':
+		return LessThanToken
+	case '+':
+		return PlusToken
+	case '!':
+		return NotToken
+	case '^':
+		return PowerToken
+	case '%':
+		return ModulusToken
+	case '&':
+		return AndToken
+	case '|':
+		return OrToken
+	default:
+		return UnknownToken
+	}
+}
+
+// isFloatLiteral returns true if the value is digits, followed by a period and optional digits, an exponent, or both,
+// such as 5.0, 5e6, or 5.0e-5.
+func isFloatLiteral(value string) bool {
+	i := skipDigits(value, 0)
+	if i == 0 || i == len(value) {
+		return false
+	}
+	if value[i] == '.' {
+		i = skipDigits(value, i+1)
+		if i == len(value) {
+			return true
+		}
+	}
+	if value[i] != 'e' && value[i] != 'E' {
+		return false
+	}
+	i++
+	if i < len(value) && (value[i] == '+' || value[i] == '-') {
+		i++
+	}
+	exponentStart := i
+	i = skipDigits(value, i)
+	return i > exponentStart && i == len(value)
+}
+
+// hasLeadingZero returns true if the value is an integer with a leading zero, such as 07. Floats, durations, and byte
+// sizes, such as 05.0 or 05m, are not included, since their meaning is clear.
+func hasLeadingZero(value string) bool {
+	return len(value) > 1 && value[0] == '0' && skipDigits(value, 0) == len(value)
+}
+
+// isIntLiteral returns true if the value is 0, or digits that do not start with 0.
+func isIntLiteral(value string) bool {
+	if value == "0" {
+		return true
+	}
+	return value[0] != '0' && skipDigits(value, 0) == len(value)
+}
+
+// isWord returns true if the value only consists of ASCII letters, digits, and underscores.
+func isWord(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if !isWordCharacter(value[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// skipDigits returns the index of the first character at or after the start that is not a digit.
+func skipDigits(value string, start int) int {
+	i := start
+	for i < len(value) && isDigit(value[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isWordCharacter(char byte) bool {
+	return isDigit(char) || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_'
+}
+
+// snippet returns the line of the source at the specified position, followed by a line with a marker starting at
+// the position, and spanning the specified number of characters. The position must include the offsets.
+// Returns an empty string if the position is not in the source.
+func (t *tokenizer) snippet(position Position, length int) string {
+	lineIndex := position.Line - t.lineOffset - 1
+	lines := strings.Split(t.source, "\n")
+	if lineIndex < 0 || lineIndex >= len(lines) {
+		return ""
+	}
+	sourceLine := []rune(strings.TrimRight(lines[lineIndex], "\r"))
+	columnIndex := position.Column - 1
+	if lineIndex == 0 {
+		columnIndex -= t.columnOffset
+	}
+	if columnIndex < 0 || columnIndex > len(sourceLine) {
+		return ""
+	}
+	var marker strings.Builder
+	// Keep tabs, so the marker lines up with the source line.
+	for _, char := range sourceLine[:columnIndex] {
+		if char == '\t' {
+			marker.WriteRune('\t')
+		} else {
+			marker.WriteRune(' ')
+		}
+	}
+	marker.WriteRune('^')
+	if length > 1 {
+		marker.WriteString(strings.Repeat("~", length-1))
+	}
+	return string(sourceLine) + "\n" + marker.String()
+}
+
+// Token is a token of an expression together with its position, as returned by Lex.
+type Token struct {
+	ID    TokenID
+	Value string
+	// Start is the position of the first character of the token.
+	Start Position
+	// End is the position directly after the last character of the token.
+	End Position
+}
+
+// Lex splits the expression into tokens without parsing it. This is useful for syntax highlighting. Invalid tokens
+// are returned with the UnknownToken ID, and the error of the first invalid token is returned together with all
+// tokens, so the caller can still highlight the rest of the expression.
+func Lex(expression string) ([]Token, error) {
+	t := initTokenizer(expression, "")
+	var result []Token
+	var firstErr error
+	var tokenValue TokenValue
+	for t.hasNextToken() {
+		if err := t.next(&tokenValue); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		result = append(result, Token{
+			ID:    tokenValue.TokenID,
+			Value: tokenValue.Value,
+			Start: tokenValue.startPosition(),
+			End:   tokenValue.endPosition(),
+		})
+	}
+	return result, firstErr
+}
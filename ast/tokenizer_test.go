@@ -0,0 +1,468 @@
+package ast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+var filename = "example.go"
+
+func TestTokenizer(t *testing.T) {
+	// Include trailing whitespace to ensure that it has no ill effects.
+	input := `$.steps.read_kubeconfig.output["success"].credentials[f(1,2)]   `
+	tokenizer := initTokenizer(input, filename)
+	expectedValue := []TokenValue{
+		{"$", RootAccessToken, filename, 1, 1, 0},
+		{".", DotObjectAccessToken, filename, 1, 2, 1},
+		{"steps", IdentifierToken, filename, 1, 3, 2},
+		{".", DotObjectAccessToken, filename, 1, 8, 7},
+		{"read_kubeconfig", IdentifierToken, filename, 1, 9, 8},
+		{".", DotObjectAccessToken, filename, 1, 24, 23},
+		{"output", IdentifierToken, filename, 1, 25, 24},
+		{"[", BracketAccessDelimiterStartToken, filename, 1, 31, 30},
+		{"\"success\"", StringLiteralToken, filename, 1, 32, 31},
+		{"]", BracketAccessDelimiterEndToken, filename, 1, 41, 40},
+		{".", DotObjectAccessToken, filename, 1, 42, 41},
+		{"credentials", IdentifierToken, filename, 1, 43, 42},
+		{"[", BracketAccessDelimiterStartToken, filename, 1, 54, 53},
+		{"f", IdentifierToken, filename, 1, 55, 54},
+		{"(", ParenthesesStartToken, filename, 1, 56, 55},
+		{"1", IntLiteralToken, filename, 1, 57, 56},
+		{",", ListSeparatorToken, filename, 1, 58, 57},
+		{"2", IntLiteralToken, filename, 1, 59, 58},
+		{")", ParenthesesEndToken, filename, 1, 60, 59},
+		{"]", BracketAccessDelimiterEndToken, filename, 1, 61, 60},
+	}
+	for _, expected := range expectedValue {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		nextToken, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, nextToken.Value, expected.Value)
+		assert.Equals(t, nextToken.TokenID, expected.TokenID)
+		assert.Equals(t, nextToken.Filename, expected.Filename)
+		assert.Equals(t, nextToken.Line, expected.Line)
+		assert.Equals(t, nextToken.Column, expected.Column)
+		assert.Equals(t, nextToken.Offset, expected.Offset)
+	}
+}
+
+func TestTokenizer_TokenizerWithEscapedStr(t *testing.T) {
+	input := `$.output["ab\"|cd"]`
+	tokenizer := initTokenizer(input, filename)
+	expectedValue := []string{"$", ".", "output", "[", `"ab\"|cd"`, "]"}
+	for _, expected := range expectedValue {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		nextToken, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, nextToken.Value, expected)
+	}
+}
+
+func TestTokenizer_BinaryOperations(t *testing.T) {
+	input := `5 + 5 / 1 >= 5^5`
+	tokenizer := initTokenizer(input, filename)
+	expectedValue := []TokenValue{
+		{"5", IntLiteralToken, filename, 1, 1, 0},
+		{"+", PlusToken, filename, 1, 3, 2},
+		{"5", IntLiteralToken, filename, 1, 5, 4},
+		{"/", DivideToken, filename, 1, 7, 6},
+		{"1", IntLiteralToken, filename, 1, 9, 8},
+		{">", GreaterThanToken, filename, 1, 11, 10},
+		{"=", EqualsToken, filename, 1, 12, 11},
+		{"5", IntLiteralToken, filename, 1, 14, 13},
+		{"^", PowerToken, filename, 1, 15, 14},
+		{"5", IntLiteralToken, filename, 1, 16, 15},
+	}
+	for _, expected := range expectedValue {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		nextToken, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, nextToken.Value, expected.Value)
+		assert.Equals(t, nextToken.TokenID, expected.TokenID)
+		assert.Equals(t, nextToken.Filename, expected.Filename)
+		assert.Equals(t, nextToken.Line, expected.Line)
+		assert.Equals(t, nextToken.Column, expected.Column)
+		assert.Equals(t, nextToken.Offset, expected.Offset)
+	}
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+}
+
+func TestTokenizer_WithFilterType(t *testing.T) {
+	input := "$.steps.foo.outputs[\"bar\"][?(@._type=='x')].a"
+	tokenizer := initTokenizer(input, filename)
+	expectedValue := []string{"$", ".", "steps", ".", "foo", ".", "outputs",
+		"[", "\"bar\"", "]", "[", "?", "(", "@", ".", "_type", "=", "=", "'x'", ")", "]", ".", "a"}
+	for _, expected := range expectedValue {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		nextToken, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, nextToken.Value, expected)
+	}
+}
+
+func TestTokenizer_InvalidToken(t *testing.T) {
+	input := "[€"
+	tokenizer := initTokenizer(input, filename)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err := tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, BracketAccessDelimiterStartToken)
+	assert.Equals(t, tokenVal.Value, "[")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.Error(t, err)
+	assert.Equals(t, tokenVal.TokenID, UnknownToken)
+	assert.Equals(t, tokenVal.Value, "€")
+	expectedError := &InvalidTokenError{}
+	isCorrectErrType := errors.As(err, &expectedError)
+	if !isCorrectErrType {
+		t.Fatalf("Error is of incorrect type")
+	}
+	assert.Equals(t, expectedError.InvalidToken.Column, 2)
+	assert.Equals(t, expectedError.InvalidToken.Line, 1)
+	assert.Equals(t, expectedError.InvalidToken.Filename, filename)
+	assert.Equals(t, expectedError.InvalidToken.Value, "€")
+}
+
+func TestTokenizer_IntLiteral(t *testing.T) {
+	input := "70 07"
+	tokenizer := initTokenizer(input, filename)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err := tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, IntLiteralToken)
+	assert.Equals(t, tokenVal.Value, "70")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	// Numbers that start with 0 are not valid.
+	_, err = tokenizer.getNext()
+	var tokenErr *InvalidTokenError
+	assert.Equals(t, errors.As(err, &tokenErr), true)
+	assert.Equals(t, tokenErr.InvalidToken.Value, "07")
+	assert.Equals(t, tokenErr.Reason, "leading zeros are not allowed in integers")
+	assert.Equals(t, tokenErr.Hint(), "remove the leading zeros, such as 7 instead of 07")
+}
+
+func TestTokenizer_UnitLiterals(t *testing.T) {
+	input := "5m30s 2Gi 512MB 10 5x"
+	tokenizer := initTokenizer(input, filename)
+	expected := []struct {
+		value   string
+		tokenID TokenID
+		column  int
+	}{
+		{"5m30s", DurationLiteralToken, 1},
+		{"2Gi", ByteSizeLiteralToken, 7},
+		{"512MB", ByteSizeLiteralToken, 11},
+		{"10", IntLiteralToken, 17},
+	}
+	for _, expectedToken := range expected {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		tokenVal, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, tokenVal.TokenID, expectedToken.tokenID)
+		assert.Equals(t, tokenVal.Value, expectedToken.value)
+		assert.Equals(t, tokenVal.Column, expectedToken.column)
+	}
+	tokenVal, err := tokenizer.getNext()
+	var expectedError *InvalidTokenError
+	assert.Equals(t, errors.As(err, &expectedError), true)
+	assert.Equals(t, tokenVal.Value, "5x")
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+}
+
+func TestTokenizer_FloatLiteral(t *testing.T) {
+	input := "0.0 40.099 5.0e5 5.0E-5 05.00 5."
+	tokenizer := initTokenizer(input, filename)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err := tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "0.0")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "40.099")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "5.0e5")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "5.0E-5")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "05.00")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, FloatLiteralToken)
+	assert.Equals(t, tokenVal.Value, "5.")
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+
+}
+
+func TestTokenizer_BooleanLiterals(t *testing.T) {
+	input := "true && false || false"
+	tokenizer := initTokenizer(input, filename)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err := tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, BooleanLiteralToken)
+	assert.Equals(t, tokenVal.Value, "true")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, AndToken)
+	assert.Equals(t, tokenVal.Value, "&")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, AndToken)
+	assert.Equals(t, tokenVal.Value, "&")
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, BooleanLiteralToken)
+	assert.Equals(t, tokenVal.Value, "false")
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, OrToken)
+	assert.Equals(t, tokenVal.Value, "|")
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, OrToken)
+	assert.Equals(t, tokenVal.Value, "|")
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, BooleanLiteralToken)
+	assert.Equals(t, tokenVal.Value, "false")
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+}
+
+func TestTokenizer_TokenPrefixSuffixInvalid(t *testing.T) {
+	// This test checks that each of the input tokens are not recognized as boolean
+	// literals (due to their substrings) by checking that they are recognized,
+	// instead, as identifiers.
+	input := "atrue truea atruea afalse falsea afalsea"
+	expectedNumTokens := strings.Count(input, " ") + 1
+	tokenizer := initTokenizer(input, filename)
+	actualTokens := 0
+	for tokenizer.hasNextToken() {
+		actualTokens++
+		tokenVal, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, tokenVal.TokenID, IdentifierToken)
+	}
+	assert.Equals(t, actualTokens, expectedNumTokens)
+}
+
+func TestTokenizer_StringLiteral(t *testing.T) {
+	input := `"" "a" "a\"b"` + " `raw_str/\\`"
+	tokenizer := initTokenizer(input, filename)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err := tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, StringLiteralToken)
+	assert.Equals(t, tokenVal.Value, `""`)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, StringLiteralToken)
+	assert.Equals(t, tokenVal.Value, `"a"`)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, StringLiteralToken)
+	assert.Equals(t, tokenVal.Value, `"a\"b"`)
+	assert.Equals(t, tokenizer.hasNextToken(), true)
+	tokenVal, err = tokenizer.getNext()
+	assert.NoError(t, err)
+	assert.Equals(t, tokenVal.TokenID, RawStringLiteralToken)
+	assert.Equals(t, tokenVal.Value, "`raw_str/\\`")
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+}
+
+func TestTokenizer_Wildcard(t *testing.T) {
+	input := `$.*`
+	tokenizer := initTokenizer(input, filename)
+	expectedValue := []string{"$", ".", "*"}
+	for _, expected := range expectedValue {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		nextToken, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, nextToken.Value, expected)
+	}
+}
+
+func TestLex(t *testing.T) {
+	tokens, err := Lex(`  f($.a["b c"],` + "\n" + `1.5) >= 2`)
+	assert.NoError(t, err)
+	expected := []Token{
+		{IdentifierToken, "f", Position{1, 3, 2}, Position{1, 4, 3}},
+		{ParenthesesStartToken, "(", Position{1, 4, 3}, Position{1, 5, 4}},
+		{RootAccessToken, "$", Position{1, 5, 4}, Position{1, 6, 5}},
+		{DotObjectAccessToken, ".", Position{1, 6, 5}, Position{1, 7, 6}},
+		{IdentifierToken, "a", Position{1, 7, 6}, Position{1, 8, 7}},
+		{BracketAccessDelimiterStartToken, "[", Position{1, 8, 7}, Position{1, 9, 8}},
+		{StringLiteralToken, `"b c"`, Position{1, 9, 8}, Position{1, 14, 13}},
+		{BracketAccessDelimiterEndToken, "]", Position{1, 14, 13}, Position{1, 15, 14}},
+		{ListSeparatorToken, ",", Position{1, 15, 14}, Position{1, 16, 15}},
+		{FloatLiteralToken, "1.5", Position{2, 1, 16}, Position{2, 4, 19}},
+		{ParenthesesEndToken, ")", Position{2, 4, 19}, Position{2, 5, 20}},
+		{GreaterThanToken, ">", Position{2, 6, 21}, Position{2, 7, 22}},
+		{EqualsToken, "=", Position{2, 7, 22}, Position{2, 8, 23}},
+		{IntLiteralToken, "2", Position{2, 9, 24}, Position{2, 10, 25}},
+	}
+	assert.Equals(t, tokens, expected)
+}
+
+func TestLex_InvalidToken(t *testing.T) {
+	tokens, err := Lex(`$.a # 1`)
+	var invalidTokenErr *InvalidTokenError
+	assert.Equals(t, errors.As(err, &invalidTokenErr), true)
+	assert.Equals(t, len(tokens), 5)
+	assert.Equals(t, tokens[3].ID, UnknownToken)
+	assert.Equals(t, tokens[3].Value, "#")
+	assert.Equals(t, tokens[4].ID, IntLiteralToken)
+}
+
+func TestTokenizer_InvalidTokenReasons(t *testing.T) {
+	cases := map[string]string{
+		`"abc`:     "the string is not closed before the end of the expression",
+		`'ab\'`:    "the string is not closed before the end of the expression",
+		"\"a\nb\"": "the string is not closed before the end of the line",
+		"0x1F":     "not a valid number, duration, or byte size",
+		"007":      "leading zeros are not allowed in integers",
+		"5.0s":     "not a valid number, duration, or byte size",
+		"héllo":    "identifiers can only contain ASCII letters, digits, and underscores",
+		"#":        "unsupported character",
+	}
+	for input, expectedReason := range cases {
+		t.Run(input, func(t *testing.T) {
+			tokenizer := initTokenizer(input, filename)
+			_, err := tokenizer.getNext()
+			var tokenErr *InvalidTokenError
+			assert.Equals(t, errors.As(err, &tokenErr), true)
+			assert.Equals(t, tokenErr.Reason, expectedReason)
+		})
+	}
+}
+
+func TestTokenizer_Scanning(t *testing.T) {
+	// Single quoted strings can contain escaped quotes, and slashes are always operators.
+	input := "'it\\'s' // 2 /* 3 */\n\t`a\\b` 1.5"
+	tokenizer := initTokenizer(input, filename)
+	expected := []struct {
+		tokenID TokenID
+		value   string
+		line    int
+		column  int
+	}{
+		{StringLiteralToken, `'it\'s'`, 1, 1},
+		{DivideToken, "/", 1, 9},
+		{DivideToken, "/", 1, 10},
+		{IntLiteralToken, "2", 1, 12},
+		{DivideToken, "/", 1, 14},
+		{AsteriskToken, "*", 1, 15},
+		{IntLiteralToken, "3", 1, 17},
+		{AsteriskToken, "*", 1, 19},
+		{DivideToken, "/", 1, 20},
+		{RawStringLiteralToken, "`a\\b`", 2, 2},
+		{FloatLiteralToken, "1.5", 2, 8},
+	}
+	for _, expectedToken := range expected {
+		assert.Equals(t, tokenizer.hasNextToken(), true)
+		tokenVal, err := tokenizer.getNext()
+		assert.NoError(t, err)
+		assert.Equals(t, tokenVal.TokenID, expectedToken.tokenID)
+		assert.Equals(t, tokenVal.Value, expectedToken.value)
+		assert.Equals(t, tokenVal.Line, expectedToken.line)
+		assert.Equals(t, tokenVal.Column, expectedToken.column)
+	}
+	assert.Equals(t, tokenizer.hasNextToken(), false)
+}
+
+func TestClassifyToken(t *testing.T) {
+	cases := map[string]TokenID{
+		"true":      BooleanLiteralToken,
+		"truefalse": IdentifierToken,
+		"_a1":       IdentifierToken,
+		"héllo":     UnknownToken,
+		"0":         IntLiteralToken,
+		"120":       IntLiteralToken,
+		"07":        UnknownToken,
+		"00":        UnknownToken,
+		"07.5":      FloatLiteralToken,
+		"1e6":       FloatLiteralToken,
+		"1E-6":      FloatLiteralToken,
+		"1e":        UnknownToken,
+		"0.5":       FloatLiteralToken,
+		"05m":       DurationLiteralToken,
+		"0x1F":      UnknownToken,
+		"1_000":     UnknownToken,
+		"5.":        FloatLiteralToken,
+		"5.0e-5":    FloatLiteralToken,
+		"5.0e":      UnknownToken,
+		"5.0e+":     UnknownToken,
+		"1h30m":     DurationLiteralToken,
+		"2GiB":      ByteSizeLiteralToken,
+		`"a"`:       StringLiteralToken,
+		`'a'`:       StringLiteralToken,
+		`"a'`:       UnknownToken,
+		`"`:         UnknownToken,
+		"`a`":       RawStringLiteralToken,
+		"`a\nb`":    UnknownToken,
+		"&":         AndToken,
+		"#":         UnknownToken,
+		"€":         UnknownToken,
+	}
+	for value, expected := range cases {
+		t.Run(value, func(t *testing.T) {
+			assert.Equals(t, classifyToken(value), expected)
+		})
+	}
+}
+
+func BenchmarkTokenizer(b *testing.B) {
+	input := `$.steps.read_kubeconfig.outputs["success"].credentials[f(1, 2.5)] + 5m30s >= 2Gi && !$.flag`
+	b.ReportAllocs()
+	var token TokenValue
+	for i := 0; i < b.N; i++ {
+		tokenizer := initTokenizer(input, filename)
+		for tokenizer.hasNextToken() {
+			if err := tokenizer.next(&token); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestTokenizer_NamedRoot(t *testing.T) {
+	tokens, err := Lex(`$steps.a + $ $_x`)
+	assert.NoError(t, err)
+	ids := make([]TokenID, len(tokens))
+	values := make([]string, len(tokens))
+	for i, token := range tokens {
+		ids[i] = token.ID
+		values[i] = token.Value
+	}
+	assert.Equals(t, values, []string{"$steps", ".", "a", "+", "$", "$_x"})
+	assert.Equals(t, ids, []TokenID{
+		RootAccessToken, DotObjectAccessToken, IdentifierToken, PlusToken, RootAccessToken, RootAccessToken,
+	})
+
+	_, err = Lex(`$éa`)
+	var invalidTokenErr *InvalidTokenError
+	assert.Equals(t, errors.As(err, &invalidTokenErr), true)
+	assert.Equals(t, invalidTokenErr.InvalidToken.Value, "$éa")
+	assert.Contains(t, invalidTokenErr.Reason, "root names can only contain")
+}
@@ -0,0 +1,90 @@
+package ast
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// durationUnits are the number of seconds in each duration unit.
+var durationUnits = map[string]int64{
+	"d": 24 * 60 * 60,
+	"h": 60 * 60,
+	"m": 60,
+	"s": 1,
+}
+
+// byteSizeUnits are the number of bytes in each byte size unit. Units without an i are powers of 1000, units with
+// an i are powers of 1024.
+var byteSizeUnits = map[string]int64{
+	"B":   1,
+	"K":   1000,
+	"KB":  1000,
+	"M":   1000 * 1000,
+	"MB":  1000 * 1000,
+	"G":   1000 * 1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"T":   1000 * 1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"P":   1000 * 1000 * 1000 * 1000 * 1000,
+	"PB":  1000 * 1000 * 1000 * 1000 * 1000,
+	"Ki":  1 << 10,
+	"KiB": 1 << 10,
+	"Mi":  1 << 20,
+	"MiB": 1 << 20,
+	"Gi":  1 << 30,
+	"GiB": 1 << 30,
+	"Ti":  1 << 40,
+	"TiB": 1 << 40,
+	"Pi":  1 << 50,
+	"PiB": 1 << 50,
+}
+
+var durationPattern = regexp.MustCompile(`^(?:\d+d)?(?:\d+h)?(?:\d+m)?(?:\d+s)?$`)
+var durationPartPattern = regexp.MustCompile(`(\d+)([dhms])`)
+var byteSizePattern = regexp.MustCompile(`^(\d+)([KMGTP]i?B?|B)$`)
+
+// ParseDurationLiteral parses a duration such as 5m30s into the number of seconds. The units are d, h, m, and s,
+// and must be in this order, each used at most once.
+func ParseDurationLiteral(literal string) (int64, error) {
+	if literal == "" || !durationPattern.MatchString(literal) {
+		return 0, errorf("invalid duration %q, expected a duration such as 1h30m with the units d, h, m, and s", literal)
+	}
+	var seconds int64
+	for _, part := range durationPartPattern.FindAllStringSubmatch(literal, -1) {
+		partSeconds, err := multiplyUnit(part[1], durationUnits[part[2]])
+		if err != nil || seconds > math.MaxInt64-partSeconds {
+			return 0, errorf("duration %q is too large", literal)
+		}
+		seconds += partSeconds
+	}
+	return seconds, nil
+}
+
+// ParseByteSizeLiteral parses a byte size such as 2Gi into the number of bytes. The units are B, K, M, G, T, and P
+// for powers of 1000, optionally followed by B, and Ki, Mi, Gi, Ti, and Pi for powers of 1024, optionally followed by
+// B.
+func ParseByteSizeLiteral(literal string) (int64, error) {
+	match := byteSizePattern.FindStringSubmatch(literal)
+	if match == nil {
+		return 0, errorf("invalid byte size %q, expected a size such as 2Gi or 512MB", literal)
+	}
+	bytes, err := multiplyUnit(match[1], byteSizeUnits[match[2]])
+	if err != nil {
+		return 0, errorf("byte size %q is too large", literal)
+	}
+	return bytes, nil
+}
+
+// multiplyUnit multiplies the number with the unit size, and returns an error if the result overflows.
+func multiplyUnit(number string, unitSize int64) (int64, error) {
+	value, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if value > math.MaxInt64/unitSize {
+		return 0, fmt.Errorf("%d times %d overflows", value, unitSize)
+	}
+	return value * unitSize, nil
+}
@@ -0,0 +1,59 @@
+package ast
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+func TestParseDurationLiteral(t *testing.T) {
+	testCases := map[string]int64{
+		"30s":       30,
+		"5m30s":     330,
+		"1h":        3600,
+		"2d1h1m1s":  2*86400 + 3661,
+		"0s":        0,
+		"1000000m":  60000000,
+		"1d0h0m10s": 86410,
+	}
+	for literal, expected := range testCases {
+		seconds, err := ParseDurationLiteral(literal)
+		assert.NoError(t, err)
+		assert.Equals(t, seconds, expected)
+	}
+	for _, literal := range []string{"", "5", "30s5m", "1h1h", "5ms", "99999999999999999999d", "106751991167301d"} {
+		_, err := ParseDurationLiteral(literal)
+		assert.Error(t, err)
+	}
+}
+
+func TestParseByteSizeLiteral(t *testing.T) {
+	testCases := map[string]int64{
+		"1B":    1,
+		"2K":    2000,
+		"512MB": 512000000,
+		"2Gi":   2 << 30,
+		"1PiB":  1 << 50,
+	}
+	for literal, expected := range testCases {
+		bytes, err := ParseByteSizeLiteral(literal)
+		assert.NoError(t, err)
+		assert.Equals(t, bytes, expected)
+	}
+	for _, literal := range []string{"", "5", "2g", "2GiBB", "9000000Pi"} {
+		_, err := ParseByteSizeLiteral(literal)
+		assert.Error(t, err)
+	}
+}
+
+func TestUnitLiteralParser(t *testing.T) {
+	p, err := InitParser("$.timeout > 5m30s", t.Name())
+	assert.NoError(t, err)
+	parsedResult, err := p.ParseExpression()
+	assert.NoError(t, err)
+	comparison := parsedResult.(*BinaryOperation)
+	literal := comparison.RightNode.(*IntLiteral)
+	assert.Equals(t, literal.IntValue, int64(330))
+	assert.Equals(t, literal.String(), "5m30s")
+	assert.Equals(t, literal.Value(), any(int64(330)))
+}
@@ -0,0 +1,97 @@
+package ast
+
+// Visitor is called for each node while walking the abstract syntax tree with Walk.
+type Visitor interface {
+	// Enter is called when a node is reached, before its children are visited. If it returns false, the children
+	// of the node are skipped, and Exit is not called for the node.
+	Enter(node Node) bool
+	// Exit is called after all children of the node have been visited.
+	Exit(node Node)
+}
+
+// VisitorFuncs is a Visitor implemented by optional callback functions. A nil EnterFunc visits all children,
+// and a nil ExitFunc does nothing.
+type VisitorFuncs struct {
+	EnterFunc func(node Node) bool
+	ExitFunc  func(node Node)
+}
+
+// Enter calls EnterFunc, if set.
+func (v VisitorFuncs) Enter(node Node) bool {
+	if v.EnterFunc == nil {
+		return true
+	}
+	return v.EnterFunc(node)
+}
+
+// Exit calls ExitFunc, if set.
+func (v VisitorFuncs) Exit(node Node) {
+	if v.ExitFunc != nil {
+		v.ExitFunc(node)
+	}
+}
+
+// Walk traverses the abstract syntax tree depth-first, starting at the specified node. The children of each node
+// are visited in the order they appear in the expression, which includes the identifier and the argument list of
+// function calls, the operand of unary operations, and the key of bracket accessors.
+func Walk(node Node, visitor Visitor) {
+	if node == nil {
+		return
+	}
+	if !visitor.Enter(node) {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(child, visitor)
+	}
+	visitor.Exit(node)
+}
+
+// Inspect traverses the abstract syntax tree depth-first, calling the specified function for each node. If the
+// function returns false, the children of the node are skipped.
+func Inspect(node Node, f func(node Node) bool) {
+	Walk(node, VisitorFuncs{EnterFunc: f})
+}
+
+// Children returns the direct children of the specified node in the order they appear in the expression.
+// Literals and identifiers have no children.
+func Children(node Node) []Node {
+	var children []Node
+	switch n := node.(type) {
+	case *DotNotation:
+		children = []Node{n.LeftAccessibleNode, n.RightAccessIdentifier}
+	case *BracketAccessor:
+		children = []Node{n.LeftNode, n.RightExpression}
+	case *FunctionCall:
+		children = []Node{n.FuncIdentifier, n.ArgumentInputs}
+	case *ArgumentList:
+		children = n.Arguments
+	case *BinaryOperation:
+		children = []Node{n.LeftNode, n.RightNode}
+	case *UnaryOperation:
+		children = []Node{n.RightNode}
+	default:
+		return nil
+	}
+	// Remove missing children, since a nil node cannot be visited.
+	result := make([]Node, 0, len(children))
+	for _, child := range children {
+		if child == nil || isNilPointer(child) {
+			continue
+		}
+		result = append(result, child)
+	}
+	return result
+}
+
+// isNilPointer checks for typed nil pointers, which are not equal to a nil interface.
+func isNilPointer(node Node) bool {
+	switch n := node.(type) {
+	case *Identifier:
+		return n == nil
+	case *ArgumentList:
+		return n == nil
+	default:
+		return false
+	}
+}
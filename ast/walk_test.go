@@ -0,0 +1,85 @@
+package ast
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+func parseForWalkTest(t *testing.T, expression string) Node {
+	p, err := InitParser(expression, t.Name())
+	assert.NoError(t, err)
+	node, err := p.ParseExpression()
+	assert.NoError(t, err)
+	return node
+}
+
+func TestWalk_AllNodeKinds(t *testing.T) {
+	node := parseForWalkTest(t, `!(f($.a["b"], -1) > 2)`)
+	var entered []string
+	var exited []string
+	Walk(node, VisitorFuncs{
+		EnterFunc: func(node Node) bool {
+			entered = append(entered, node.String())
+			return true
+		},
+		ExitFunc: func(node Node) {
+			exited = append(exited, node.String())
+		},
+	})
+	assert.Equals(t, entered, []string{
+		`!(f($.a["b"], -1) > 2)`,
+		`f($.a["b"], -1) > 2`,
+		`f($.a["b"], -1)`,
+		`f`,
+		`$.a["b"], -1`,
+		`$.a["b"]`,
+		`$.a`,
+		`$`,
+		`a`,
+		`"b"`,
+		`-1`,
+		`1`,
+		`2`,
+	})
+	assert.Equals(t, len(exited), len(entered))
+	// The root is exited last.
+	assert.Equals(t, exited[len(exited)-1], entered[0])
+}
+
+func TestWalk_SkipChildren(t *testing.T) {
+	node := parseForWalkTest(t, `f(1, 2) + g(3)`)
+	var visited []string
+	var exited []string
+	Walk(node, VisitorFuncs{
+		EnterFunc: func(node Node) bool {
+			visited = append(visited, node.String())
+			_, isFunction := node.(*FunctionCall)
+			return !isFunction
+		},
+		ExitFunc: func(node Node) {
+			exited = append(exited, node.String())
+		},
+	})
+	assert.Equals(t, visited, []string{`f(1, 2) + g(3)`, `f(1, 2)`, `g(3)`})
+	// Exit is not called for the skipped nodes.
+	assert.Equals(t, exited, []string{`f(1, 2) + g(3)`})
+}
+
+func TestInspect_FindFunctions(t *testing.T) {
+	node := parseForWalkTest(t, `a(b(1), $.c[d()])`)
+	var functions []string
+	Inspect(node, func(node Node) bool {
+		if functionCall, isFunctionCall := node.(*FunctionCall); isFunctionCall {
+			functions = append(functions, functionCall.FuncIdentifier.IdentifierName)
+		}
+		return true
+	})
+	assert.Equals(t, functions, []string{"a", "b", "d"})
+}
+
+func TestChildren_Leaves(t *testing.T) {
+	assert.Equals(t, len(Children(&IntLiteral{IntValue: 1})), 0)
+	assert.Equals(t, len(Children(&Identifier{IdentifierName: "a"})), 0)
+	assert.Equals(t, len(Children(&FunctionCall{FuncIdentifier: &Identifier{IdentifierName: "a"}})), 1)
+}
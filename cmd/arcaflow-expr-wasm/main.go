@@ -0,0 +1,132 @@
+//go:build js && wasm
+
+// Command arcaflow-expr-wasm exposes the parser and the evaluator to JavaScript, so workflow editors can check and
+// preview expressions in the browser. Build it with Go or TinyGo:
+//
+//	GOOS=js GOARCH=wasm go build -o expressions.wasm ./cmd/arcaflow-expr-wasm
+//	tinygo build -o expressions.wasm -target wasm ./cmd/arcaflow-expr-wasm
+//
+// Once loaded with the wasm_exec.js file of the compiler, it defines the global arcaflowExpressions object with the
+// following functions, which return JSON strings:
+//
+//	arcaflowExpressions.check(expression)
+//	    {"warnings": [...]} or {"error": {"message": ..., "code": ..., "line": ..., "column": ...}}
+//	arcaflowExpressions.evaluate(expression, dataJSON)
+//	    {"result": ...} or {"error": {...}}
+//
+// The expressions are evaluated without functions.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func main() {
+	js.Global().Set("arcaflowExpressions", js.ValueOf(map[string]any{
+		"check": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) != 1 {
+				return respond(map[string]any{"error": errorResponse(errors.New("check expects an expression"))})
+			}
+			return respond(check(args[0].String()))
+		}),
+		"evaluate": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			if len(args) != 2 {
+				return respond(map[string]any{
+					"error": errorResponse(errors.New("evaluate expects an expression and the data as JSON")),
+				})
+			}
+			return respond(evaluate(args[0].String(), args[1].String()))
+		}),
+	}))
+	// Keep the functions available until the page is closed.
+	select {}
+}
+
+// check parses the expression and returns its warnings or the parse error.
+func check(expressionString string) map[string]any {
+	var warnings []map[string]any
+	_, err := expressions.NewWithOptions(expressionString, expressions.Options{
+		OnWarning: func(warning expressions.Diagnostic) {
+			warnings = append(warnings, map[string]any{
+				"kind":    warning.Kind,
+				"message": warning.Message,
+				"line":    warning.Start.Line,
+				"column":  warning.Start.Column,
+			})
+		},
+	})
+	if err != nil {
+		return map[string]any{"error": errorResponse(err)}
+	}
+	return map[string]any{"warnings": warnings}
+}
+
+// evaluate evaluates the expression on the data, which is encoded as JSON.
+func evaluate(expressionString string, dataJSON string) map[string]any {
+	expr, err := expressions.New(expressionString)
+	if err != nil {
+		return map[string]any{"error": errorResponse(err)}
+	}
+	var data any
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return map[string]any{"error": errorResponse(err)}
+	}
+	result, err := expr.Evaluate(normalizeNumbers(data), nil, nil)
+	if err != nil {
+		return map[string]any{"error": errorResponse(err)}
+	}
+	return map[string]any{"result": result}
+}
+
+// normalizeNumbers converts the whole numbers decoded from JSON to integers, since expressions tell integers and
+// floats apart.
+func normalizeNumbers(value any) any {
+	switch typedValue := value.(type) {
+	case float64:
+		if typedValue == float64(int64(typedValue)) {
+			return int64(typedValue)
+		}
+	case []any:
+		for i, item := range typedValue {
+			typedValue[i] = normalizeNumbers(item)
+		}
+	case map[string]any:
+		for key, item := range typedValue {
+			typedValue[key] = normalizeNumbers(item)
+		}
+	}
+	return value
+}
+
+// errorResponse returns the message, the code, and the position of the error.
+func errorResponse(err error) map[string]any {
+	response := map[string]any{
+		"message": err.Error(),
+		"code":    expressions.ErrorCodeOf(err),
+	}
+	var codedErr *expressions.Error
+	var grammarErr *ast.InvalidGrammarError
+	switch {
+	case errors.As(err, &grammarErr) && grammarErr.FoundToken != nil:
+		response["line"] = grammarErr.FoundToken.Line
+		response["column"] = grammarErr.FoundToken.Column
+	case errors.As(err, &codedErr) && codedErr.Position.IsValid():
+		response["line"] = codedErr.Position.Line
+		response["column"] = codedErr.Position.Column
+	}
+	return response
+}
+
+// respond encodes the response as JSON.
+func respond(response map[string]any) any {
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		return `{"error": {"message": "failed to encode the response"}}`
+	}
+	return string(encoded)
+}
@@ -0,0 +1,208 @@
+// Command arcaflow-expr parses an Arcaflow expression and prints information about it. This is useful for
+// debugging the expressions of a workflow outside the engine.
+//
+// Usage:
+//
+//	arcaflow-expr [-schema scope.yaml] [-data data.yaml] [-context directory] [-ast] expression
+//
+// With a schema file, the type and the dependencies of the expression are printed. With a data file, the expression
+// is evaluated against the data and the result is printed as JSON. Both files may be in JSON or YAML format. The
+// built-in functions of the functions package can be called. With -ast, the syntax tree of the expression is printed
+// with the kinds and positions of its nodes.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"gopkg.in/yaml.v3"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the command with the specified arguments and returns the exit code.
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("arcaflow-expr", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	schemaFile := flags.String("schema", "", "Scope schema file (JSON or YAML) to resolve the type and dependencies with.")
+	dataFile := flags.String("data", "", "Data file (JSON or YAML) to evaluate the expression against.")
+	contextDir := flags.String("context", "", "Directory holding the workflow context files.")
+	printAST := flags.Bool("ast", false, "Print the syntax tree of the expression with the positions of its nodes.")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintf(stderr, "Usage: arcaflow-expr [options] expression\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	if err := execute(flags.Arg(0), *schemaFile, *dataFile, *contextDir, *printAST, stdout); err != nil {
+		_, _ = fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func execute(
+	expressionString string,
+	schemaFile string,
+	dataFile string,
+	contextDir string,
+	printAST bool,
+	stdout io.Writer,
+) error {
+	expr, err := expressions.New(expressionString)
+	if err != nil {
+		return err
+	}
+	registry := functions.NewFunctionRegistry()
+	if err := registry.RegisterBuiltins(""); err != nil {
+		return err
+	}
+	workflowContext, err := readWorkflowContext(contextDir)
+	if err != nil {
+		return err
+	}
+	if printAST {
+		_, _ = fmt.Fprintf(stdout, "AST:\n%s", expr.DumpAST())
+	}
+	if schemaFile != "" {
+		scope, err := readScope(schemaFile)
+		if err != nil {
+			return err
+		}
+		if err := printTypeInfo(expr, scope, registry.Functions(), workflowContext, stdout); err != nil {
+			return err
+		}
+	}
+	if dataFile != "" {
+		data, err := readData(dataFile)
+		if err != nil {
+			return err
+		}
+		result, err := expr.Evaluate(data, registry.CallableFunctions(), workflowContext)
+		if err != nil {
+			return err
+		}
+		encodedResult, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode the result (%w)", err)
+		}
+		_, _ = fmt.Fprintf(stdout, "Result: %s\n", encodedResult)
+	}
+	return nil
+}
+
+// printTypeInfo prints the type and the dependencies of the expression.
+func printTypeInfo(
+	expr expressions.Expression,
+	scope schema.Scope,
+	registeredFunctions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	stdout io.Writer,
+) error {
+	resultType, err := expr.Type(scope, registeredFunctions, workflowContext)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Type: %s\n", resultType.TypeID())
+	dependencies, err := expr.Dependencies(scope, registeredFunctions, workflowContext, expressions.UnpackRequirements{
+		IncludeKeys: true,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Dependencies:\n")
+	for _, dependency := range dependencies {
+		_, _ = fmt.Fprintf(stdout, "  - %s\n", dependency.String())
+	}
+	return nil
+}
+
+// readScope reads a scope schema from a JSON or YAML file.
+func readScope(file string) (schema.Scope, error) {
+	rawScope, err := readData(file)
+	if err != nil {
+		return nil, err
+	}
+	scope, err := schema.UnserializeScope(rawScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scope schema in %s (%w)", file, err)
+	}
+	return scope, nil
+}
+
+// readData reads a JSON or YAML file. JSON files are also parsed as YAML, since YAML is a superset of JSON.
+func readData(file string) (any, error) {
+	content, err := os.ReadFile(file) //nolint:gosec // The file is specified by the user on purpose.
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s (%w)", file, err)
+	}
+	var data any
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse %s (%w)", file, err)
+	}
+	return normalizeData(data), nil
+}
+
+// normalizeData converts the integers decoded from YAML to int64, which is the integer type used by expressions.
+func normalizeData(data any) any {
+	switch value := data.(type) {
+	case int:
+		return int64(value)
+	case map[string]any:
+		for key, item := range value {
+			value[key] = normalizeData(item)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = normalizeData(item)
+		}
+		return value
+	default:
+		return data
+	}
+}
+
+// readWorkflowContext reads the files in the directory into a map of file names to content.
+func readWorkflowContext(dir string) (map[string][]byte, error) {
+	workflowContext := map[string][]byte{}
+	if dir == "" {
+		return workflowContext, nil
+	}
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		relativePath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(path) //nolint:gosec // The directory is specified by the user on purpose.
+		if err != nil {
+			return err
+		}
+		workflowContext[filepath.ToSlash(relativePath)] = content
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the workflow context from %s (%w)", dir, err)
+	}
+	return workflowContext, nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.arcalot.io/assert"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRun_Evaluate(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := writeFile(t, dir, "data.yaml", "foo:\n  bar: 41\n")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"-data", dataFile, "-ast", "$.foo.bar + 1"}, stdout, stderr)
+	assert.Equals(t, stderr.String(), "")
+	assert.Equals(t, exitCode, 0)
+	assert.Equals(t, stdout.String(), "AST:\n"+
+		"BinaryOperation + @ 1:1-1:14\n"+
+		"  DotNotation @ 1:1-1:10\n"+
+		"    DotNotation @ 1:1-1:6\n"+
+		"      Identifier $ @ 1:1-1:2\n"+
+		"      Identifier foo @ 1:3-1:6\n"+
+		"    Identifier bar @ 1:7-1:10\n"+
+		"  IntLiteral 1 @ 1:13-1:14\n"+
+		"Result: 42\n")
+}
+
+func TestRun_Builtins(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := writeFile(t, dir, "data.yaml", "value: \"41\"\n")
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"-data", dataFile, "toInt($.value) + 1"}, stdout, stderr)
+	assert.Equals(t, stderr.String(), "")
+	assert.Equals(t, exitCode, 0)
+	assert.Equals(t, stdout.String(), "Result: 42\n")
+}
+
+func TestRun_EvaluateJSON(t *testing.T) {
+	dir := t.TempDir()
+	dataFile := writeFile(t, dir, "data.json", `{"items": ["a", "b"]}`)
+	stdout := &bytes.Buffer{}
+	exitCode := run([]string{"-data", dataFile, "$.items[1]"}, stdout, &bytes.Buffer{})
+	assert.Equals(t, exitCode, 0)
+	assert.Equals(t, stdout.String(), "Result: \"b\"\n")
+}
+
+func TestRun_Schema(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := writeFile(t, dir, "schema.yaml", `
+root: Root
+objects:
+  Root:
+    id: Root
+    properties:
+      name:
+        type:
+          type_id: string
+`)
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	exitCode := run([]string{"-schema", schemaFile, "$.name"}, stdout, stderr)
+	assert.Equals(t, stderr.String(), "")
+	assert.Equals(t, exitCode, 0)
+	assert.Equals(t, stdout.String(), "Type: string\nDependencies:\n  - $.name\n")
+}
+
+func TestReadWorkflowContext(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0700))
+	writeFile(t, dir, "file.txt", "hello")
+	writeFile(t, filepath.Join(dir, "sub"), "other.txt", "world")
+	workflowContext, err := readWorkflowContext(dir)
+	assert.NoError(t, err)
+	assert.Equals(t, workflowContext, map[string][]byte{
+		"file.txt":      []byte("hello"),
+		"sub/other.txt": []byte("world"),
+	})
+}
+
+func TestRun_Errors(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	assert.Equals(t, run([]string{}, &bytes.Buffer{}, stderr), 2)
+	assert.Contains(t, stderr.String(), "Usage")
+
+	stderr = &bytes.Buffer{}
+	assert.Equals(t, run([]string{"$.a )"}, &bytes.Buffer{}, stderr), 1)
+	assert.Contains(t, stderr.String(), "Error: ")
+
+	stderr = &bytes.Buffer{}
+	assert.Equals(t, run([]string{"-data", "nonexistent.yaml", "$.a"}, &bytes.Buffer{}, stderr), 1)
+	assert.Contains(t, stderr.String(), "nonexistent.yaml")
+}
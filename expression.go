@@ -1,26 +1,98 @@
 package expressions
 
 import (
-	"fmt"
+	"context"
 
-	"go.flow.arcalot.io/expressions/internal/ast"
+	"go.flow.arcalot.io/expressions/ast"
 	"go.flow.arcalot.io/pluginsdk/schema"
 )
 
 // New parses the specified expression and returns the expression structure.
 func New(expressionString string) (Expression, error) {
-	parser, err := ast.InitParser(expressionString, "workflow.yaml")
+	return NewWithOptions(expressionString, Options{})
+}
+
+// NewWithOptions parses the specified expression with the specified options and returns the expression structure.
+func NewWithOptions(expressionString string, options Options) (_ Expression, err error) {
+	defer recoverInternalError("parsing the expression", &err)
+	// The trace function and tracers that cannot be compared cannot be part of the cache key, so expressions that are
+	// traced with them are not cached.
+	if !parsedExpressionCache.enabled() || options.OnTypeTrace != nil || !tracerComparable(options.Tracer) {
+		result, err := parse(expressionString, options)
+		if err != nil {
+			return nil, err
+		}
+		options.reportWarnings(result.warnings)
+		return result, nil
+	}
+	key := newExpressionCacheKey(expressionString, options)
+	if cached, found := parsedExpressionCache.get(key); found {
+		options.reportWarnings(cached.warnings)
+		return cached, nil
+	}
+	result, err := parse(expressionString, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expression: %s (%w)", expressionString, err)
+		return nil, err
+	}
+	parsedExpressionCache.add(key, result)
+	options.reportWarnings(result.warnings)
+	return result, nil
+}
+
+// parse parses the expression without using the package-level cache.
+func parse(expressionString string, options Options) (*expression, error) {
+	if err := options.validateStringComparison(); err != nil {
+		return nil, err
+	}
+	if err := options.validateExponentLiterals(); err != nil {
+		return nil, err
 	}
+	if err := options.validateLanguageVersion(); err != nil {
+		return nil, err
+	}
+	parser, err := ast.InitParser(expressionString, options.sourceName())
+	if err != nil {
+		return nil, newCodedError(ErrorCodeParse, "failed to parse expression: %s (%w)", expressionString, err)
+	}
+	parser.SetPositionOffset(options.LineOffset, options.ColumnOffset)
+	if options.MaxNestingDepth > 0 {
+		parser.SetMaxNestingDepth(options.MaxNestingDepth)
+	}
+	parser.SetIntegerExponents(options.ExponentLiterals == ExponentLiteralInteger)
 	exprAst, err := parser.ParseExpression()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse expression: %s (%w)", expressionString, err)
+		code := parseErrorCode(err)
+		if code == ErrorCodeUnknown {
+			// Not all syntax errors have an error type of the ast package.
+			code = ErrorCodeParse
+		}
+		return nil, newCodedError(code, "failed to parse expression: %s (%w)", expressionString, err)
 	}
+	if err := options.validateFeatures(exprAst); err != nil {
+		return nil, newCodedError(ErrorCodeOf(err), "failed to parse expression: %s (%w)", expressionString, err)
+	}
+	// Find the warnings before folding, since folded calls are not written as literals.
+	warnings := literalWarnings(exprAst)
+	warnings = append(warnings, deprecatedFeatureWarnings(exprAst, options.DeprecatedFeatures)...)
+	warnings = append(warnings, deprecatedFunctionWarnings(exprAst, func(name string) (schema.Function, bool) {
+		function, found := options.Functions[name]
+		return function, found
+	})...)
+	if len(options.Functions) > 0 {
+		exprAst, err = foldPureCalls(exprAst, options.Functions, options.Policy)
+		if err != nil {
+			return nil, Errorf("failed to parse expression: %s (%w)", expressionString, err)
+		}
+	}
+	// Resolve the access chains after folding, so keys that are folded calls are part of the chains too.
+	ast.ResolveAccessChains(exprAst)
 
 	return &expression{
 		ast:        exprAst,
 		expression: expressionString,
+		options:    options,
+		cache:      newResolutionCache(),
+		warnings:   warnings,
 	}, nil
 }
 
@@ -28,74 +100,264 @@ func New(expressionString string) (Expression, error) {
 type Expression interface {
 	// Type evaluates the expression and evaluates the type on the specified schema.
 	Type(schema schema.Scope, functions map[string]schema.Function, workflowContext map[string][]byte) (schema.Type, error)
+	// TypeWithVariables is the same as Type, but identifiers that are the names of the specified variables, and the
+	// fields of $vars, have the types of the variables.
+	TypeWithVariables(scope schema.Scope, variables map[string]schema.Type, functions map[string]schema.Function, workflowContext map[string][]byte) (schema.Type, error)
 	// Dependencies traverses the passed scope and evaluates the items this expression depends on. This is useful to
 	// construct a dependency tree based on expressions.
 	// Returns the path to the object in the schema that it depends on, or nil if it's a literal that doesn't depend
 	// on it.
 	// unpackRequirements specifies which paths to include, and which values to include in paths.
 	Dependencies(schema schema.Type, functions map[string]schema.Function, workflowContext map[string][]byte, unpackRequirements UnpackRequirements) ([]Path, error)
+	// DependenciesWithVariables is the same as Dependencies, but identifiers that are the names of the specified
+	// variables, and the fields of $vars, have the types of the variables. Variables are not part of the root data,
+	// so they are not returned as dependencies.
+	DependenciesWithVariables(schema schema.Type, variables map[string]schema.Type, functions map[string]schema.Function, workflowContext map[string][]byte, unpackRequirements UnpackRequirements) ([]Path, error)
+	// TypedDependencies is the same as Dependencies, but each returned path also contains the schema type resolved
+	// for the value at the end of the path. This allows validating the types of the dependencies without calling
+	// Type for each of them.
+	TypedDependencies(schema schema.Type, functions map[string]schema.Function, workflowContext map[string][]byte, unpackRequirements UnpackRequirements) ([]TypedPath, error)
+	// Validate checks the expression against the specified scope, functions, and workflow context, and returns the
+	// errors and warnings found. This checks the types and dependencies in a single call, so it is useful for
+	// validating a workflow before running it.
+	Validate(scope schema.Scope, functions map[string]schema.Function, workflowContext map[string][]byte) ValidationReport
+	// Explain returns a plain-language description of the expression, such as "takes field bar of object foo, adds
+	// 5". The scope and functions are used to check the expression, and to describe the kinds of the values it
+	// accesses. This is useful for reviewing workflows and generating documentation.
+	Explain(scope schema.Scope, functions map[string]schema.Function) (string, error)
+	// AnalyzeSensitivity reports whether the result of the expression may contain sensitive data, and which of the
+	// referenced paths hold it. The fields for which isSensitive returns true are sensitive, as are the values that
+	// contain them. Operators and function calls are assumed to pass on sensitive data, so the engine can decide
+	// whether a result is safe to log or display.
+	AnalyzeSensitivity(scope schema.Scope, functions map[string]schema.Function, isSensitive SensitiveFieldFunc) (SensitivityReport, error)
 	// Evaluate evaluates the expression on the given data set regardless of any
 	// schema. The caller is responsible for validating the expected schema.
 	Evaluate(data any, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error)
+	// EvaluateAndValidate is the same as Evaluate, but also validates the result against the expected type. If the
+	// result does not match, the error is an *InvalidResultError that names the expression. This is useful for
+	// checking that an expression produces a valid input of a step.
+	EvaluateAndValidate(data any, expectedType schema.Type, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error)
+	// EvaluateWithContext is the same as Evaluate, but the spans created by the tracer of the options are children
+	// of the span in the context, so they are part of the trace of the caller. The evaluation is not canceled when
+	// the context is.
+	EvaluateWithContext(ctx context.Context, data any, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error)
+	// EvaluateWithVariables is the same as Evaluate, but identifiers that are the names of the specified variables
+	// evaluate to their values instead of the fields of the root data, and $vars evaluates to the variables. This is
+	// useful for injecting loop variables or computed values without adding them to the data.
+	EvaluateWithVariables(data any, variables map[string]any, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error)
+	// EvaluateDeferred is the same as Evaluate, but does not wait for the values of functions that return a
+	// Deferred. If the result of the expression is a deferred value that is not resolved yet, it returns the Deferred.
+	// If the expression needs such a value to compute its result, it returns a *PendingError.
+	EvaluateDeferred(data any, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error)
+	// EvaluateMany evaluates the expression on each of the data items, using up to the specified number of items
+	// evaluated in parallel. If parallelism is 0 or less, it defaults to GOMAXPROCS. The results are in the order of
+	// the data items. If any item fails, the error is a *BatchEvaluationError with the errors of all failed items, and
+	// the results of the failed items are nil.
+	EvaluateMany(dataItems []any, parallelism int, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) ([]any, error)
+	// Compile type checks the expression against the root schema, and returns an AccessPlan that evaluates the
+	// expression on many records of that schema. The accesses of the data are resolved with the schema once, so
+	// evaluating each record has less overhead than Evaluate, which is useful for evaluating an expression in a loop.
+	Compile(scope schema.Scope, functions map[string]schema.Function, workflowContext map[string][]byte) (*AccessPlan, error)
+	// Incremental returns an IncrementalEvaluation, which evaluates the expression repeatedly on data that changes
+	// between evaluations, and only evaluates the parts of the expression that read the changed paths again. This is
+	// useful for conditions that are checked each time a part of the data changes.
+	Incremental(functions map[string]schema.CallableFunction, workflowContext map[string][]byte) *IncrementalEvaluation
 	// String returns the string representation of the expression.
 	String() string
+	// AST returns the root node of the parsed abstract syntax tree of the expression. This is useful for tooling
+	// that needs to inspect the structure of the expression. The returned tree must not be modified.
+	AST() ast.Node
+	// Metrics returns the complexity metrics of the expression, such as the number of nodes and function calls.
+	Metrics() Metrics
+	// DumpAST returns an indented tree of the nodes of the parsed expression, with their kinds, values, and
+	// positions. This is useful for debugging the parser and for including in bug reports.
+	DumpAST() string
+	// RewritePaths calls the rewrite function for each path the expression references, and returns a new expression
+	// with the paths replaced for which the rewrite function returned true. This is useful when a referenced item is
+	// renamed. The paths start with the root ($), and contain the identifiers and literal keys of the reference.
+	// The rest of the expression, including its formatting, is preserved.
+	RewritePaths(rewrite func(path Path) (Path, bool)) (Expression, error)
+	// FindReferences returns the location of each reference to the path or to a path within it, such as each access
+	// of the outputs of a step, in the order they are written. The path starts with the root ($), which may be
+	// omitted. References to a part of the path, such as $.steps when looking for $.steps.a, and accesses with a
+	// computed key within the path, such as $.steps[$.name], are not included, since they may access other paths.
+	FindReferences(prefix Path) []Location
+	// Inventory returns the literal values, the function calls with their number of arguments, and the references to
+	// the data of the expression, which are collected in a single walk of the syntax tree. This is useful for policy
+	// engines that audit expressions, such as to forbid literals that look like credentials.
+	Inventory() Inventory
+	// ReplaceSubexpression returns a new expression with each subtree for which the match function returns true
+	// replaced by the expression string that the replacement function returns for it, such as a literal replaced by a
+	// reference, or the identifier of a renamed function replaced by the new name. The nodes are matched from the root,
+	// and the nodes within a replaced subtree are not matched. Replacements that are operations are put in parentheses
+	// where needed to keep the structure of the expression. The rest of the expression, including its formatting, is
+	// preserved.
+	ReplaceSubexpression(match func(node ast.Node) bool, replacement func(node ast.Node) string) (Expression, error)
+	// Canonical returns a normalized form of the expression that does not depend on whitespace, redundant
+	// parentheses, or the quote style used. The canonical form is a valid expression.
+	Canonical() string
+	// Equivalent returns true if both expressions have the same canonical form. Use this instead of comparing
+	// the expression strings when detecting duplicates or changes.
+	Equivalent(other Expression) bool
+	// MarshalJSON encodes the expression as a JSON string. Use Field to unmarshal expressions.
+	MarshalJSON() ([]byte, error)
+	// MarshalText returns the original expression.
+	MarshalText() ([]byte, error)
 }
 
 // expression is the implementation of Expression. It holds the original expression, as well as the parsed AST.
 type expression struct {
 	expression string
 	ast        ast.Node
+	options    Options
+	cache      *resolutionCache
+	// warnings are the warnings found when parsing the expression.
+	warnings []Diagnostic
 }
 
 func (e expression) String() string {
 	return e.expression
 }
 
+func (e expression) AST() ast.Node {
+	return e.ast
+}
+
 func (e expression) Type(scope schema.Scope, functions map[string]schema.Function, workflowContext map[string][]byte) (schema.Type, error) {
-	tree := PathTree{
-		PathItem: "$",
-		NodeType: DataRootNode,
-		Subtrees: nil,
-	}
-	d := &dependencyContext{
-		rootType:        scope,
-		rootPath:        tree,
-		workflowContext: workflowContext,
-		functions:       functions,
-	}
-	dependencyResolutionResult, err := d.rootDependencies(e.ast)
+	dependencyResolutionResult, err := e.resolveDependencies(scope, functions, workflowContext)
 	if err != nil {
 		return nil, err
 	}
 	return dependencyResolutionResult.resolvedType, nil
 }
 
+func (e expression) TypeWithVariables(
+	scope schema.Scope,
+	variables map[string]schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) (schema.Type, error) {
+	dependencyResolutionResult, err := e.resolveDependenciesWithVariables(scope, variables, functions, workflowContext)
+	if err != nil {
+		return nil, err
+	}
+	return dependencyResolutionResult.resolvedType, nil
+}
+
+// resolveDependencies runs the dependency resolution on the AST, or returns the cached result if the expression was
+// already resolved with the same inputs.
+func (e expression) resolveDependencies(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) (*dependencyResult, error) {
+	return e.resolveDependenciesWithVariables(scope, nil, functions, workflowContext)
+}
+
+// resolveDependenciesWithVariables is the same as resolveDependencies, but identifiers refer to the variables with
+// the specified types.
+func (e expression) resolveDependenciesWithVariables(
+	scope schema.Type,
+	variables map[string]schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) (result *dependencyResult, err error) {
+	defer recoverInternalError("resolving the expression", &err)
+	if err := e.options.Policy.check(e.ast); err != nil {
+		return nil, err
+	}
+	resolver := func() (*dependencyResult, error) {
+		root := PathTree{
+			PathItem:     "$",
+			NodeType:     DataRootNode,
+			Subtrees:     nil,
+			ResolvedType: scope,
+		}
+		d := &dependencyContext{
+			expression:      e.expression,
+			trace:           e.options.OnTypeTrace,
+			rootType:        scope,
+			rootPath:        root,
+			workflowContext: workflowContext,
+			functions:       functions,
+			variables:       variables,
+		}
+		return d.rootDependencies(e.ast)
+	}
+	if e.options.OnTypeTrace != nil || len(variables) > 0 {
+		// A cached result would not report the steps, and the variables are not part of the cache key.
+		return resolver()
+	}
+	return e.cache.resolve(scope, functions, workflowContext, resolver)
+}
+
 func (e expression) Dependencies(
 	scope schema.Type,
 	functions map[string]schema.Function,
 	workflowContext map[string][]byte,
 	unpackRequirements UnpackRequirements,
 ) ([]Path, error) {
-	root := PathTree{
-		PathItem: "$",
-		NodeType: DataRootNode,
-		Subtrees: nil,
-	}
-	d := &dependencyContext{
-		rootType:        scope,
-		rootPath:        root,
-		workflowContext: workflowContext,
-		functions:       functions,
-	}
-	dependencyResolutionResult, err := d.rootDependencies(e.ast)
+	typedDependencies, err := e.TypedDependencies(scope, functions, workflowContext, unpackRequirements)
+	if err != nil {
+		return nil, err
+	}
+	finalDependencies := make([]Path, len(typedDependencies))
+	for i, dependency := range typedDependencies {
+		finalDependencies[i] = dependency.Path
+	}
+	return finalDependencies, nil
+}
+
+func (e expression) DependenciesWithVariables(
+	scope schema.Type,
+	variables map[string]schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	unpackRequirements UnpackRequirements,
+) ([]Path, error) {
+	dependencyResolutionResult, err := e.resolveDependenciesWithVariables(scope, variables, functions, workflowContext)
+	if err != nil {
+		return nil, err
+	}
+	typedDependencies, err := unpackDependencies(dependencyResolutionResult, unpackRequirements, e.options.SchemaDefaults)
+	if err != nil {
+		return nil, err
+	}
+	finalDependencies := make([]Path, len(typedDependencies))
+	for i, dependency := range typedDependencies {
+		finalDependencies[i] = dependency.Path
+	}
+	return finalDependencies, nil
+}
+
+func (e expression) TypedDependencies(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	unpackRequirements UnpackRequirements,
+) ([]TypedPath, error) {
+	dependencyResolutionResult, err := e.resolveDependencies(scope, functions, workflowContext)
 	if err != nil {
 		return nil, err
 	}
+	return unpackDependencies(dependencyResolutionResult, unpackRequirements, e.options.SchemaDefaults)
+}
+
+// unpackDependencies unpacks the completed paths of the result, leaving out duplicate paths. If schemaDefaults is set,
+// the paths through fields with a default value are optional.
+func unpackDependencies(
+	dependencyResolutionResult *dependencyResult,
+	unpackRequirements UnpackRequirements,
+	schemaDefaults bool,
+) ([]TypedPath, error) {
 	// Now convert to paths, saving only unique values.
 	finalDependencySet := make(map[string]bool)
-	finalDependencies := make([]Path, 0)
+	finalDependencies := make([]TypedPath, 0)
 	for _, dependencyTree := range dependencyResolutionResult.completedPaths {
-		unpackedDependencies := dependencyTree.Unpack(unpackRequirements)
+		unpackedDependencies, err := dependencyTree.unpackTypedPaths(unpackRequirements, schemaDefaults)
+		if err != nil {
+			return nil, err
+		}
 		for _, dependency := range unpackedDependencies {
 			asString := dependency.String()
 			_, dependencyExists := finalDependencySet[asString]
@@ -108,11 +370,68 @@ func (e expression) Dependencies(
 	return finalDependencies, nil
 }
 
-func (e expression) Evaluate(data any, functions map[string]schema.CallableFunction, workflowContext map[string][]byte) (any, error) {
-	context := &evaluateContext{
-		functions:       functions,
-		rootData:        data,
-		workflowContext: workflowContext,
+func (e expression) Evaluate(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (any, error) {
+	return e.EvaluateWithVariables(data, nil, functions, workflowContext)
+}
+
+func (e expression) EvaluateWithContext(
+	ctx context.Context,
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (any, error) {
+	return e.evaluateWithVariables(ctx, data, nil, functions, workflowContext)
+}
+
+func (e expression) EvaluateWithVariables(
+	data any,
+	variables map[string]any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (any, error) {
+	return e.evaluateWithVariables(context.Background(), data, variables, functions, workflowContext)
+}
+
+// evaluateWithVariables evaluates the expression with the variables. The spans of the tracer are children of the span
+// in the trace context.
+func (e expression) evaluateWithVariables(
+	traceContext context.Context,
+	data any,
+	variables map[string]any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (result any, err error) {
+	defer recoverInternalError("evaluating the expression", &err)
+	if err := e.options.Policy.check(e.ast); err != nil {
+		return nil, err
+	}
+	context := e.newEvaluateContext(data, functions, workflowContext)
+	context.variables = variables
+	context.traceContext = traceContext
+	return context.evaluateTraced(e.ast, data)
+}
+
+// newEvaluateContext returns the context that evaluates the expression on the data with its options. The callers set
+// the fields of their kind of evaluation, such as the variables or the access plan.
+func (e expression) newEvaluateContext(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) *evaluateContext {
+	return &evaluateContext{
+		expression:              e.expression,
+		functions:               functions,
+		rootData:                data,
+		workflowContext:         workflowContext,
+		stringComparison:        e.options.StringComparison,
+		redactArgumentValues:    e.options.RedactArgumentValues,
+		validateFunctionResults: e.options.ValidateFunctionResults,
+		allowNonFiniteFloats:    e.options.AllowNonFiniteFloats,
+		euclideanModulo:         e.options.EuclideanModulo,
+		tracer:                  e.options.Tracer,
 	}
-	return context.evaluate(e.ast, data)
 }
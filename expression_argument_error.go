@@ -0,0 +1,74 @@
+package expressions
+
+import (
+	"fmt"
+	"strings"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// ArgumentTypeError is returned by Type, Dependencies, and Validate when arguments of a function call are not
+// compatible with the parameters of the function. It lists all incompatible arguments of the call, so they can be
+// fixed together.
+type ArgumentTypeError struct {
+	// Function is the ID of the function.
+	Function string
+	// Signature is the string representation of the function schema.
+	Signature string
+	// Mismatches are the incompatible arguments, ordered by their index.
+	Mismatches []ArgumentTypeMismatch
+}
+
+// ArgumentTypeMismatch is an argument whose type is not compatible with the type of its parameter.
+type ArgumentTypeMismatch struct {
+	// Index is the 0-based position of the argument in the call.
+	Index int
+	// Expected is the type of the parameter.
+	Expected schema.Type
+	// Actual is the resolved type of the argument.
+	Actual schema.Type
+	// Cause is the error returned by the compatibility check.
+	Cause error
+}
+
+func (e *ArgumentTypeError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *ArgumentTypeError) LocalizedError(localize func(format string, args ...any) string) string {
+	mismatches := make([]string, len(e.Mismatches))
+	for i, mismatch := range e.Mismatches {
+		mismatches[i] = localize("at 0-index %d expected %s, got %s (%v)",
+			mismatch.Index, mismatch.Expected.TypeID(), mismatch.Actual.TypeID(), mismatch.Cause)
+	}
+	return localize("error while validating arg/param type compatibility for function '%s' %s. Function schema: %s",
+		e.Function, strings.Join(mismatches, "; "), e.Signature)
+}
+
+func (e *ArgumentTypeError) ErrorCode() ErrorCode {
+	return ErrorCodeInvalidArguments
+}
+
+// validateArgumentTypes checks the compatibility of all argument types with the parameters of the function, and
+// returns an ArgumentTypeError with all incompatible arguments, if any.
+func validateArgumentTypes(function schema.Function, argumentTypes []schema.Type) error {
+	var mismatches []ArgumentTypeMismatch
+	for i, parameterType := range function.Parameters() {
+		if err := parameterType.ValidateCompatibility(argumentTypes[i]); err != nil {
+			mismatches = append(mismatches, ArgumentTypeMismatch{
+				Index:    i,
+				Expected: parameterType,
+				Actual:   argumentTypes[i],
+				Cause:    err,
+			})
+		}
+	}
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &ArgumentTypeError{
+		Function:   function.ID(),
+		Signature:  function.String(),
+		Mismatches: mismatches,
+	}
+}
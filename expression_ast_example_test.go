@@ -0,0 +1,23 @@
+package expressions_test
+
+import (
+	"fmt"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func ExampleExpression_AST() {
+	expr, err := expressions.New("toUpper($.foo)")
+	if err != nil {
+		panic(err)
+	}
+
+	functionCall, isFunctionCall := expr.AST().(*ast.FunctionCall)
+	if !isFunctionCall {
+		panic("expected a function call")
+	}
+
+	fmt.Printf("%s %d", functionCall.FuncIdentifier.IdentifierName, functionCall.ArgumentInputs.NumChildren())
+	// Output: toUpper 1
+}
@@ -0,0 +1,89 @@
+package expressions
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// BatchEvaluationError is returned by EvaluateMany when the evaluation fails for some of the data items. It holds the
+// error of each failed item, so all failures can be reported together. Use errors.As to get the errors of the items,
+// such as a FunctionCallError.
+type BatchEvaluationError struct {
+	// Items are the errors of the failed items, ordered by their index.
+	Items []ItemEvaluationError
+}
+
+// ItemEvaluationError is the error of a single data item in a BatchEvaluationError.
+type ItemEvaluationError struct {
+	// Index is the 0-based position of the item in the data items.
+	Index int
+	// Cause is the error returned by the evaluation of the item.
+	Cause error
+}
+
+func (e *BatchEvaluationError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *BatchEvaluationError) LocalizedError(localize func(format string, args ...any) string) string {
+	items := make([]string, len(e.Items))
+	for i, item := range e.Items {
+		items[i] = localize("item %d: %v", item.Index, item.Cause)
+	}
+	return localize("failed to evaluate %d item(s) (%s)", len(e.Items), strings.Join(items, "; "))
+}
+
+func (e *BatchEvaluationError) ErrorCode() ErrorCode {
+	return ErrorCodeBatchFailed
+}
+
+func (e *BatchEvaluationError) Unwrap() []error {
+	result := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		result[i] = item.Cause
+	}
+	return result
+}
+
+func (e expression) EvaluateMany(
+	dataItems []any,
+	parallelism int,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) ([]any, error) {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	parallelism = min(parallelism, len(dataItems))
+	results := make([]any, len(dataItems))
+	errs := make([]error, len(dataItems))
+	// Each worker takes the next item that is not evaluated yet, so slow items do not hold up the other workers.
+	var next atomic.Int64
+	wg := &sync.WaitGroup{}
+	for worker := 0; worker < parallelism; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := int(next.Add(1) - 1); i < len(dataItems); i = int(next.Add(1) - 1) {
+				results[i], errs[i] = e.Evaluate(dataItems[i], functions, workflowContext)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var failedItems []ItemEvaluationError
+	for i, err := range errs {
+		if err != nil {
+			failedItems = append(failedItems, ItemEvaluationError{Index: i, Cause: err})
+		}
+	}
+	if len(failedItems) > 0 {
+		return results, &BatchEvaluationError{Items: failedItems}
+	}
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package expressions_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestEvaluateMany(t *testing.T) {
+	functions := map[string]schema.CallableFunction{"double": newDoubleFunction(t)}
+	expr, err := expressions.New(`double($.simple_int)`)
+	assert.NoError(t, err)
+	dataItems := make([]any, 50)
+	for i := range dataItems {
+		dataItems[i] = map[string]any{"simple_int": int64(i + 1)}
+	}
+	results, err := expr.EvaluateMany(dataItems, 4, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(results), len(dataItems))
+	for i, result := range results {
+		assert.Equals(t, result, any(int64(2*(i+1))))
+	}
+}
+
+func TestEvaluateMany_Errors(t *testing.T) {
+	functions := map[string]schema.CallableFunction{"double": newDoubleFunction(t)}
+	expr, err := expressions.New(`double($.simple_int)`)
+	assert.NoError(t, err)
+	dataItems := []any{
+		map[string]any{"simple_int": int64(1)},
+		map[string]any{"simple_int": int64(0)},
+		map[string]any{},
+		map[string]any{"simple_int": int64(2)},
+	}
+	results, err := expr.EvaluateMany(dataItems, 0, functions, nil)
+	assert.Error(t, err)
+	// The results of the items that did not fail are returned.
+	assert.Equals(t, results, []any{int64(2), nil, nil, int64(4)})
+	var batchErr *expressions.BatchEvaluationError
+	assert.Equals(t, errors.As(err, &batchErr), true)
+	assert.Equals(t, len(batchErr.Items), 2)
+	assert.Equals(t, batchErr.Items[0].Index, 1)
+	assert.Equals(t, batchErr.Items[1].Index, 2)
+	assert.Contains(t, err.Error(), "failed to evaluate 2 item(s)")
+	var callErr *expressions.FunctionCallError
+	assert.Equals(t, errors.As(err, &callErr), true)
+	assert.Equals(t, callErr.Values, []any{int64(0)})
+}
+
+func TestEvaluateMany_Parallelism(t *testing.T) {
+	var running atomic.Int64
+	var maxRunning atomic.Int64
+	track, err := schema.NewCallableFunction(
+		"track",
+		[]schema.Type{},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func() int64 {
+			current := running.Add(1)
+			defer running.Add(-1)
+			for {
+				previous := maxRunning.Load()
+				if current <= previous || maxRunning.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			return current
+		},
+	)
+	assert.NoError(t, err)
+	expr, err := expressions.New(`track()`)
+	assert.NoError(t, err)
+	_, err = expr.EvaluateMany(make([]any, 20), 3, map[string]schema.CallableFunction{"track": track}, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, maxRunning.Load() <= 3, true)
+	assert.Equals(t, maxRunning.Load() > 1, true)
+}
+
+func TestEvaluateMany_Empty(t *testing.T) {
+	expr, err := expressions.New(`$.simple_int`)
+	assert.NoError(t, err)
+	results, err := expr.EvaluateMany(nil, 4, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(results), 0)
+}
@@ -0,0 +1,139 @@
+package expressions
+
+import (
+	"reflect"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// BindTag is the name of the struct tag that holds the expression of a field for Bind.
+const BindTag = "expr"
+
+// Bind evaluates the expression in the `expr` tag of each field of the struct target points to on the data, and
+// stores the results in the fields, such as:
+//
+//	type stepInput struct {
+//		Name  string   `expr:"$.input.name"`
+//		Count int      `expr:"$.input.count * 2"`
+//		Tags  []string `expr:"$.input.tags"`
+//	}
+//
+// The results are converted to the types of the fields. Integers are converted to smaller integer types if they fit,
+// and to floats. Lists and maps are converted item by item, and pointers are allocated. A result that cannot be
+// converted fails with ErrorCodeTypeMismatch. Fields without the tag are left unchanged. The expressions are parsed
+// with New, so enabling the parsed expression cache avoids parsing them on each call.
+func Bind(
+	target any,
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Pointer || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return newCodedError(ErrorCodeTypeMismatch, "the binding target must be a pointer to a struct, %T given", target)
+	}
+	structValue := targetValue.Elem()
+	structType := structValue.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		expressionString, tagged := field.Tag.Lookup(BindTag)
+		if !tagged || !field.IsExported() {
+			continue
+		}
+		expr, err := New(expressionString)
+		if err != nil {
+			return Errorf("failed to bind the field %s (%w)", field.Name, err)
+		}
+		result, err := expr.Evaluate(data, functions, workflowContext)
+		if err != nil {
+			return Errorf("failed to bind the field %s (%w)", field.Name, err)
+		}
+		if err := bindValue(structValue.Field(i), result); err != nil {
+			return Errorf("failed to bind the result of %s to the field %s (%w)", expressionString, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// bindValue stores the value in the target, converting it to the type of the target.
+func bindValue(target reflect.Value, value any) error {
+	targetType := target.Type()
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			target.Set(reflect.Zero(targetType))
+			return nil
+		default:
+			return newCodedError(ErrorCodeTypeMismatch, "cannot store null in a %s", targetType)
+		}
+	}
+	source := reflect.ValueOf(value)
+	if source.Type().AssignableTo(targetType) {
+		target.Set(source)
+		return nil
+	}
+	switch target.Kind() {
+	case reflect.Pointer:
+		element := reflect.New(targetType.Elem())
+		if err := bindValue(element.Elem(), value); err != nil {
+			return err
+		}
+		target.Set(element)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if source.CanInt() && !target.OverflowInt(source.Int()) {
+			target.SetInt(source.Int())
+			return nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if source.CanInt() && source.Int() >= 0 && !target.OverflowUint(uint64(source.Int())) {
+			target.SetUint(uint64(source.Int()))
+			return nil
+		}
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case source.CanInt():
+			target.SetFloat(float64(source.Int()))
+			return nil
+		case source.CanFloat():
+			target.SetFloat(source.Float())
+			return nil
+		}
+	case reflect.String, reflect.Bool:
+		// Named types, such as enums based on strings, are converted.
+		if source.Kind() == target.Kind() {
+			target.Set(source.Convert(targetType))
+			return nil
+		}
+	case reflect.Slice:
+		if source.Kind() == reflect.Slice || source.Kind() == reflect.Array {
+			result := reflect.MakeSlice(targetType, source.Len(), source.Len())
+			for i := 0; i < source.Len(); i++ {
+				if err := bindValue(result.Index(i), source.Index(i).Interface()); err != nil {
+					return Errorf("item %d: %w", i, err)
+				}
+			}
+			target.Set(result)
+			return nil
+		}
+	case reflect.Map:
+		if source.Kind() == reflect.Map {
+			result := reflect.MakeMapWithSize(targetType, source.Len())
+			iterator := source.MapRange()
+			for iterator.Next() {
+				key := reflect.New(targetType.Key()).Elem()
+				if err := bindValue(key, iterator.Key().Interface()); err != nil {
+					return Errorf("key %v: %w", iterator.Key(), err)
+				}
+				item := reflect.New(targetType.Elem()).Elem()
+				if err := bindValue(item, iterator.Value().Interface()); err != nil {
+					return Errorf("key %v: %w", iterator.Key(), err)
+				}
+				result.SetMapIndex(key, item)
+			}
+			target.Set(result)
+			return nil
+		}
+	}
+	return newCodedError(ErrorCodeTypeMismatch, "cannot store a %T in a %s", value, targetType)
+}
@@ -0,0 +1,69 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+type bindMode string
+
+type bindTarget struct {
+	Name     string           `expr:"$.name"`
+	Mode     bindMode         `expr:"$.mode"`
+	Count    int32            `expr:"$.count * 2"`
+	Ratio    float64          `expr:"$.count"`
+	Optional *int             `expr:"$.count"`
+	Tags     []string         `expr:"$.tags"`
+	Limits   map[string]uint8 `expr:"$.limits"`
+	Raw      any              `expr:"$.tags[0]"`
+	Untagged string
+}
+
+func TestBind(t *testing.T) {
+	data := map[string]any{
+		"name":   "a",
+		"mode":   "fast",
+		"count":  int64(3),
+		"tags":   []any{"x", "y"},
+		"limits": map[string]any{"cpu": int64(2)},
+	}
+	target := bindTarget{Untagged: "kept"}
+	assert.NoError(t, expressions.Bind(&target, data, nil, nil))
+	assert.Equals(t, target.Name, "a")
+	assert.Equals(t, target.Mode, bindMode("fast"))
+	assert.Equals(t, target.Count, int32(6))
+	assert.Equals(t, target.Ratio, 3.0)
+	assert.Equals(t, *target.Optional, 3)
+	assert.Equals(t, target.Tags, []string{"x", "y"})
+	assert.Equals(t, target.Limits, map[string]uint8{"cpu": 2})
+	assert.Equals(t, target.Raw, any("x"))
+	assert.Equals(t, target.Untagged, "kept")
+}
+
+func TestBind_Errors(t *testing.T) {
+	type overflow struct {
+		Value int8 `expr:"$.value"`
+	}
+	err := expressions.Bind(&overflow{}, map[string]any{"value": int64(300)}, nil, nil)
+	assert.Error(t, err)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+	assert.Contains(t, err.Error(), "Value")
+
+	type wrongItem struct {
+		Values []int `expr:"$.values"`
+	}
+	err = expressions.Bind(&wrongItem{}, map[string]any{"values": []any{int64(1), "a"}}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+	assert.Contains(t, err.Error(), "item 1")
+
+	type missing struct {
+		Value string `expr:"$.missing"`
+	}
+	err = expressions.Bind(&missing{}, map[string]any{}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+
+	err = expressions.Bind(missing{}, map[string]any{}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+}
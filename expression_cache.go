@@ -0,0 +1,138 @@
+package expressions
+
+import (
+	"container/list"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SetCacheSize enables the package-level cache of parsed expressions, keeping up to maxEntries of the most recently
+// used expressions. When enabled, calling New or NewWithOptions with an expression and options that were parsed
+// before returns the previously parsed expression instead of parsing it again. This is useful when the same
+// expressions are used across many workflow steps. A size of 0 or less disables the cache, which is the default.
+func SetCacheSize(maxEntries int) {
+	parsedExpressionCache.resize(maxEntries)
+}
+
+// parsedExpressionCache is the package-level cache used by NewWithOptions.
+var parsedExpressionCache = &expressionCache{
+	entries: map[expressionCacheKey]*list.Element{},
+	order:   list.New(),
+}
+
+// expressionCache is a least-recently-used cache of parsed expressions. Expressions are immutable, so the same
+// instance can be returned for each call.
+type expressionCache struct {
+	lock       sync.Mutex
+	maxEntries int
+	entries    map[expressionCacheKey]*list.Element
+	// order holds the entries with the most recently used entry at the front.
+	order *list.List
+}
+
+type expressionCacheKey struct {
+	expression       string
+	filename         string
+	lineOffset       int
+	columnOffset     int
+	disabledFeatures string
+	languageVersion  LanguageVersion
+	deprecations     string
+	policy           *Policy
+	stringComparison StringComparisonMode
+	redactValues     bool
+	validateResults  bool
+	maxNestingDepth  int
+	exponentLiterals ExponentLiteralMode
+	allowNonFinite   bool
+	euclideanModulo  bool
+	schemaDefaults   bool
+	tracer           Tracer
+	// functions identifies the map of functions to fold calls of, since maps cannot be compared.
+	functions uintptr
+}
+
+type expressionCacheEntry struct {
+	key   expressionCacheKey
+	value *expression
+}
+
+func newExpressionCacheKey(expressionString string, options Options) expressionCacheKey {
+	features := make([]string, len(options.DisabledFeatures))
+	for i, feature := range options.DisabledFeatures {
+		features[i] = string(feature)
+	}
+	return expressionCacheKey{
+		expression:       expressionString,
+		filename:         options.Filename,
+		lineOffset:       options.LineOffset,
+		columnOffset:     options.ColumnOffset,
+		disabledFeatures: strings.Join(features, ","),
+		languageVersion:  options.languageVersion(),
+		deprecations:     deprecatedFeaturesKey(options.DeprecatedFeatures),
+		policy:           options.Policy,
+		stringComparison: options.StringComparison,
+		redactValues:     options.RedactArgumentValues,
+		validateResults:  options.ValidateFunctionResults,
+		maxNestingDepth:  options.MaxNestingDepth,
+		exponentLiterals: options.ExponentLiterals,
+		allowNonFinite:   options.AllowNonFiniteFloats,
+		euclideanModulo:  options.EuclideanModulo,
+		schemaDefaults:   options.SchemaDefaults,
+		tracer:           options.Tracer,
+		functions:        reflect.ValueOf(options.Functions).Pointer(),
+	}
+}
+
+// get returns the cached expression, or false if it is not cached.
+func (c *expressionCache) get(key expressionCacheKey) (*expression, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	element, found := c.entries[key]
+	if !found {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*expressionCacheEntry).value, true
+}
+
+// add stores the expression in the cache if the cache is enabled, evicting the least recently used entry if the
+// cache is full.
+func (c *expressionCache) add(key expressionCacheKey, value *expression) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.maxEntries <= 0 {
+		return
+	}
+	if element, found := c.entries[key]; found {
+		element.Value.(*expressionCacheEntry).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&expressionCacheEntry{key: key, value: value})
+	c.evict()
+}
+
+// enabled returns true if expressions are cached.
+func (c *expressionCache) enabled() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.maxEntries > 0
+}
+
+func (c *expressionCache) resize(maxEntries int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.maxEntries = maxEntries
+	c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits its size. The lock must be held.
+func (c *expressionCache) evict() {
+	for c.order.Len() > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*expressionCacheEntry).key)
+	}
+}
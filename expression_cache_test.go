@@ -0,0 +1,61 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestSetCacheSize(t *testing.T) {
+	t.Cleanup(func() {
+		expressions.SetCacheSize(0)
+	})
+
+	// Disabled by default.
+	first, err := expressions.New("$.a.b")
+	assert.NoError(t, err)
+	second, err := expressions.New("$.a.b")
+	assert.NoError(t, err)
+	assert.Equals(t, first == second, false)
+
+	expressions.SetCacheSize(2)
+	first, err = expressions.New("$.a.b")
+	assert.NoError(t, err)
+	second, err = expressions.New("$.a.b")
+	assert.NoError(t, err)
+	assert.Equals(t, first == second, true)
+
+	// Different options are cached separately.
+	withOptions, err := expressions.NewWithOptions("$.a.b", expressions.Options{LineOffset: 3})
+	assert.NoError(t, err)
+	assert.Equals(t, first == withOptions, false)
+
+	// Adding a third expression evicts the least recently used one.
+	_, err = expressions.New("$.c")
+	assert.NoError(t, err)
+	third, err := expressions.New("$.a.b")
+	assert.NoError(t, err)
+	assert.Equals(t, first == third, false)
+
+	// Errors are not cached.
+	_, err = expressions.New("$.a )")
+	assert.Error(t, err)
+	_, err = expressions.New("$.a )")
+	assert.Error(t, err)
+
+	// Feature validation still applies to cached expressions.
+	_, err = expressions.New("f()")
+	assert.NoError(t, err)
+	_, err = expressions.NewWithOptions("f()", expressions.Options{
+		DisabledFeatures: []expressions.Feature{expressions.FeatureFunctionCalls},
+	})
+	assert.Error(t, err)
+
+	expressions.SetCacheSize(0)
+	first, err = expressions.New("$.a.b")
+	assert.NoError(t, err)
+	second, err = expressions.New("$.a.b")
+	assert.NoError(t, err)
+	assert.Equals(t, first == second, false)
+}
@@ -0,0 +1,99 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// redactedValue replaces the argument values in errors if Options.RedactArgumentValues is set.
+const redactedValue = "<redacted>"
+
+// FunctionCallError is returned by Evaluate when a function fails. It holds the context of the call, so failures
+// in nested calls can be told apart. Use errors.Unwrap or errors.As to get the error returned by the function.
+type FunctionCallError struct {
+	// Function is the name of the function as written in the expression.
+	Function string
+	// Arguments are the expressions of the arguments, as written in the expression.
+	Arguments []string
+	// Values are the values of the arguments. They are nil if Options.RedactArgumentValues is set.
+	Values []any
+	// Position is the position of the call in the expression.
+	Position ast.Position
+	// Cause is the error returned by the function.
+	Cause error
+}
+
+func (e *FunctionCallError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *FunctionCallError) LocalizedError(localize func(format string, args ...any) string) string {
+	arguments := make([]string, len(e.Arguments))
+	for i, argument := range e.Arguments {
+		value := redactedValue
+		if e.Values != nil {
+			value = formatArgumentValue(e.Values[i])
+		}
+		if argument == value {
+			// Literals are their own value.
+			arguments[i] = argument
+		} else {
+			arguments[i] = argument + " = " + value
+		}
+	}
+	return localize("function '%s' failed at %s with the arguments (%s) (%v)",
+		e.Function, e.Position, strings.Join(arguments, ", "), e.Cause)
+}
+
+// ErrorCode returns ErrorCodeFunctionTimeout or ErrorCodeInvalidFunctionResult if the call timed out or returned an
+// invalid value, and ErrorCodeFunctionFailed otherwise.
+func (e *FunctionCallError) ErrorCode() ErrorCode {
+	var timeoutErr *FunctionTimeoutError
+	var resultErr *InvalidFunctionResultError
+	switch {
+	case errors.As(e.Cause, &timeoutErr):
+		return ErrorCodeFunctionTimeout
+	case errors.As(e.Cause, &resultErr):
+		return ErrorCodeInvalidFunctionResult
+	default:
+		return ErrorCodeFunctionFailed
+	}
+}
+
+func (e *FunctionCallError) Unwrap() error {
+	return e.Cause
+}
+
+// newFunctionCallError creates the error of a failed call of the node with the values of its arguments.
+func newFunctionCallError(node *ast.FunctionCall, values []any, redactValues bool, cause error) *FunctionCallError {
+	arguments := make([]string, len(node.ArgumentInputs.Arguments))
+	for i, argument := range node.ArgumentInputs.Arguments {
+		arguments[i] = argument.String()
+	}
+	if redactValues {
+		values = nil
+	}
+	return &FunctionCallError{
+		Function:  node.FuncIdentifier.IdentifierName,
+		Arguments: arguments,
+		Values:    values,
+		Position:  node.Start(),
+		Cause:     cause,
+	}
+}
+
+// formatArgumentValue formats the value like a literal, if it is of a type that has literals.
+func formatArgumentValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,52 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestFunctionCallError(t *testing.T) {
+	functions := map[string]schema.CallableFunction{"double": newDoubleFunction(t)}
+	expr, err := expressions.New(`double(double($.simple_int) + 1)`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(map[string]any{"simple_int": int64(0)}, functions, nil)
+	var callErr *expressions.FunctionCallError
+	assert.Equals(t, errors.As(err, &callErr), true)
+	// The error is reported for the inner call, which failed.
+	assert.Equals(t, callErr.Function, "double")
+	assert.Equals(t, callErr.Arguments, []string{"$.simple_int"})
+	assert.Equals(t, callErr.Values, []any{int64(0)})
+	assert.Equals(t, callErr.Position, ast.Position{Line: 1, Column: 8, Offset: 7})
+	assert.Equals(t, callErr.Error(), "function 'double' failed at 1:8 with the arguments ($.simple_int = 0) (zero input)")
+	assert.Equals(t, errors.Unwrap(callErr).Error(), "zero input")
+
+	expr, err = expressions.NewWithOptions(`double(0)`, expressions.Options{RedactArgumentValues: true})
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, functions, nil)
+	assert.Equals(t, errors.As(err, &callErr), true)
+	assert.Equals(t, callErr.Values, nil)
+	assert.Contains(t, callErr.Error(), "with the arguments (0 = <redacted>)")
+}
+
+func TestFunctionCallError_ValuesNotReused(t *testing.T) {
+	functions := map[string]schema.CallableFunction{"double": newDoubleFunction(t)}
+	failing, err := expressions.New(`double($.simple_int)`)
+	assert.NoError(t, err)
+	_, err = failing.Evaluate(map[string]any{"simple_int": int64(0)}, functions, nil)
+	var callErr *expressions.FunctionCallError
+	assert.Equals(t, errors.As(err, &callErr), true)
+	// Successful calls reuse their argument slices, which must not change the values kept by the error.
+	succeeding, err := expressions.New(`double(double($.simple_int))`)
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		result, err := succeeding.Evaluate(map[string]any{"simple_int": int64(i + 1)}, functions, nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any(int64(4*(i+1))))
+	}
+	assert.Equals(t, callErr.Values, []any{int64(0)})
+}
@@ -0,0 +1,168 @@
+package expressions
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// CallPolicy limits how long a call to a function may take, and how often it is retried if it fails. Attach it to a
+// function with NewFunctionWithCallPolicy, or with FunctionRegistry.SetCallPolicy in the functions package. The
+// evaluator enforces it for each call of the function.
+type CallPolicy struct {
+	// Timeout is the longest time a single call may take. A call that takes longer fails with a
+	// *FunctionTimeoutError. The call is not interrupted, so the function keeps running in the background until it
+	// returns, but its result is ignored. Since timed out calls are retried, up to Retries+1 calls of the function may
+	// run at the same time, so functions that hold resources should return when they take too long. Zero means no
+	// timeout.
+	Timeout time.Duration
+	// Retries is the number of times a failed or timed out call is repeated before its error is returned.
+	Retries int
+	// RetryDelay is the time to wait before each retry.
+	RetryDelay time.Duration
+}
+
+// Validate returns an error if any of the settings is negative.
+func (p CallPolicy) Validate() error {
+	switch {
+	case p.Timeout < 0:
+		return Errorf("invalid call policy timeout %s, it must not be negative", p.Timeout)
+	case p.Retries < 0:
+		return Errorf("invalid call policy retries %d, it must not be negative", p.Retries)
+	case p.RetryDelay < 0:
+		return Errorf("invalid call policy retry delay %s, it must not be negative", p.RetryDelay)
+	default:
+		return nil
+	}
+}
+
+// CallPolicyFunction is implemented by functions that have a CallPolicy.
+type CallPolicyFunction interface {
+	// CallPolicy returns the policy enforced for each call of the function.
+	CallPolicy() CallPolicy
+}
+
+// FunctionTimeoutError is returned when a call to a function takes longer than the timeout of its CallPolicy.
+type FunctionTimeoutError struct {
+	// Function is the ID of the function.
+	Function string
+	// Timeout is the timeout that was exceeded.
+	Timeout time.Duration
+}
+
+func (e *FunctionTimeoutError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *FunctionTimeoutError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize("the call to the function '%s' timed out after %s", e.Function, e.Timeout)
+}
+
+func (e *FunctionTimeoutError) ErrorCode() ErrorCode {
+	return ErrorCodeFunctionTimeout
+}
+
+// NewFunctionWithCallPolicy attaches the call policy to the function. The returned function keeps implementing
+// LiteralArgumentValidator, LiteralArgumentTyper, PureFunction, and DeprecatedFunction if the passed function does.
+// To attach a policy to an overloaded function, attach it to each overload before creating the OverloadedFunction.
+func NewFunctionWithCallPolicy(function schema.CallableFunction, policy CallPolicy) (schema.CallableFunction, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return functionWithCallPolicy{CallableFunction: function, policy: policy}, nil
+}
+
+// functionWithCallPolicy is a function with a call policy created by NewFunctionWithCallPolicy.
+type functionWithCallPolicy struct {
+	schema.CallableFunction
+	policy CallPolicy
+}
+
+func (f functionWithCallPolicy) CallPolicy() CallPolicy {
+	return f.policy
+}
+
+// Pure returns true if the wrapped function is pure.
+func (f functionWithCallPolicy) Pure() bool {
+	return isPure(f.CallableFunction)
+}
+
+// Deprecation returns the deprecation of the wrapped function, if any.
+func (f functionWithCallPolicy) Deprecation() *Deprecation {
+	return functionDeprecation(f.CallableFunction)
+}
+
+// ValidateLiteralArguments validates the literal arguments with the wrapped function, if it implements
+// LiteralArgumentValidator.
+func (f functionWithCallPolicy) ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error {
+	return pureFunction{f.CallableFunction}.ValidateLiteralArguments(literals, argumentTypes)
+}
+
+// OutputForLiteralArguments returns the output type from the wrapped function, using the literal arguments if it
+// implements LiteralArgumentTyper.
+func (f functionWithCallPolicy) OutputForLiteralArguments(
+	literals map[int]any,
+	argumentTypes []schema.Type,
+) (schema.Type, error) {
+	return pureFunction{f.CallableFunction}.OutputForLiteralArguments(literals, argumentTypes)
+}
+
+// callFunction calls the function, enforcing its call policy if it has one. A retry after a timeout does not wait for
+// the timed out call, which keeps running, see CallPolicy.Timeout.
+func callFunction(function schema.CallableFunction, arguments []any) (any, error) {
+	policyFunction, hasPolicy := function.(CallPolicyFunction)
+	if !hasPolicy {
+		return function.Call(arguments)
+	}
+	policy := policyFunction.CallPolicy()
+	var result any
+	var err error
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 && policy.RetryDelay > 0 {
+			time.Sleep(policy.RetryDelay)
+		}
+		result, err = callWithTimeout(function, arguments, policy.Timeout)
+		if err == nil {
+			return result, nil
+		}
+	}
+	if policy.Retries > 0 {
+		return nil, Errorf("the call to the function '%s' failed after %d attempts (%w)",
+			function.ID(), policy.Retries+1, err)
+	}
+	return nil, err
+}
+
+// callWithTimeout calls the function, and returns a *FunctionTimeoutError if it does not return within the timeout.
+func callWithTimeout(function schema.CallableFunction, arguments []any, timeout time.Duration) (any, error) {
+	if timeout == 0 {
+		return function.Call(arguments)
+	}
+	type callResult struct {
+		value any
+		err   error
+	}
+	// The channel is buffered, so the goroutine can exit after a timeout. The goroutine gets its own copy of the
+	// arguments, since the caller may reuse the slice once a retry succeeds while the timed out call still runs.
+	results := make(chan callResult, 1)
+	arguments = slices.Clone(arguments)
+	go func() {
+		var result callResult
+		defer func() {
+			results <- result
+		}()
+		// A panic in the goroutine cannot be recovered by the caller, so it is returned as the result instead.
+		defer recoverInternalError("calling the function '"+function.ID()+"'", &result.err)
+		result.value, result.err = function.Call(arguments)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result := <-results:
+		return result.value, result.err
+	case <-timer.C:
+		return nil, &FunctionTimeoutError{Function: function.ID(), Timeout: timeout}
+	}
+}
@@ -0,0 +1,123 @@
+package expressions_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestCallPolicy_RetriesExhausted(t *testing.T) {
+	attempts := 0
+	failing, err := schema.NewCallableFunction(
+		"failing",
+		[]schema.Type{},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		nil,
+		func() (int64, error) {
+			attempts++
+			return 0, fmt.Errorf("attempt %d failed", attempts)
+		},
+	)
+	assert.NoError(t, err)
+	withPolicy, err := expressions.NewFunctionWithCallPolicy(failing, expressions.CallPolicy{
+		Retries:    2,
+		RetryDelay: time.Millisecond,
+	})
+	assert.NoError(t, err)
+
+	expr, err := expressions.New(`failing()`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, map[string]schema.CallableFunction{"failing": withPolicy}, nil)
+	assert.Error(t, err)
+	assert.Equals(t, attempts, 3)
+	assert.Contains(t, err.Error(), "failed after 3 attempts")
+	assert.Contains(t, err.Error(), "attempt 3 failed")
+}
+
+func TestCallPolicy_TimeoutOfOverload(t *testing.T) {
+	overloads := newMaxOverloads(t)
+	slowOverload, err := schema.NewCallableFunction(
+		"max",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a string) string {
+			time.Sleep(time.Second)
+			return a
+		},
+	)
+	assert.NoError(t, err)
+	slowOverload, err = expressions.NewFunctionWithCallPolicy(
+		expressions.NewPureFunction(slowOverload),
+		expressions.CallPolicy{Timeout: 10 * time.Millisecond},
+	)
+	assert.NoError(t, err)
+	pure, isPureFunction := slowOverload.(expressions.PureFunction)
+	assert.Equals(t, isPureFunction, true)
+	assert.Equals(t, pure.Pure(), true)
+	overloaded, err := expressions.NewOverloadedFunction(append(overloads.Overloads(), slowOverload)...)
+	assert.NoError(t, err)
+	functions := map[string]schema.CallableFunction{"max": overloaded}
+
+	expr, err := expressions.New(`max(1, 2)`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(nil, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(2)))
+
+	expr, err = expressions.New(`max("a")`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, functions, nil)
+	var timeoutErr *expressions.FunctionTimeoutError
+	assert.Equals(t, errors.As(err, &timeoutErr), true)
+	assert.Equals(t, timeoutErr.Timeout, 10*time.Millisecond)
+}
+
+// slowOddCalls makes every other call of the function take longer than the timeout of the test. It reads the
+// arguments after the delay, like a transport that only encodes them once it has a connection.
+type slowOddCalls struct {
+	schema.CallableFunction
+	calls *atomic.Int64
+}
+
+func (f slowOddCalls) Call(arguments []any) (any, error) {
+	if f.calls.Add(1)%2 == 1 {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return f.CallableFunction.Call(arguments)
+}
+
+// TestCallPolicy_TimedOutCallKeepsArguments checks that a timed out call, which keeps running after its retry
+// succeeded, does not share the argument slice that the evaluator reuses for the next calls. Run it with -race.
+func TestCallPolicy_TimedOutCallKeepsArguments(t *testing.T) {
+	withPolicy, err := expressions.NewFunctionWithCallPolicy(
+		slowOddCalls{CallableFunction: newDoubleFunction(t), calls: &atomic.Int64{}},
+		expressions.CallPolicy{Timeout: 20 * time.Millisecond, Retries: 1},
+	)
+	assert.NoError(t, err)
+	functions := map[string]schema.CallableFunction{"double": withPolicy}
+
+	expr, err := expressions.New(`double($.a) + double($.b)`)
+	assert.NoError(t, err)
+	for i := int64(1); i <= 5; i++ {
+		result, err := expr.Evaluate(map[string]any{"a": i, "b": int64(10)}, functions, nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any(2*i+20))
+	}
+}
+
+func TestCallPolicy_Invalid(t *testing.T) {
+	function := newDoubleFunction(t)
+	_, err := expressions.NewFunctionWithCallPolicy(function, expressions.CallPolicy{Timeout: -time.Second})
+	assert.Error(t, err)
+	_, err = expressions.NewFunctionWithCallPolicy(function, expressions.CallPolicy{RetryDelay: -time.Second})
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package expressions
+
+import (
+	"strconv"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// Canonical returns the normalized form of the expression. Whitespace, redundant parentheses, and the quote style
+// of string literals are not retained, references use the explicit root, so $steps is written as $.steps, and string
+// keys that are valid identifiers use the dot notation. Two expressions with the same canonical form evaluate the same
+// way.
+func (e expression) Canonical() string {
+	var result strings.Builder
+	writeCanonical(&result, e.ast, true)
+	return result.String()
+}
+
+// Equivalent returns true if the other expression has the same canonical form as this expression.
+func (e expression) Equivalent(other Expression) bool {
+	if other == nil {
+		return false
+	}
+	return e.Canonical() == other.Canonical()
+}
+
+// writeCanonical writes the canonical form of the node to the builder. The atRoot parameter indicates that the
+// node is evaluated on the root data, so a bare identifier is a reference to a root field.
+func writeCanonical(result *strings.Builder, node ast.Node, atRoot bool) {
+	switch n := node.(type) {
+	case *ast.StringLiteral:
+		result.WriteString(quoteString(n.StrValue))
+	case *ast.IntLiteral:
+		result.WriteString(strconv.FormatInt(n.IntValue, 10))
+	case *ast.FloatLiteral:
+		formatted := strconv.FormatFloat(n.FloatValue, 'f', -1, 64)
+		if !strings.Contains(formatted, ".") {
+			// Keep the value a float when parsed again.
+			formatted += ".0"
+		}
+		result.WriteString(formatted)
+	case *ast.BooleanLiteral:
+		result.WriteString(strconv.FormatBool(n.BooleanValue))
+	case *ast.Identifier:
+		if rootName := n.RootName(); rootName != "" && rootName != ast.VariablesRootName {
+			// A named root is the field of the root with its name.
+			result.WriteString("$." + rootName)
+			return
+		}
+		if atRoot && !n.IsRoot() {
+			result.WriteString("$.")
+		}
+		result.WriteString(n.IdentifierName)
+	case *ast.DotNotation:
+		writeCanonical(result, n.LeftAccessibleNode, atRoot)
+		result.WriteString(".")
+		writeCanonical(result, n.RightAccessIdentifier, false)
+	case *ast.BracketAccessor:
+		writeCanonical(result, n.LeftNode, atRoot)
+		if key, isString := n.RightExpression.(*ast.StringLiteral); isString && identifierPattern.MatchString(key.StrValue) {
+			result.WriteString("." + key.StrValue)
+			return
+		}
+		result.WriteString("[")
+		// The key is evaluated on the value on the left, not on the root.
+		writeCanonical(result, n.RightExpression, false)
+		result.WriteString("]")
+	case *ast.FunctionCall:
+		result.WriteString(n.FuncIdentifier.IdentifierName)
+		result.WriteString("(")
+		writeCanonical(result, n.ArgumentInputs, true)
+		result.WriteString(")")
+	case *ast.ArgumentList:
+		for i, arg := range n.Arguments {
+			if i > 0 {
+				result.WriteString(", ")
+			}
+			writeCanonical(result, arg, true)
+		}
+	case *ast.BinaryOperation:
+		// A unary operator applies to the rest of the expression, so a unary left operand is always surrounded.
+		writeCanonicalOperand(result, n.LeftNode, true)
+		result.WriteString(" " + canonicalOperator(n.Operation) + " ")
+		writeCanonicalOperand(result, n.RightNode, ast.NeedsParenthesesOnRight(n.Operation, n.RightNode))
+	case *ast.UnaryOperation:
+		result.WriteString(canonicalOperator(n.LeftOperation))
+		writeCanonicalOperand(result, n.RightNode, true)
+	default:
+		// Unknown nodes fall back to their own string representation.
+		result.WriteString(node.String())
+	}
+}
+
+// writeCanonicalOperand writes an operand of an operation, surrounding it with parentheses if it is a binary
+// operation. The parentheses make the evaluation order explicit regardless of operator precedence. Unary operands
+// are surrounded if wrapUnary is set, which is the case for the operands of unary operations, so that the operators
+// are not merged, and for the operands of binary operations where the unary operator would apply to more than the
+// operand.
+func writeCanonicalOperand(result *strings.Builder, node ast.Node, wrapUnary bool) {
+	_, isUnary := node.(*ast.UnaryOperation)
+	_, isBinary := node.(*ast.BinaryOperation)
+	if isBinary || (isUnary && wrapUnary) {
+		result.WriteString("(")
+		writeCanonical(result, node, true)
+		result.WriteString(")")
+		return
+	}
+	writeCanonical(result, node, true)
+}
+
+// canonicalOperator returns the operator as it is written in an expression.
+func canonicalOperator(operation ast.MathOperationType) string {
+	if operation == ast.Divide {
+		// The string representation of the divide operation is not the token used in expressions.
+		return "/"
+	}
+	return operation.String()
+}
@@ -0,0 +1,149 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestCanonical(t *testing.T) {
+	testCases := map[string]struct {
+		expression string
+		expected   string
+	}{
+		"explicit-root": {
+			`$.a.b`,
+			`$.a.b`,
+		},
+		"implicit-root": {
+			`a.b`,
+			`$.a.b`,
+		},
+		"bracket-string-key": {
+			`$["a"]['b']`,
+			`$.a.b`,
+		},
+		"bracket-non-identifier-key": {
+			`$["a b"][0]`,
+			`$["a b"][0]`,
+		},
+		"bracket-relative-identifier-key": {
+			`$.a[b]`,
+			`$.a[b]`,
+		},
+		"whitespace-and-parentheses": {
+			` ( ( 1 + 2 ) )*$.a `,
+			`(1 + 2) * $.a`,
+		},
+		"redundant-parentheses": {
+			`(1 * 2) + 3`,
+			`(1 * 2) + 3`,
+		},
+		"quote-style": {
+			`f('a"b', "c", x)`,
+			`f("a\"b", "c", $.x)`,
+		},
+		"float": {
+			`2.00 / 1.5`,
+			`2.0 / 1.5`,
+		},
+		"unary": {
+			`!( $.a > -1 )`,
+			`!($.a > -1)`,
+		},
+		"unary-left-operand": {
+			`(-2) ^ 2`,
+			`(-2) ^ 2`,
+		},
+		"unary-operand-of-whole-operation": {
+			`-(1 + $.a)`,
+			`-(1 + $.a)`,
+		},
+		"not-right-operand": {
+			`$.a && !$.b`,
+			`$.a && !$.b`,
+		},
+		"not-right-operand-of-arithmetic": {
+			`!(!2) - (!$.b)`,
+			`!((!2) - (!$.b))`,
+		},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			expr, err := expressions.New(tc.expression)
+			assert.NoError(t, err)
+			canonical := expr.Canonical()
+			assert.Equals(t, canonical, tc.expected)
+			// The canonical form must be a valid expression with the same canonical form.
+			reparsed, err := expressions.New(canonical)
+			assert.NoError(t, err)
+			assert.Equals(t, reparsed.Canonical(), canonical)
+		})
+	}
+}
+
+func TestEquivalent(t *testing.T) {
+	testCases := map[string]struct {
+		left       string
+		right      string
+		equivalent bool
+	}{
+		"same":              {`$.a + 1`, `$.a + 1`, true},
+		"whitespace":        {`$.a+1`, ` $.a  +  1 `, true},
+		"parentheses":       {`($.a + 1)`, `$.a + 1`, true},
+		"quotes":            {`$["a"] == 'x'`, "$.a == `x`", true},
+		"precedence":        {`1 + 2 * 3`, `1 + (2 * 3)`, true},
+		"grouping-differs":  {`(1 + 2) * 3`, `1 + 2 * 3`, false},
+		"different-value":   {`$.a + 1`, `$.a + 2`, false},
+		"different-types":   {`1`, `1.0`, false},
+		"different-keys":    {`$.a[0]`, `$.a["0"]`, false},
+		"different-targets": {`$.a.b`, `$.a[b]`, false},
+		"unary-grouping":    {`(-1) + $.a`, `-(1 + $.a)`, false},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			left, err := expressions.New(tc.left)
+			assert.NoError(t, err)
+			right, err := expressions.New(tc.right)
+			assert.NoError(t, err)
+			assert.Equals(t, left.Equivalent(right), tc.equivalent)
+			assert.Equals(t, right.Equivalent(left), tc.equivalent)
+		})
+	}
+	expr, err := expressions.New(`$.a`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.Equivalent(nil), false)
+}
+
+func TestCanonical_RoundTrip(t *testing.T) {
+	data := map[string]any{"a": int64(3), "b": true}
+	for _, expression := range []string{
+		`(-2) ^ 2`,
+		`-2 ^ 2`,
+		`(-1) + $.a`,
+		`-(1 + $.a)`,
+		`-1 + $.a`,
+		`(-$.a) * 2 - 1`,
+		`2 - (-$.a)`,
+		`(!$.b) && $.b`,
+		`$.b && !$.b`,
+		`(!$.b) == false`,
+		`!$.b == false`,
+	} {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			expected, err := expr.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			reparsed, err := expressions.New(expr.Canonical())
+			assert.NoError(t, err)
+			assert.Equals(t, reparsed.Canonical(), expr.Canonical())
+			result, err := reparsed.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, expected)
+		})
+	}
+}
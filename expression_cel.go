@@ -0,0 +1,701 @@
+package expressions
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// The Common Expression Language (CEL, https://cel.dev) shares field accesses, arithmetic, comparisons, logical
+// operators, and function calls with this language. The fields of the root data are the variables of a CEL
+// expression, so `$.steps.a.outputs` is `steps.a.outputs` in CEL.
+
+// celReservedWords are the identifiers CEL does not allow as names of variables and fields, besides the boolean
+// literals.
+var celReservedWords = map[string]bool{
+	"null": true, "in": true, "as": true, "break": true, "const": true, "continue": true, "else": true,
+	"for": true, "function": true, "if": true, "import": true, "let": true, "loop": true, "package": true,
+	"namespace": true, "return": true, "var": true, "void": true, "while": true, "true": true, "false": true,
+}
+
+// ToCEL translates the expression to a CEL expression. The fields of the root data become the variables of the CEL
+// expression, and the functions keep their names. Expressions that use constructs CEL does not have, such as the
+// power operator, the root ($) as a value, $vars, or keys that are looked up in the value on their left, fail with
+// ErrorCodeUntranslatable.
+func ToCEL(expression Expression) (string, error) {
+	var result strings.Builder
+	if err := writeCEL(&result, expression.AST(), true); err != nil {
+		return "", err
+	}
+	return result.String(), nil
+}
+
+// writeCEL writes the CEL form of the node to the builder. The atRoot parameter indicates that the node is evaluated
+// on the root data, so a bare identifier is a reference to a root field.
+func writeCEL(result *strings.Builder, node ast.Node, atRoot bool) error {
+	switch n := node.(type) {
+	case *ast.StringLiteral:
+		result.WriteString(strconv.Quote(n.StrValue))
+	case *ast.IntLiteral:
+		result.WriteString(strconv.FormatInt(n.IntValue, 10))
+	case *ast.FloatLiteral:
+		if math.IsNaN(n.FloatValue) || math.IsInf(n.FloatValue, 0) {
+			return newCodedError(ErrorCodeUntranslatable, "CEL has no literal for the float %v", n.FloatValue)
+		}
+		formatted := strconv.FormatFloat(n.FloatValue, 'g', -1, 64)
+		if !strings.ContainsAny(formatted, ".e") {
+			// Keep the value a float in CEL.
+			formatted += ".0"
+		}
+		result.WriteString(formatted)
+	case *ast.BooleanLiteral:
+		result.WriteString(strconv.FormatBool(n.BooleanValue))
+	case *ast.Identifier:
+		switch rootName := n.RootName(); {
+		case n.IdentifierName == "$":
+			return newCodedError(ErrorCodeUntranslatable, "CEL has no value for the whole root ($)")
+		case rootName == ast.VariablesRootName:
+			return newCodedError(ErrorCodeUntranslatable, "CEL has no variables root ($vars)")
+		case rootName != "":
+			return writeCELName(result, rootName)
+		case !atRoot:
+			return newCodedError(
+				ErrorCodeUntranslatable, "CEL has no keys that are looked up in the value on their left (%s)",
+				n.IdentifierName)
+		default:
+			return writeCELName(result, n.IdentifierName)
+		}
+	case *ast.DotNotation:
+		field, isField := n.RightAccessIdentifier.(*ast.Identifier)
+		if !isField {
+			return newCodedError(ErrorCodeUntranslatable, "unsupported field access %s", n.String())
+		}
+		if left, isIdentifier := n.LeftAccessibleNode.(*ast.Identifier); !isIdentifier || left.IdentifierName != "$" {
+			if err := writeCELOperand(result, n.LeftAccessibleNode, atRoot); err != nil {
+				return err
+			}
+			result.WriteString(".")
+		}
+		return writeCELName(result, field.IdentifierName)
+	case *ast.BracketAccessor:
+		if left, isIdentifier := n.LeftNode.(*ast.Identifier); isIdentifier && left.IdentifierName == "$" {
+			// The fields of the root are variables in CEL, so only keys that are valid names can be translated.
+			key, isString := n.RightExpression.(*ast.StringLiteral)
+			if !isString || !identifierPattern.MatchString(key.StrValue) {
+				return newCodedError(ErrorCodeUntranslatable, "CEL has no variable for the root key %s", n.RightExpression)
+			}
+			return writeCELName(result, key.StrValue)
+		}
+		if err := writeCELOperand(result, n.LeftNode, atRoot); err != nil {
+			return err
+		}
+		result.WriteString("[")
+		// The key is evaluated on the value on the left, not on the root.
+		if err := writeCEL(result, n.RightExpression, false); err != nil {
+			return err
+		}
+		result.WriteString("]")
+	case *ast.FunctionCall:
+		result.WriteString(n.FuncIdentifier.IdentifierName + "(")
+		for i, argument := range n.ArgumentInputs.Arguments {
+			if i > 0 {
+				result.WriteString(", ")
+			}
+			if err := writeCEL(result, argument, true); err != nil {
+				return err
+			}
+		}
+		result.WriteString(")")
+	case *ast.BinaryOperation:
+		if n.Operation == ast.Power {
+			return newCodedError(ErrorCodeUntranslatable, "CEL has no power operator (^)")
+		}
+		left, isBinary := n.LeftNode.(*ast.BinaryOperation)
+		if err := writeCELParenthesized(
+			result, n.LeftNode, isBinary && celPrecedence(left.Operation) < celPrecedence(n.Operation)); err != nil {
+			return err
+		}
+		result.WriteString(" " + canonicalOperator(n.Operation) + " ")
+		right, isBinary := n.RightNode.(*ast.BinaryOperation)
+		return writeCELParenthesized(
+			result, n.RightNode, isBinary && celPrecedence(right.Operation) <= celPrecedence(n.Operation))
+	case *ast.UnaryOperation:
+		result.WriteString(canonicalOperator(n.LeftOperation))
+		// Unary operators apply to the rest of the expression, but only to the next operand in CEL.
+		return writeCELOperand(result, n.RightNode, true)
+	default:
+		return newCodedError(ErrorCodeUntranslatable, "unsupported node type %T", node)
+	}
+	return nil
+}
+
+// celPrecedence returns how strongly the binary operator binds its operands in CEL. Operators with a higher
+// precedence are applied first.
+func celPrecedence(operation ast.MathOperationType) int {
+	switch operation {
+	case ast.Or:
+		return 1
+	case ast.And:
+		return 2
+	case ast.Add, ast.Subtract:
+		return 4
+	case ast.Multiply, ast.Divide, ast.Modulus:
+		return 5
+	default:
+		// The comparison operators.
+		return 3
+	}
+}
+
+// writeCELName writes the name of a variable or field, which must not be a reserved word in CEL.
+func writeCELName(result *strings.Builder, name string) error {
+	if celReservedWords[name] {
+		return newCodedError(ErrorCodeUntranslatable, "%s is a reserved word in CEL", name)
+	}
+	result.WriteString(name)
+	return nil
+}
+
+// writeCELOperand writes the node, surrounding it with parentheses if it is an operation.
+func writeCELOperand(result *strings.Builder, node ast.Node, atRoot bool) error {
+	switch node.(type) {
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		return writeCELParenthesized(result, node, true)
+	default:
+		return writeCEL(result, node, atRoot)
+	}
+}
+
+// writeCELParenthesized writes the node evaluated on the root, surrounded by parentheses if requested.
+func writeCELParenthesized(result *strings.Builder, node ast.Node, parenthesized bool) error {
+	if parenthesized {
+		result.WriteString("(")
+	}
+	if err := writeCEL(result, node, true); err != nil {
+		return err
+	}
+	if parenthesized {
+		result.WriteString(")")
+	}
+	return nil
+}
+
+// FromCEL translates a CEL expression to an expression of this language, and parses it with the options. The
+// variables of the CEL expression become the fields of the root data, so `steps.a.outputs` is `$.steps.a.outputs`.
+// Only the subset of CEL this language shares is supported: field and index accesses, arithmetic, comparisons,
+// logical operators, calls of global functions, and int, float, string, and boolean literals. Other constructs,
+// such as the conditional operator, the in operator, macros like has(), and list or map literals, fail with
+// ErrorCodeUntranslatable. The positions of the parsed expression are the positions in the translated expression,
+// which is returned by String.
+func FromCEL(celExpression string, options Options) (Expression, error) {
+	p := &celParser{source: celExpression}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != celEnd {
+		return nil, p.unexpected()
+	}
+	return NewWithOptions(node.String(), options)
+}
+
+// celTokenKind is the kind of a CEL token.
+type celTokenKind int
+
+const (
+	celEnd celTokenKind = iota
+	celIdentifier
+	celInt
+	celFloat
+	celString
+	celSymbol
+)
+
+// celToken is a token of a CEL expression.
+type celToken struct {
+	kind celTokenKind
+	// text is the token as written, or the unescaped value for strings.
+	text string
+	// offset is the byte offset of the token in the expression.
+	offset int
+}
+
+// celParser parses the subset of CEL that can be translated into the nodes of this language.
+type celParser struct {
+	source string
+	offset int
+	token  celToken
+}
+
+// untranslatable returns an error for a construct at the offset that cannot be translated.
+func (p *celParser) untranslatable(offset int, format string, args ...any) error {
+	return newCodedError(
+		ErrorCodeUntranslatable, "failed to translate the CEL expression %s at offset %d: %s",
+		p.source, offset, fmt.Sprintf(format, args...))
+}
+
+// unexpected returns an error for the current token.
+func (p *celParser) unexpected() error {
+	if p.token.kind == celEnd {
+		return newCodedError(ErrorCodeParse, "failed to parse the CEL expression %s: unexpected end", p.source)
+	}
+	return newCodedError(
+		ErrorCodeParse, "failed to parse the CEL expression %s: unexpected %q at offset %d",
+		p.source, p.token.text, p.token.offset)
+}
+
+// next reads the next token.
+func (p *celParser) next() error {
+	for p.offset < len(p.source) {
+		c := p.source[p.offset]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			p.offset++
+		} else if strings.HasPrefix(p.source[p.offset:], "//") {
+			end := strings.IndexByte(p.source[p.offset:], '\n')
+			if end < 0 {
+				p.offset = len(p.source)
+			} else {
+				p.offset += end
+			}
+		} else {
+			break
+		}
+	}
+	start := p.offset
+	if start == len(p.source) {
+		p.token = celToken{kind: celEnd, offset: start}
+		return nil
+	}
+	c := p.source[start]
+	switch {
+	case c == '"' || c == '\'' || ((c == 'r' || c == 'R' || c == 'b' || c == 'B') && start+1 < len(p.source) &&
+		(p.source[start+1] == '"' || p.source[start+1] == '\'')):
+		return p.scanString()
+	case c == '_' || isASCIILetter(c):
+		end := start
+		for end < len(p.source) && (p.source[end] == '_' || isASCIILetter(p.source[end]) || isASCIIDigit(p.source[end])) {
+			end++
+		}
+		p.token = celToken{kind: celIdentifier, text: p.source[start:end], offset: start}
+		p.offset = end
+		return nil
+	case isASCIIDigit(c) || (c == '.' && start+1 < len(p.source) && isASCIIDigit(p.source[start+1])):
+		return p.scanNumber()
+	}
+	for _, symbol := range []string{"||", "&&", "==", "!=", "<=", ">="} {
+		if strings.HasPrefix(p.source[start:], symbol) {
+			p.token = celToken{kind: celSymbol, text: symbol, offset: start}
+			p.offset += len(symbol)
+			return nil
+		}
+	}
+	if strings.IndexByte("+-*/%<>!()[].,", c) >= 0 {
+		p.token = celToken{kind: celSymbol, text: string(c), offset: start}
+		p.offset++
+		return nil
+	}
+	if strings.IndexByte("?:{}", c) >= 0 {
+		return p.untranslatable(start, "%q is not supported", string(c))
+	}
+	return newCodedError(
+		ErrorCodeParse, "failed to parse the CEL expression %s: unexpected character at offset %d", p.source, start)
+}
+
+// scanNumber reads an int or float literal.
+func (p *celParser) scanNumber() error {
+	start := p.offset
+	end := start
+	isFloat := false
+	if strings.HasPrefix(p.source[start:], "0x") || strings.HasPrefix(p.source[start:], "0X") {
+		end += 2
+		for end < len(p.source) && strings.IndexByte("0123456789abcdefABCDEF", p.source[end]) >= 0 {
+			end++
+		}
+	} else {
+		for end < len(p.source) && isASCIIDigit(p.source[end]) {
+			end++
+		}
+		if end+1 < len(p.source) && p.source[end] == '.' && isASCIIDigit(p.source[end+1]) {
+			isFloat = true
+			end++
+			for end < len(p.source) && isASCIIDigit(p.source[end]) {
+				end++
+			}
+		}
+		if end < len(p.source) && (p.source[end] == 'e' || p.source[end] == 'E') {
+			isFloat = true
+			end++
+			if end < len(p.source) && (p.source[end] == '+' || p.source[end] == '-') {
+				end++
+			}
+			for end < len(p.source) && isASCIIDigit(p.source[end]) {
+				end++
+			}
+		}
+	}
+	if end < len(p.source) && (p.source[end] == 'u' || p.source[end] == 'U') {
+		return p.untranslatable(start, "unsigned integers are not supported")
+	}
+	p.offset = end
+	p.token = celToken{kind: celInt, text: p.source[start:end], offset: start}
+	if isFloat {
+		p.token.kind = celFloat
+	}
+	return nil
+}
+
+// scanString reads a string literal, which may be raw or triple-quoted, and unescapes its value.
+func (p *celParser) scanString() error {
+	start := p.offset
+	raw := false
+	switch p.source[p.offset] {
+	case 'b', 'B':
+		return p.untranslatable(start, "bytes literals are not supported")
+	case 'r', 'R':
+		raw = true
+		p.offset++
+	}
+	quote := p.source[p.offset : p.offset+1]
+	if strings.HasPrefix(p.source[p.offset:], quote+quote+quote) {
+		quote = quote + quote + quote
+	}
+	p.offset += len(quote)
+	var value strings.Builder
+	for {
+		if p.offset >= len(p.source) || (len(quote) == 1 && p.source[p.offset] == '\n') {
+			return newCodedError(
+				ErrorCodeParse, "failed to parse the CEL expression %s: unclosed string at offset %d", p.source, start)
+		}
+		if strings.HasPrefix(p.source[p.offset:], quote) {
+			p.offset += len(quote)
+			p.token = celToken{kind: celString, text: value.String(), offset: start}
+			return nil
+		}
+		if p.source[p.offset] != '\\' || raw {
+			value.WriteByte(p.source[p.offset])
+			p.offset++
+			continue
+		}
+		if err := p.scanEscape(&value); err != nil {
+			return err
+		}
+	}
+}
+
+// scanEscape reads the escape sequence at the current offset and writes the character it stands for.
+func (p *celParser) scanEscape(value *strings.Builder) error {
+	start := p.offset
+	if p.offset+1 >= len(p.source) {
+		return p.untranslatable(start, "invalid escape sequence")
+	}
+	escaped := p.source[p.offset+1]
+	p.offset += 2
+	simple := map[byte]string{
+		'\\': "\\", '"': "\"", '\'': "'", '`': "`", '?': "?",
+		'a': "\a", 'b': "\b", 'f': "\f", 'n': "\n", 'r': "\r", 't': "\t", 'v': "\v",
+	}
+	if replacement, found := simple[escaped]; found {
+		value.WriteString(replacement)
+		return nil
+	}
+	var digits, base int
+	switch {
+	case escaped == 'x' || escaped == 'X':
+		digits, base = 2, 16
+	case escaped == 'u':
+		digits, base = 4, 16
+	case escaped == 'U':
+		digits, base = 8, 16
+	case escaped >= '0' && escaped <= '3':
+		digits, base = 2, 8
+		p.offset--
+		digits++
+	default:
+		return newCodedError(
+			ErrorCodeParse, "failed to parse the CEL expression %s: invalid escape sequence at offset %d", p.source, start)
+	}
+	if p.offset+digits > len(p.source) {
+		return newCodedError(
+			ErrorCodeParse, "failed to parse the CEL expression %s: invalid escape sequence at offset %d", p.source, start)
+	}
+	code, err := strconv.ParseUint(p.source[p.offset:p.offset+digits], base, 32)
+	if err != nil {
+		return newCodedError(
+			ErrorCodeParse, "failed to parse the CEL expression %s: invalid escape sequence at offset %d", p.source, start)
+	}
+	p.offset += digits
+	if escaped == 'u' || escaped == 'U' {
+		if !utf8.ValidRune(rune(code)) {
+			return newCodedError(
+				ErrorCodeParse, "failed to parse the CEL expression %s: invalid code point at offset %d", p.source, start)
+		}
+		value.WriteRune(rune(code))
+	} else {
+		// Octal and hexadecimal escapes are bytes.
+		value.WriteByte(byte(code))
+	}
+	return nil
+}
+
+// isSymbol returns true if the current token is one of the symbols.
+func (p *celParser) isSymbol(symbols ...string) bool {
+	if p.token.kind != celSymbol {
+		return false
+	}
+	for _, symbol := range symbols {
+		if p.token.text == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// expect reads the symbol, or returns an error if the current token is not the symbol.
+func (p *celParser) expect(symbol string) error {
+	if !p.isSymbol(symbol) {
+		return p.unexpected()
+	}
+	return p.next()
+}
+
+// celBinaryOperators maps the binary operators of CEL to the operations of this language.
+var celBinaryOperators = map[string]ast.MathOperationType{
+	"||": ast.Or, "&&": ast.And,
+	"==": ast.EqualTo, "!=": ast.NotEqualTo, "<": ast.LessThan, "<=": ast.LessThanEqualTo,
+	">": ast.GreaterThan, ">=": ast.GreaterThanEqualTo,
+	"+": ast.Add, "-": ast.Subtract, "*": ast.Multiply, "/": ast.Divide, "%": ast.Modulus,
+}
+
+// parseBinary parses a left-associative sequence of operands separated by the operators.
+func (p *celParser) parseBinary(parseOperand func() (ast.Node, error), operators ...string) (ast.Node, error) {
+	left, err := parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	for p.isSymbol(operators...) || (p.token.kind == celIdentifier && p.token.text == "in") {
+		if p.token.kind == celIdentifier {
+			return nil, p.untranslatable(p.token.offset, "the in operator is not supported")
+		}
+		operation := celBinaryOperators[p.token.text]
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		right, err := parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		left = &ast.BinaryOperation{LeftNode: left, RightNode: right, Operation: operation}
+	}
+	return left, nil
+}
+
+func (p *celParser) parseOr() (ast.Node, error) {
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *celParser) parseAnd() (ast.Node, error) {
+	return p.parseBinary(p.parseRelation, "&&")
+}
+
+func (p *celParser) parseRelation() (ast.Node, error) {
+	return p.parseBinary(p.parseAddition, "==", "!=", "<", "<=", ">", ">=")
+}
+
+func (p *celParser) parseAddition() (ast.Node, error) {
+	return p.parseBinary(p.parseMultiplication, "+", "-")
+}
+
+func (p *celParser) parseMultiplication() (ast.Node, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+// parseUnary parses an operand with optional unary operators. Unlike in this language, they only apply to the
+// operand that follows them.
+func (p *celParser) parseUnary() (ast.Node, error) {
+	if !p.isSymbol("!", "-") {
+		return p.parseMember()
+	}
+	operation := ast.Not
+	if p.token.text == "-" {
+		operation = ast.Subtract
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	return &ast.UnaryOperation{LeftOperation: operation, RightNode: operand}, nil
+}
+
+// parseMember parses a primary expression followed by field accesses and indexes.
+func (p *celParser) parseMember() (ast.Node, error) {
+	node, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.isSymbol("."):
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.token.kind != celIdentifier {
+				return nil, p.unexpected()
+			}
+			field := p.token
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.isSymbol("(") {
+				return nil, p.untranslatable(field.offset, "receiver-style calls, such as .%s(), are not supported",
+					field.text)
+			}
+			node = &ast.DotNotation{LeftAccessibleNode: node, RightAccessIdentifier: &ast.Identifier{
+				IdentifierName: field.text,
+			}}
+		case p.isSymbol("["):
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			key, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			node = &ast.BracketAccessor{LeftNode: node, RightExpression: key}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// parsePrimary parses a literal, a variable, a function call, or a parenthesized expression.
+func (p *celParser) parsePrimary() (ast.Node, error) {
+	token := p.token
+	switch token.kind {
+	case celString:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return &ast.StringLiteral{StrValue: token.text, Literal: quoteString(token.text)}, nil
+	case celInt:
+		value, err := strconv.ParseInt(token.text, 0, 64)
+		if err != nil {
+			return nil, p.untranslatable(token.offset, "the integer %s is out of range", token.text)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		return &ast.IntLiteral{IntValue: value}, nil
+	case celFloat:
+		value, err := strconv.ParseFloat(token.text, 64)
+		if err != nil {
+			return nil, p.untranslatable(token.offset, "the float %s is out of range", token.text)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		literal := strconv.FormatFloat(value, 'f', -1, 64)
+		if !strings.Contains(literal, ".") {
+			literal += ".0"
+		}
+		return &ast.FloatLiteral{FloatValue: value, Literal: literal}, nil
+	case celIdentifier:
+		return p.parseIdentifier()
+	case celSymbol:
+		switch token.text {
+		case "(":
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			node, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			return node, p.expect(")")
+		case "[":
+			return nil, p.untranslatable(token.offset, "list literals are not supported")
+		case ".":
+			// A leading dot refers to the name in the root namespace, which is the root data.
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.token.kind != celIdentifier {
+				return nil, p.unexpected()
+			}
+			return p.parseIdentifier()
+		}
+	}
+	return nil, p.unexpected()
+}
+
+// parseIdentifier parses a boolean literal, a variable, or a call of a global function.
+func (p *celParser) parseIdentifier() (ast.Node, error) {
+	token := p.token
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	switch {
+	case token.text == "true" || token.text == "false":
+		return &ast.BooleanLiteral{BooleanValue: token.text == "true"}, nil
+	case celReservedWords[token.text]:
+		return nil, p.untranslatable(token.offset, "%s is not supported", token.text)
+	case p.isSymbol("("):
+		if celMacros[token.text] {
+			return nil, p.untranslatable(token.offset, "the %s macro is not supported", token.text)
+		}
+		return p.parseCall(token.text)
+	default:
+		// The variables are the fields of the root data.
+		return &ast.DotNotation{
+			LeftAccessibleNode:    &ast.Identifier{IdentifierName: "$"},
+			RightAccessIdentifier: &ast.Identifier{IdentifierName: token.text},
+		}, nil
+	}
+}
+
+// celMacros are the global macros of CEL, which are expanded to constructs this language does not have.
+var celMacros = map[string]bool{"has": true}
+
+// parseCall parses the arguments of a call of the function.
+func (p *celParser) parseCall(name string) (ast.Node, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	arguments := &ast.ArgumentList{Arguments: []ast.Node{}}
+	for !p.isSymbol(")") {
+		if len(arguments.Arguments) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		argument, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		arguments.Arguments = append(arguments.Arguments, argument)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	return &ast.FunctionCall{FuncIdentifier: &ast.Identifier{IdentifierName: name}, ArgumentInputs: arguments}, nil
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isASCIIDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
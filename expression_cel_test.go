@@ -0,0 +1,106 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestToCEL(t *testing.T) {
+	testCases := map[string]string{
+		`$.foo.bar`:                          `foo.bar`,
+		`$steps.a.outputs["success"]`:        `steps.a.outputs["success"]`,
+		`$["foo"].int_list[0]`:               `foo.int_list[0]`,
+		`$.simple_int + 2 * 3`:               `simple_int + 2 * 3`,
+		`($.simple_int + 2) * 3`:             `(simple_int + 2) * 3`,
+		`$.simple_int - (1 - 2)`:             `simple_int - (1 - 2)`,
+		`-($.simple_int + 1)`:                `-(simple_int + 1)`,
+		`!$.simple_bool && $.simple_int > 1`: `!(simple_bool && simple_int > 1)`,
+		`intToFloat($.simple_int) / 2.0`:     `intToFloat(simple_int) / 2.0`,
+		`"a\"b" == 'c'`:                      `"a\"b" == "c"`,
+	}
+	for expressionString, expected := range testCases {
+		t.Run(expressionString, func(t *testing.T) {
+			expr, err := expressions.New(expressionString)
+			assert.NoError(t, err)
+			result, err := expressions.ToCEL(expr)
+			assert.NoError(t, err)
+			assert.Equals(t, result, expected)
+		})
+	}
+}
+
+func TestToCEL_Untranslatable(t *testing.T) {
+	for _, expressionString := range []string{
+		`$`,
+		`$vars.a`,
+		`$.simple_int ^ 2`,
+		`$.foo[bar]`,
+		`$["with space"]`,
+		`$.null`,
+	} {
+		t.Run(expressionString, func(t *testing.T) {
+			expr, err := expressions.New(expressionString)
+			assert.NoError(t, err)
+			_, err = expressions.ToCEL(expr)
+			assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUntranslatable)
+		})
+	}
+}
+
+func TestFromCEL(t *testing.T) {
+	testCases := map[string]string{
+		`foo.bar`:                         `$.foo.bar`,
+		`.foo["bar"]`:                     `$.foo["bar"]`,
+		`foo.int_list[0] + 1`:             `$.foo.int_list[0] + 1`,
+		`-simple_int + 1`:                 `(-$.simple_int) + 1`,
+		`!simple_bool || a < 2.5`:         `(!$.simple_bool) || $.a < 2.5`,
+		`size(r'a\n') >= 0x10`:            `size("a\\n") >= 16`,
+		`'it\'s' + "é" == a`:              `"it's" + "é" == $.a`,
+		`f(1e3, // comment` + "\n" + `2)`: `f(1000.0, 2)`,
+	}
+	for celExpression, expected := range testCases {
+		t.Run(celExpression, func(t *testing.T) {
+			expr, err := expressions.FromCEL(celExpression, expressions.Options{})
+			assert.NoError(t, err)
+			assert.Equals(t, expr.String(), expected)
+		})
+	}
+
+	// Translated expressions evaluate like the original.
+	expr, err := expressions.FromCEL(`foo.int_list[1] * (simple_int - 1)`, expressions.Options{})
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(map[string]any{
+		"foo":        map[string]any{"int_list": []any{int64(1), int64(4)}},
+		"simple_int": int64(3),
+	}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(8)))
+}
+
+func TestFromCEL_Errors(t *testing.T) {
+	untranslatable := []string{
+		`a ? b : c`,
+		`a in [1, 2]`,
+		`[1, 2]`,
+		`{"a": 1}`,
+		`has(a.b)`,
+		`a.size()`,
+		`1u`,
+		`b"bytes"`,
+		`null`,
+	}
+	for _, celExpression := range untranslatable {
+		t.Run(celExpression, func(t *testing.T) {
+			_, err := expressions.FromCEL(celExpression, expressions.Options{})
+			assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUntranslatable)
+		})
+	}
+	for _, celExpression := range []string{`a +`, `(a`, `"unclosed`, `a #`} {
+		t.Run(celExpression, func(t *testing.T) {
+			_, err := expressions.FromCEL(celExpression, expressions.Options{})
+			assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeParse)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package expressions
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// CompletionKind describes what a completion candidate refers to.
+type CompletionKind string
+
+const (
+	// CompletionField is a field of an object, or of the root object.
+	CompletionField CompletionKind = "field"
+	// CompletionFunction is a function name.
+	CompletionFunction CompletionKind = "function"
+)
+
+// Completion is a candidate that can be inserted at the cursor position.
+type Completion struct {
+	// Label is the text to insert.
+	Label string
+	Kind  CompletionKind
+	// Detail holds additional information for displaying the candidate, such as the type of a field.
+	Detail string
+	// Start is the rune index in the expression where the candidate starts. The text between Start and the cursor
+	// is the prefix that was already typed, and should be replaced by the label.
+	Start int
+}
+
+// Complete returns the candidates that are valid at the cursor position of a partially written expression. The
+// cursor is the rune index in the expression. After a dot, the candidates are the fields of the value on the left of
+// the dot, and directly after a $, the fields of the root object, which can be used as named roots. Elsewhere, the
+// candidates are the fields of the root object and the function names. Only candidates that start with the
+// identifier already typed before the cursor are returned, sorted by their label.
+//
+// The expression does not need to be valid as a whole, only the access chain before a dot is parsed. If the type of
+// that chain cannot be resolved, no candidates are returned.
+func Complete(
+	partialExpression string,
+	cursor int,
+	scope schema.Scope,
+	functions map[string]schema.Function,
+) ([]Completion, error) {
+	runes := []rune(partialExpression)
+	if cursor < 0 || cursor > len(runes) {
+		return nil, Errorf("cursor position %d out of range for expression of length %d", cursor, len(runes))
+	}
+	if insideStringLiteral(runes[:cursor]) {
+		return []Completion{}, nil
+	}
+	prefixStart := cursor
+	for prefixStart > 0 && isIdentifierRune(runes[prefixStart-1]) {
+		prefixStart--
+	}
+	prefix := string(runes[prefixStart:cursor])
+
+	var candidates []Completion
+	switch {
+	case prefixStart > 0 && runes[prefixStart-1] == '.':
+		chainEnd := prefixStart - 1
+		chain := string(runes[accessChainStart(runes, chainEnd):chainEnd])
+		candidates = fieldCompletions(chain, scope, functions)
+	case prefixStart > 0 && runes[prefixStart-1] == '$':
+		// The name of a named root, such as $steps, is a field of the root object.
+		candidates = objectFieldCompletions(scope, false)
+	default:
+		candidates = objectFieldCompletions(scope, true)
+		for name := range functions {
+			candidates = append(candidates, Completion{Label: name, Kind: CompletionFunction})
+		}
+	}
+
+	result := make([]Completion, 0, len(candidates))
+	for _, candidate := range candidates {
+		if !strings.HasPrefix(candidate.Label, prefix) {
+			continue
+		}
+		candidate.Start = prefixStart
+		result = append(result, candidate)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Label < result[j].Label
+	})
+	return result, nil
+}
+
+// fieldCompletions returns the fields of the type the access chain resolves to.
+func fieldCompletions(chain string, scope schema.Scope, functions map[string]schema.Function) []Completion {
+	if chain == "" {
+		return nil
+	}
+	chainExpression, err := New(chain)
+	if err != nil {
+		return nil
+	}
+	chainType, err := chainExpression.Type(scope, functions, nil)
+	if err != nil {
+		return nil
+	}
+	return objectFieldCompletions(chainType, false)
+}
+
+// objectFieldCompletions returns the fields of the type if it is an object. Fields that cannot be written with the
+// dot notation are left out, as well as reserved words if the fields are referenced with an implicit root.
+func objectFieldCompletions(objectType schema.Type, implicitRoot bool) []Completion {
+	switch objectType.TypeID() {
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+	default:
+		return nil
+	}
+	var result []Completion
+	for name, property := range objectType.(schema.Object).Properties() {
+		if !identifierPattern.MatchString(name) || (implicitRoot && reservedWords[name]) {
+			continue
+		}
+		result = append(result, Completion{
+			Label:  name,
+			Kind:   CompletionField,
+			Detail: string(property.Type().TypeID()),
+		})
+	}
+	return result
+}
+
+// accessChainStart returns the index where the access chain ending at the end index starts. The chain consists of
+// identifiers, dots, the root, and bracketed or parenthesized parts, such as $.a["b"].c or f(1).d.
+func accessChainStart(runes []rune, end int) int {
+	depth := 0
+	start := end
+	for start > 0 {
+		r := runes[start-1]
+		switch {
+		case depth > 0 && (r == ']' || r == ')'):
+			depth++
+		case depth > 0 && (r == '[' || r == '('):
+			depth--
+		case depth > 0:
+		case r == ']' || r == ')':
+			depth++
+		case isIdentifierRune(r) || r == '.' || r == '$':
+		default:
+			return start
+		}
+		start--
+	}
+	return start
+}
+
+// insideStringLiteral returns true if the text ends within an unterminated string literal.
+func insideStringLiteral(runes []rune) bool {
+	var quote rune
+	escaped := false
+	for _, r := range runes {
+		switch {
+		case quote == 0:
+			if r == '"' || r == '\'' || r == '`' {
+				quote = r
+			}
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '`':
+			escaped = true
+		case r == quote:
+			quote = 0
+		}
+	}
+	return quote != 0
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,103 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func completionLabels(completions []expressions.Completion) []string {
+	labels := make([]string, len(completions))
+	for i, completion := range completions {
+		labels[i] = completion.Label
+	}
+	return labels
+}
+
+func TestComplete_Fields(t *testing.T) {
+	completions, err := expressions.Complete("$.foo.", 6, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"bar", "int_list"})
+	assert.Equals(t, completions[0].Kind, expressions.CompletionField)
+	assert.Equals(t, completions[0].Detail, string(schema.TypeIDString))
+	assert.Equals(t, completions[0].Start, 6)
+}
+
+func TestComplete_Prefix(t *testing.T) {
+	completions, err := expressions.Complete("$.simple_i + 1", 10, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"simple_int", "simple_int_2"})
+	assert.Equals(t, completions[0].Start, 2)
+}
+
+func TestComplete_RootAndFunctions(t *testing.T) {
+	intInFunc, err := schema.NewCallableFunction(
+		"intIn",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		nil,
+		false,
+		nil,
+		func(a int64) {},
+	)
+	assert.NoError(t, err)
+	funcMap := map[string]schema.Function{"intIn": intInFunc}
+
+	completions, err := expressions.Complete("1 + in", 6, testScope, funcMap)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"intIn", "int_list"})
+	assert.Equals(t, completions[0].Kind, expressions.CompletionFunction)
+	assert.Equals(t, completions[1].Kind, expressions.CompletionField)
+
+	completions, err = expressions.Complete("intIn($.foo.b", 13, testScope, funcMap)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"bar"})
+}
+
+func TestComplete_Nested(t *testing.T) {
+	completions, err := expressions.Complete(`$.faz["a"]. == 1`, 11, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(completions), 0)
+
+	completions, err = expressions.Complete(`foo.`, 4, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"bar", "int_list"})
+}
+
+func TestComplete_NoCandidates(t *testing.T) {
+	// Unknown field on the left.
+	completions, err := expressions.Complete("$.nonexistent.", 14, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(completions), 0)
+	// Inside a string.
+	completions, err = expressions.Complete(`"$.foo.`, 7, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(completions), 0)
+	// Not an object.
+	completions, err = expressions.Complete("$.simple_int.", 13, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(completions), 0)
+}
+
+func TestComplete_InvalidCursor(t *testing.T) {
+	_, err := expressions.Complete("$.foo", 6, testScope, nil)
+	assert.Error(t, err)
+	_, err = expressions.Complete("$.foo", -1, testScope, nil)
+	assert.Error(t, err)
+}
+
+func TestComplete_FieldNamedLikeLiteral(t *testing.T) {
+	scope := schema.NewScopeSchema(schema.NewObjectSchema("root", map[string]*schema.PropertySchema{
+		"true": schema.NewPropertySchema(schema.NewBoolSchema(), nil, true, nil, nil, nil, nil, nil),
+		"tree": schema.NewPropertySchema(schema.NewBoolSchema(), nil, true, nil, nil, nil, nil, nil),
+	}))
+	// After a dot, the field can be referenced.
+	completions, err := expressions.Complete("$.tr", 4, scope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"tree", "true"})
+	// With an implicit root, it would be a literal.
+	completions, err = expressions.Complete("tr", 2, scope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, completionLabels(completions), []string{"tree"})
+}
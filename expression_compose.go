@@ -0,0 +1,96 @@
+package expressions
+
+import (
+	"regexp"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// The functions in this file build new expressions from existing ones, such as conditions that engines synthesize
+// from multiple user inputs. The text of each operand is kept as it is, and surrounded by parentheses only where they
+// are needed to keep its structure. The new expression is parsed with the options of the first operand, without its
+// file name and position offsets, since its text is not written in a file.
+
+// And returns the expression that is true if both conditions are true, such as `$.a > 1 && $.b`.
+func And(left Expression, right Expression) (Expression, error) {
+	return combine(ast.And, left, right)
+}
+
+// Or returns the expression that is true if any of the conditions is true, such as `$.a > 1 || $.b`.
+func Or(left Expression, right Expression) (Expression, error) {
+	return combine(ast.Or, left, right)
+}
+
+// Not returns the expression that negates the condition, such as `!($.a > 1)`.
+func Not(condition Expression) (Expression, error) {
+	text := condition.String()
+	switch condition.AST().(type) {
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		text = "(" + text + ")"
+	}
+	return NewWithOptions("!"+text, composeOptions(condition))
+}
+
+// Wrap returns the expression that calls the function with the expressions as its arguments, such as
+// `toUpper($.name)`. Namespaced function names, such as `str::upper`, are supported.
+func Wrap(function string, arguments ...Expression) (Expression, error) {
+	if !functionNamePattern.MatchString(function) {
+		return nil, Errorf("invalid function name %q", function)
+	}
+	texts := make([]string, len(arguments))
+	for i, argument := range arguments {
+		texts[i] = argument.String()
+	}
+	var options Options
+	if len(arguments) > 0 {
+		options = composeOptions(arguments[0])
+	}
+	return NewWithOptions(function+"("+strings.Join(texts, ", ")+")", options)
+}
+
+// Access returns the expression that accesses the path within the value of the base expression, such as
+// `$.steps.a.outputs.success` for the base `$.steps.a` and the path outputs.success. The path does not start with
+// the root. Only references and function calls can be accessed, since the values of other expressions cannot be.
+func Access(base Expression, path Path) (Expression, error) {
+	switch base.AST().(type) {
+	case *ast.Identifier, *ast.DotNotation, *ast.BracketAccessor, *ast.FunctionCall:
+	default:
+		return nil, Errorf("cannot access %s within %q, since only references and function calls can be accessed",
+			path, base.String())
+	}
+	suffix, err := renderPathItems(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithOptions(base.String()+suffix, composeOptions(base))
+}
+
+// functionNamePattern matches the names of functions, which may be namespaced.
+var functionNamePattern = regexp.MustCompile(`^[a-zA-Z_]\w*((\.|::)[a-zA-Z_]\w*)*$`)
+
+// combine returns the binary operation of the left and the right expression.
+func combine(operation ast.MathOperationType, left Expression, right Expression) (Expression, error) {
+	leftText := left.String()
+	if ast.NeedsParenthesesOnLeft(operation, left.AST()) {
+		leftText = "(" + leftText + ")"
+	}
+	rightText := right.String()
+	if ast.NeedsParenthesesOnRight(operation, right.AST()) {
+		rightText = "(" + rightText + ")"
+	}
+	return NewWithOptions(leftText+" "+canonicalOperator(operation)+" "+rightText, composeOptions(left))
+}
+
+// composeOptions returns the options to parse an expression built from the expression with.
+func composeOptions(from Expression) Options {
+	impl, isExpression := from.(*expression)
+	if !isExpression {
+		return Options{}
+	}
+	options := impl.options
+	options.Filename = ""
+	options.LineOffset = 0
+	options.ColumnOffset = 0
+	return options
+}
@@ -0,0 +1,89 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func mustParse(t *testing.T, expression string) expressions.Expression {
+	t.Helper()
+	expr, err := expressions.New(expression)
+	assert.NoError(t, err)
+	return expr
+}
+
+func TestAnd_Or(t *testing.T) {
+	a := mustParse(t, `$.a || $.b`)
+	b := mustParse(t, `!$.c`)
+	c := mustParse(t, `$.d  >  1`)
+
+	combined, err := expressions.And(a, c)
+	assert.NoError(t, err)
+	assert.Equals(t, combined.String(), `($.a || $.b) && $.d  >  1`)
+	combined, err = expressions.And(b, c)
+	assert.NoError(t, err)
+	assert.Equals(t, combined.String(), `(!$.c) && $.d  >  1`)
+	combined, err = expressions.And(c, b)
+	assert.NoError(t, err)
+	assert.Equals(t, combined.String(), `$.d  >  1 && !$.c`)
+	combined, err = expressions.Or(a, a)
+	assert.NoError(t, err)
+	assert.Equals(t, combined.String(), `$.a || $.b || ($.a || $.b)`)
+
+	result, err := combined.Evaluate(map[string]any{"a": false, "b": true}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(true))
+}
+
+func TestNot(t *testing.T) {
+	negated, err := expressions.Not(mustParse(t, `$.a && $.b`))
+	assert.NoError(t, err)
+	assert.Equals(t, negated.String(), `!($.a && $.b)`)
+	negated, err = expressions.Not(mustParse(t, `$.a`))
+	assert.NoError(t, err)
+	assert.Equals(t, negated.String(), `!$.a`)
+}
+
+func TestWrap(t *testing.T) {
+	wrapped, err := expressions.Wrap("toUpper", mustParse(t, `$.a + "b"`))
+	assert.NoError(t, err)
+	assert.Equals(t, wrapped.String(), `toUpper($.a + "b")`)
+	wrapped, err = expressions.Wrap("str::concat", mustParse(t, `$.a`), mustParse(t, `"b"`))
+	assert.NoError(t, err)
+	assert.Equals(t, wrapped.String(), `str::concat($.a, "b")`)
+	wrapped, err = expressions.Wrap("now")
+	assert.NoError(t, err)
+	assert.Equals(t, wrapped.String(), `now()`)
+
+	_, err = expressions.Wrap("f(1) + g", mustParse(t, `$.a`))
+	assert.Error(t, err)
+}
+
+func TestAccess(t *testing.T) {
+	accessed, err := expressions.Access(mustParse(t, `$steps.a`), expressions.Path{"outputs", "with space", 0})
+	assert.NoError(t, err)
+	assert.Equals(t, accessed.String(), `$steps.a.outputs["with space"][0]`)
+	accessed, err = expressions.Access(mustParse(t, `f()`), expressions.Path{"a"})
+	assert.NoError(t, err)
+	assert.Equals(t, accessed.String(), `f().a`)
+
+	_, err = expressions.Access(mustParse(t, `$.a + $.b`), expressions.Path{"c"})
+	assert.Error(t, err)
+}
+
+func TestCompose_Options(t *testing.T) {
+	a, err := expressions.NewWithOptions(`$.a`, expressions.Options{
+		DisabledFeatures: []expressions.Feature{expressions.FeatureFunctionCalls},
+		LineOffset:       10,
+	})
+	assert.NoError(t, err)
+	_, err = expressions.Wrap("f", a)
+	assert.Error(t, err)
+	_, err = expressions.Wrap("f", mustParse(t, `$.a`))
+	assert.NoError(t, err)
+	combined, err := expressions.And(a, a)
+	assert.NoError(t, err)
+	assert.Equals(t, combined.AST().Start().Line, 1)
+}
@@ -0,0 +1,28 @@
+package expressions
+
+// DataProvider provides the data of an expression on demand. Pass it to Evaluate in place of the root data, so only the
+// values the expression accesses are fetched, which is useful when the data is large or stored elsewhere.
+//
+// Each access of the root data that only uses field names and literal keys, such as $.steps.a.outputs["x"], is
+// requested with its full path, which starts with the root ($), like the paths returned by Dependencies. The value
+// before a computed key, such as $.items in $.items[$.index], is requested as a whole, as is the root itself if the
+// expression uses $ on its own. The same path may be requested more than once during an evaluation.
+type DataProvider interface {
+	// Get returns the value at the path. To report that the value does not exist, return an *Error with
+	// ErrorCodeUnknownField.
+	Get(path Path) (any, error)
+}
+
+// getProvidedValue gets the value at the path from the provider. The errors of the provider keep their code, or get
+// ErrorCodeDataProviderFailed if they have none.
+func getProvidedValue(provider DataProvider, path Path) (any, error) {
+	value, err := provider.Get(path)
+	if err != nil {
+		code := ErrorCodeOf(err)
+		if code == ErrorCodeUnknown {
+			code = ErrorCodeDataProviderFailed
+		}
+		return nil, newCodedError(code, "failed to get %s from the data provider (%w)", path, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,73 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+// recordingProvider returns the values of the data it holds, and records the paths it was asked for.
+type recordingProvider struct {
+	data      any
+	requested []string
+}
+
+func (p *recordingProvider) Get(path expressions.Path) (any, error) {
+	p.requested = append(p.requested, path.String())
+	value := p.data
+	for _, item := range path[1:] {
+		dataMap, isMap := value.(map[string]any)
+		if !isMap {
+			return nil, errors.New("not a map")
+		}
+		var found bool
+		value, found = dataMap[item.(string)]
+		if !found {
+			return nil, &expressions.Error{Code: expressions.ErrorCodeUnknownField, Err: errors.New("not found")}
+		}
+	}
+	return value, nil
+}
+
+func TestDataProvider(t *testing.T) {
+	data := map[string]any{
+		"steps": map[string]any{"a": map[string]any{"x": int64(1), "y": int64(2)}},
+		"key":   "y",
+	}
+	testCases := map[string]struct {
+		expected  any
+		requested []string
+	}{
+		`$.steps.a["x"]`:           {int64(1), []string{"$.steps.a.x"}},
+		`$steps.a.x + $.steps.a.y`: {int64(3), []string{"$.steps.a.x", "$.steps.a.y"}},
+		`steps.a.x`:                {int64(1), []string{"$.steps"}},
+		`$.steps.a[$.key]`:         {int64(2), []string{"$.steps.a", "$.key"}},
+		`$`:                        {data, []string{"$"}},
+	}
+	for expression, testCase := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			provider := &recordingProvider{data: data}
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			result, err := expr.Evaluate(provider, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, testCase.expected)
+			assert.Equals(t, provider.requested, testCase.requested)
+		})
+	}
+}
+
+func TestDataProvider_Errors(t *testing.T) {
+	expr, err := expressions.New(`1 + $.missing`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(&recordingProvider{data: map[string]any{}}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+	assert.Contains(t, err.Error(), "failed to get $.missing from the data provider (not found) at 1:5")
+
+	expr, err = expressions.New(`$.a.b`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(&recordingProvider{data: map[string]any{"a": "text"}}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeDataProviderFailed)
+}
@@ -0,0 +1,113 @@
+package expressions
+
+import (
+	"fmt"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Deferred is the handle of a value that may not be available yet, such as the result of a slow external lookup.
+// Functions return a Deferred from Call to defer their execution. The output type of such a function is the type of
+// the value, not of the handle.
+//
+// Evaluate awaits deferred values, so expressions using them behave as if the functions returned the values
+// directly. EvaluateDeferred does not wait, so the engine can wait for the values itself.
+type Deferred interface {
+	// Resolved returns true if the value is available, so Await returns without blocking.
+	Resolved() bool
+	// Await blocks until the value is available and returns it, or returns the error of the execution.
+	Await() (any, error)
+}
+
+// NewDeferred starts executing the function in a new goroutine, and returns the handle of its result.
+func NewDeferred(execute func() (any, error)) Deferred {
+	d := &deferred{done: make(chan struct{})}
+	go func() {
+		defer close(d.done)
+		// A panic in the goroutine cannot be recovered by the caller, so it is returned by Await instead.
+		defer recoverInternalError("executing a deferred function", &d.err)
+		d.value, d.err = execute()
+	}()
+	return d
+}
+
+// deferred is the Deferred returned by NewDeferred.
+type deferred struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func (d *deferred) Resolved() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *deferred) Await() (any, error) {
+	<-d.done
+	return d.value, d.err
+}
+
+// ResolvedDeferred returns a Deferred that is already resolved to the value or error. This is useful for functions
+// that only defer their execution in some cases, such as when the value is not cached.
+func ResolvedDeferred(value any, err error) Deferred {
+	return &resolvedDeferred{value: value, err: err}
+}
+
+type resolvedDeferred struct {
+	value any
+	err   error
+}
+
+func (d *resolvedDeferred) Resolved() bool {
+	return true
+}
+
+func (d *resolvedDeferred) Await() (any, error) {
+	return d.value, d.err
+}
+
+// PendingError is returned by EvaluateDeferred when the expression needs a deferred value that is not resolved yet,
+// such as to compute an operation with it. Wait for the Deferred to be resolved, then evaluate the expression again.
+// Functions should return the same Deferred for the same arguments while it is pending, so evaluating again does not
+// start another execution.
+type PendingError struct {
+	// Function is the name of the function that returned the deferred value.
+	Function string
+	// Deferred is the handle of the value that is not resolved yet.
+	Deferred Deferred
+}
+
+func (e *PendingError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *PendingError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize("the result of the function '%s' is not available yet", e.Function)
+}
+
+func (e *PendingError) ErrorCode() ErrorCode {
+	return ErrorCodePending
+}
+
+// EvaluateDeferred evaluates the expression like Evaluate, but without waiting for deferred values. If the
+// expression is a call to a function that returns a Deferred that is not resolved yet, the Deferred is returned as the
+// result. If any other part of the expression needs a deferred value that is not resolved yet, a *PendingError is
+// returned with the Deferred to wait for.
+func (e expression) EvaluateDeferred(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (result any, err error) {
+	defer recoverInternalError("evaluating the expression", &err)
+	if err := e.options.Policy.check(e.ast); err != nil {
+		return nil, err
+	}
+	context := e.newEvaluateContext(data, functions, workflowContext)
+	context.deferredRoot = e.ast
+	return context.evaluateTraced(e.ast, data)
+}
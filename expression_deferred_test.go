@@ -0,0 +1,98 @@
+package expressions_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// newLookupFunction creates a function that looks up keys once the release channel is closed. It returns the same
+// Deferred for the same key, as the engine expects.
+func newLookupFunction(t *testing.T, release chan struct{}) map[string]schema.CallableFunction {
+	lookups := map[string]expressions.Deferred{}
+	lookup, err := schema.NewDynamicCallableFunction(
+		"lookup",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		nil,
+		func(key string) (any, error) {
+			if _, found := lookups[key]; !found {
+				lookups[key] = expressions.NewDeferred(func() (any, error) {
+					<-release
+					if key == "missing" {
+						return nil, fmt.Errorf("key not found")
+					}
+					return "value of " + key, nil
+				})
+			}
+			return lookups[key], nil
+		},
+		func(_ []schema.Type) (schema.Type, error) {
+			return schema.NewStringSchema(nil, nil, nil), nil
+		},
+	)
+	assert.NoError(t, err)
+	return map[string]schema.CallableFunction{"lookup": lookup}
+}
+
+func TestDeferred_Evaluate(t *testing.T) {
+	release := make(chan struct{})
+	close(release)
+	functions := newLookupFunction(t, release)
+
+	expr, err := expressions.New(`lookup("a") + "!"`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(nil, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("value of a!"))
+
+	expr, err = expressions.New(`lookup("missing")`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, functions, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "key not found")
+}
+
+func TestDeferred_EvaluateDeferred(t *testing.T) {
+	release := make(chan struct{})
+	functions := newLookupFunction(t, release)
+
+	// The result of the expression is returned as a Deferred.
+	expr, err := expressions.New(`lookup("a")`)
+	assert.NoError(t, err)
+	result, err := expr.EvaluateDeferred(nil, functions, nil)
+	assert.NoError(t, err)
+	deferred, isDeferred := result.(expressions.Deferred)
+	assert.Equals(t, isDeferred, true)
+	assert.Equals(t, deferred.Resolved(), false)
+
+	// A deferred value needed for an operation is reported as pending.
+	expr, err = expressions.New(`lookup("b") + "!"`)
+	assert.NoError(t, err)
+	_, err = expr.EvaluateDeferred(nil, functions, nil)
+	var pendingErr *expressions.PendingError
+	assert.Equals(t, errors.As(err, &pendingErr), true)
+	assert.Equals(t, pendingErr.Function, "lookup")
+
+	close(release)
+	value, err := deferred.Await()
+	assert.NoError(t, err)
+	assert.Equals(t, value, any("value of a"))
+	_, err = pendingErr.Deferred.Await()
+	assert.NoError(t, err)
+	// Once resolved, evaluating again uses the value.
+	result, err = expr.EvaluateDeferred(nil, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("value of b!"))
+}
+
+func TestDeferred_ResolvedDeferred(t *testing.T) {
+	deferred := expressions.ResolvedDeferred(int64(1), nil)
+	assert.Equals(t, deferred.Resolved(), true)
+	value, err := deferred.Await()
+	assert.NoError(t, err)
+	assert.Equals(t, value, any(int64(1)))
+}
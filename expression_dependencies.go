@@ -1,8 +1,7 @@
 package expressions
 
 import (
-	"fmt"
-	"go.flow.arcalot.io/expressions/internal/ast"
+	"go.flow.arcalot.io/expressions/ast"
 	"go.flow.arcalot.io/pluginsdk/schema"
 	"slices"
 )
@@ -10,10 +9,16 @@ import (
 // dependencyContext holds the root data for a dependency evaluation in an expression. This is useful so that we
 // don't need to pass the root type, path, and workflow context along with each function call.
 type dependencyContext struct {
+	// expression is the expression that is resolved, which is added to the errors.
+	expression string
+	// trace is called with each step of the resolution, if it is set.
+	trace           func(step TypeTraceStep)
 	rootType        schema.Type
 	rootPath        PathTree
 	workflowContext map[string][]byte
 	functions       map[string]schema.Function
+	// variables are the types of the variables, which identifiers refer to before the fields of the root.
+	variables map[string]schema.Type
 }
 
 type dependencyResult struct {
@@ -47,6 +52,15 @@ func (c *dependencyContext) rootDependencies(
 	return result, nil
 }
 
+// typeID returns the type ID of the node, or an empty string if it cannot be resolved.
+func (c *dependencyContext) typeID(node ast.Node) schema.TypeID {
+	result, err := c.rootDependencies(node)
+	if err != nil || result.resolvedType == nil {
+		return ""
+	}
+	return result.resolvedType.TypeID()
+}
+
 // dependencies evaluates an AST node for possible dependencies. It adds items to the specified path tree and returns
 // it. You can use this to build a list of value paths that make up the dependencies of this expression. Furthermore,
 // you can also use this function to evaluate the type the resolved expression's value will have.
@@ -63,11 +77,73 @@ func (c *dependencyContext) dependencies(
 	node ast.Node,
 	currentType schema.Type,
 	path *PathTree,
+) (*dependencyResult, error) {
+	result, err := c.nodeDependencies(node, currentType, path)
+	return c.finishDependencies(node, currentType, result, err)
+}
+
+// fieldNameDependencies resolves the dependencies of the node on the right of a dot notation. Unlike other
+// identifiers, field names never refer to variables.
+func (c *dependencyContext) fieldNameDependencies(
+	node ast.Node,
+	currentType schema.Type,
+	path *PathTree,
+) (*dependencyResult, error) {
+	identifier, isIdentifier := node.(*ast.Identifier)
+	if !isIdentifier {
+		return c.dependencies(node, currentType, path)
+	}
+	result, err := dependenciesAccessObject(currentType, identifier.IdentifierName, path)
+	return c.finishDependencies(node, currentType, result, err)
+}
+
+// finishDependencies adds the position of the node to the error, and traces the result of the node.
+func (c *dependencyContext) finishDependencies(
+	node ast.Node,
+	currentType schema.Type,
+	result *dependencyResult,
+	err error,
+) (*dependencyResult, error) {
+	err = locateError(err, c.expression, node)
+	if c.trace != nil && !hasAccessChain(node) {
+		var inputType schema.Type
+		if _, isIdentifier := node.(*ast.Identifier); isIdentifier {
+			inputType = currentType
+		}
+		c.traceStep(traceExpression(node), node.Start(), inputType, result, err)
+	}
+	return result, err
+}
+
+// hasAccessChain returns true if the node is resolved as an access chain, which traces each of its steps itself.
+func hasAccessChain(node ast.Node) bool {
+	switch n := node.(type) {
+	case *ast.DotNotation:
+		return n.Chain != nil
+	case *ast.BracketAccessor:
+		return n.Chain != nil
+	default:
+		return false
+	}
+}
+
+// nodeDependencies evaluates the dependencies of the node. Use dependencies instead, which adds the position of the
+// node to the errors.
+func (c *dependencyContext) nodeDependencies(
+	node ast.Node,
+	currentType schema.Type,
+	path *PathTree,
 ) (*dependencyResult, error) {
 	switch n := node.(type) {
 	case *ast.DotNotation:
+		if n.Chain != nil {
+			return c.accessChainDependencies(n, n.Chain, currentType, path)
+		}
 		return c.dotNotationDependencies(n, currentType, path)
 	case *ast.BracketAccessor:
+		if n.Chain != nil {
+			return c.accessChainDependencies(n, n.Chain, currentType, path)
+		}
 		return c.bracketAccessorDependencies(n, currentType, path)
 	case *ast.Identifier:
 		return c.identifierDependencies(n, currentType, path)
@@ -86,7 +162,7 @@ func (c *dependencyContext) dependencies(
 	case *ast.FunctionCall:
 		return c.functionDependencies(n)
 	default:
-		return nil, fmt.Errorf("unsupported AST node type: %T", n)
+		return nil, newCodedError(ErrorCodeInternal, "unsupported AST node type: %T", n)
 	}
 }
 
@@ -104,18 +180,18 @@ func (c *dependencyContext) unaryOperationDependencies(
 		if innerResult.resolvedType.TypeID() != schema.TypeIDInt &&
 			innerResult.resolvedType.TypeID() != schema.TypeIDFloat {
 			return nil,
-				fmt.Errorf("attempted negation operation on non-numeric type %q",
+				newCodedError(ErrorCodeTypeMismatch, "attempted negation operation on non-numeric type %q",
 					innerResult.resolvedType.TypeID())
 		}
 	case ast.Not:
 		// 'not' expects a boolean input
 		if innerResult.resolvedType.TypeID() != schema.TypeIDBool {
 			return nil,
-				fmt.Errorf("attempted 'not' operation on non-boolean type %q",
+				newCodedError(ErrorCodeTypeMismatch, "attempted 'not' operation on non-boolean type %q",
 					innerResult.resolvedType.TypeID())
 		}
 	default:
-		return nil, fmt.Errorf("unsupported unary operation: %q", node.LeftOperation)
+		return nil, newCodedError(ErrorCodeInternal, "unsupported unary operation: %q", node.LeftOperation)
 	}
 	// Negation and 'not' do not change the type or dependencies.
 	return innerResult, nil
@@ -147,7 +223,10 @@ func (c *dependencyContext) binaryOperationDependencies(
 		if err != nil {
 			return nil, err
 		}
-		resultType = cleanType(leftResult.resolvedType.TypeID())
+		resultType, err = cleanType(leftResult.resolvedType.TypeID())
+		if err != nil {
+			return nil, err
+		}
 	case ast.Subtract, ast.Multiply, ast.Divide, ast.Modulus, ast.Power:
 		// Math. Same as type going in. Plus validate that it's numeric.
 		err = validateValidBinaryOpTypes(
@@ -159,7 +238,10 @@ func (c *dependencyContext) binaryOperationDependencies(
 		if err != nil {
 			return nil, err
 		}
-		resultType = cleanType(leftResult.resolvedType.TypeID())
+		resultType, err = cleanType(leftResult.resolvedType.TypeID())
+		if err != nil {
+			return nil, err
+		}
 	case ast.And, ast.Or:
 		// Boolean operations. Bool in and out.
 		err = validateValidBinaryOpTypes(
@@ -197,9 +279,9 @@ func (c *dependencyContext) binaryOperationDependencies(
 		}
 		resultType = schema.NewBoolSchema()
 	case ast.Invalid:
-		panic(fmt.Errorf("attempted to perform invalid operation (binary operation type invalid)"))
+		return nil, newCodedError(ErrorCodeInternal, "attempted to perform invalid operation (binary operation type invalid)")
 	default:
-		panic(fmt.Errorf("bug: binary operation %s missing from dependency evaluation code", node.Operation))
+		return nil, newCodedError(ErrorCodeInternal, "bug: binary operation %s missing from dependency evaluation code", node.Operation)
 	}
 	// Combine the left and right dependencies.
 	finalDependencies := append(leftResult.completedPaths, rightResult.completedPaths...)
@@ -212,16 +294,16 @@ func (c *dependencyContext) binaryOperationDependencies(
 
 // Returns a version of ths schema without limiting details.
 // Used for when an expression is modifying the type, invalidating the restrictions.
-func cleanType(inputType schema.TypeID) schema.Type {
+func cleanType(inputType schema.TypeID) (schema.Type, error) {
 	switch inputType {
 	case schema.TypeIDInt:
-		return schema.NewIntSchema(nil, nil, nil)
+		return schema.NewIntSchema(nil, nil, nil), nil
 	case schema.TypeIDFloat:
-		return schema.NewFloatSchema(nil, nil, nil)
+		return schema.NewFloatSchema(nil, nil, nil), nil
 	case schema.TypeIDString:
-		return schema.NewStringSchema(nil, nil, nil)
+		return schema.NewStringSchema(nil, nil, nil), nil
 	default:
-		panic(fmt.Errorf("bug: case missing from cleanType: %s", inputType))
+		return nil, newCodedError(ErrorCodeInternal, "bug: case missing from cleanType: %s", inputType)
 	}
 }
 
@@ -234,17 +316,17 @@ func validateValidBinaryOpTypes(
 	// First validate left and right types are within the expected types.
 	leftIsValid := slices.Contains(expectedTypes, leftType)
 	if !leftIsValid {
-		return fmt.Errorf("invalid type %q from left expression %q for binary operation %q; expected one of %q",
+		return newCodedError(ErrorCodeTypeMismatch, "invalid type %q from left expression %q for binary operation %q; expected one of %q",
 			leftType, node.LeftNode.String(), node.Operation, expectedTypes)
 	}
 	rightIsValid := slices.Contains(expectedTypes, rightType)
 	if !rightIsValid {
-		return fmt.Errorf("invalid type %q from right expression %q for binary operation %q; expected one of %q",
+		return newCodedError(ErrorCodeTypeMismatch, "invalid type %q from right expression %q for binary operation %q; expected one of %q",
 			rightType, node.RightNode.String(), node.Operation, expectedTypes)
 	}
 	// Next, validate that left and right types match
 	if leftType != rightType {
-		return fmt.Errorf("left (%s) and right (%s) types do not match for binary expression %q", leftType, rightType, node.String())
+		return newCodedError(ErrorCodeTypeMismatch, "left (%s) and right (%s) types do not match for binary expression %q", leftType, rightType, node.String())
 	}
 	return nil
 }
@@ -253,45 +335,71 @@ func (c *dependencyContext) functionDependencies(node *ast.FunctionCall) (*depen
 	// Get the types and dependencies of all parameters.
 	functionSchema, found := c.functions[node.FuncIdentifier.IdentifierName]
 	if !found {
-		return nil, fmt.Errorf("could not find function '%s'", node.FuncIdentifier.IdentifierName)
-	}
-	paramTypes := functionSchema.Parameters()
-	// Validate param count
-	if node.ArgumentInputs.NumChildren() != len(paramTypes) {
-		return nil, fmt.Errorf("invalid call to function '%s'. Expected %d args, got %d args. Function schema: %s",
-			functionSchema.ID(), len(paramTypes), node.ArgumentInputs.NumChildren(), functionSchema.String())
+		return nil, newCodedError(ErrorCodeUnknownFunction, "could not find function '%s'", node.FuncIdentifier.IdentifierName)
 	}
 	// Types need to be saved to validate argument types with parameter types, which are also needed to get the output type.
 	// Dependencies need to also be added to the PathTree
-	dependencies := make([]*PathTree, 0)
+	var dependencies []*PathTree
 	// Save arg types for passing into output function
-	argTypes := make([]schema.Type, 0)
+	argTypes := make([]schema.Type, 0, len(node.ArgumentInputs.Arguments))
 	for i := 0; i < len(node.ArgumentInputs.Arguments); i++ {
 		arg := node.ArgumentInputs.Arguments[i]
 		argResult, err := c.rootDependencies(arg)
 		if err != nil {
 			return nil, err
 		}
-		// Validate type compatibility with function's schema
-		paramType := paramTypes[i]
-		if err := paramType.ValidateCompatibility(argResult.resolvedType); err != nil {
-			return nil, fmt.Errorf("error while validating arg/param type compatibility for function '%s' at 0-index %d (%w). Function schema: %s",
-				functionSchema.ID(), i, err, functionSchema.String())
-		}
 		argTypes = append(argTypes, argResult.resolvedType)
 		// Add dependency to the path tree
 		dependencies = append(dependencies, argResult.completedPaths...)
 	}
+	// Overloads are resolved by the argument types, and the resolved overload is type checked like any other function.
+	if overloaded, isOverloaded := functionSchema.(*OverloadedFunction); isOverloaded {
+		overload, err := overloaded.Resolve(argTypes)
+		if err != nil {
+			return nil, err
+		}
+		functionSchema = overload
+	}
+	paramTypes := functionSchema.Parameters()
+	// Validate param count
+	if len(argTypes) != len(paramTypes) {
+		return nil, newCodedError(ErrorCodeInvalidArguments, "invalid call to function '%s'. Expected %d args, got %d args. Function schema: %s",
+			functionSchema.ID(), len(paramTypes), len(argTypes), functionSchema.String())
+	}
+	// Validate type compatibility with function's schema, reporting all incompatible arguments at once.
+	if err := validateArgumentTypes(functionSchema, argTypes); err != nil {
+		return nil, err
+	}
+	if validator, isValidator := functionSchema.(LiteralArgumentValidator); isValidator {
+		if err := validator.ValidateLiteralArguments(literalArguments(node.ArgumentInputs.Arguments), argTypes); err != nil {
+			return nil, newCodedError(ErrorCodeInvalidArguments, "invalid literal argument for function '%s' (%w)", functionSchema.ID(), err)
+		}
+	}
 	// Now get the type from the function output
-	outputType, _, err := functionSchema.Output(argTypes)
+	var outputType schema.Type
+	var err error
+	if typer, isTyper := functionSchema.(LiteralArgumentTyper); isTyper {
+		outputType, err = typer.OutputForLiteralArguments(literalArguments(node.ArgumentInputs.Arguments), argTypes)
+	} else {
+		outputType, _, err = functionSchema.Output(argTypes)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error while getting return type (%w)", err)
+		return nil, newCodedError(ErrorCodeInvalidArguments, "error while getting return type (%w)", err)
 	}
 	// Create the chainable path and root dependency node for the function
 	functionRootPath := &PathTree{
-		PathItem: node.FuncIdentifier.IdentifierName,
-		NodeType: FunctionNode,
-		Subtrees: nil,
+		PathItem:     node.FuncIdentifier.IdentifierName,
+		NodeType:     FunctionNode,
+		Subtrees:     nil,
+		ResolvedType: outputType,
+	}
+	if isPure(functionSchema) && len(dependencies) == 0 {
+		// A call to a pure function whose arguments do not depend on any data is constant, so neither the call nor
+		// its fields are dependencies.
+		return &dependencyResult{
+			resolvedType:  outputType,
+			chainablePath: functionRootPath,
+		}, nil
 	}
 	return &dependencyResult{
 		resolvedType:   outputType,
@@ -301,6 +409,36 @@ func (c *dependencyContext) functionDependencies(node *ast.FunctionCall) (*depen
 	}, nil
 }
 
+// LiteralArgumentValidator can be implemented by functions to validate the arguments that are passed as literals
+// when the expression is type checked, so invalid literals fail Type, Dependencies, and Validate instead of failing
+// at runtime.
+type LiteralArgumentValidator interface {
+	// ValidateLiteralArguments receives the values of the arguments that are literals, indexed by the position of the
+	// argument, and the resolved types of all arguments. Arguments that are not literals are not included in the
+	// literals.
+	ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error
+}
+
+// LiteralArgumentTyper can be implemented by functions whose output type depends on the values of the arguments
+// that are passed as literals, such as a field name. If implemented, it is used instead of the Output function of
+// the function schema.
+type LiteralArgumentTyper interface {
+	// OutputForLiteralArguments receives the values of the arguments that are literals, indexed by the position of
+	// the argument, and the resolved types of all arguments, and returns the output type of the function.
+	OutputForLiteralArguments(literals map[int]any, argumentTypes []schema.Type) (schema.Type, error)
+}
+
+// literalArguments returns the values of the arguments that are literals, indexed by their position.
+func literalArguments(arguments []ast.Node) map[int]any {
+	literals := map[int]any{}
+	for i, argument := range arguments {
+		if literal, isLiteral := argument.(ast.ValueLiteral); isLiteral {
+			literals[i] = literal.Value()
+		}
+	}
+	return literals
+}
+
 // dotNotationDependencies resolves dependencies of a DotNotation node.
 //
 // The dot notation is when item.item is encountered. We simply traverse the AST in order, left to right,
@@ -319,7 +457,7 @@ func (c *dependencyContext) dotNotationDependencies(
 		return nil, err
 	}
 	// Right dependencies, using left type.
-	rightResult, err := c.dependencies(node.RightAccessIdentifier, leftResult.resolvedType, leftResult.chainablePath)
+	rightResult, err := c.fieldNameDependencies(node.RightAccessIdentifier, leftResult.resolvedType, leftResult.chainablePath)
 	if err != nil {
 		return nil, err
 	}
@@ -351,14 +489,29 @@ func (c *dependencyContext) bracketAccessorDependencies(
 		return nil, err
 	}
 	mergedDependencies := append(leftResult.completedPaths, keyResult.completedPaths...)
-	var overallResult *dependencyResult
+	overallResult, err := c.bracketKeyDependencies(leftResult, keyResult.resolvedType, currentType)
+	if err != nil {
+		return nil, err
+	}
+	// For literals, add key data.
+	overallResult.chainablePath = c.addKeyNode(node.RightExpression, overallResult.chainablePath, overallResult.resolvedType)
+	overallResult.addCompletedDependencies(mergedDependencies)
+	return overallResult, nil
+}
+
+// bracketKeyDependencies resolves the type of accessing the result on the left with a key of the specified type.
+func (c *dependencyContext) bracketKeyDependencies(
+	leftResult *dependencyResult,
+	keyType schema.Type,
+	currentType schema.Type,
+) (*dependencyResult, error) {
 	switch leftResult.resolvedType.TypeID() {
 	case schema.TypeIDMap:
-		overallResult, err = c.bracketMapDependencies(leftResult, keyResult.resolvedType)
+		return c.bracketMapDependencies(leftResult, keyType)
 	case schema.TypeIDList:
-		overallResult, err = c.bracketListDependencies(leftResult, keyResult.resolvedType)
+		return c.bracketListDependencies(leftResult, keyType)
 	case schema.TypeIDAny:
-		overallResult, err = &dependencyResult{
+		return &dependencyResult{
 			resolvedType:   schema.NewAnySchema(),
 			chainablePath:  leftResult.chainablePath,
 			rootPathResult: leftResult.rootPathResult,
@@ -366,22 +519,89 @@ func (c *dependencyContext) bracketAccessorDependencies(
 	case schema.TypeIDScope, schema.TypeIDObject, schema.TypeIDRef:
 		// This is supported in JavaScript, but not this expression language. This is because objects have
 		// different types for each field, meaning that the type cannot be determined at this point.
-		return nil, fmt.Errorf(
+		return nil, newCodedError(
+			ErrorCodeTypeMismatch,
 			"bracket ([]) access is not supported for object/scope/ref types; please use dot notation",
 		)
 	default:
-		return nil, fmt.Errorf(
+		return nil, newCodedError(
+			ErrorCodeTypeMismatch,
 			"bracket ([]) subexpressions are only supported on 'map', 'list', and 'any' types; %s given",
 			currentType.TypeID(),
 		)
 	}
+}
+
+// accessChainDependencies resolves the dependencies of a precomputed access chain. The result is the same as
+// resolving the nodes of the chain, but the keys are used as they are, without resolving their literals.
+func (c *dependencyContext) accessChainDependencies(
+	node ast.Node,
+	chain *ast.AccessChain,
+	currentType schema.Type,
+	path *PathTree,
+) (*dependencyResult, error) {
+	result, err := c.rootIdentifierDependencies(path)
 	if err != nil {
 		return nil, err
 	}
-	// For literals, add key data.
-	overallResult.chainablePath = c.addKeyNode(node.RightExpression, overallResult.chainablePath)
-	overallResult.addCompletedDependencies(mergedDependencies)
-	return overallResult, nil
+	// The expression of each step is only built if the steps are traced.
+	var stepExpression string
+	if c.trace != nil {
+		stepExpression = "$"
+	}
+	for i, step := range chain.Steps {
+		if c.trace != nil {
+			if i == 0 && chain.Root != "" {
+				stepExpression += chain.Root
+			} else {
+				stepExpression += accessStepString(step)
+			}
+		}
+		var stepResult *dependencyResult
+		if !step.Bracket {
+			stepResult, err = dependenciesAccessObject(result.resolvedType, step.Key.(string), result.chainablePath)
+			if err == nil {
+				// Like dot notation, the root of the chain stays the root of the result.
+				stepResult.rootPathResult = result.rootPathResult
+			}
+		} else {
+			stepResult, err = c.bracketKeyDependencies(result, literalKeyType(step.Key), currentType)
+			if err == nil {
+				stepResult.chainablePath = addKeyPathItem(step.Key, stepResult.chainablePath, stepResult.resolvedType)
+			}
+		}
+		if c.trace != nil {
+			c.traceStep(stepExpression, node.Start(), result.resolvedType, stepResult, locateError(err, c.expression, node))
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = stepResult
+	}
+	return result, nil
+}
+
+// accessStepString returns the step as it is written in the canonical form of an expression, such as `.foo` or
+// `["key"]`.
+func accessStepString(step ast.AccessStep) string {
+	if !step.Bracket {
+		return "." + step.Key.(string)
+	}
+	return "[" + formatArgumentValue(step.Key) + "]"
+}
+
+// literalKeyType returns the type of the literal value of a key.
+func literalKeyType(key any) schema.Type {
+	switch key.(type) {
+	case string:
+		return schema.NewStringSchema(nil, nil, nil)
+	case int64:
+		return schema.NewIntSchema(nil, nil, nil)
+	case float64:
+		return schema.NewFloatSchema(nil, nil, nil)
+	default:
+		return schema.NewBoolSchema()
+	}
 }
 
 // bracketMapDependencies is used to resolve dependencies when a bracket accessor has a subexpression,
@@ -395,7 +615,7 @@ func (c *dependencyContext) bracketMapDependencies(
 	// to their expressions to convert an integer to a string, for example.
 	mapType := leftResult.resolvedType.(schema.UntypedMap)
 	if keyType.TypeID() != mapType.Keys().TypeID() {
-		return nil, fmt.Errorf("subexpression evaluates to type '%s' for a map, '%s' expected", keyType.TypeID(), mapType.Keys().TypeID())
+		return nil, newCodedError(ErrorCodeTypeMismatch, "subexpression evaluates to type '%s' for a map, '%s' expected", keyType.TypeID(), mapType.Keys().TypeID())
 	}
 	return &dependencyResult{
 		resolvedType:   mapType.Values(),
@@ -413,7 +633,7 @@ func (c *dependencyContext) bracketListDependencies(
 	// Lists have integer indexes, so make sure that the subexpression is yielding an int.
 	list := leftResult.resolvedType.(schema.UntypedList)
 	if keyType.TypeID() != schema.TypeIDInt {
-		return nil, fmt.Errorf("subexpressions resulted in a %s type for a list key, integer expected", keyType.TypeID())
+		return nil, newCodedError(ErrorCodeTypeMismatch, "subexpressions resulted in a %s type for a list key, integer expected", keyType.TypeID())
 	}
 	return &dependencyResult{
 		resolvedType:   list.Items(), // The type is the type for an individual item of the list.
@@ -424,15 +644,21 @@ func (c *dependencyContext) bracketListDependencies(
 
 // If the key is literal, include the value in a key-type node.
 // This extends the chainable path.
-func (c *dependencyContext) addKeyNode(node ast.Node, path *PathTree) *PathTree {
+func (c *dependencyContext) addKeyNode(node ast.Node, path *PathTree, resolvedType schema.Type) *PathTree {
 	literalValue, isLiteral := node.(ast.ValueLiteral)
 	if !isLiteral {
 		return path
 	}
+	return addKeyPathItem(literalValue.Value(), path, resolvedType)
+}
+
+// addKeyPathItem adds a key-type node with the key to the path, and returns the new node.
+func addKeyPathItem(key any, path *PathTree, resolvedType schema.Type) *PathTree {
 	pathItem := &PathTree{
-		PathItem: literalValue.Value(),
-		NodeType: KeyNode,
-		Subtrees: nil,
+		PathItem:     key,
+		NodeType:     KeyNode,
+		Subtrees:     nil,
+		ResolvedType: resolvedType,
 	}
 	path.Subtrees = append(path.Subtrees, pathItem)
 	return pathItem
@@ -443,33 +669,83 @@ func (c *dependencyContext) identifierDependencies(
 	currentType schema.Type,
 	path *PathTree,
 ) (*dependencyResult, error) {
-	switch node.IdentifierName {
-	case "$":
-		var root *PathTree
-		// If the given node is root, use it. If nil, create it.
-		if path == nil {
-			root = &PathTree{
-				PathItem: "$",
-				NodeType: DataRootNode,
-				Subtrees: nil,
-			}
-		} else if path.NodeType == DataRootNode {
-			root = path
-		} else {
-			return nil, fmt.Errorf("root access %q of type %q not at root", path.PathItem, path.NodeType)
-		}
-		// The path is validated as the root already.
-		return &dependencyResult{
-			resolvedType:   c.rootType,
-			chainablePath:  path,
-			rootPathResult: root,
-		}, nil
+	switch {
+	case node.IdentifierName == "$":
+		return c.rootIdentifierDependencies(path)
+	case node.RootName() == ast.VariablesRootName:
+		return variableDependencies("$"+ast.VariablesRootName, c.variablesType()), nil
+	case node.IsRoot():
+		return c.namedRootDependencies(node.RootName(), path)
 	default:
+		if variableType, isVariable := c.variables[node.IdentifierName]; isVariable {
+			return variableDependencies(node.IdentifierName, variableType), nil
+		}
 		// This case is the item.item type expression, where the right item is the "identifier" in question.
 		return dependenciesAccessObject(currentType, node.IdentifierName, path)
 	}
 }
 
+// variableDependencies returns the result of a reference to a variable. Variables are not part of the root data, so
+// the accesses within the variable are added to a path that is not reported as a dependency.
+func variableDependencies(name string, variableType schema.Type) *dependencyResult {
+	return &dependencyResult{
+		resolvedType: variableType,
+		chainablePath: &PathTree{
+			PathItem:     name,
+			NodeType:     AccessNode,
+			ResolvedType: variableType,
+		},
+	}
+}
+
+// variablesType returns the type of $vars, which is an object with a property for each variable.
+func (c *dependencyContext) variablesType() schema.Type {
+	properties := make(map[string]*schema.PropertySchema, len(c.variables))
+	for name, variableType := range c.variables {
+		properties[name] = schema.NewPropertySchema(variableType, nil, true, nil, nil, nil, nil, nil)
+	}
+	return schema.NewObjectSchema(ast.VariablesRootName, properties)
+}
+
+// rootIdentifierDependencies resolves the dependencies of the root identifier ($) on the specified path.
+func (c *dependencyContext) rootIdentifierDependencies(path *PathTree) (*dependencyResult, error) {
+	var root *PathTree
+	// If the given node is root, use it. If nil, create it.
+	if path == nil {
+		root = &PathTree{
+			PathItem:     "$",
+			NodeType:     DataRootNode,
+			Subtrees:     nil,
+			ResolvedType: c.rootType,
+		}
+	} else if path.NodeType == DataRootNode {
+		root = path
+	} else {
+		return nil, newCodedError(ErrorCodeInternal, "root access %q of type %q not at root", path.PathItem, path.NodeType)
+	}
+	// The path is validated as the root already.
+	return &dependencyResult{
+		resolvedType:   c.rootType,
+		chainablePath:  path,
+		rootPathResult: root,
+	}, nil
+}
+
+// namedRootDependencies resolves the dependencies of a named root, such as $steps, which is the field of the root
+// with its name.
+func (c *dependencyContext) namedRootDependencies(rootName string, path *PathTree) (*dependencyResult, error) {
+	root, err := c.rootIdentifierDependencies(path)
+	if err != nil {
+		return nil, err
+	}
+	result, err := dependenciesAccessObject(root.resolvedType, rootName, root.chainablePath)
+	if err != nil {
+		return nil, err
+	}
+	result.rootPathResult = root.rootPathResult
+	return result, nil
+}
+
 // dependenciesAccessObject reads the object on the left to determine
 // the type of the property referenced.
 func dependenciesAccessObject(
@@ -487,13 +763,14 @@ func dependenciesAccessObject(
 			for property := range properties {
 				propertiesMsg += "\n - " + property
 			}
-			return nil, fmt.Errorf("object %s does not have a property named %q; properties:%s",
+			return nil, newCodedError(ErrorCodeUnknownField, "object %s does not have a property named %q; properties:%s",
 				currentObject.ID(), identifier, propertiesMsg)
 		}
 		pathItem := &PathTree{
-			PathItem: identifier,
-			NodeType: AccessNode,
-			Subtrees: nil,
+			PathItem:     identifier,
+			NodeType:     AccessNode,
+			Subtrees:     nil,
+			ResolvedType: property.Type(),
 		}
 		path.Subtrees = append(path.Subtrees, pathItem)
 		return &dependencyResult{
@@ -505,9 +782,10 @@ func dependenciesAccessObject(
 	case schema.TypeIDAny:
 		// Since the left type is any (a terminal type), this access (deeper than the 'any' node) is past-terminal.
 		pathItem := &PathTree{
-			PathItem: identifier,
-			NodeType: PastTerminalNode,
-			Subtrees: nil,
+			PathItem:     identifier,
+			NodeType:     PastTerminalNode,
+			Subtrees:     nil,
+			ResolvedType: schema.NewAnySchema(),
 		}
 		path.Subtrees = append(path.Subtrees, pathItem)
 		return &dependencyResult{
@@ -515,6 +793,6 @@ func dependenciesAccessObject(
 			chainablePath: pathItem,
 		}, nil
 	default:
-		return nil, fmt.Errorf("cannot evaluate expression identifier %s on data type %s", identifier, leftType.TypeID())
+		return nil, newCodedError(ErrorCodeTypeMismatch, "cannot evaluate expression identifier %s on data type %s", identifier, leftType.TypeID())
 	}
 }
@@ -1,6 +1,7 @@
 package expressions_test
 
 import (
+	"errors"
 	"testing"
 
 	"go.arcalot.io/assert"
@@ -335,6 +336,40 @@ func TestFunctionDependencyResolution_error_wrongType(t *testing.T) {
 	assert.Contains(t, err.Error(), "intIn(integer) void")
 }
 
+func TestFunctionDependencyResolution_error_allWrongTypes(t *testing.T) {
+	threeInFunc, err := schema.NewCallableFunction(
+		"threeIn",
+		[]schema.Type{
+			schema.NewIntSchema(nil, nil, nil),
+			schema.NewStringSchema(nil, nil, nil),
+			schema.NewBoolSchema(),
+		},
+		nil,
+		false,
+		nil,
+		func(a int64, b string, c bool) {},
+	)
+	assert.NoError(t, err)
+	funcMap := map[string]schema.Function{"threeIn": threeInFunc}
+
+	expr, err := expressions.New(`threeIn("wrongType", "right", 1.5)`)
+	assert.NoError(t, err)
+	_, err = expr.Dependencies(testScope, funcMap, nil, noKeyOrPastTerminalRequirements)
+	// Both incompatible arguments are reported together.
+	var argumentErr *expressions.ArgumentTypeError
+	assert.Equals(t, errors.As(err, &argumentErr), true)
+	assert.Equals(t, argumentErr.Function, "threeIn")
+	assert.Equals(t, len(argumentErr.Mismatches), 2)
+	assert.Equals(t, argumentErr.Mismatches[0].Index, 0)
+	assert.Equals(t, argumentErr.Mismatches[0].Expected.TypeID(), schema.TypeIDInt)
+	assert.Equals(t, argumentErr.Mismatches[0].Actual.TypeID(), schema.TypeIDString)
+	assert.Equals(t, argumentErr.Mismatches[1].Index, 2)
+	assert.Equals(t, argumentErr.Mismatches[1].Expected.TypeID(), schema.TypeIDBool)
+	assert.Equals(t, argumentErr.Mismatches[1].Actual.TypeID(), schema.TypeIDFloat)
+	assert.Contains(t, err.Error(), "at 0-index 0 expected integer, got string")
+	assert.Contains(t, err.Error(), "at 0-index 2 expected bool, got float")
+}
+
 func TestFunctionDependencyResolution_error_wrongArgCount(t *testing.T) {
 	intInFunc, err := schema.NewCallableFunction(
 		"intIn",
@@ -577,3 +612,161 @@ func TestDependencyResolution_Error_InvalidListIndexType(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "integer expected")
 }
+
+func TestTypedDependencyResolution(t *testing.T) {
+	expr, err := expressions.New(`$.foo.bar + $.simple_str`)
+	assert.NoError(t, err)
+	typedPaths, err := expr.TypedDependencies(testScope, nil, nil, noKeyOrPastTerminalRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 2)
+	for _, typedPath := range typedPaths {
+		assert.NotNil(t, typedPath.Type)
+		assert.Equals(t, typedPath.Type.TypeID(), schema.TypeIDString)
+	}
+	assert.SliceContainsExtractor(t, typedPathStrExtractor, "$.foo.bar", typedPaths)
+	assert.SliceContainsExtractor(t, typedPathStrExtractor, "$.simple_str", typedPaths)
+}
+
+func TestTypedDependencyResolution_Keys(t *testing.T) {
+	expr, err := expressions.New(`$.int_list[0]`)
+	assert.NoError(t, err)
+	// With keys, the leaf is the list item.
+	typedPaths, err := expr.TypedDependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].String(), "$.int_list.0")
+	assert.Equals(t, typedPaths[0].Type.TypeID(), schema.TypeIDInt)
+	// Without keys, the leaf is the list itself.
+	typedPaths, err = expr.TypedDependencies(testScope, nil, nil, noKeyOrPastTerminalRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].String(), "$.int_list")
+	assert.Equals(t, typedPaths[0].Type.TypeID(), schema.TypeIDList)
+}
+
+func TestTypedDependencyResolution_PastTerminal(t *testing.T) {
+	expr, err := expressions.New(`$.simple_any.a`)
+	assert.NoError(t, err)
+	typedPaths, err := expr.TypedDependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].String(), "$.simple_any.a")
+	assert.Equals(t, typedPaths[0].Type.TypeID(), schema.TypeIDAny)
+}
+
+func TestTypedDependencyResolution_Function(t *testing.T) {
+	assert.NoError(t, intToFloatFuncErr)
+	funcMap := map[string]schema.Function{"intToFloat": intToFloatFunc}
+	expr, err := expressions.New(`intToFloat($.simple_int)`)
+	assert.NoError(t, err)
+	typedPaths, err := expr.TypedDependencies(testScope, funcMap, nil, withFunctionsRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 2)
+	for _, typedPath := range typedPaths {
+		switch typedPath.String() {
+		case "intToFloat":
+			assert.Equals(t, typedPath.Type.TypeID(), schema.TypeIDFloat)
+		case "$.simple_int":
+			assert.Equals(t, typedPath.Type.TypeID(), schema.TypeIDInt)
+		default:
+			t.Fatalf("unexpected path %s", typedPath.String())
+		}
+	}
+}
+
+func TestTypedDependencyResolution_Optional(t *testing.T) {
+	scope := newDefaultsScope(`"hello"`)
+	expr, err := expressions.NewWithOptions(
+		`$.with_default + $.without_default`, expressions.Options{SchemaDefaults: true},
+	)
+	assert.NoError(t, err)
+	typedPaths, err := expr.TypedDependencies(scope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 2)
+	assert.Equals(t, typedPaths[0].String(), "$.with_default")
+	assert.Equals(t, typedPaths[0].Optional, true)
+	assert.Equals(t, typedPaths[1].String(), "$.without_default")
+	assert.Equals(t, typedPaths[1].Optional, false)
+
+	// Without the option, the default is not used, so all paths are required.
+	expr, err = expressions.New(`$.with_default`)
+	assert.NoError(t, err)
+	typedPaths, err = expr.TypedDependencies(scope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].Optional, false)
+}
+
+func TestDependencies_SchemaDefaults(t *testing.T) {
+	scope := newDefaultsScope(`"hello"`)
+	expr, err := expressions.NewWithOptions(
+		`$.with_default + $.without_default`, expressions.Options{SchemaDefaults: true},
+	)
+	assert.NoError(t, err)
+	typedPaths, err := expr.TypedDependencies(scope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 2)
+	assert.Equals(t, typedPaths[0].Optional, true)
+
+	// Both APIs that return plain paths unpack the same paths as TypedDependencies with the option.
+	paths, err := expr.Dependencies(scope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	variablePaths, err := expr.DependenciesWithVariables(scope, nil, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(paths), len(typedPaths))
+	assert.Equals(t, len(variablePaths), len(typedPaths))
+	for i, typedPath := range typedPaths {
+		assert.Equals(t, paths[i].String(), typedPath.String())
+		assert.Equals(t, variablePaths[i].String(), typedPath.String())
+	}
+}
+
+func BenchmarkDependencies(b *testing.B) {
+	intInOutFunc, err := schema.NewCallableFunction(
+		"intInOut",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a int64) int64 { return a },
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	funcMap := map[string]schema.Function{"intInOut": intInOutFunc}
+	expr, err := expressions.New(`intInOut($.simple_int) + $.int_list[1] > $.simple_int_2 && $.simple_str == "abc"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expr.Dependencies(testScope, funcMap, nil, withFunctionsRequirements); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestDependencyResolution_AccessChainSameAsSubexpression(t *testing.T) {
+	// Literal keys make the access a precomputed chain, while subexpression keys are resolved node by node. Without
+	// keys, both must result in the same paths and types.
+	testCases := map[string]string{
+		`$.int_list[1]`:       `$.int_list[0 + 1]`,
+		`$.simple_any["a"].b`: `$.simple_any["a" + ""].b`,
+		`$.int_list[-1] + 1`:  `$.int_list[0 - 1] + 1`,
+	}
+	for chain, subexpression := range testCases {
+		t.Run(chain, func(t *testing.T) {
+			chainExpr, err := expressions.New(chain)
+			assert.NoError(t, err)
+			subexpressionExpr, err := expressions.New(subexpression)
+			assert.NoError(t, err)
+			chainPaths, err := chainExpr.TypedDependencies(testScope, nil, nil, noKeyOrPastTerminalRequirements)
+			assert.NoError(t, err)
+			subexpressionPaths, err := subexpressionExpr.TypedDependencies(testScope, nil, nil, noKeyOrPastTerminalRequirements)
+			assert.NoError(t, err)
+			assert.Equals(t, chainPaths[0].String(), subexpressionPaths[0].String())
+			assert.Equals(t, chainPaths[0].Type.TypeID(), subexpressionPaths[0].Type.TypeID())
+		})
+	}
+}
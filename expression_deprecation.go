@@ -0,0 +1,157 @@
+package expressions
+
+import (
+	"fmt"
+	"sort"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Deprecation describes why a function or a language feature is deprecated, and what to use instead. Deprecated
+// functions and features keep working, but each use is reported as a warning, so workflows can be migrated before
+// they are removed.
+type Deprecation struct {
+	// Reason explains why it is deprecated, such as "it does not handle Unicode". Optional.
+	Reason string
+	// Replacement is what to use instead, such as "toUpper()". Optional.
+	Replacement string
+}
+
+// warning returns the diagnostic with the message for a use of the deprecated subject, whose format is such as
+// `the function %q`.
+func (d Deprecation) warning(diagnostic Diagnostic, subject string, subjectArgs ...any) Diagnostic {
+	format := subject + " is deprecated"
+	args := append([]any{}, subjectArgs...)
+	if d.Reason != "" {
+		format += ": %s"
+		args = append(args, d.Reason)
+	}
+	if d.Replacement != "" {
+		format += "; use %s instead"
+		args = append(args, d.Replacement)
+	}
+	return diagnostic.withMessage(format, args...)
+}
+
+// DeprecatedFunction can be implemented by functions to declare that they are deprecated. Calls of deprecated
+// functions are reported as DiagnosticDeprecatedFunction warnings by Validate, and by NewWithOptions if the function
+// is passed in Options.Functions.
+type DeprecatedFunction interface {
+	// Deprecation returns the deprecation of the function, or nil if it is not deprecated.
+	Deprecation() *Deprecation
+}
+
+// NewDeprecatedFunction declares the function as deprecated. The returned function keeps implementing
+// LiteralArgumentValidator, LiteralArgumentTyper, PureFunction, and CallPolicyFunction if the passed function does. To
+// deprecate an overloaded function, deprecate each overload before creating the OverloadedFunction.
+func NewDeprecatedFunction(function schema.CallableFunction, deprecation Deprecation) schema.CallableFunction {
+	return deprecatedFunction{CallableFunction: function, deprecation: deprecation}
+}
+
+// deprecatedFunction is a function that was declared as deprecated with NewDeprecatedFunction.
+type deprecatedFunction struct {
+	schema.CallableFunction
+	deprecation Deprecation
+}
+
+func (f deprecatedFunction) Deprecation() *Deprecation {
+	deprecation := f.deprecation
+	return &deprecation
+}
+
+// Pure returns true if the wrapped function is pure.
+func (f deprecatedFunction) Pure() bool {
+	return isPure(f.CallableFunction)
+}
+
+// CallPolicy returns the call policy of the wrapped function, or the zero policy, which calls the function once
+// without a timeout, if it has none.
+func (f deprecatedFunction) CallPolicy() CallPolicy {
+	if policyFunction, hasPolicy := f.CallableFunction.(CallPolicyFunction); hasPolicy {
+		return policyFunction.CallPolicy()
+	}
+	return CallPolicy{}
+}
+
+// ValidateLiteralArguments validates the literal arguments with the wrapped function, if it implements
+// LiteralArgumentValidator.
+func (f deprecatedFunction) ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error {
+	return pureFunction{f.CallableFunction}.ValidateLiteralArguments(literals, argumentTypes)
+}
+
+// OutputForLiteralArguments returns the output type from the wrapped function, using the literal arguments if it
+// implements LiteralArgumentTyper.
+func (f deprecatedFunction) OutputForLiteralArguments(
+	literals map[int]any,
+	argumentTypes []schema.Type,
+) (schema.Type, error) {
+	return pureFunction{f.CallableFunction}.OutputForLiteralArguments(literals, argumentTypes)
+}
+
+// functionDeprecation returns the deprecation of the function, or nil if it does not declare itself as deprecated.
+func functionDeprecation(function schema.Function) *Deprecation {
+	if deprecated, declaresDeprecation := function.(DeprecatedFunction); declaresDeprecation {
+		return deprecated.Deprecation()
+	}
+	return nil
+}
+
+// deprecatedFeatureWarnings returns a warning for each node in the tree that uses a deprecated feature.
+func deprecatedFeatureWarnings(root ast.Node, deprecated map[Feature]Deprecation) []Diagnostic {
+	if len(deprecated) == 0 {
+		return nil
+	}
+	var result []Diagnostic
+	ast.Inspect(root, func(node ast.Node) bool {
+		deprecation, isDeprecated := deprecated[featureUsedBy(node)]
+		if !isDeprecated {
+			return true
+		}
+		result = append(result, deprecation.warning(Diagnostic{
+			Severity: SeverityWarning,
+			Kind:     DiagnosticDeprecatedSyntax,
+			Start:    node.Start(),
+			End:      node.End(),
+		}, "%q uses %s, which", node.String(), featureUsedBy(node)))
+		return true
+	})
+	return result
+}
+
+// deprecatedFunctionWarnings returns a warning for each call in the tree of a function that is deprecated.
+func deprecatedFunctionWarnings(root ast.Node, lookup func(name string) (schema.Function, bool)) []Diagnostic {
+	var result []Diagnostic
+	ast.Inspect(root, func(node ast.Node) bool {
+		call, isCall := node.(*ast.FunctionCall)
+		if !isCall {
+			return true
+		}
+		name := call.FuncIdentifier.IdentifierName
+		function, found := lookup(name)
+		if !found {
+			return true
+		}
+		if deprecation := functionDeprecation(function); deprecation != nil {
+			result = append(result, deprecation.warning(Diagnostic{
+				Severity: SeverityWarning,
+				Kind:     DiagnosticDeprecatedFunction,
+				Start:    call.FuncIdentifier.Start(),
+				End:      call.FuncIdentifier.End(),
+			}, "the function %q", name))
+		}
+		return true
+	})
+	return result
+}
+
+// deprecatedFeaturesKey returns the deprecated features in a stable order, to compare the options in the parsed
+// expression cache key.
+func deprecatedFeaturesKey(deprecated map[Feature]Deprecation) string {
+	entries := make([]string, 0, len(deprecated))
+	for feature, deprecation := range deprecated {
+		entries = append(entries, fmt.Sprintf("%s=%q/%q", feature, deprecation.Reason, deprecation.Replacement))
+	}
+	sort.Strings(entries)
+	return fmt.Sprint(entries)
+}
@@ -0,0 +1,118 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestDeprecatedFeatures(t *testing.T) {
+	var warnings []expressions.Diagnostic
+	expr, err := expressions.NewWithOptions(`$.foo + 5m`, expressions.Options{
+		DeprecatedFeatures: map[expressions.Feature]expressions.Deprecation{
+			expressions.FeatureUnitLiterals: {Replacement: "seconds"},
+		},
+		OnWarning: func(warning expressions.Diagnostic) {
+			warnings = append(warnings, warning)
+		},
+	})
+	assert.NoError(t, err)
+	var deprecations []expressions.Diagnostic
+	for _, warning := range warnings {
+		if warning.Kind == expressions.DiagnosticDeprecatedSyntax {
+			deprecations = append(deprecations, warning)
+		}
+	}
+	assert.Equals(t, len(deprecations), 1)
+	assert.Equals(t, deprecations[0].Severity, expressions.SeverityWarning)
+	assert.Equals(t, deprecations[0].Message, `"5m" uses unit-literals, which is deprecated; use seconds instead`)
+	assert.Equals(t, deprecations[0].Start.Column, 9)
+
+	report := expr.Validate(testScope, nil, nil)
+	assert.SliceContains(t, deprecations[0], report.Warnings)
+}
+
+func TestDeprecatedFunction(t *testing.T) {
+	upper, err := schema.NewCallableFunction(
+		"upper",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(value string) string {
+			return value + "!"
+		},
+	)
+	assert.NoError(t, err)
+	deprecated := expressions.NewDeprecatedFunction(
+		expressions.NewPureFunction(upper),
+		expressions.Deprecation{Reason: "it does not handle Unicode", Replacement: "toUpper()"},
+	)
+	functions := map[string]schema.Function{"upper": deprecated}
+
+	expr, err := expressions.New(`upper($.simple_str)`)
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, functions, nil)
+	assert.Equals(t, report.HasErrors(), false)
+	assert.Equals(t, len(report.Warnings), 1)
+	assert.Equals(t, report.Warnings[0].Kind, expressions.DiagnosticDeprecatedFunction)
+	assert.Equals(t, report.Warnings[0].Message,
+		`the function "upper" is deprecated: it does not handle Unicode; use toUpper() instead`)
+	assert.Equals(t, report.Warnings[0].Start.Column, 1)
+
+	// Calls found when parsing are reported once, even if they are folded.
+	var warnings []expressions.Diagnostic
+	expr, err = expressions.NewWithOptions(`upper("a")`, expressions.Options{
+		Functions: map[string]schema.CallableFunction{"upper": deprecated},
+		OnWarning: func(warning expressions.Diagnostic) {
+			warnings = append(warnings, warning)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, len(warnings), 1)
+	assert.Equals(t, len(expr.Validate(testScope, functions, nil).Warnings), 1)
+	result, err := expr.Evaluate(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("a!"))
+}
+
+func TestDeprecatedFunction_Overloaded(t *testing.T) {
+	intOverload, err := schema.NewCallableFunction(
+		"old",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(value int64) int64 {
+			return value
+		},
+	)
+	assert.NoError(t, err)
+	stringOverload, err := schema.NewCallableFunction(
+		"old",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(value string) string {
+			return value
+		},
+	)
+	assert.NoError(t, err)
+	overloads := []schema.CallableFunction{intOverload, stringOverload}
+	partially, err := expressions.NewOverloadedFunction(
+		expressions.NewDeprecatedFunction(overloads[0], expressions.Deprecation{}),
+		overloads[1],
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, partially.Deprecation() == nil, true)
+
+	fully, err := expressions.NewOverloadedFunction(
+		expressions.NewDeprecatedFunction(overloads[0], expressions.Deprecation{Replacement: "new()"}),
+		expressions.NewDeprecatedFunction(overloads[1], expressions.Deprecation{}),
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, *fully.Deprecation(), expressions.Deprecation{Replacement: "new()"})
+}
@@ -0,0 +1,257 @@
+package expressions
+
+import (
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// ChangeKind identifies the kind of a change found by Diff.
+type ChangeKind string
+
+const (
+	// ChangeLiteral reports a literal that has a different value, such as 5 changed to 10.
+	ChangeLiteral ChangeKind = "changed-literal"
+	// ChangeOperator reports an operation that has a different operator, such as + changed to -.
+	ChangeOperator ChangeKind = "changed-operator"
+	// ChangeReplaced reports a part of the expression that was replaced by code of a different form, such as a
+	// literal replaced by an operation, or a call with a different number of arguments.
+	ChangeReplaced ChangeKind = "replaced"
+	// ChangeAddedAccess reports a path of the data that only the new expression accesses.
+	ChangeAddedAccess ChangeKind = "added-access"
+	// ChangeRemovedAccess reports a path of the data that only the old expression accesses.
+	ChangeRemovedAccess ChangeKind = "removed-access"
+	// ChangeAddedCall reports a call of a function that only the new expression has, or that it has more often.
+	ChangeAddedCall ChangeKind = "added-call"
+	// ChangeRemovedCall reports a call of a function that only the old expression has, or that it has more often.
+	ChangeRemovedCall ChangeKind = "removed-call"
+)
+
+// Change is a single difference between two expressions found by Diff.
+type Change struct {
+	Kind ChangeKind
+	// Old is the changed code in the old expression in its canonical form. It is empty for added accesses and calls.
+	Old string
+	// New is the changed code in the new expression in its canonical form. It is empty for removed accesses and calls.
+	New string
+	// Path is the path of added and removed accesses.
+	Path Path
+	// OldStart is the position of the changed code in the old expression. It is not valid for added accesses and
+	// calls.
+	OldStart ast.Position
+	// NewStart is the position of the changed code in the new expression. It is not valid for removed accesses and
+	// calls.
+	NewStart ast.Position
+}
+
+// String returns a short description of the change, such as `changed-literal: 5 -> 10`.
+func (c Change) String() string {
+	switch {
+	case c.Old == "":
+		return string(c.Kind) + ": " + c.New
+	case c.New == "":
+		return string(c.Kind) + ": " + c.Old
+	default:
+		return string(c.Kind) + ": " + c.Old + " -> " + c.New
+	}
+}
+
+// Diff returns the structural differences between the old and the new expression, so reviews of workflows can show
+// what changed in their meaning instead of in their text. Formatting, parentheses, and the quote style of strings are
+// ignored, like Equivalent ignores them.
+//
+// The parts of the expressions are compared where both have the same form, which reports changed literals and
+// operators, and parts of a different form as replaced. Changes of the accessed paths and of the called functions are
+// reported for the whole expressions, so moving an access or a call does not report it. The changes are ordered by
+// their position in the expressions, with the changed and replaced parts first, followed by the removed and added
+// accesses, and the removed and added calls. Two equivalent expressions have no changes.
+func Diff(oldExpression Expression, newExpression Expression) []Change {
+	var changes []Change
+	diffNodes(&changes, oldExpression.AST(), newExpression.AST())
+	changes = append(changes, diffAccesses(oldExpression.AST(), newExpression.AST())...)
+	changes = append(changes, diffCalls(oldExpression.AST(), newExpression.AST())...)
+	return changes
+}
+
+// diffNodes adds the changes between the nodes, which are at the same place in the old and the new expression.
+func diffNodes(changes *[]Change, oldNode ast.Node, newNode ast.Node) {
+	oldCanonical := canonicalNode(oldNode)
+	newCanonical := canonicalNode(newNode)
+	if oldCanonical == newCanonical {
+		return
+	}
+	change := Change{Old: oldCanonical, New: newCanonical, OldStart: oldNode.Start(), NewStart: newNode.Start()}
+	_, oldIsLiteral := oldNode.(ast.ValueLiteral)
+	_, newIsLiteral := newNode.(ast.ValueLiteral)
+	if oldIsLiteral && newIsLiteral {
+		change.Kind = ChangeLiteral
+		*changes = append(*changes, change)
+		return
+	}
+	switch oldN := oldNode.(type) {
+	case *ast.BinaryOperation:
+		if newN, isBinary := newNode.(*ast.BinaryOperation); isBinary {
+			if oldN.Operation != newN.Operation {
+				change.Kind = ChangeOperator
+				change.Old = canonicalOperator(oldN.Operation)
+				change.New = canonicalOperator(newN.Operation)
+				*changes = append(*changes, change)
+			}
+			diffNodes(changes, oldN.LeftNode, newN.LeftNode)
+			diffNodes(changes, oldN.RightNode, newN.RightNode)
+			return
+		}
+	case *ast.UnaryOperation:
+		if newN, isUnary := newNode.(*ast.UnaryOperation); isUnary {
+			if oldN.LeftOperation != newN.LeftOperation {
+				change.Kind = ChangeOperator
+				change.Old = canonicalOperator(oldN.LeftOperation)
+				change.New = canonicalOperator(newN.LeftOperation)
+				*changes = append(*changes, change)
+			}
+			diffNodes(changes, oldN.RightNode, newN.RightNode)
+			return
+		}
+	case *ast.FunctionCall:
+		newN, isCall := newNode.(*ast.FunctionCall)
+		if isCall && oldN.FuncIdentifier.IdentifierName != newN.FuncIdentifier.IdentifierName {
+			// Calls of other functions are reported as removed and added calls.
+			return
+		}
+		if isCall && len(oldN.ArgumentInputs.Arguments) == len(newN.ArgumentInputs.Arguments) {
+			for i, argument := range oldN.ArgumentInputs.Arguments {
+				diffNodes(changes, argument, newN.ArgumentInputs.Arguments[i])
+			}
+			return
+		}
+	case *ast.BracketAccessor:
+		if newN, isBracket := newNode.(*ast.BracketAccessor); isBracket {
+			diffNodes(changes, oldN.LeftNode, newN.LeftNode)
+			diffNodes(changes, oldN.RightExpression, newN.RightExpression)
+			return
+		}
+		if isAccess(newNode) {
+			return
+		}
+	case *ast.DotNotation:
+		newN, isDot := newNode.(*ast.DotNotation)
+		if isDot && oldN.RightAccessIdentifier.String() == newN.RightAccessIdentifier.String() {
+			diffNodes(changes, oldN.LeftAccessibleNode, newN.LeftAccessibleNode)
+			return
+		}
+		if isAccess(newNode) {
+			// Accesses of other fields are reported as removed and added accesses.
+			return
+		}
+	case *ast.Identifier:
+		if isAccess(newNode) {
+			return
+		}
+	}
+	change.Kind = ChangeReplaced
+	*changes = append(*changes, change)
+}
+
+// isAccess returns true if the node accesses a field, a map key, or a list item, or is an identifier.
+func isAccess(node ast.Node) bool {
+	switch node.(type) {
+	case *ast.Identifier, *ast.DotNotation, *ast.BracketAccessor:
+		return true
+	default:
+		return false
+	}
+}
+
+// diffAccesses returns the paths of the data that only one of the expressions accesses.
+func diffAccesses(oldRoot ast.Node, newRoot ast.Node) []Change {
+	oldReferences := findReferences(oldRoot)
+	newReferences := findReferences(newRoot)
+	var changes []Change
+	for _, ref := range uniqueReferences(oldReferences, newReferences) {
+		changes = append(changes, Change{
+			Kind:     ChangeRemovedAccess,
+			Old:      ref.path().String(),
+			Path:     ref.path(),
+			OldStart: ref.node.Start(),
+		})
+	}
+	for _, ref := range uniqueReferences(newReferences, oldReferences) {
+		changes = append(changes, Change{
+			Kind:     ChangeAddedAccess,
+			New:      ref.path().String(),
+			Path:     ref.path(),
+			NewStart: ref.node.Start(),
+		})
+	}
+	return changes
+}
+
+// uniqueReferences returns the first reference to each path of the references that the other references do not
+// have.
+func uniqueReferences(references []reference, others []reference) []reference {
+	seen := map[string]bool{}
+	for _, other := range others {
+		seen[other.path().String()] = true
+	}
+	var result []reference
+	for _, ref := range references {
+		key := ref.path().String()
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+// diffCalls returns the calls of the functions that one of the expressions calls more often than the other. For
+// each function, the calls after the ones both expressions have are reported.
+func diffCalls(oldRoot ast.Node, newRoot ast.Node) []Change {
+	oldCalls := findCalls(oldRoot)
+	newCalls := findCalls(newRoot)
+	var changes []Change
+	for _, call := range extraCalls(oldCalls, newCalls) {
+		changes = append(changes, Change{Kind: ChangeRemovedCall, Old: canonicalNode(call), OldStart: call.Start()})
+	}
+	for _, call := range extraCalls(newCalls, oldCalls) {
+		changes = append(changes, Change{Kind: ChangeAddedCall, New: canonicalNode(call), NewStart: call.Start()})
+	}
+	return changes
+}
+
+// findCalls returns the function calls in the tree, in the order they are written.
+func findCalls(root ast.Node) []*ast.FunctionCall {
+	var result []*ast.FunctionCall
+	ast.Inspect(root, func(node ast.Node) bool {
+		if call, isCall := node.(*ast.FunctionCall); isCall {
+			result = append(result, call)
+		}
+		return true
+	})
+	return result
+}
+
+// extraCalls returns the calls of each function that come after the number of calls of the function in the others.
+func extraCalls(calls []*ast.FunctionCall, others []*ast.FunctionCall) []*ast.FunctionCall {
+	remaining := map[string]int{}
+	for _, other := range others {
+		remaining[other.FuncIdentifier.IdentifierName]++
+	}
+	var result []*ast.FunctionCall
+	for _, call := range calls {
+		name := call.FuncIdentifier.IdentifierName
+		if remaining[name] > 0 {
+			remaining[name]--
+		} else {
+			result = append(result, call)
+		}
+	}
+	return result
+}
+
+// canonicalNode returns the canonical form of the node, see Expression.Canonical.
+func canonicalNode(node ast.Node) string {
+	var result strings.Builder
+	writeCanonical(&result, node, true)
+	return result.String()
+}
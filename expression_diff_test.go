@@ -0,0 +1,75 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestDiff(t *testing.T) {
+	testCases := map[string]struct {
+		old      string
+		new      string
+		expected []string
+	}{
+		"equivalent": {`($.a + 1)`, `$.a+1`, nil},
+		"literal":    {`$.a > 5`, `$.a > 10`, []string{`changed-literal: 5 -> 10`}},
+		"operator": {`$.a + 1 > 5`, `$.a - 1 > 5`, []string{
+			`changed-operator: + -> -`,
+		}},
+		"access": {`$.a.b == "x"`, `$.a.c == 'x'`, []string{
+			`removed-access: $.a.b`,
+			`added-access: $.a.c`,
+		}},
+		"index": {`$.list[0]`, `$.list[1]`, []string{
+			`changed-literal: 0 -> 1`,
+			`removed-access: $.list.0`,
+			`added-access: $.list.1`,
+		}},
+		"call": {`f($.a)`, `g($.a)`, []string{
+			`removed-call: f($.a)`,
+			`added-call: g($.a)`,
+		}},
+		"arguments": {`f(1)`, `f(1, $.b)`, []string{
+			`replaced: f(1) -> f(1, $.b)`,
+			`added-access: $.b`,
+		}},
+		"replaced": {`$.a * 2`, `$.a * abs($.b)`, []string{
+			`replaced: 2 -> abs($.b)`,
+			`added-access: $.b`,
+			`added-call: abs($.b)`,
+		}},
+		"moved": {`$.a + $.b`, `$.b + $.a`, nil},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			oldExpr, err := expressions.New(tc.old)
+			assert.NoError(t, err)
+			newExpr, err := expressions.New(tc.new)
+			assert.NoError(t, err)
+			var changes []string
+			for _, change := range expressions.Diff(oldExpr, newExpr) {
+				changes = append(changes, change.String())
+			}
+			assert.Equals(t, changes, tc.expected)
+		})
+	}
+}
+
+func TestDiff_Positions(t *testing.T) {
+	oldExpr, err := expressions.New(`$.a > 5`)
+	assert.NoError(t, err)
+	newExpr, err := expressions.New(`$.a  >  10 + $.b`)
+	assert.NoError(t, err)
+	changes := expressions.Diff(oldExpr, newExpr)
+	assert.Equals(t, len(changes), 2)
+	assert.Equals(t, changes[0].Kind, expressions.ChangeReplaced)
+	assert.Equals(t, changes[0].OldStart.Column, 7)
+	assert.Equals(t, changes[0].NewStart.Column, 9)
+	assert.Equals(t, changes[1].Kind, expressions.ChangeAddedAccess)
+	assert.Equals(t, changes[1].Path, expressions.Path{"$", "b"})
+	assert.Equals(t, changes[1].NewStart.Column, 14)
+	assert.Equals(t, changes[1].OldStart.IsValid(), false)
+}
@@ -0,0 +1,59 @@
+package expressions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func (e expression) DumpAST() string {
+	var result strings.Builder
+	depth := 0
+	ast.Walk(e.ast, ast.VisitorFuncs{
+		EnterFunc: func(node ast.Node) bool {
+			result.WriteString(strings.Repeat("  ", depth))
+			result.WriteString(strings.TrimPrefix(fmt.Sprintf("%T", node), "*ast."))
+			if details := dumpNodeDetails(node); details != "" {
+				result.WriteString(" " + details)
+			}
+			if start := node.Start(); start.IsValid() {
+				result.WriteString(" @ " + start.String() + "-" + node.End().String())
+			}
+			result.WriteString("\n")
+			depth++
+			return true
+		},
+		ExitFunc: func(node ast.Node) {
+			depth--
+		},
+	})
+	return result.String()
+}
+
+// dumpNodeDetails returns the value of the node that is not shown by its children, such as the value of a literal
+// or the operator of an operation.
+func dumpNodeDetails(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.StringLiteral:
+		return strconv.Quote(n.StrValue)
+	case *ast.IntLiteral:
+		if n.Literal != "" {
+			return strconv.FormatInt(n.IntValue, 10) + " (" + n.Literal + ")"
+		}
+		return strconv.FormatInt(n.IntValue, 10)
+	case *ast.FloatLiteral:
+		return strconv.FormatFloat(n.FloatValue, 'g', -1, 64)
+	case *ast.BooleanLiteral:
+		return strconv.FormatBool(n.BooleanValue)
+	case *ast.Identifier:
+		return n.IdentifierName
+	case *ast.BinaryOperation:
+		return canonicalOperator(n.Operation)
+	case *ast.UnaryOperation:
+		return canonicalOperator(n.LeftOperation)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,32 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestDumpAST(t *testing.T) {
+	expr, err := expressions.New(`toUpper($.a["b"]) + 5m`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.DumpAST(), `BinaryOperation + @ 1:1-1:23
+  FunctionCall @ 1:1-1:18
+    Identifier toUpper @ 1:1-1:8
+    ArgumentList @ 1:8-1:18
+      BracketAccessor @ 1:9-1:17
+        DotNotation @ 1:9-1:12
+          Identifier $ @ 1:9-1:10
+          Identifier a @ 1:11-1:12
+        StringLiteral "b" @ 1:13-1:16
+  IntLiteral 300 (5m) @ 1:21-1:23
+`)
+}
+
+func TestDumpAST_Literals(t *testing.T) {
+	expr, err := expressions.New(`-1.5 * 2 != true`)
+	assert.NoError(t, err)
+	assert.Contains(t, expr.DumpAST(), "FloatLiteral 1.5 @ 1:2-1:5\n")
+	assert.Contains(t, expr.DumpAST(), "IntLiteral 2 @ 1:8-1:9\n")
+	assert.Contains(t, expr.DumpAST(), "BooleanLiteral true @ 1:13-1:17\n")
+}
@@ -0,0 +1,182 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// ErrorCode is a stable, machine-readable code of the category of an error. The codes do not change between
+// versions, so they can be used to localize, aggregate, or handle errors without matching their messages. Use
+// ErrorCodeOf to get the code of an error.
+type ErrorCode string
+
+const (
+	// ErrorCodeUnknown is the code of errors that do not have a code, such as the errors returned by functions.
+	ErrorCodeUnknown ErrorCode = "unknown"
+	// ErrorCodeParse means that the expression is not syntactically valid.
+	ErrorCodeParse ErrorCode = "parse-error"
+	// ErrorCodeNestingTooDeep means that the expression is nested deeper than Options.MaxNestingDepth allows.
+	ErrorCodeNestingTooDeep ErrorCode = "nesting-too-deep"
+	// ErrorCodeFeatureDisabled means that the expression uses a feature listed in Options.DisabledFeatures.
+	ErrorCodeFeatureDisabled ErrorCode = "feature-disabled"
+	// ErrorCodeLanguageVersion means that the expression uses a feature of a later version than Options.LanguageVersion.
+	ErrorCodeLanguageVersion ErrorCode = "language-version"
+	// ErrorCodeInvalidOptions means that the options passed to NewWithOptions are not valid.
+	ErrorCodeInvalidOptions ErrorCode = "invalid-options"
+	// ErrorCodeTypeMismatch means that a value or type is not valid for the operation applied to it, such as adding
+	// a string to an integer.
+	ErrorCodeTypeMismatch ErrorCode = "type-mismatch"
+	// ErrorCodeUnknownFunction means that the expression calls a function that is not passed.
+	ErrorCodeUnknownFunction ErrorCode = "unknown-function"
+	// ErrorCodeInvalidArguments means that a function is called with the wrong number or types of arguments.
+	ErrorCodeInvalidArguments ErrorCode = "invalid-arguments"
+	// ErrorCodeUnknownField means that a field of an object, or a key of a map, does not exist.
+	ErrorCodeUnknownField ErrorCode = "unknown-field"
+	// ErrorCodeIndexOutOfRange means that a list index is outside of the list.
+	ErrorCodeIndexOutOfRange ErrorCode = "index-out-of-range"
+	// ErrorCodeDivisionByZero means that an integer is divided by zero, or the modulus by zero is computed.
+	ErrorCodeDivisionByZero ErrorCode = "division-by-zero"
+	// ErrorCodeNonFiniteFloat means that a float operation results in NaN or an infinite value, which is not
+	// allowed by default. See Options.AllowNonFiniteFloats.
+	ErrorCodeNonFiniteFloat ErrorCode = "non-finite-float"
+	// ErrorCodeFunctionFailed means that a function called by the expression returned an error. See
+	// FunctionCallError.
+	ErrorCodeFunctionFailed ErrorCode = "function-failed"
+	// ErrorCodeFunctionTimeout means that a function did not return within the timeout of its call policy. See
+	// FunctionTimeoutError.
+	ErrorCodeFunctionTimeout ErrorCode = "function-timeout"
+	// ErrorCodeInvalidFunctionResult means that a function returned a value that does not match its output type.
+	// See InvalidFunctionResultError.
+	ErrorCodeInvalidFunctionResult ErrorCode = "invalid-function-result"
+	// ErrorCodeInvalidResult means that the result of the expression does not match the type passed to
+	// EvaluateAndValidate. See InvalidResultError.
+	ErrorCodeInvalidResult ErrorCode = "invalid-result"
+	// ErrorCodePending means that the expression needs the value of a deferred call that is not resolved yet. See
+	// PendingError.
+	ErrorCodePending ErrorCode = "pending"
+	// ErrorCodePolicyViolation means that the expression uses a function or path its policy does not allow. See
+	// PolicyViolationError.
+	ErrorCodePolicyViolation ErrorCode = "policy-violation"
+	// ErrorCodeBatchFailed means that the evaluation failed for some of the data items passed to EvaluateMany. See
+	// BatchEvaluationError.
+	ErrorCodeBatchFailed ErrorCode = "batch-failed"
+	// ErrorCodeDuplicateFunction means that a function is registered more than once.
+	ErrorCodeDuplicateFunction ErrorCode = "duplicate-function"
+	// ErrorCodeDataProviderFailed means that the DataProvider passed as the data returned an error that does not have
+	// a code.
+	ErrorCodeDataProviderFailed ErrorCode = "data-provider-failed"
+	// ErrorCodeUntranslatable means that an expression cannot be translated to or from another expression language,
+	// because it uses a construct the other language does not have.
+	ErrorCodeUntranslatable ErrorCode = "untranslatable"
+	// ErrorCodeInternal means that an unexpected state was reached, such as a bug in this package. See
+	// InternalError.
+	ErrorCodeInternal ErrorCode = "internal-error"
+)
+
+// CodedError is implemented by the errors that have an ErrorCode.
+type CodedError interface {
+	error
+	// ErrorCode returns the code of the category of the error.
+	ErrorCode() ErrorCode
+}
+
+// Error is an error with an ErrorCode, returned for failures that do not have a more specific error type. If the
+// type resolution, the dependency resolution, or the evaluation of an expression fails, the error also holds the
+// expression and the position of the node that failed.
+type Error struct {
+	// Code is the code of the category of the error.
+	Code ErrorCode
+	// Expression is the expression that failed, if the error was returned for an expression.
+	Expression string
+	// Position is the start of the node that failed in the expression. It is not valid if the error was not returned
+	// for a node.
+	Position ast.Position
+	// End is the position directly after the node that failed. Together with the byte offsets of the positions, it
+	// can be used to highlight the node in the expression.
+	End ast.Position
+	// Err holds the message of the error, and the error it wraps, if any.
+	Err error
+	// format and args are the format and the arguments of the message of Err, if it was created by newCodedError.
+	format string
+	args   []any
+}
+
+func (e *Error) Error() string {
+	if e.Position.IsValid() {
+		return e.Err.Error() + " at " + e.Position.String()
+	}
+	return e.Err.Error()
+}
+
+// LocalizedError returns the message of the error like Error does, formatted by the localize function. The
+// position is appended with the format "%s at %s".
+func (e *Error) LocalizedError(localize func(format string, args ...any) string) string {
+	message := e.Err.Error()
+	if e.format != "" {
+		message = localize(sprintfFormat(e.format), e.args...)
+	} else if localizable, isLocalizable := e.Err.(LocalizableError); isLocalizable {
+		message = localizable.LocalizedError(localize)
+	}
+	if e.Position.IsValid() {
+		return localize("%s at %s", message, e.Position.String())
+	}
+	return message
+}
+
+// Unwrap returns the error wrapped by the error, if any.
+func (e *Error) Unwrap() error {
+	return errors.Unwrap(e.Err)
+}
+
+func (e *Error) ErrorCode() ErrorCode {
+	return e.Code
+}
+
+// newCodedError creates an *Error with the code, and the message formatted like fmt.Errorf does.
+func newCodedError(code ErrorCode, format string, args ...any) error {
+	return &Error{Code: code, Err: fmt.Errorf(format, args...), format: format, args: args}
+}
+
+// locateError sets the expression and the position of the node on the error, if it is an *Error that was not
+// located yet. The innermost node that fails is the first to locate the error, so its position is kept.
+func locateError(err error, expression string, node ast.Node) error {
+	codedErr, isCodedErr := err.(*Error)
+	if !isCodedErr || codedErr.Position.IsValid() {
+		return err
+	}
+	codedErr.Expression = expression
+	codedErr.Position = node.Start()
+	codedErr.End = node.End()
+	return codedErr
+}
+
+// ErrorCodeOf returns the code of the outermost error in the chain of wrapped errors that has one. The errors of the
+// ast package are parse errors. Returns ErrorCodeUnknown if no error in the chain has a code, and an empty code if
+// the error is nil.
+func ErrorCodeOf(err error) ErrorCode {
+	if err == nil {
+		return ""
+	}
+	var codedErr CodedError
+	if errors.As(err, &codedErr) {
+		return codedErr.ErrorCode()
+	}
+	return parseErrorCode(err)
+}
+
+// parseErrorCode returns the code of an error returned by the parser.
+func parseErrorCode(err error) ErrorCode {
+	var depthErr *ast.NestingDepthError
+	var tokenErr *ast.InvalidTokenError
+	var grammarErr *ast.InvalidGrammarError
+	switch {
+	case errors.As(err, &depthErr):
+		return ErrorCodeNestingTooDeep
+	case errors.As(err, &tokenErr), errors.As(err, &grammarErr):
+		return ErrorCodeParse
+	default:
+		return ErrorCodeUnknown
+	}
+}
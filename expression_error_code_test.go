@@ -0,0 +1,142 @@
+package expressions_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestErrorCodeOf_Parse(t *testing.T) {
+	testCases := map[string]struct {
+		expression string
+		options    expressions.Options
+		expected   expressions.ErrorCode
+	}{
+		"invalid-token":    {`$.a + 07`, expressions.Options{}, expressions.ErrorCodeParse},
+		"invalid-grammar":  {`$.a +`, expressions.Options{}, expressions.ErrorCodeParse},
+		"after-literal":    {`"a".b`, expressions.Options{}, expressions.ErrorCodeParse},
+		"nesting":          {`((1))`, expressions.Options{MaxNestingDepth: 1}, expressions.ErrorCodeNestingTooDeep},
+		"feature-disabled": {`f()`, expressions.Options{DisabledFeatures: []expressions.Feature{expressions.FeatureFunctionCalls}}, expressions.ErrorCodeFeatureDisabled},
+		"invalid-options":  {`1`, expressions.Options{StringComparison: "locale"}, expressions.ErrorCodeInvalidOptions},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			_, err := expressions.NewWithOptions(tc.expression, tc.options)
+			assert.Error(t, err)
+			assert.Equals(t, expressions.ErrorCodeOf(err), tc.expected)
+		})
+	}
+}
+
+func TestErrorCodeOf_Type(t *testing.T) {
+	functions := map[string]schema.Function{"double": newDoubleFunction(t)}
+	testCases := map[string]expressions.ErrorCode{
+		`$.simple_int + $.simple_str`: expressions.ErrorCodeTypeMismatch,
+		`-$.simple_str`:               expressions.ErrorCodeTypeMismatch,
+		`$.missing`:                   expressions.ErrorCodeUnknownField,
+		`missing($.simple_int)`:       expressions.ErrorCodeUnknownFunction,
+		`double($.simple_str)`:        expressions.ErrorCodeInvalidArguments,
+		`double(1, 2)`:                expressions.ErrorCodeInvalidArguments,
+	}
+	for expression, expected := range testCases {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Type(testScope, functions, nil)
+		assert.Error(t, err)
+		assert.Equals(t, expressions.ErrorCodeOf(err), expected)
+	}
+}
+
+func TestErrorCodeOf_Evaluate(t *testing.T) {
+	functions := map[string]schema.CallableFunction{
+		"double":  newDoubleFunction(t),
+		"explode": newPanickingFunction(t),
+	}
+	data := map[string]any{"int": int64(1), "zero": int64(0), "str": "a", "list": []any{int64(1)}}
+	testCases := map[string]expressions.ErrorCode{
+		`$.int + $.str`:     expressions.ErrorCodeTypeMismatch,
+		`$.missing`:         expressions.ErrorCodeUnknownField,
+		`$.list[1]`:         expressions.ErrorCodeIndexOutOfRange,
+		`$.list["a"]`:       expressions.ErrorCodeTypeMismatch,
+		`$.int / $.zero`:    expressions.ErrorCodeDivisionByZero,
+		`1.0 / 0.0`:         expressions.ErrorCodeNonFiniteFloat,
+		`missing()`:         expressions.ErrorCodeUnknownFunction,
+		`double(1, 2)`:      expressions.ErrorCodeInvalidArguments,
+		`double($.zero)`:    expressions.ErrorCodeFunctionFailed,
+		`explode($.int)`:    expressions.ErrorCodeInternal,
+		`double($.int) + 1`: "",
+	}
+	for expression, expected := range testCases {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(data, functions, nil)
+		assert.Equals(t, expressions.ErrorCodeOf(err), expected)
+	}
+
+	expr, err := expressions.NewWithOptions(`$.int`, expressions.Options{Policy: &expressions.Policy{DeniedPaths: []string{"$.int"}}})
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(data, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodePolicyViolation)
+
+	expr, err = expressions.New(`$.int / $.zero`)
+	assert.NoError(t, err)
+	_, err = expr.EvaluateMany([]any{data}, 1, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeBatchFailed)
+	// The codes of the errors of the items are available through the chain.
+	var codedErr expressions.CodedError
+	var batchErr *expressions.BatchEvaluationError
+	assert.Equals(t, errors.As(err, &batchErr), true)
+	assert.Equals(t, errors.As(batchErr.Items[0].Cause, &codedErr), true)
+	assert.Equals(t, codedErr.ErrorCode(), expressions.ErrorCodeDivisionByZero)
+}
+
+func TestErrorCodeOf_Wrapped(t *testing.T) {
+	assert.Equals(t, expressions.ErrorCodeOf(nil), "")
+	assert.Equals(t, expressions.ErrorCodeOf(errors.New("plain")), expressions.ErrorCodeUnknown)
+
+	expr, err := expressions.New(`$.list[5]`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(map[string]any{"list": []any{}}, nil, nil)
+	wrapped := fmt.Errorf("step failed (%w)", err)
+	assert.Equals(t, expressions.ErrorCodeOf(wrapped), expressions.ErrorCodeIndexOutOfRange)
+	// The message is unchanged by the code, and ends with the position of the failing node.
+	assert.Equals(t, err.Error(), "index 5 is larger than the list items length (0) at 1:1")
+}
+
+func TestError_Position(t *testing.T) {
+	data := map[string]any{"list": []any{}, "text": "a"}
+	for expression, expected := range map[string]ast.Position{
+		`1 + $.list[5]`:              {Line: 1, Column: 5, Offset: 4},
+		`2 *` + "\n" + `($.missing)`: {Line: 2, Column: 2, Offset: 5},
+		`5 / (1 - 1)`:                {Line: 1, Column: 1, Offset: 0},
+	} {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			_, err = expr.Evaluate(data, nil, nil)
+			var codedErr *expressions.Error
+			assert.Equals(t, errors.As(err, &codedErr), true)
+			assert.Equals(t, codedErr.Expression, expression)
+			assert.Equals(t, codedErr.Position, expected)
+		})
+	}
+}
+
+func TestError_PositionOfType(t *testing.T) {
+	expression := `$.simple_int +` + "\n" + `  (!$.simple_str)`
+	expr, err := expressions.New(expression)
+	assert.NoError(t, err)
+	_, err = expr.Type(testScope, nil, nil)
+	var codedErr *expressions.Error
+	assert.Equals(t, errors.As(err, &codedErr), true)
+	assert.Equals(t, codedErr.Expression, expression)
+	assert.Equals(t, codedErr.Position, ast.Position{Line: 2, Column: 4, Offset: 18})
+	assert.Equals(t, expression[codedErr.Position.Offset:codedErr.End.Offset], `!$.simple_str`)
+	assert.Contains(t, err.Error(), " at 2:4")
+}
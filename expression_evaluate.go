@@ -1,20 +1,51 @@
 package expressions
 
 import (
-	"fmt"
+	"context"
+
 	"go.flow.arcalot.io/pluginsdk/schema"
 	"math"
 	"reflect"
+	"slices"
+	"sync"
 
-	"go.flow.arcalot.io/expressions/internal/ast"
+	"go.flow.arcalot.io/expressions/ast"
 )
 
 // evaluateContext holds the root data and context for a value evaluation in an expression. This is useful so that we
 // don't need to pass the data, root data, and workflow context along with each function call.
 type evaluateContext struct {
-	rootData        any
-	functions       map[string]schema.CallableFunction
-	workflowContext map[string][]byte
+	// expression is the expression that is evaluated, which is added to the errors.
+	expression       string
+	rootData         any
+	functions        map[string]schema.CallableFunction
+	workflowContext  map[string][]byte
+	stringComparison StringComparisonMode
+	// variables are the values of the variables, which identifiers refer to before the fields of the root data.
+	variables map[string]any
+	// deferredRoot is set if deferred values are not awaited. If the node is a function call, its deferred value is
+	// returned without awaiting it. Other nodes that need a deferred value that is not resolved yet fail with a
+	// PendingError.
+	deferredRoot ast.Node
+	// redactArgumentValues omits the argument values from the errors of failed function calls.
+	redactArgumentValues bool
+	// validateFunctionResults validates the values returned by functions against their declared output types.
+	validateFunctionResults bool
+	// allowNonFiniteFloats allows float operations to produce NaN and infinite values, instead of failing.
+	allowNonFiniteFloats bool
+	// euclideanModulo makes the result of the modulus operator never negative.
+	euclideanModulo bool
+	// plan is the access plan the expression is evaluated with, if any. The values of its access chains are stored
+	// in planSlots.
+	plan      *AccessPlan
+	planSlots []planSlot
+	// incremental is the incremental evaluation the expression is evaluated with, if any. It keeps the results of
+	// the nodes for the next evaluations.
+	incremental *IncrementalEvaluation
+	// tracer creates the spans of the evaluation and the function calls, if set. The spans are children of the span
+	// in traceContext.
+	tracer       Tracer
+	traceContext context.Context
 }
 
 // evaluate evaluates the passed  node on a set of data consisting of primitive types. It must also have access
@@ -25,11 +56,33 @@ func (c evaluateContext) evaluate(node ast.Node, data any) (any, error) {
 	if literal, isLiteral := node.(ast.ValueLiteral); isLiteral {
 		return literal.Value(), nil
 	}
-	// Checks non-generic types.
+	if c.incremental != nil {
+		if result, found := c.incremental.cachedResult(node); found {
+			return result, nil
+		}
+	}
+	result, err := c.evaluateNode(node, data)
+	if err != nil {
+		return result, locateError(err, c.expression, node)
+	}
+	if c.incremental != nil {
+		c.incremental.keepResult(node, result)
+	}
+	return result, nil
+}
+
+// evaluateNode evaluates the node, which is not a literal.
+func (c evaluateContext) evaluateNode(node ast.Node, data any) (any, error) {
 	switch n := node.(type) {
 	case *ast.DotNotation:
+		if n.Chain != nil {
+			return c.evaluateAccessChain(n.Chain)
+		}
 		return c.evaluateDotNotation(n, data)
 	case *ast.BracketAccessor:
+		if n.Chain != nil {
+			return c.evaluateAccessChain(n.Chain)
+		}
 		return c.evaluateBracketAccessor(n, data)
 	case *ast.Identifier:
 		return c.evaluateIdentifier(n, data)
@@ -40,7 +93,7 @@ func (c evaluateContext) evaluate(node ast.Node, data any) (any, error) {
 	case *ast.UnaryOperation:
 		return c.evaluateUnaryOperation(n)
 	default:
-		return nil, fmt.Errorf("unsupported node type: %T", n)
+		return nil, newCodedError(ErrorCodeInternal, "unsupported node type: %T", n)
 	}
 }
 
@@ -48,7 +101,9 @@ type SupportedNumber interface {
 	int64 | float64
 }
 
-func evalNumericalOperation[T SupportedNumber](a, b T, op ast.MathOperationType) (any, error) {
+// evalNumericalOperation applies the operation to the numbers. If euclideanModulo is set, the result of the modulus
+// operation is never negative.
+func evalNumericalOperation[T SupportedNumber](a, b T, op ast.MathOperationType, euclideanModulo bool) (any, error) {
 	switch op {
 	case ast.Add:
 		return a + b, nil
@@ -57,15 +112,31 @@ func evalNumericalOperation[T SupportedNumber](a, b T, op ast.MathOperationType)
 	case ast.Multiply:
 		return a * b, nil
 	case ast.Divide:
+		if _, isInt := any(b).(int64); isInt && b == 0 {
+			return nil, newCodedError(ErrorCodeDivisionByZero, "integer division by zero")
+		}
 		return a / b, nil
 	case ast.Modulus:
+		var result T
 		switch any(a).(type) {
 		case int64:
-			return int64(a) % int64(b), nil
+			if b == 0 {
+				return nil, newCodedError(ErrorCodeDivisionByZero, "integer modulus by zero")
+			}
+			result = T(int64(a) % int64(b))
 		case float64:
-			return math.Mod(float64(a), float64(b)), nil
+			result = T(math.Mod(float64(a), float64(b)))
+		default:
+			return nil, newCodedError(ErrorCodeTypeMismatch, "unsupported type for modulus: %T", a)
+		}
+		if euclideanModulo && result < 0 {
+			// The result has the sign of a, so adding the absolute value of b makes it positive.
+			if b < 0 {
+				return result - b, nil
+			}
+			return result + b, nil
 		}
-		return nil, fmt.Errorf("unsupported type for modulus: %T", a)
+		return result, nil
 	case ast.Power:
 		return T(math.Pow(float64(a), float64(b))), nil
 	case ast.EqualTo:
@@ -81,12 +152,25 @@ func evalNumericalOperation[T SupportedNumber](a, b T, op ast.MathOperationType)
 	case ast.LessThanEqualTo:
 		return a <= b, nil
 	case ast.And, ast.Or:
-		return nil, fmt.Errorf("attempted logical operation %s on numeric input %T", op, a)
+		return nil, newCodedError(ErrorCodeTypeMismatch, "attempted logical operation %s on numeric input %T", op, a)
 	case ast.Invalid:
-		panic(fmt.Errorf("invalid operation encountered evaluating numerical operation; this is likely due to a bug in the parser"))
+		return nil, newCodedError(ErrorCodeInternal, "bug: invalid operation encountered evaluating numerical operation")
 	default:
-		panic(fmt.Errorf("numeric eval missing case for logical operation %s", op))
+		return nil, newCodedError(ErrorCodeInternal, "bug: numeric eval missing case for logical operation %s", op)
+	}
+}
+
+// checkFiniteResult returns an error if the result of a float operation is NaN or infinite. Such values compare
+// unexpectedly, since NaN is not equal to any value, including itself, so they are rejected unless allowed.
+func checkFiniteResult(result any, left float64, right float64, op ast.MathOperationType) error {
+	value, isFloat := result.(float64)
+	if !isFloat || (!math.IsNaN(value) && !math.IsInf(value, 0)) {
+		return nil
 	}
+	return newCodedError(
+		ErrorCodeNonFiniteFloat,
+		"float operation %v %s %v results in %v; set AllowNonFiniteFloats in the options to allow NaN and infinite values",
+		left, op, right, value)
 }
 
 func evalBooleanOperation(a, b bool, op ast.MathOperationType) (any, error) {
@@ -101,37 +185,37 @@ func evalBooleanOperation(a, b bool, op ast.MathOperationType) (any, error) {
 		return a || b, nil
 	case ast.Power, ast.Modulus, ast.Divide, ast.Multiply, ast.Subtract, ast.Add,
 		ast.GreaterThan, ast.LessThan, ast.GreaterThanEqualTo, ast.LessThanEqualTo:
-		return nil, fmt.Errorf("attempted to perform invalid operation '%s' on boolean", op)
+		return nil, newCodedError(ErrorCodeTypeMismatch, "attempted to perform invalid operation '%s' on boolean", op)
 	case ast.Invalid:
-		panic(fmt.Errorf("invalid operation encountered evaluating boolean operation; this is likely due to a bug in the parser"))
+		return nil, newCodedError(ErrorCodeInternal, "bug: invalid operation encountered evaluating boolean operation")
 	default:
-		panic(fmt.Errorf("boolean eval missing case for logical operation %s", op))
+		return nil, newCodedError(ErrorCodeInternal, "bug: boolean eval missing case for logical operation %s", op)
 	}
 }
 
-func evalStringOperation(a, b string, op ast.MathOperationType) (any, error) {
+func evalStringOperation(a, b string, op ast.MathOperationType, mode StringComparisonMode) (any, error) {
 	switch op {
 	case ast.Add:
 		// Concatenate
 		return a + b, nil
 	case ast.EqualTo:
-		return a == b, nil
+		return mode.compareStrings(a, b) == 0, nil
 	case ast.NotEqualTo:
-		return a != b, nil
+		return mode.compareStrings(a, b) != 0, nil
 	case ast.GreaterThan:
-		return a > b, nil
+		return mode.compareStrings(a, b) > 0, nil
 	case ast.LessThan:
-		return a < b, nil
+		return mode.compareStrings(a, b) < 0, nil
 	case ast.GreaterThanEqualTo:
-		return a >= b, nil
+		return mode.compareStrings(a, b) >= 0, nil
 	case ast.LessThanEqualTo:
-		return a <= b, nil
+		return mode.compareStrings(a, b) <= 0, nil
 	case ast.Subtract, ast.Multiply, ast.Divide, ast.Modulus, ast.Power, ast.And, ast.Or:
-		return nil, fmt.Errorf("string operations do not support operator '%s'", op)
+		return nil, newCodedError(ErrorCodeTypeMismatch, "string operations do not support operator '%s'", op)
 	case ast.Invalid:
-		panic(fmt.Errorf("invalid operation encountered evaluating string operation; this is likely due to a bug in the parser"))
+		return nil, newCodedError(ErrorCodeInternal, "bug: invalid operation encountered evaluating string operation")
 	default:
-		panic(fmt.Errorf("string eval missing case for logical operation %s", op))
+		return nil, newCodedError(ErrorCodeInternal, "bug: string eval missing case for logical operation %s", op)
 	}
 }
 
@@ -147,21 +231,25 @@ func (c evaluateContext) evaluateBinaryOperation(node *ast.BinaryOperation) (any
 	rightType := reflect.TypeOf(rightEval)
 	leftType := reflect.TypeOf(leftEval)
 	if rightType != leftType {
-		return nil, fmt.Errorf("left type '%s' and right type '%s' of binary operation '%s' do not match",
+		return nil, newCodedError(ErrorCodeTypeMismatch, "left type '%s' and right type '%s' of binary operation '%s' do not match",
 			leftType, rightType, node.Operation)
 	}
 
 	switch left := leftEval.(type) {
 	case int64:
-		return evalNumericalOperation(left, rightEval.(int64), node.Operation)
+		return evalNumericalOperation(left, rightEval.(int64), node.Operation, c.euclideanModulo)
 	case float64:
-		return evalNumericalOperation(left, rightEval.(float64), node.Operation)
+		result, err := evalNumericalOperation(left, rightEval.(float64), node.Operation, c.euclideanModulo)
+		if err != nil || c.allowNonFiniteFloats {
+			return result, err
+		}
+		return result, checkFiniteResult(result, left, rightEval.(float64), node.Operation)
 	case string:
-		return evalStringOperation(left, rightEval.(string), node.Operation)
+		return evalStringOperation(left, rightEval.(string), node.Operation, c.stringComparison)
 	case bool:
 		return evalBooleanOperation(left, rightEval.(bool), node.Operation)
 	default:
-		return nil, fmt.Errorf("unsupported type to perform binary operation on: %T", left)
+		return nil, newCodedError(ErrorCodeTypeMismatch, "unsupported type to perform binary operation on: %T", left)
 	}
 }
 
@@ -177,16 +265,16 @@ func (c evaluateContext) evaluateUnaryOperation(node *ast.UnaryOperation) (any,
 		case float64:
 			return -right, nil
 		default:
-			return nil, fmt.Errorf("unsupported type for arithmetic negation: %T; expected 64-bit int or float", right)
+			return nil, newCodedError(ErrorCodeTypeMismatch, "unsupported type for arithmetic negation: %T; expected 64-bit int or float", right)
 		}
 	} else if node.LeftOperation == ast.Not {
 		booleanResult, isBool := rightEval.(bool)
 		if !isBool {
-			return nil, fmt.Errorf("unsupported type for boolean complement: %T; expected boolean", rightEval)
+			return nil, newCodedError(ErrorCodeTypeMismatch, "unsupported type for boolean complement: %T; expected boolean", rightEval)
 		}
 		return !booleanResult, nil
 	} else {
-		return nil, fmt.Errorf("only unary operators '-' and '!' are currently supported; got '%s'", node.LeftOperation)
+		return nil, newCodedError(ErrorCodeInternal, "only unary operators '-' and '!' are currently supported; got '%s'", node.LeftOperation)
 	}
 }
 
@@ -194,33 +282,86 @@ func (c evaluateContext) evaluateFuncCall(node *ast.FunctionCall) (any, error) {
 	funcID := node.FuncIdentifier
 	functionSchema, found := c.functions[funcID.String()]
 	if !found {
-		return nil, fmt.Errorf("function with ID '%s' not found", funcID)
+		return nil, newCodedError(ErrorCodeUnknownFunction, "function with ID '%s' not found", funcID)
 	}
 	// Evaluate args
-	evaluatedArgs, err := c.evaluateParameters(node.ArgumentInputs)
+	arguments := argumentPool.Get().(*[]any)
+	evaluatedArgs, err := c.evaluateParameters(node.ArgumentInputs, (*arguments)[:0])
 	if err != nil {
 		return nil, err
 	}
+	if overloaded, isOverloaded := functionSchema.(*OverloadedFunction); isOverloaded {
+		functionSchema, err = overloaded.resolveArguments(evaluatedArgs)
+		if err != nil {
+			return nil, err
+		}
+	}
 	expectedArgs := len(functionSchema.Parameters())
 	gotArgs := len(evaluatedArgs)
 	if gotArgs != expectedArgs {
-		return nil, fmt.Errorf(
+		return nil, newCodedError(
+			ErrorCodeInvalidArguments,
 			"function '%s' called with incorrect number of arguments; expected %d, got %d",
 			funcID, expectedArgs, gotArgs)
 	}
-	return functionSchema.Call(evaluatedArgs)
+	result, err := c.callTraced(funcID.String(), functionSchema, evaluatedArgs)
+	if err != nil {
+		return nil, newFunctionCallError(node, evaluatedArgs, c.redactArgumentValues, err)
+	}
+	deferredResult, isDeferred := result.(Deferred)
+	switch {
+	case !isDeferred:
+	case c.deferredRoot == nil || deferredResult.Resolved():
+		result, err = deferredResult.Await()
+		if err != nil {
+			return nil, newFunctionCallError(node, evaluatedArgs, c.redactArgumentValues, err)
+		}
+	case c.deferredRoot == ast.Node(node):
+		return deferredResult, nil
+	default:
+		return nil, &PendingError{Function: funcID.String(), Deferred: deferredResult}
+	}
+	if c.validateFunctionResults {
+		if err := validateFunctionResult(functionSchema, result); err != nil {
+			return nil, newFunctionCallError(node, evaluatedArgs, c.redactArgumentValues, err)
+		}
+	}
+	if !isDeferred {
+		releaseArguments(arguments, evaluatedArgs)
+	}
+	return result, nil
+}
+
+// argumentPool holds the slices used to pass the arguments to functions, so calls do not allocate a new slice each
+// time.
+var argumentPool = sync.Pool{
+	New: func() any {
+		return new([]any)
+	},
+}
+
+// releaseArguments returns the argument slice to the pool. It must only be called once the function returned
+// successfully. Failed calls keep their arguments in the returned error, and calls that returned a deferred value may
+// still be using them, so their slices are left to the garbage collector. Calls with a timeout get a copy of the
+// arguments, so a timed out call that keeps running does not use the slice.
+func releaseArguments(arguments *[]any, evaluatedArgs []any) {
+	// Clear the values, so the pool does not keep them alive.
+	clear(evaluatedArgs)
+	*arguments = evaluatedArgs[:0]
+	argumentPool.Put(arguments)
 }
 
-func (c evaluateContext) evaluateParameters(node *ast.ArgumentList) ([]any, error) {
+// evaluateParameters evaluates the arguments of a function call, and appends their values to the buffer.
+func (c evaluateContext) evaluateParameters(node *ast.ArgumentList, buffer []any) ([]any, error) {
 	// A value for each argument
-	result := make([]any, node.NumChildren())
+	result := slices.Grow(buffer, node.NumChildren())
 	for i := 0; i < node.NumChildren(); i++ {
 		arg := node.Arguments[i]
-		var err error
-		result[i], err = c.evaluate(arg, c.rootData)
+		value, err := c.evaluate(arg, c.rootData)
 		if err != nil {
 			return nil, err
 		}
+		result = append(result, value)
 	}
 	return result, nil
 }
@@ -234,7 +375,18 @@ func (c evaluateContext) evaluateDotNotation(node *ast.DotNotation, data any) (a
 	if err != nil {
 		return nil, err
 	}
-	return c.evaluate(node.RightAccessIdentifier, leftResult)
+	return c.evaluateFieldName(node.RightAccessIdentifier, leftResult)
+}
+
+// evaluateFieldName evaluates the node on the right of a dot notation on the value on the left. Unlike other
+// identifiers, field names never refer to variables.
+func (c evaluateContext) evaluateFieldName(node ast.Node, data any) (any, error) {
+	identifier, isIdentifier := node.(*ast.Identifier)
+	if !isIdentifier {
+		return c.evaluate(node, data)
+	}
+	result, err := evaluateField(data, identifier.IdentifierName)
+	return result, locateError(err, c.expression, node)
 }
 
 // Evaluates a MapAccessor node, which is a more advanced version of dot notation
@@ -255,57 +407,137 @@ func (c evaluateContext) evaluateBracketAccessor(node *ast.BracketAccessor, data
 	return evaluateMapAccess(leftResult, mapKey)
 }
 
+// evaluateAccessChain follows a precomputed access chain from the root data. It gives the same result as evaluating the
+// nodes of the chain, without evaluating the literals of the keys.
+func (c evaluateContext) evaluateAccessChain(chain *ast.AccessChain) (any, error) {
+	if provider, isProvider := c.rootData.(DataProvider); isProvider {
+		return getProvidedValue(provider, accessChainPath(chain))
+	}
+	if c.plan != nil {
+		return c.plan.access(chain, c.rootData, c.planSlots)
+	}
+	data := c.rootData
+	for _, step := range chain.Steps {
+		var err error
+		// The keys are stored as interfaces, so they do not need to be converted for each access.
+		data, err = evaluateMapAccess(data, step.Key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
 // Evaluates an identifier
 // Identifiers are items in dot notation.
 func (c evaluateContext) evaluateIdentifier(node *ast.Identifier, data any) (any, error) {
-	switch node.IdentifierName {
-	case "$":
+	switch {
+	case node.IdentifierName == "$":
+		if provider, isProvider := c.rootData.(DataProvider); isProvider {
+			return getProvidedValue(provider, Path{"$"})
+		}
 		// $ is the root node of the data structure.
 		return c.rootData, nil
+	case node.RootName() == ast.VariablesRootName:
+		if c.variables == nil {
+			return map[string]any{}, nil
+		}
+		return c.variables, nil
+	case node.IsRoot():
+		// A named root, such as $steps, is the field of the root data with its name.
+		return evaluateField(c.rootData, node.RootName())
 	default:
-		// Otherwise, it's a normal accessor key, which we evaluate like a map key.
-		return evaluateMapAccess(data, node.IdentifierName)
+		if value, isVariable := c.variables[node.IdentifierName]; isVariable {
+			return value, nil
+		}
+		return evaluateField(data, node.IdentifierName)
+	}
+}
+
+// evaluateField looks up the field with the specified name in the data. If the data is a DataProvider, it is the
+// root data, so the field is requested from the provider.
+func evaluateField(data any, name string) (any, error) {
+	if provider, isProvider := data.(DataProvider); isProvider {
+		return getProvidedValue(provider, Path{"$", name})
+	}
+	// Maps decoded from JSON or YAML are looked up directly, which avoids converting the key to an interface.
+	if dataMap, isMap := data.(map[string]any); isMap {
+		return evaluateStringMapAccess(dataMap, name)
 	}
+	// Otherwise, it's a normal accessor key, which we evaluate like a map key.
+	return evaluateMapAccess(data, name)
+}
+
+// evaluateStringMapAccess looks up a key in a map with string keys without using reflection.
+func evaluateStringMapAccess(data map[string]any, mapKey string) (any, error) {
+	value, found := data[mapKey]
+	if !found {
+		return nil, newCodedError(ErrorCodeUnknownField, "map key %v not found", mapKey)
+	}
+	return value, nil
 }
 
 // evaluateMapKey is a helper function for evaluate that extracts an item in maps, lists, or object-likes when an
 // identifier or map accessor is encountered.
 func evaluateMapAccess(data any, mapKey any) (any, error) {
+	// The most common types of data are accessed without reflection.
+	switch typedData := data.(type) {
+	case map[string]any:
+		if stringKey, isString := mapKey.(string); isString {
+			return evaluateStringMapAccess(typedData, stringKey)
+		}
+	case []any:
+		sliceIndex, err := resolveSliceIndex(mapKey, len(typedData))
+		if err != nil {
+			return nil, err
+		}
+		return typedData[sliceIndex], nil
+	}
 	dataVal := reflect.ValueOf(data)
 	switch dataVal.Kind() {
 	case reflect.Map:
 		// In case of a map, we simply look up the value passed.
 		indexValue := dataVal.MapIndex(reflect.ValueOf(mapKey))
 		if !indexValue.IsValid() {
-			return nil, fmt.Errorf("map key %v not found", mapKey)
+			return nil, newCodedError(ErrorCodeUnknownField, "map key %v not found", mapKey)
 		}
 		return indexValue.Interface(), nil
 	case reflect.Slice:
-		// In case of slices we want integers. The user is responsible for converting the type to an integer themselves.
-		asInt64, isInt64 := mapKey.(int64)
-		if !isInt64 {
-			return nil, fmt.Errorf("unsupported slice index type '%T', expected int64", mapKey)
-		}
-		sliceIndex := int(asInt64)
-		if int64(sliceIndex) != asInt64 {
-			return nil, fmt.Errorf("int64 %d specified is too large for a slice index on the current system", asInt64)
-		}
-		sliceLen := dataVal.Len()
-		if sliceIndex >= sliceLen {
-			return nil, fmt.Errorf("index %d is larger than the list items length (%d)", sliceIndex, sliceLen)
-		} else if sliceIndex < -sliceLen {
-			return nil, fmt.Errorf("negative index %d is larger than the list items length (%d)", sliceIndex, sliceLen)
-		}
-		if sliceIndex < 0 {
-			sliceIndex = sliceLen + sliceIndex
+		sliceIndex, err := resolveSliceIndex(mapKey, dataVal.Len())
+		if err != nil {
+			return nil, err
 		}
 		indexValue := dataVal.Index(sliceIndex)
 		return indexValue.Interface(), nil
 	default:
-		return nil, fmt.Errorf(
+		return nil, newCodedError(
+			ErrorCodeTypeMismatch,
 			"cannot evaluate identifier %v on a %s",
 			mapKey,
 			dataVal.Kind().String(),
 		)
 	}
 }
+
+// resolveSliceIndex converts the index to a position in a slice of the given length. Negative indexes count from the
+// end of the slice.
+func resolveSliceIndex(index any, sliceLen int) (int, error) {
+	// In case of slices we want integers. The user is responsible for converting the type to an integer themselves.
+	asInt64, isInt64 := index.(int64)
+	if !isInt64 {
+		return 0, newCodedError(ErrorCodeTypeMismatch, "unsupported slice index type '%T', expected int64", index)
+	}
+	sliceIndex := int(asInt64)
+	if int64(sliceIndex) != asInt64 {
+		return 0, newCodedError(ErrorCodeIndexOutOfRange, "int64 %d specified is too large for a slice index on the current system", asInt64)
+	}
+	if sliceIndex >= sliceLen {
+		return 0, newCodedError(ErrorCodeIndexOutOfRange, "index %d is larger than the list items length (%d)", sliceIndex, sliceLen)
+	} else if sliceIndex < -sliceLen {
+		return 0, newCodedError(ErrorCodeIndexOutOfRange, "negative index %d is larger than the list items length (%d)", sliceIndex, sliceLen)
+	}
+	if sliceIndex < 0 {
+		sliceIndex = sliceLen + sliceIndex
+	}
+	return sliceIndex, nil
+}
@@ -3,11 +3,13 @@ package expressions_test
 import (
 	"fmt"
 	"go.flow.arcalot.io/pluginsdk/schema"
+	"math"
 	"reflect"
 	"testing"
 
 	"go.arcalot.io/assert"
 	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
 )
 
 var voidFunc, voidFuncErr = schema.NewCallableFunction(
@@ -989,6 +991,24 @@ var testData = map[string]struct {
 		false,
 		true,
 	},
+	"duration-literal": {
+		map[string]any{
+			"timeout": int64(300),
+		},
+		nil,
+		`$.timeout < 5m30s`,
+		false,
+		false,
+		true,
+	},
+	"byte-size-literal": {
+		nil,
+		nil,
+		`2Gi + 512MB`,
+		false,
+		false,
+		int64(2<<30 + 512000000),
+	},
 }
 
 func TestEvaluate(t *testing.T) {
@@ -1022,3 +1042,135 @@ func TestEvaluate(t *testing.T) {
 		})
 	}
 }
+
+func TestExpressionAST(t *testing.T) {
+	expr, err := expressions.New("$.foo.bar")
+	assert.NoError(t, err)
+	dotNotation, ok := expr.AST().(*ast.DotNotation)
+	if !ok {
+		t.Fatalf("AST root is not of type *ast.DotNotation")
+	}
+	assert.Equals(t, dotNotation.RightAccessIdentifier.String(), "bar")
+	assert.Equals(t, dotNotation.LeftAccessibleNode.String(), "$.foo")
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	double, err := schema.NewCallableFunction(
+		"double",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a int64) int64 {
+			return 2 * a
+		},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	functions := map[string]schema.CallableFunction{"double": double}
+	data := map[string]any{
+		"simple_int": int64(5),
+		"simple_str": "abc",
+		"int_list":   []any{int64(1), int64(2), int64(3)},
+	}
+	expr, err := expressions.New(`double($.simple_int) + $.int_list[1] > 5 && $.simple_str == "abc"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := expr.Evaluate(data, functions, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEvaluate_AccessChain(t *testing.T) {
+	data := map[string]any{
+		"a": map[string]any{"b": []any{int64(1), int64(2)}},
+		"c": map[int64]string{1: "one"},
+		"d": []string{"x", "y"},
+	}
+	testCases := map[string]any{
+		`$.a["b"][1]`:  int64(2),
+		`$.a.b[-2]`:    int64(1),
+		`$["c"][1]`:    "one",
+		`$.d[1] + "z"`: "yz",
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			result, err := expr.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, expected)
+		})
+	}
+	// Errors are the same as when evaluating the accesses one by one.
+	expr, err := expressions.New(`$.a["missing"]`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(data, nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "map key missing not found")
+	expr, err = expressions.New(`$.d[2]`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(data, nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "index 2 is larger than the list items length (2)")
+}
+
+func TestEvaluate_FieldNamedLikeLiteral(t *testing.T) {
+	expr, err := expressions.New(`$.flags.true && !$.flags.false`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(map[string]any{"flags": map[string]any{"true": true, "false": false}}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(true))
+	// The dot notation and the bracket notation are the same reference.
+	bracketExpr, err := expressions.New(`$["flags"]["true"] && !$.flags["false"]`)
+	assert.NoError(t, err)
+	assert.Equals(t, bracketExpr.Canonical(), expr.Canonical())
+}
+
+func TestEvaluate_RawString(t *testing.T) {
+	// Raw strings have no escape sequences, so backslashes and quotes are part of the value.
+	expr, err := expressions.New("$[`C:\\path`] + `\\n\"'`")
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(map[string]any{`C:\path`: "dir"}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(`dir\n"'`))
+	assert.Equals(t, expr.String(), "$[`C:\\path`] + `\\n\"'`")
+	// The same value written with escapes is the same expression.
+	escapedExpr, err := expressions.New(`$["C:\\path"] + "\\n\"'"`)
+	assert.NoError(t, err)
+	assert.Equals(t, escapedExpr.Canonical(), expr.Canonical())
+}
+
+func TestEvaluate_NonFiniteFloats(t *testing.T) {
+	for _, expression := range []string{`0.0 / 0.0`, `1.0 / 0.0`, `-$.big * $.big`, `10.0 ^ 400.0`, `1.0 % 0.0`} {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(map[string]any{"big": 1e200}, nil, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "AllowNonFiniteFloats")
+	}
+
+	// If allowed, the values follow IEEE 754, so NaN is not equal to any value, including itself.
+	options := expressions.Options{AllowNonFiniteFloats: true}
+	testCases := map[string]any{
+		`0.0 / 0.0 == 0.0 / 0.0`: false,
+		`0.0 / 0.0 != 0.0 / 0.0`: true,
+		`0.0 / 0.0 < 1.0`:        false,
+		`0.0 / 0.0 >= 1.0`:       false,
+		`1.0 / 0.0 > 1e308`:      true,
+		`-1.0 / 0.0`:             math.Inf(-1),
+	}
+	for expression, expected := range testCases {
+		expr, err := expressions.NewWithOptions(expression, options)
+		assert.NoError(t, err)
+		result, err := expr.Evaluate(nil, nil, nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, expected)
+	}
+}
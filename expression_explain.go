@@ -0,0 +1,167 @@
+package expressions
+
+import (
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func (e expression) Explain(scope schema.Scope, functions map[string]schema.Function) (result string, err error) {
+	defer recoverInternalError("explaining the expression", &err)
+	if _, err := e.resolveDependencies(scope, functions, nil); err != nil {
+		return "", err
+	}
+	x := &explainer{
+		dependencyContext: &dependencyContext{
+			expression: e.expression,
+			rootType:   scope,
+			rootPath: PathTree{
+				PathItem:     "$",
+				NodeType:     DataRootNode,
+				ResolvedType: scope,
+			},
+			functions: functions,
+		},
+	}
+	return x.describe(e.ast), nil
+}
+
+// explainer describes the nodes of an expression in plain language. The types resolved with the dependency context
+// are used to tell objects, maps, and lists apart.
+type explainer struct {
+	dependencyContext *dependencyContext
+}
+
+// describe returns the description of the whole expression. Operations are described as a sequence of steps, and
+// other nodes as the value they stand for.
+func (x *explainer) describe(node ast.Node) string {
+	switch node.(type) {
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		return x.steps(node)
+	default:
+		return x.value(node)
+	}
+}
+
+// steps describes the node as a sequence of steps, such as "takes field a, adds 5". Operations whose left operand is
+// an operation continue the steps of the left operand, so a chain of operations reads from left to right.
+func (x *explainer) steps(node ast.Node) string {
+	switch n := node.(type) {
+	case *ast.BinaryOperation:
+		switch n.Operation {
+		case ast.And:
+			return "checks that both (" + x.describe(n.LeftNode) + ") and (" + x.describe(n.RightNode) + ")"
+		case ast.Or:
+			return "checks that either (" + x.describe(n.LeftNode) + ") or (" + x.describe(n.RightNode) + ")"
+		}
+		return x.steps(n.LeftNode) + ", " + binaryOperationVerb(n.Operation) + " " + x.value(n.RightNode)
+	case *ast.UnaryOperation:
+		if n.LeftOperation == ast.Not {
+			return x.steps(n.RightNode) + ", inverts it"
+		}
+		return x.steps(n.RightNode) + ", negates it"
+	default:
+		return "takes " + x.value(node)
+	}
+}
+
+// binaryOperationVerb returns the phrase describing the operation applied to the result of the previous step.
+func binaryOperationVerb(operation ast.MathOperationType) string {
+	switch operation {
+	case ast.Add:
+		return "adds"
+	case ast.Subtract:
+		return "subtracts"
+	case ast.Multiply:
+		return "multiplies it by"
+	case ast.Divide:
+		return "divides it by"
+	case ast.Modulus:
+		return "takes the remainder of dividing it by"
+	case ast.Power:
+		return "raises it to the power of"
+	case ast.EqualTo:
+		return "checks if it is equal to"
+	case ast.NotEqualTo:
+		return "checks if it is not equal to"
+	case ast.GreaterThan:
+		return "checks if it is greater than"
+	case ast.LessThan:
+		return "checks if it is less than"
+	case ast.GreaterThanEqualTo:
+		return "checks if it is greater than or equal to"
+	case ast.LessThanEqualTo:
+		return "checks if it is less than or equal to"
+	default:
+		return "applies " + operation.String() + " with"
+	}
+}
+
+// value describes the value the node stands for, such as "field bar of object foo".
+func (x *explainer) value(node ast.Node) string {
+	switch n := node.(type) {
+	case ast.ValueLiteral:
+		return n.String()
+	case *ast.Identifier:
+		if n.IdentifierName == "$" {
+			return "the input"
+		}
+		if rootName := n.RootName(); rootName != "" {
+			return "root " + rootName
+		}
+		// A bare identifier is a field of the root.
+		return "field " + n.IdentifierName
+	case *ast.DotNotation:
+		return "field " + n.RightAccessIdentifier.String() + x.of(n.LeftAccessibleNode)
+	case *ast.BracketAccessor:
+		return x.key(n) + x.of(n.LeftNode)
+	case *ast.FunctionCall:
+		arguments := make([]string, len(n.ArgumentInputs.Arguments))
+		for i, argument := range n.ArgumentInputs.Arguments {
+			arguments[i] = x.value(argument)
+		}
+		if len(arguments) == 0 {
+			return "the output of " + n.FuncIdentifier.IdentifierName + "()"
+		}
+		return "the output of " + n.FuncIdentifier.IdentifierName + " with " + strings.Join(arguments, ", ")
+	default:
+		return "the result of (" + x.describe(node) + ")"
+	}
+}
+
+// key describes the key of the bracket accessor, depending on the type of the value it is applied to.
+func (x *explainer) key(node *ast.BracketAccessor) string {
+	kind := "key"
+	switch x.dependencyContext.typeID(node.LeftNode) {
+	case schema.TypeIDList:
+		kind = "item"
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+		kind = "field"
+	}
+	if literal, isLiteral := node.RightExpression.(ast.ValueLiteral); isLiteral {
+		return kind + " " + literal.String()
+	}
+	return "the " + kind + " given by " + x.value(node.RightExpression)
+}
+
+// of returns the phrase that names the value an access is applied to, or an empty string for the root.
+func (x *explainer) of(node ast.Node) string {
+	if identifier, isIdentifier := node.(*ast.Identifier); isIdentifier && identifier.IdentifierName == "$" {
+		return ""
+	}
+	dotNotation, isDotNotation := node.(*ast.DotNotation)
+	if !isDotNotation {
+		return " of " + x.value(node)
+	}
+	kind := "field"
+	switch x.dependencyContext.typeID(node) {
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+		kind = "object"
+	case schema.TypeIDMap:
+		kind = "map"
+	case schema.TypeIDList:
+		kind = "list"
+	}
+	return " of " + kind + " " + dotNotation.RightAccessIdentifier.String() + x.of(dotNotation.LeftAccessibleNode)
+}
@@ -0,0 +1,41 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestExplain(t *testing.T) {
+	functions := map[string]schema.Function{"double": newDoubleFunction(t)}
+	testCases := map[string]string{
+		`$.foo.bar`:  `field bar of object foo`,
+		`$.faz["a"]`: `key "a" of map faz`,
+		`$.foo.int_list[0] + 5 == $.simple_int`: "takes item 0 of list int_list of object foo, adds 5, " +
+			"checks if it is equal to field simple_int",
+		`$.int_list[$.simple_int]`: `the item given by field simple_int of list int_list`,
+		`double($.simple_int) * (2 - 1)`: "takes the output of double with field simple_int, " +
+			"multiplies it by the result of (takes 2, subtracts 1)",
+		`(!$.simple_bool) || $.simple_bool`: "checks that either (takes field simple_bool, inverts it) " +
+			"or (field simple_bool)",
+		`-$.simple_int`: `takes field simple_int, negates it`,
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			explanation, err := expr.Explain(testScope, functions)
+			assert.NoError(t, err)
+			assert.Equals(t, explanation, expected)
+		})
+	}
+}
+
+func TestExplain_Invalid(t *testing.T) {
+	expr, err := expressions.New(`$.foo.nonexistent`)
+	assert.NoError(t, err)
+	_, err = expr.Explain(testScope, nil)
+	assert.Error(t, err)
+}
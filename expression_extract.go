@@ -0,0 +1,154 @@
+package expressions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// ExtractOptions sets how ExtractExpressions finds the expressions in a structure.
+type ExtractOptions struct {
+	// Prefix and Suffix mark the string values that are expressions, such as the prefix "!expr " for a YAML tag that
+	// was kept in the decoded value. They are removed before the expression is parsed. If both are empty, every
+	// string value is an expression.
+	Prefix string
+	Suffix string
+	// Options are the options the expressions are parsed with.
+	Options Options
+}
+
+// match returns the expression in the value, and whether the value is an expression.
+func (o ExtractOptions) match(value string) (string, bool) {
+	if len(value) < len(o.Prefix)+len(o.Suffix) ||
+		!strings.HasPrefix(value, o.Prefix) || !strings.HasSuffix(value, o.Suffix) {
+		return "", false
+	}
+	return value[len(o.Prefix) : len(value)-len(o.Suffix)], true
+}
+
+// ExtractedExpression is an expression found in a structure by ExtractExpressions.
+type ExtractedExpression struct {
+	// Location is the path of the string within the structure, made of map keys and list positions.
+	Location []any
+	// Expression is the parsed expression. It is nil if the expression failed to parse.
+	Expression Expression
+	// Report holds the parse error of the expression, and the diagnostics found by AnalyzeExpressions.
+	Report ValidationReport
+}
+
+// LocationString returns the location as dot-separated map keys and list positions in brackets, such as
+// `steps.a.input[0]`.
+func (e ExtractedExpression) LocationString() string {
+	var result strings.Builder
+	for _, item := range e.Location {
+		if position, isPosition := item.(int); isPosition {
+			_, _ = fmt.Fprintf(&result, "[%d]", position)
+			continue
+		}
+		if result.Len() > 0 {
+			result.WriteString(".")
+		}
+		_, _ = fmt.Fprintf(&result, "%v", item)
+	}
+	return result.String()
+}
+
+// ExtractExpressions walks a decoded structure, such as a workflow decoded from YAML, and parses the string values
+// that are marked as expressions. Maps and lists are walked recursively, and map keys are visited in sorted order, so
+// the expressions are returned in a stable order. Expressions that fail to parse are returned with the error in their
+// report.
+func ExtractExpressions(data any, options ExtractOptions) []ExtractedExpression {
+	var result []ExtractedExpression
+	extractExpressions(data, nil, options, &result)
+	return result
+}
+
+// extractExpressions appends the expressions in the data at the location to the result.
+func extractExpressions(data any, location []any, options ExtractOptions, result *[]ExtractedExpression) {
+	switch value := data.(type) {
+	case string:
+		expressionString, isExpression := options.match(value)
+		if !isExpression {
+			return
+		}
+		extracted := ExtractedExpression{Location: slices.Clone(location)}
+		expr, err := NewWithOptions(expressionString, options.Options)
+		if err != nil {
+			extracted.Report.addError(err)
+		} else {
+			extracted.Expression = expr
+		}
+		*result = append(*result, extracted)
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			extractExpressions(value[key], append(location, key), options, result)
+		}
+	case map[any]any:
+		// Some YAML decoders decode maps with keys of any type.
+		keys := make([]any, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.SortFunc(keys, func(a, b any) int {
+			return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+		})
+		for _, key := range keys {
+			extractExpressions(value[key], append(location, key), options, result)
+		}
+	case []any:
+		for i, item := range value {
+			extractExpressions(item, append(location, i), options, result)
+		}
+	}
+}
+
+// ExpressionAnalysis is the result of AnalyzeExpressions.
+type ExpressionAnalysis struct {
+	// Expressions are the expressions found in the structure, with the diagnostics found for each.
+	Expressions []ExtractedExpression
+	// PathTrees are the dependency trees of the valid expressions merged together, like ExpressionSet.PathTrees.
+	PathTrees []*PathTree
+}
+
+// HasErrors returns true if any of the expressions failed to parse or validate.
+func (a ExpressionAnalysis) HasErrors() bool {
+	return slices.ContainsFunc(a.Expressions, func(extracted ExtractedExpression) bool {
+		return extracted.Report.HasErrors()
+	})
+}
+
+// AnalyzeExpressions extracts the expressions from the structure with ExtractExpressions, validates each against the
+// scope, and merges the dependency trees of the valid ones. This is useful for checking all expressions of a
+// workflow and building its dependency graph with a single call.
+func AnalyzeExpressions(
+	data any,
+	options ExtractOptions,
+	scope schema.Scope,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) (ExpressionAnalysis, error) {
+	analysis := ExpressionAnalysis{Expressions: ExtractExpressions(data, options)}
+	valid := NewExpressionSet()
+	for i, extracted := range analysis.Expressions {
+		if extracted.Expression == nil {
+			continue
+		}
+		analysis.Expressions[i].Report = extracted.Expression.Validate(scope, functions, workflowContext)
+		if !analysis.Expressions[i].Report.HasErrors() {
+			valid.Add(extracted.Expression)
+		}
+	}
+	pathTrees, err := valid.PathTrees(scope, functions, workflowContext)
+	if err != nil {
+		return ExpressionAnalysis{}, err
+	}
+	analysis.PathTrees = pathTrees
+	return analysis, nil
+}
@@ -0,0 +1,65 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestExtractExpressions(t *testing.T) {
+	workflow := map[string]any{
+		"steps": map[string]any{
+			"b": map[string]any{"input": []any{"!expr $.foo.bar", "plain"}},
+			"a": map[string]any{"input": "!expr $.simple_int + 1", "count": int64(1)},
+		},
+		"output": map[any]any{"result": "!expr $.foo.int_list[0]"},
+	}
+	extracted := expressions.ExtractExpressions(workflow, expressions.ExtractOptions{Prefix: "!expr "})
+	locations := make([]string, len(extracted))
+	expressionStrings := make([]string, len(extracted))
+	for i, expression := range extracted {
+		locations[i] = expression.LocationString()
+		expressionStrings[i] = expression.Expression.String()
+	}
+	assert.Equals(t, locations, []string{"output.result", "steps.a.input", "steps.b.input[0]"})
+	assert.Equals(t, expressionStrings, []string{"$.foo.int_list[0]", "$.simple_int + 1", "$.foo.bar"})
+	assert.Equals(t, extracted[2].Location, []any{"steps", "b", "input", 0})
+}
+
+func TestExtractExpressions_PrefixAndSuffix(t *testing.T) {
+	extracted := expressions.ExtractExpressions(
+		[]any{"${$.foo.bar}", "${", "$.foo.bar", "${$.foo.}"},
+		expressions.ExtractOptions{Prefix: "${", Suffix: "}"},
+	)
+	assert.Equals(t, len(extracted), 2)
+	assert.Equals(t, extracted[0].Expression.String(), "$.foo.bar")
+	// Invalid expressions are returned with the parse error.
+	assert.Equals(t, extracted[1].Location, []any{3})
+	assert.Nil(t, extracted[1].Expression)
+	assert.Equals(t, extracted[1].Report.HasErrors(), true)
+}
+
+func TestAnalyzeExpressions(t *testing.T) {
+	workflow := map[string]any{
+		"a": "!expr $.foo.bar",
+		"b": "!expr $.simple_int + $.foo.missing",
+		"c": []any{"!expr $.simple_int", "!expr $.foo.bar"},
+	}
+	analysis, err := expressions.AnalyzeExpressions(
+		workflow, expressions.ExtractOptions{Prefix: "!expr "}, testScope, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(analysis.Expressions), 4)
+	assert.Equals(t, analysis.HasErrors(), true)
+	assert.Equals(t, analysis.Expressions[0].Report.HasErrors(), false)
+	assert.Equals(t, analysis.Expressions[1].Report.HasErrors(), true)
+	assert.Equals(t, len(analysis.PathTrees), 1)
+	var paths []string
+	for _, tree := range analysis.PathTrees {
+		unpacked := tree.Unpack(expressions.UnpackRequirements{})
+		for _, path := range unpacked {
+			paths = append(paths, path.String())
+		}
+	}
+	assert.Equals(t, paths, []string{"$.foo.bar", "$.simple_int"})
+}
@@ -0,0 +1,42 @@
+package expressions
+
+import "go.flow.arcalot.io/expressions/ast"
+
+// Location is an occurrence of a reference to the data in an expression, see Expression.FindReferences.
+type Location struct {
+	// Path is the path of the whole reference, starting with the root ($), such as $.steps.a.outputs.success.
+	Path Path
+	// Start is the position of the first character of the reference.
+	Start ast.Position
+	// End is the position directly after the last character of the reference.
+	End ast.Position
+}
+
+func (e expression) FindReferences(prefix Path) []Location {
+	if len(prefix) == 0 || prefix[0] != "$" {
+		prefix = append(Path{"$"}, prefix...)
+	}
+	var result []Location
+	for _, ref := range findReferences(e.ast) {
+		path := ref.path()
+		if !hasPathPrefix(path, prefix) {
+			continue
+		}
+		result = append(result, Location{Path: path, Start: ref.node.Start(), End: ref.node.End()})
+	}
+	return result
+}
+
+// hasPathPrefix returns true if the path starts with the items of the prefix. Integer items of any type are equal if
+// their values are equal.
+func hasPathPrefix(path Path, prefix Path) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i, item := range prefix {
+		if pathItemKey(item) != pathItemKey(path[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestFindReferences(t *testing.T) {
+	expr, err := expressions.New(
+		`$steps.a.outputs.success.x + $.steps.b.outputs[$.steps.a.outputs.k] + f($.steps.a) + $.steps[$.name].c`,
+	)
+	assert.NoError(t, err)
+	locations := expr.FindReferences(expressions.Path{"$", "steps", "a", "outputs"})
+	assert.Equals(t, len(locations), 2)
+	assert.Equals(t, locations[0].Path, expressions.Path{"$", "steps", "a", "outputs", "success", "x"})
+	assert.Equals(t, locations[0].Start.Column, 1)
+	assert.Equals(t, locations[0].End.Column, 27)
+	assert.Equals(t, locations[1].Path, expressions.Path{"$", "steps", "a", "outputs", "k"})
+	assert.Equals(t, locations[1].Start.Column, 48)
+
+	// The root may be omitted, and integer items match integer keys of any type.
+	expr, err = expressions.New(`$.list[1].a + $.list[2] + $.list[1]`)
+	assert.NoError(t, err)
+	locations = expr.FindReferences(expressions.Path{"list", 1})
+	assert.Equals(t, len(locations), 2)
+	assert.Equals(t, locations[0].Path, expressions.Path{"$", "list", int64(1), "a"})
+	assert.Equals(t, locations[1].Start.Column, 27)
+
+	assert.Equals(t, len(expr.FindReferences(expressions.Path{"other"})), 0)
+	assert.Equals(t, len(expr.FindReferences(nil)), 3)
+
+	// The key k in $.a[k] is a key of $.a, not a reference to $.k.
+	expr, err = expressions.New(`$.a[k]`)
+	assert.NoError(t, err)
+	assert.Equals(t, len(expr.FindReferences(expressions.Path{"k"})), 0)
+	assert.Equals(t, len(expr.FindReferences(expressions.Path{"a"})), 1)
+}
@@ -0,0 +1,187 @@
+package expressions
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// IncrementalEvaluation evaluates an expression repeatedly on data that changes between evaluations, such as a
+// condition that is checked each time a step of a workflow finishes. The results of the parts of the expression are
+// kept between evaluations, and Invalidate drops the results that depend on the paths that changed, so the next
+// evaluation only evaluates the parts of the expression that are affected by the change.
+//
+// Calls to functions that are not pure, see PureFunction, are evaluated each time, together with the parts of the
+// expression that contain them. An IncrementalEvaluation can be used concurrently, but its evaluations are serialized.
+type IncrementalEvaluation struct {
+	expression      expression
+	functions       map[string]schema.CallableFunction
+	workflowContext map[string][]byte
+	// paths holds the paths of the root data that each node that can be memoized reads.
+	paths map[ast.Node][]Path
+
+	lock sync.Mutex
+	// results holds the results of the nodes evaluated since they were last invalidated.
+	results map[ast.Node]any
+}
+
+func (e expression) Incremental(
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) *IncrementalEvaluation {
+	i := &IncrementalEvaluation{
+		expression:      e,
+		functions:       functions,
+		workflowContext: workflowContext,
+		paths:           map[ast.Node][]Path{},
+		results:         map[ast.Node]any{},
+	}
+	i.collectPaths(e.ast, nil)
+	return i
+}
+
+// collectPaths stores the paths of the root data the node and its children read, and returns the paths of the node.
+// The base holds the paths of the data bare identifiers are looked up in, or nil for the root data. It returns false
+// if the node calls a function that is not pure, so its result cannot be kept.
+func (i *IncrementalEvaluation) collectPaths(node ast.Node, base []Path) ([]Path, bool) {
+	var paths []Path
+	memoizable := true
+	collect := func(child ast.Node, childBase []Path) []Path {
+		childPaths, childMemoizable := i.collectPaths(child, childBase)
+		paths = append(paths, childPaths...)
+		memoizable = memoizable && childMemoizable
+		return childPaths
+	}
+	switch n := node.(type) {
+	case ast.ValueLiteral:
+		// Literals are not memoized, since they are not evaluated.
+		return nil, true
+	case *ast.DotNotation:
+		if n.Chain != nil {
+			paths = append(paths, accessChainPath(n.Chain))
+			break
+		}
+		leftPaths := collect(n.LeftAccessibleNode, base)
+		if _, isIdentifier := n.RightAccessIdentifier.(*ast.Identifier); !isIdentifier {
+			collect(n.RightAccessIdentifier, leftPaths)
+		}
+	case *ast.BracketAccessor:
+		if n.Chain != nil {
+			paths = append(paths, accessChainPath(n.Chain))
+			break
+		}
+		// The key is evaluated on the value on the left, so its bare identifiers are fields of that value.
+		leftPaths := collect(n.LeftNode, base)
+		collect(n.RightExpression, leftPaths)
+	case *ast.Identifier:
+		switch {
+		case n.IdentifierName == "$":
+			paths = append(paths, Path{"$"})
+		case n.RootName() == ast.VariablesRootName:
+		case n.IsRoot():
+			paths = append(paths, Path{"$", n.RootName()})
+		case base != nil:
+			paths = append(paths, base...)
+		default:
+			paths = append(paths, Path{"$", n.IdentifierName})
+		}
+	case *ast.FunctionCall:
+		function, found := i.functions[n.FuncIdentifier.IdentifierName]
+		memoizable = found && isPure(function)
+		for _, argument := range n.ArgumentInputs.Arguments {
+			collect(argument, nil)
+		}
+	default:
+		// Operations evaluate their operands on the root data.
+		for _, child := range ast.Children(node) {
+			collect(child, nil)
+		}
+	}
+	if memoizable {
+		i.paths[node] = paths
+	}
+	return paths, memoizable
+}
+
+// accessChainPath returns the path of the root data the access chain reads.
+func accessChainPath(chain *ast.AccessChain) Path {
+	path := make(Path, 0, len(chain.Steps)+1)
+	path = append(path, "$")
+	for _, step := range chain.Steps {
+		path = append(path, step.Key)
+	}
+	return path
+}
+
+// Expression returns the expression that is evaluated.
+func (i *IncrementalEvaluation) Expression() Expression {
+	return i.expression
+}
+
+// Invalidate drops the kept results of the parts of the expression that read the changed paths, or values inside
+// them. The paths start with the root ($), like the paths returned by Expression.Dependencies. Invalidate must be
+// called for each change of the data before the next evaluation, otherwise the evaluation may return a result
+// computed from the old data.
+func (i *IncrementalEvaluation) Invalidate(changedPaths ...Path) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	for node := range i.results {
+		for _, path := range i.paths[node] {
+			if slices.ContainsFunc(changedPaths, func(changedPath Path) bool {
+				return pathsOverlap(path, changedPath)
+			}) {
+				delete(i.results, node)
+				break
+			}
+		}
+	}
+}
+
+// Reset drops all kept results, so the next evaluation evaluates the whole expression.
+func (i *IncrementalEvaluation) Reset() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	clear(i.results)
+}
+
+// pathsOverlap returns true if one of the paths is the start of the other, so a change of the value at one path
+// changes the value at the other. The keys are compared by their printed values, so a path built with other integer
+// types than the expression, such as int instead of int64, still overlaps.
+func pathsOverlap(a Path, b Path) bool {
+	for j := 0; j < len(a) && j < len(b); j++ {
+		if a[j] != b[j] && fmt.Sprint(a[j]) != fmt.Sprint(b[j]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate evaluates the expression on the data, reusing the results of the parts of the expression that were not
+// invalidated since they were evaluated. The result is the same as the result of Expression.Evaluate for the data.
+func (i *IncrementalEvaluation) Evaluate(data any) (result any, err error) {
+	defer recoverInternalError("evaluating the expression", &err)
+	if err := i.expression.options.Policy.check(i.expression.ast); err != nil {
+		return nil, err
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	context := i.expression.newEvaluateContext(data, i.functions, i.workflowContext)
+	context.incremental = i
+	return context.evaluateTraced(i.expression.ast, data)
+}
+
+// cachedResult returns the kept result of the node, if it has one.
+func (i *IncrementalEvaluation) cachedResult(node ast.Node) (any, bool) {
+	result, found := i.results[node]
+	return result, found
+}
+
+// keepResult keeps the result of the node for the next evaluations, if the node can be memoized.
+func (i *IncrementalEvaluation) keepResult(node ast.Node, result any) {
+	if _, memoizable := i.paths[node]; memoizable {
+		i.results[node] = result
+	}
+}
@@ -0,0 +1,134 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// newCountingFunction returns a function that doubles its argument, and counts how often it is called.
+func newCountingFunction(t *testing.T, calls *int) schema.CallableFunction {
+	function, err := schema.NewCallableFunction(
+		"count",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		nil,
+		func(a int64) (int64, error) {
+			*calls++
+			return a * 2, nil
+		},
+	)
+	assert.NoError(t, err)
+	return function
+}
+
+func TestIncremental(t *testing.T) {
+	var leftCalls, rightCalls int
+	functions := map[string]schema.CallableFunction{
+		"left":  expressions.NewPureFunction(newCountingFunction(t, &leftCalls)),
+		"right": expressions.NewPureFunction(newCountingFunction(t, &rightCalls)),
+	}
+	expr, err := expressions.New(`left($.steps.a.output) + right($steps.b.output)`)
+	assert.NoError(t, err)
+	incremental := expr.Incremental(functions, nil)
+	data := map[string]any{
+		"steps": map[string]any{
+			"a": map[string]any{"output": int64(1)},
+			"b": map[string]any{"output": int64(2)},
+		},
+	}
+	result, err := incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(6)))
+	assert.Equals(t, leftCalls, 1)
+	assert.Equals(t, rightCalls, 1)
+
+	// Nothing changed, so the result is reused.
+	result, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(6)))
+	assert.Equals(t, leftCalls, 1)
+	assert.Equals(t, rightCalls, 1)
+
+	// Only the call that reads the changed path is evaluated again.
+	data["steps"].(map[string]any)["b"] = map[string]any{"output": int64(3)}
+	incremental.Invalidate(expressions.Path{"$", "steps", "b"})
+	result, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(8)))
+	assert.Equals(t, leftCalls, 1)
+	assert.Equals(t, rightCalls, 2)
+
+	// A change of a value that contains the paths affects both calls.
+	incremental.Invalidate(expressions.Path{"$", "steps"})
+	_, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, leftCalls, 2)
+	assert.Equals(t, rightCalls, 3)
+
+	incremental.Reset()
+	_, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, leftCalls, 3)
+	assert.Equals(t, rightCalls, 4)
+}
+
+func TestIncremental_ComputedKeys(t *testing.T) {
+	var calls int
+	functions := map[string]schema.CallableFunction{
+		"count": expressions.NewPureFunction(newCountingFunction(t, &calls)),
+	}
+	expr, err := expressions.New(`count($.list[$.index]) + count($.other)`)
+	assert.NoError(t, err)
+	incremental := expr.Incremental(functions, nil)
+	data := map[string]any{"list": []any{int64(1), int64(2)}, "index": int64(0), "other": int64(5)}
+	result, err := incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(12)))
+	assert.Equals(t, calls, 2)
+
+	// Paths with other integer types than the expression still match.
+	data["list"].([]any)[0] = int64(4)
+	incremental.Invalidate(expressions.Path{"$", "list", 0})
+	result, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(18)))
+	assert.Equals(t, calls, 3)
+
+	data["index"] = int64(1)
+	incremental.Invalidate(expressions.Path{"$", "index"})
+	result, err = incremental.Evaluate(data)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(14)))
+	assert.Equals(t, calls, 4)
+}
+
+func TestIncremental_ImpureFunctions(t *testing.T) {
+	var calls int
+	functions := map[string]schema.CallableFunction{"count": newCountingFunction(t, &calls)}
+	expr, err := expressions.New(`count($.simple_int)`)
+	assert.NoError(t, err)
+	incremental := expr.Incremental(functions, nil)
+	data := map[string]any{"simple_int": int64(1)}
+	for i := 1; i <= 2; i++ {
+		result, err := incremental.Evaluate(data)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any(int64(2)))
+		assert.Equals(t, calls, i)
+	}
+}
+
+func TestIncremental_Errors(t *testing.T) {
+	expr, err := expressions.New(`$.a.b`)
+	assert.NoError(t, err)
+	incremental := expr.Incremental(nil, nil)
+	_, err = incremental.Evaluate(map[string]any{"a": map[string]any{}})
+	assert.Error(t, err)
+	// Failed results are not kept.
+	result, err := incremental.Evaluate(map[string]any{"a": map[string]any{"b": "x"}})
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("x"))
+}
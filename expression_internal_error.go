@@ -0,0 +1,44 @@
+package expressions
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// InternalError is returned instead of panicking when an unexpected state is reached, such as a bug in this package,
+// or a function called by the expression panicking. This way, a malformed expression or unexpected data cannot crash
+// the program evaluating it. Use errors.Unwrap or errors.As to get the error passed to panic, if it was an error.
+type InternalError struct {
+	// Operation describes what was being done when the panic happened, such as "evaluating the expression".
+	Operation string
+	// Value is the value passed to panic.
+	Value any
+	// Stack is the stack trace of the goroutine at the time of the panic.
+	Stack []byte
+}
+
+func (e *InternalError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *InternalError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize("internal error while %s: %v", e.Operation, e.Value)
+}
+
+// Unwrap returns the value passed to panic if it is an error, or nil otherwise.
+func (e *InternalError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func (e *InternalError) ErrorCode() ErrorCode {
+	return ErrorCodeInternal
+}
+
+// recoverInternalError converts a panic into an *InternalError, and stores it in err. It must be deferred directly,
+// so it can recover the panic.
+func recoverInternalError(operation string, err *error) {
+	if value := recover(); value != nil {
+		*err = &InternalError{Operation: operation, Value: value, Stack: debug.Stack()}
+	}
+}
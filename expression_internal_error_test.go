@@ -0,0 +1,80 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func newPanickingFunction(t *testing.T) schema.CallableFunction {
+	function, err := schema.NewCallableFunction(
+		"explode",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a int64) int64 {
+			panic(errors.New("exploded"))
+		},
+	)
+	assert.NoError(t, err)
+	return function
+}
+
+func TestInternalError_FunctionPanics(t *testing.T) {
+	function := newPanickingFunction(t)
+	expr, err := expressions.New(`explode($.simple_int) + 1`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(map[string]any{"simple_int": int64(1)}, map[string]schema.CallableFunction{"explode": function}, nil)
+	var internalErr *expressions.InternalError
+	assert.Equals(t, errors.As(err, &internalErr), true)
+	assert.Equals(t, internalErr.Operation, "evaluating the expression")
+	assert.Contains(t, err.Error(), "exploded")
+	assert.Equals(t, len(internalErr.Stack) > 0, true)
+	assert.Equals(t, errors.Unwrap(err).Error(), "exploded")
+
+	// The panics of functions called in other goroutines are returned too.
+	withTimeout, err := expressions.NewFunctionWithCallPolicy(function, expressions.CallPolicy{Timeout: time.Second})
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(map[string]any{"simple_int": int64(1)}, map[string]schema.CallableFunction{"explode": withTimeout}, nil)
+	assert.Equals(t, errors.As(err, &internalErr), true)
+	assert.Equals(t, internalErr.Operation, "calling the function 'explode'")
+
+	deferred := expressions.NewDeferred(func() (any, error) {
+		panic("deferred exploded")
+	})
+	_, err = deferred.Await()
+	assert.Equals(t, errors.As(err, &internalErr), true)
+	assert.Equals(t, internalErr.Value, any("deferred exploded"))
+	assert.Equals(t, errors.Unwrap(err), nil)
+}
+
+func TestInternalError_RewritePanics(t *testing.T) {
+	expr, err := expressions.New(`$.a`)
+	assert.NoError(t, err)
+	_, err = expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		panic("rewrite exploded")
+	})
+	var internalErr *expressions.InternalError
+	assert.Equals(t, errors.As(err, &internalErr), true)
+	assert.Equals(t, err.Error(), "internal error while rewriting the paths of the expression: rewrite exploded")
+}
+
+func TestEvaluate_IntegerDivisionByZero(t *testing.T) {
+	testCases := map[string]string{
+		`$.a / $.b`: "integer division by zero",
+		`$.a % $.b`: "integer modulus by zero",
+		`1 / 0`:     "integer division by zero",
+	}
+	for expression, expectedError := range testCases {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(map[string]any{"a": int64(1), "b": int64(0)}, nil, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), expectedError)
+	}
+}
@@ -0,0 +1,80 @@
+package expressions
+
+import "go.flow.arcalot.io/expressions/ast"
+
+// Inventory lists the literals, function calls, and references of an expression, see Expression.Inventory.
+type Inventory struct {
+	// Literals are the literal values, in the order they are written. The literal keys of references, such as "a" in
+	// $["a"], are part of the references instead.
+	Literals []Literal
+	// Calls are the function calls, in the order they are written. Calls in the arguments of a call come after it.
+	Calls []Call
+	// References are the references to the data, like Expression.FindReferences returns them, in the order they are
+	// written.
+	References []Location
+}
+
+// Literal is a literal value written in an expression.
+type Literal struct {
+	// Value is the value of the literal, such as a string, an int64, a float64, or a bool. Unit literals, such as 5m,
+	// have their converted value.
+	Value any
+	// Start is the position of the first character of the literal.
+	Start ast.Position
+	// End is the position directly after the last character of the literal.
+	End ast.Position
+}
+
+// Call is a function call written in an expression.
+type Call struct {
+	// Function is the name of the called function.
+	Function string
+	// Arguments is the number of arguments passed to the function.
+	Arguments int
+	// Start is the position of the first character of the call.
+	Start ast.Position
+	// End is the position directly after the closing parenthesis of the call.
+	End ast.Position
+}
+
+func (e expression) Inventory() Inventory {
+	var result Inventory
+	result.add(e.ast, false)
+	return result
+}
+
+// add adds the literals, calls, and references of the node and its descendants, which are found in a single walk of
+// the tree. The relative parameter indicates that the node is evaluated on the value accessed by a bracket accessor,
+// see collectReferences.
+func (i *Inventory) add(node ast.Node, relative bool) {
+	if segments, isReference := referenceSegments(node); isReference {
+		ref := reference{segments: segments, node: node}
+		if !relative || !ref.startsWithKey() {
+			i.References = append(i.References, Location{Path: ref.path(), Start: node.Start(), End: node.End()})
+		}
+		return
+	}
+	switch n := node.(type) {
+	case ast.ValueLiteral:
+		i.Literals = append(i.Literals, Literal{Value: n.Value(), Start: node.Start(), End: node.End()})
+	case *ast.DotNotation:
+		// The right identifier is a field name, not a reference to the root.
+		i.add(n.LeftAccessibleNode, relative)
+	case *ast.BracketAccessor:
+		i.add(n.LeftNode, relative)
+		i.add(n.RightExpression, true)
+	case *ast.FunctionCall:
+		i.Calls = append(i.Calls, Call{
+			Function:  n.FuncIdentifier.IdentifierName,
+			Arguments: len(n.ArgumentInputs.Arguments),
+			Start:     n.Start(),
+			End:       n.End(),
+		})
+		// The identifier is a function name, not a reference to the root.
+		i.add(n.ArgumentInputs, false)
+	default:
+		for _, child := range ast.Children(node) {
+			i.add(child, false)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestInventory(t *testing.T) {
+	expr, err := expressions.New(
+		`toUpper(concat($.foo.bar, "secret")) == $.list["a"] && f()[2] > 5m && !g($.simple_str, 1.5, true)`,
+	)
+	assert.NoError(t, err)
+	inventory := expr.Inventory()
+
+	assert.Equals(t, len(inventory.Literals), 5)
+	assert.Equals(t, inventory.Literals[0].Value, any("secret"))
+	assert.Equals(t, inventory.Literals[0].Start.Column, 27)
+	assert.Equals(t, inventory.Literals[1].Value, any(int64(2)))
+	assert.Equals(t, inventory.Literals[2].Value, any(int64(300)))
+	assert.Equals(t, inventory.Literals[3].Value, any(1.5))
+	assert.Equals(t, inventory.Literals[4].Value, any(true))
+
+	assert.Equals(t, len(inventory.Calls), 4)
+	assert.Equals(t, inventory.Calls[0], expressions.Call{
+		Function:  "toUpper",
+		Arguments: 1,
+		Start:     inventory.Calls[0].Start,
+		End:       inventory.Calls[0].End,
+	})
+	assert.Equals(t, inventory.Calls[0].Start.Column, 1)
+	assert.Equals(t, inventory.Calls[0].End.Column, 37)
+	assert.Equals(t, inventory.Calls[1].Function, "concat")
+	assert.Equals(t, inventory.Calls[1].Arguments, 2)
+	assert.Equals(t, inventory.Calls[2].Function, "f")
+	assert.Equals(t, inventory.Calls[2].Arguments, 0)
+	assert.Equals(t, inventory.Calls[3].Function, "g")
+	assert.Equals(t, inventory.Calls[3].Arguments, 3)
+
+	// The keys of references are part of the paths, and references are the same as found by FindReferences.
+	assert.Equals(t, inventory.References, expr.FindReferences(nil))
+	assert.Equals(t, len(inventory.References), 3)
+	assert.Equals(t, inventory.References[1].Path, expressions.Path{"$", "list", "a"})
+
+	// The key k in $.a[k] is a key of $.a, not a reference to $.k.
+	expr, err = expressions.New(`$.a[k]`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.Inventory().References, expr.FindReferences(nil))
+	assert.Equals(t, len(expr.Inventory().References), 1)
+}
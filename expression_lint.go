@@ -0,0 +1,397 @@
+package expressions
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// LintRule identifies the kind of issue a lint finding reports.
+type LintRule string
+
+const (
+	// LintConstantComparison reports comparisons that always have the same result.
+	LintConstantComparison LintRule = "constant-comparison"
+	// LintDeadBranch reports logical operations where an operand has no effect on the result.
+	LintDeadBranch LintRule = "dead-branch"
+	// LintDeprecatedField reports references to fields whose description marks them as deprecated.
+	LintDeprecatedField LintRule = "deprecated-field"
+	// LintRedundantParentheses reports parentheses that do not change the evaluation order.
+	LintRedundantParentheses LintRule = "redundant-parentheses"
+	// LintSuspiciousNumeric reports integer and float operations that may not behave as intended, such as mixing
+	// integers and floats, integer division, or comparing floats for equality.
+	LintSuspiciousNumeric LintRule = "suspicious-numeric"
+)
+
+// LintFinding is a non-fatal issue found in an expression.
+type LintFinding struct {
+	Rule    LintRule
+	Message string
+	// Start is the position of the start of the code the finding is about.
+	Start ast.Position
+	// End is the position directly after the code the finding is about.
+	End ast.Position
+	// format and args are the format and the arguments of the message, to translate it.
+	format string
+	args   []any
+}
+
+// String returns the position, the message, and the rule of the finding.
+func (f LintFinding) String() string {
+	return f.Start.String() + ": " + f.Message + " (" + string(f.Rule) + ")"
+}
+
+// Localize returns the message of the finding translated by the localizer.
+func (f LintFinding) Localize(localizer Localizer) string {
+	return localizeMessage(localizer, f.Message, f.format, f.args)
+}
+
+// Lint reports issues in the expression that don't prevent it from being used, but likely indicate a mistake. The
+// scope and functions are used for the checks that need type information, and may be nil, in which case only the
+// types of literals are known. Use Validate to check for errors. The findings are ordered by position.
+func Lint(expr Expression, scope schema.Scope, functions map[string]schema.Function) []LintFinding {
+	impl, ok := expr.(*expression)
+	if !ok {
+		parsed, err := New(expr.String())
+		if err != nil {
+			return nil
+		}
+		impl = parsed.(*expression)
+	}
+	var rootType schema.Type = scope
+	if scope == nil {
+		rootType = schema.NewScopeSchema(schema.NewObjectSchema("root", map[string]*schema.PropertySchema{}))
+	}
+	l := &linter{
+		dependencyContext: &dependencyContext{
+			rootType: rootType,
+			rootPath: PathTree{
+				PathItem:     "$",
+				NodeType:     DataRootNode,
+				ResolvedType: rootType,
+			},
+			functions: functions,
+		},
+	}
+	ast.Inspect(impl.ast, func(node ast.Node) bool {
+		if operation, isBinary := node.(*ast.BinaryOperation); isBinary {
+			l.lintBinaryOperation(operation)
+		}
+		return true
+	})
+	if scope != nil {
+		for _, ref := range findReferences(impl.ast) {
+			l.lintDeprecatedFields(scope, ref)
+		}
+	}
+	l.lintParentheses(impl)
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i].Start, l.findings[j].Start
+		return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+	})
+	return l.findings
+}
+
+type linter struct {
+	dependencyContext *dependencyContext
+	findings          []LintFinding
+}
+
+func (l *linter) report(rule LintRule, start ast.Position, end ast.Position, format string, args ...any) {
+	l.findings = append(l.findings, LintFinding{
+		Rule:    rule,
+		Message: fmt.Sprintf(format, args...),
+		Start:   start,
+		End:     end,
+		format:  format,
+		args:    args,
+	})
+}
+
+// typeID returns the type ID of the node, or an empty string if it cannot be resolved.
+func (l *linter) typeID(node ast.Node) schema.TypeID {
+	return l.dependencyContext.typeID(node)
+}
+
+func (l *linter) lintBinaryOperation(node *ast.BinaryOperation) {
+	switch node.Operation {
+	case ast.EqualTo, ast.NotEqualTo, ast.GreaterThan, ast.LessThan, ast.GreaterThanEqualTo, ast.LessThanEqualTo:
+		l.lintConstantComparison(node)
+		l.lintNumericOperands(node)
+	case ast.And, ast.Or:
+		l.lintDeadBranch(node)
+	case ast.Add, ast.Subtract, ast.Multiply, ast.Divide, ast.Modulus, ast.Power:
+		l.lintNumericOperands(node)
+	}
+}
+
+func (l *linter) lintConstantComparison(node *ast.BinaryOperation) {
+	_, leftIsLiteral := node.LeftNode.(ast.ValueLiteral)
+	_, rightIsLiteral := node.RightNode.(ast.ValueLiteral)
+	if leftIsLiteral && rightIsLiteral {
+		context := evaluateContext{}
+		result, err := context.evaluate(node, nil)
+		if err == nil {
+			l.report(LintConstantComparison, node.Start(), node.End(),
+				"comparison of two literals is always %v", result)
+		}
+		return
+	}
+	var leftCanonical, rightCanonical strings.Builder
+	writeCanonical(&leftCanonical, node.LeftNode, true)
+	writeCanonical(&rightCanonical, node.RightNode, true)
+	if leftCanonical.String() != rightCanonical.String() || containsFunctionCall(node) {
+		return
+	}
+	result := node.Operation == ast.EqualTo ||
+		node.Operation == ast.GreaterThanEqualTo ||
+		node.Operation == ast.LessThanEqualTo
+	l.report(LintConstantComparison, node.Start(), node.End(),
+		"comparison of %s with itself is always %v", leftCanonical.String(), result)
+}
+
+// containsFunctionCall returns true if the node calls a function, which may return a different value each time.
+func containsFunctionCall(node ast.Node) bool {
+	found := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		if _, isFunctionCall := node.(*ast.FunctionCall); isFunctionCall {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func (l *linter) lintDeadBranch(node *ast.BinaryOperation) {
+	// The value for which a literal operand determines the result on its own.
+	deciding := node.Operation == ast.Or
+	for _, operand := range []ast.Node{node.LeftNode, node.RightNode} {
+		literal, isBoolean := operand.(*ast.BooleanLiteral)
+		if !isBoolean {
+			continue
+		}
+		if literal.BooleanValue == deciding {
+			l.report(LintDeadBranch, node.Start(), node.End(),
+				"the %s operation is always %v, so the other operand has no effect", node.Operation, deciding)
+		} else {
+			l.report(LintDeadBranch, literal.Start(), literal.End(),
+				"the literal %v has no effect on the result of the %s operation", literal.BooleanValue, node.Operation)
+		}
+		return
+	}
+}
+
+func (l *linter) lintNumericOperands(node *ast.BinaryOperation) {
+	leftType := l.typeID(node.LeftNode)
+	rightType := l.typeID(node.RightNode)
+	isNumeric := func(typeID schema.TypeID) bool {
+		return typeID == schema.TypeIDInt || typeID == schema.TypeIDFloat
+	}
+	switch {
+	case isNumeric(leftType) && isNumeric(rightType) && leftType != rightType:
+		l.report(LintSuspiciousNumeric, node.Start(), node.End(),
+			"the %s operation mixes %s and %s operands; convert one of them so that both have the same type",
+			node.Operation, leftType, rightType)
+	case node.Operation == ast.Divide && leftType == schema.TypeIDInt && rightType == schema.TypeIDInt:
+		l.report(LintSuspiciousNumeric, node.Start(), node.End(),
+			"integer division discards the remainder; use float operands if the fraction is needed")
+	case (node.Operation == ast.EqualTo || node.Operation == ast.NotEqualTo) &&
+		leftType == schema.TypeIDFloat && rightType == schema.TypeIDFloat:
+		l.report(LintSuspiciousNumeric, node.Start(), node.End(),
+			"comparing floats with %s is unreliable due to rounding errors", node.Operation)
+	}
+}
+
+// lintDeprecatedFields reports each field in the reference that is marked as deprecated in its description.
+func (l *linter) lintDeprecatedFields(scope schema.Scope, ref reference) {
+	forEachDeprecatedField(scope, ref, func(segment int, fieldName string, description string) {
+		itemNode := referenceItemNode(ref.segments[segment].node)
+		l.report(LintDeprecatedField, itemNode.Start(), itemNode.End(),
+			"the field %q is deprecated: %s", fieldName, description)
+	})
+}
+
+// forEachDeprecatedField calls report with the index of the segment, the name, and the description of each field in
+// the reference that is marked as deprecated in its description.
+func forEachDeprecatedField(
+	scope schema.Scope,
+	ref reference,
+	report func(segment int, fieldName string, description string),
+) {
+	var currentType schema.Type = scope
+	for i := 1; i < len(ref.segments); i++ {
+		segment := ref.segments[i]
+		switch currentType.TypeID() {
+		case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+			fieldName, isString := segment.pathItem.(string)
+			if !isString {
+				return
+			}
+			property, found := currentType.(schema.Object).Properties()[fieldName]
+			if !found {
+				return
+			}
+			if description := propertyDescription(property); isDeprecated(description) {
+				report(i, fieldName, description)
+			}
+			currentType = property.Type()
+		case schema.TypeIDMap:
+			currentType = currentType.(schema.UntypedMap).Values()
+		case schema.TypeIDList:
+			currentType = currentType.(schema.UntypedList).Items()
+		default:
+			return
+		}
+	}
+}
+
+// propertyDescription returns the description of the property, or an empty string if it has none.
+func propertyDescription(property *schema.PropertySchema) string {
+	display := property.Display()
+	if display == nil {
+		return ""
+	}
+	if value := reflect.ValueOf(display); value.Kind() == reflect.Pointer && value.IsNil() {
+		return ""
+	}
+	description := display.Description()
+	if description == nil {
+		return ""
+	}
+	return *description
+}
+
+// isDeprecated returns true if the description starts with "Deprecated", the convention for marking deprecated
+// fields.
+func isDeprecated(description string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(description)), "deprecated")
+}
+
+// referenceItemNode returns the node of the last path item of a reference segment, such as the identifier on the
+// right of a dot notation.
+func referenceItemNode(node ast.Node) ast.Node {
+	switch n := node.(type) {
+	case *ast.DotNotation:
+		return n.RightAccessIdentifier
+	case *ast.BracketAccessor:
+		return n.RightExpression
+	default:
+		return node
+	}
+}
+
+// lintParentheses reports parentheses that are not needed. Since the syntax tree doesn't keep the parentheses, this
+// check works on the tokens of the expression.
+func (l *linter) lintParentheses(e *expression) {
+	tokens, err := ast.Lex(e.expression)
+	if err != nil {
+		return
+	}
+	// Find the matching parentheses, skipping function calls.
+	var groupStarts []int
+	var groups [][2]int
+	for i, token := range tokens {
+		switch token.ID {
+		case ast.ParenthesesStartToken:
+			groupStarts = append(groupStarts, i)
+		case ast.ParenthesesEndToken:
+			if len(groupStarts) == 0 {
+				return
+			}
+			start := groupStarts[len(groupStarts)-1]
+			groupStarts = groupStarts[:len(groupStarts)-1]
+			if start > 0 && tokens[start-1].ID == ast.IdentifierToken {
+				continue
+			}
+			groups = append(groups, [2]int{start, i})
+		}
+	}
+	for _, group := range groups {
+		start, end := group[0], group[1]
+		if !isCompleteOperand(tokens, start, end) &&
+			(hasOperatorAtTopLevel(tokens[start+1:end]) || isParenthesizedGroup(tokens, start+1, end-1)) {
+			continue
+		}
+		l.report(LintRedundantParentheses, e.tokenPosition(tokens[start].Start), e.tokenPosition(tokens[end].End),
+			"the parentheses are redundant")
+	}
+}
+
+// isCompleteOperand returns true if the tokens between start and end form a complete expression, argument, or key,
+// where parentheses never change the evaluation order.
+func isCompleteOperand(tokens []ast.Token, start int, end int) bool {
+	before := start == 0 ||
+		tokens[start-1].ID == ast.ParenthesesStartToken ||
+		tokens[start-1].ID == ast.BracketAccessDelimiterStartToken ||
+		tokens[start-1].ID == ast.ListSeparatorToken
+	after := end == len(tokens)-1 ||
+		tokens[end+1].ID == ast.ParenthesesEndToken ||
+		tokens[end+1].ID == ast.BracketAccessDelimiterEndToken ||
+		tokens[end+1].ID == ast.ListSeparatorToken
+	return before && after
+}
+
+// isParenthesizedGroup returns true if the tokens from start to end, inclusive, are a single parenthesized group.
+func isParenthesizedGroup(tokens []ast.Token, start int, end int) bool {
+	if start >= end || tokens[start].ID != ast.ParenthesesStartToken || tokens[end].ID != ast.ParenthesesEndToken {
+		return false
+	}
+	depth := 0
+	for i := start; i <= end; i++ {
+		switch tokens[i].ID {
+		case ast.ParenthesesStartToken:
+			depth++
+		case ast.ParenthesesEndToken:
+			depth--
+			if depth == 0 && i != end {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// operatorTokens are the tokens of unary and binary operators.
+var operatorTokens = map[ast.TokenID]bool{
+	ast.PlusToken:        true,
+	ast.NegationToken:    true,
+	ast.AsteriskToken:    true,
+	ast.DivideToken:      true,
+	ast.ModulusToken:     true,
+	ast.PowerToken:       true,
+	ast.GreaterThanToken: true,
+	ast.LessThanToken:    true,
+	ast.EqualsToken:      true,
+	ast.NotToken:         true,
+	ast.AndToken:         true,
+	ast.OrToken:          true,
+}
+
+// hasOperatorAtTopLevel returns true if the tokens contain an operator outside parentheses and brackets.
+func hasOperatorAtTopLevel(tokens []ast.Token) bool {
+	depth := 0
+	for _, token := range tokens {
+		switch {
+		case token.ID == ast.ParenthesesStartToken || token.ID == ast.BracketAccessDelimiterStartToken:
+			depth++
+		case token.ID == ast.ParenthesesEndToken || token.ID == ast.BracketAccessDelimiterEndToken:
+			depth--
+		case depth == 0 && operatorTokens[token.ID]:
+			return true
+		}
+	}
+	return false
+}
+
+// tokenPosition applies the position offsets of the expression options to a position returned by ast.Lex.
+func (e expression) tokenPosition(position ast.Position) ast.Position {
+	if position.Line == 1 {
+		position.Column += e.options.ColumnOffset
+	}
+	position.Line += e.options.LineOffset
+	return position
+}
@@ -0,0 +1,161 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func lint(t *testing.T, expression string, scope schema.Scope) []expressions.LintFinding {
+	expr, err := expressions.New(expression)
+	assert.NoError(t, err)
+	return expressions.Lint(expr, scope, nil)
+}
+
+func lintRules(findings []expressions.LintFinding) []expressions.LintRule {
+	rules := make([]expressions.LintRule, len(findings))
+	for i, finding := range findings {
+		rules[i] = finding.Rule
+	}
+	return rules
+}
+
+func TestLint_NoFindings(t *testing.T) {
+	for _, expression := range []string{
+		`$.foo.bar`,
+		`$.simple_int + 1 > 2`,
+		`($.simple_int + 1) * 2`,
+		`$.simple_bool && $.simple_int > 1`,
+		`f($.foo.bar, 1)`,
+	} {
+		findings := lint(t, expression, testScope)
+		assert.Equals(t, len(findings), 0)
+	}
+}
+
+func TestLint_ConstantComparison(t *testing.T) {
+	findings := lint(t, `1 < 2`, nil)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintConstantComparison})
+	assert.Contains(t, findings[0].Message, "always true")
+
+	findings = lint(t, `$.simple_int != $.simple_int`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintConstantComparison})
+	assert.Contains(t, findings[0].Message, "always false")
+
+	findings = lint(t, `$.foo.bar == $["foo"]["bar"]`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintConstantComparison})
+	assert.Contains(t, findings[0].Message, "always true")
+
+	// Functions may return different values for each call.
+	findings = lint(t, `f() == f()`, testScope)
+	assert.Equals(t, len(findings), 0)
+}
+
+func TestLint_DeadBranch(t *testing.T) {
+	findings := lint(t, `false && $.simple_bool`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintDeadBranch})
+	assert.Contains(t, findings[0].Message, "always false")
+
+	findings = lint(t, `$.simple_bool || true`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintDeadBranch})
+	assert.Contains(t, findings[0].Message, "always true")
+
+	findings = lint(t, `$.simple_bool && true`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintDeadBranch})
+	assert.Contains(t, findings[0].Message, "no effect")
+	assert.Equals(t, findings[0].Start.String(), "1:18")
+	assert.Equals(t, findings[0].End.String(), "1:22")
+}
+
+func TestLint_RedundantParentheses(t *testing.T) {
+	testCases := map[string]struct {
+		expression string
+		positions  []string
+	}{
+		"whole":           {`($.a + 1)`, []string{"1:1"}},
+		"single-operand":  {`($.a) + 1`, []string{"1:1"}},
+		"double":          {`(($.a + 1)) * 2`, []string{"1:2"}},
+		"argument":        {`f(1, ($.a + 1))`, []string{"1:6"}},
+		"key":             {`$.a[(1 + 2)]`, []string{"1:5"}},
+		"unary":           {`-(1)`, []string{"1:2"}},
+		"needed":          {`($.a + 1) * 2`, nil},
+		"needed-unary":    {`!($.a && $.b)`, nil},
+		"function-call":   {`f()`, nil},
+		"nested-function": {`f(g(1))`, nil},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			findings := lint(t, tc.expression, nil)
+			var positions []string
+			for _, finding := range findings {
+				if finding.Rule == expressions.LintRedundantParentheses {
+					positions = append(positions, finding.Start.String())
+				}
+			}
+			assert.Equals(t, positions, tc.positions)
+		})
+	}
+}
+
+func TestLint_RedundantParenthesesOffset(t *testing.T) {
+	expr, err := expressions.NewWithOptions(`($.a)`, expressions.Options{LineOffset: 4, ColumnOffset: 10})
+	assert.NoError(t, err)
+	findings := expressions.Lint(expr, nil, nil)
+	assert.Equals(t, len(findings), 1)
+	assert.Equals(t, findings[0].Start.String(), "5:11")
+	assert.Equals(t, findings[0].End.String(), "5:16")
+}
+
+func TestLint_SuspiciousNumeric(t *testing.T) {
+	findings := lint(t, `$.simple_int / 2`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintSuspiciousNumeric})
+	assert.Contains(t, findings[0].Message, "integer division")
+
+	findings = lint(t, `$.simple_int * 1.5`, testScope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintSuspiciousNumeric})
+	assert.Contains(t, findings[0].Message, "mixes")
+
+	findings = lint(t, `1.5 * 2.0 == 3.0`, nil)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintSuspiciousNumeric})
+	assert.Contains(t, findings[0].Message, "unreliable")
+}
+
+func TestLint_DeprecatedField(t *testing.T) {
+	description := "Deprecated: use new_name instead."
+	scope := schema.NewScopeSchema(
+		schema.NewObjectSchema(
+			"root",
+			map[string]*schema.PropertySchema{
+				"old_name": schema.NewPropertySchema(
+					schema.NewStringSchema(nil, nil, nil),
+					schema.NewDisplayValue(nil, &description, nil),
+					false,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+				),
+				"new_name": schema.NewPropertySchema(
+					schema.NewStringSchema(nil, nil, nil),
+					nil,
+					false,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+				),
+			},
+		),
+	)
+	findings := lint(t, `$.new_name + $.old_name`, scope)
+	assert.Equals(t, lintRules(findings), []expressions.LintRule{expressions.LintDeprecatedField})
+	assert.Contains(t, findings[0].Message, "use new_name instead")
+	assert.Equals(t, findings[0].Start.String(), "1:16")
+	assert.Equals(t, findings[0].End.String(), "1:24")
+	assert.Contains(t, findings[0].String(), "(deprecated-field)")
+}
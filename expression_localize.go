@@ -0,0 +1,119 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Localizer translates the messages of errors and diagnostics, so engines can show them to users in their language.
+// Each message is identified by its English format, such as "unknown field %q", and is formatted with its arguments.
+// Formats that wrap an error with %w are identified with %v instead, as fmt.Sprintf formats them.
+// Pass it to LocalizeError, Diagnostic.Localize, or LintFinding.Localize.
+type Localizer interface {
+	// Localize returns the message with the English format and the arguments in the language of the localizer, or
+	// false if it has no translation, in which case the English message is used. Errors in the arguments are already
+	// translated to strings, so wrapped errors are translated too.
+	Localize(format string, args []any) (string, bool)
+}
+
+// Catalog is a Localizer that maps the English formats of messages to translated formats, which are formatted with
+// the same arguments like fmt.Sprintf does. Use argument indexes, such as %[2]s, if a translation needs the arguments
+// in a different order:
+//
+//	catalog := expressions.Catalog{
+//	    "%s at %s": "%s à %s",
+//	    "the function %q is deprecated": "la fonction %q est obsolète",
+//	}
+//	message := expressions.LocalizeError(err, catalog)
+type Catalog map[string]string
+
+// Localize returns the message formatted with the translated format, if the catalog has one.
+func (c Catalog) Localize(format string, args []any) (string, bool) {
+	translated, found := c[format]
+	if !found {
+		return "", false
+	}
+	return fmt.Sprintf(sprintfFormat(translated), args...), true
+}
+
+// LocalizableError is implemented by the errors whose message can be translated by a Localizer. All errors of this
+// package and its subpackages implement it, except errors that report a bug.
+type LocalizableError interface {
+	error
+	// LocalizedError returns the message of the error, with each message it is made of formatted by the localize
+	// function. Passing fmt.Sprintf returns the English message, like Error does.
+	LocalizedError(localize func(format string, args ...any) string) string
+}
+
+// LocalizeError returns the message of the error translated by the localizer. Errors that do not implement
+// LocalizableError, such as errors returned by functions that are not created with Errorf, keep their message. A nil
+// localizer returns the English message.
+func LocalizeError(err error, localizer Localizer) string {
+	localizable, isLocalizable := err.(LocalizableError)
+	if !isLocalizable || localizer == nil {
+		return err.Error()
+	}
+	return localizable.LocalizedError(localizeFunc(localizer))
+}
+
+// Errorf creates an error like fmt.Errorf does, whose message can be translated by LocalizeError with the format as
+// its ID. Functions can create their errors with it, so they are translated with the errors of the expressions that
+// call them.
+func Errorf(format string, args ...any) error {
+	return &formattedError{format: format, args: args, err: fmt.Errorf(format, args...)}
+}
+
+// formattedError is an error created by Errorf.
+type formattedError struct {
+	format string
+	args   []any
+	err    error
+}
+
+func (e *formattedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the error wrapped with %w, if any.
+func (e *formattedError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+func (e *formattedError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize(sprintfFormat(e.format), e.args...)
+}
+
+// localizeFunc returns the function that formats messages with the localizer. The errors in the arguments are
+// translated first, and messages without a translation are formatted in English.
+func localizeFunc(localizer Localizer) func(format string, args ...any) string {
+	return func(format string, args ...any) string {
+		localizedArgs := make([]any, len(args))
+		for i, arg := range args {
+			if err, isErr := arg.(error); isErr {
+				localizedArgs[i] = LocalizeError(err, localizer)
+			} else {
+				localizedArgs[i] = arg
+			}
+		}
+		if message, found := localizer.Localize(format, localizedArgs); found {
+			return message
+		}
+		return fmt.Sprintf(format, localizedArgs...)
+	}
+}
+
+// localizeMessage returns the message formatted with the format and the arguments by the localizer, or the message
+// if it has no format or the localizer is nil.
+func localizeMessage(localizer Localizer, message string, format string, args []any) string {
+	if format == "" || localizer == nil {
+		return message
+	}
+	return localizeFunc(localizer)(format, args...)
+}
+
+// sprintfFormat returns the format with %w replaced with %v, since only fmt.Errorf supports %w, so the format can be
+// passed to a localize function.
+func sprintfFormat(format string) string {
+	return strings.ReplaceAll(format, "%w", "%v")
+}
@@ -0,0 +1,57 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+var testCatalog = expressions.Catalog{
+	"%s at %s":                            "%s à %s",
+	"map key %v not found":                "clé %v introuvable",
+	"failed to parse expression: %s (%v)": "échec de l'analyse de l'expression %s (%v)",
+	"Expected token not found":            "Jeton attendu introuvable",
+	"the duration %s is converted to the integer %d, in seconds": "la durée %s est convertie en l'entier " +
+		"%d, en secondes",
+}
+
+func TestLocalizeError(t *testing.T) {
+	expr, err := expressions.New(`$.foo.missing`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(map[string]any{"foo": map[string]any{}}, nil, nil)
+	assert.Error(t, err)
+	assert.Equals(t, expressions.LocalizeError(err, testCatalog), "clé missing introuvable à 1:1")
+	assert.Equals(t, expressions.LocalizeError(err, nil), err.Error())
+
+	// Wrapped errors, including parse errors, are translated too, and messages without a translation are kept.
+	_, err = expressions.New(`$.a +`)
+	assert.Error(t, err)
+	localized := expressions.LocalizeError(err, testCatalog)
+	assert.Contains(t, localized, "échec de l'analyse de l'expression $.a + (Jeton attendu introuvable; expected any")
+	assert.Contains(t, localized, "Hint: the expression ended unexpectedly")
+	assert.Equals(t, expressions.LocalizeError(err, expressions.Catalog{}), err.Error())
+}
+
+func TestErrorf(t *testing.T) {
+	cause := errors.New("cause")
+	err := expressions.Errorf("failed to fetch %s (%w)", "a", cause)
+	assert.Equals(t, err.Error(), "failed to fetch a (cause)")
+	assert.Equals(t, errors.Is(err, cause), true)
+	localized := expressions.LocalizeError(err, expressions.Catalog{"failed to fetch %s (%v)": "%[2]v: %[1]s"})
+	assert.Equals(t, localized, "cause: a")
+}
+
+func TestDiagnostic_Localize(t *testing.T) {
+	var warnings []expressions.Diagnostic
+	_, err := expressions.NewWithOptions(`$.timeout < 5m`, expressions.Options{
+		OnWarning: func(warning expressions.Diagnostic) {
+			warnings = append(warnings, warning)
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, len(warnings), 1)
+	assert.Equals(t, warnings[0].Localize(testCatalog), "la durée 5m est convertie en l'entier 300, en secondes")
+	assert.Equals(t, warnings[0].Localize(nil), warnings[0].Message)
+}
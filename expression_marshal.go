@@ -0,0 +1,62 @@
+package expressions
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// MarshalJSON encodes the expression as a JSON string of the original expression.
+func (e expression) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.expression)
+}
+
+// MarshalText returns the original expression.
+func (e expression) MarshalText() ([]byte, error) {
+	return []byte(e.expression), nil
+}
+
+// Field holds an Expression so it can be used as a field in structs that are marshalled to and unmarshalled from
+// JSON or text. The expression is encoded as a string, and parsed when unmarshalling. A Field without an expression
+// is encoded as JSON null.
+type Field struct {
+	Expression
+}
+
+// MarshalJSON encodes the expression as a JSON string, or null if there is no expression.
+func (f Field) MarshalJSON() ([]byte, error) {
+	if f.Expression == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f.Expression.String())
+}
+
+// UnmarshalJSON parses the expression from a JSON string. JSON null results in an empty Field.
+func (f *Field) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		f.Expression = nil
+		return nil
+	}
+	var expressionString string
+	if err := json.Unmarshal(data, &expressionString); err != nil {
+		return Errorf("expressions must be encoded as JSON strings (%w)", err)
+	}
+	return f.UnmarshalText([]byte(expressionString))
+}
+
+// MarshalText returns the original expression, or an empty result if there is no expression.
+func (f Field) MarshalText() ([]byte, error) {
+	if f.Expression == nil {
+		return []byte{}, nil
+	}
+	return []byte(f.Expression.String()), nil
+}
+
+// UnmarshalText parses the expression from text.
+func (f *Field) UnmarshalText(data []byte) error {
+	expr, err := New(string(data))
+	if err != nil {
+		return err
+	}
+	f.Expression = expr
+	return nil
+}
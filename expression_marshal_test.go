@@ -0,0 +1,68 @@
+package expressions_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+type exprContainer struct {
+	Expr     expressions.Field  `json:"expr"`
+	Optional *expressions.Field `json:"optional,omitempty"`
+}
+
+func TestExpressionMarshalJSON(t *testing.T) {
+	expr, err := expressions.New(`$.foo["bar"]`)
+	assert.NoError(t, err)
+	marshalled, err := json.Marshal(expr)
+	assert.NoError(t, err)
+	assert.Equals(t, string(marshalled), `"$.foo[\"bar\"]"`)
+	text, err := expr.MarshalText()
+	assert.NoError(t, err)
+	assert.Equals(t, string(text), `$.foo["bar"]`)
+}
+
+func TestFieldJSONRoundTrip(t *testing.T) {
+	expr, err := expressions.New(`$.foo + 1`)
+	assert.NoError(t, err)
+	marshalled, err := json.Marshal(exprContainer{Expr: expressions.Field{Expression: expr}})
+	assert.NoError(t, err)
+	assert.Equals(t, string(marshalled), `{"expr":"$.foo + 1"}`)
+
+	var unmarshalled exprContainer
+	assert.NoError(t, json.Unmarshal(marshalled, &unmarshalled))
+	assert.NotNil(t, unmarshalled.Expr.Expression)
+	assert.Nil(t, unmarshalled.Optional)
+	assert.Equals(t, unmarshalled.Expr.String(), "$.foo + 1")
+	result, err := unmarshalled.Expr.Evaluate(map[string]any{"foo": int64(1)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals[any](t, result, int64(2))
+}
+
+func TestFieldJSONNull(t *testing.T) {
+	var unmarshalled exprContainer
+	assert.NoError(t, json.Unmarshal([]byte(`{"expr":null}`), &unmarshalled))
+	assert.Nil(t, unmarshalled.Expr.Expression)
+	marshalled, err := json.Marshal(unmarshalled)
+	assert.NoError(t, err)
+	assert.Equals(t, string(marshalled), `{"expr":null}`)
+}
+
+func TestFieldJSONErrors(t *testing.T) {
+	var unmarshalled exprContainer
+	// Not a string
+	assert.Error(t, json.Unmarshal([]byte(`{"expr":5}`), &unmarshalled))
+	// Invalid expression
+	assert.Error(t, json.Unmarshal([]byte(`{"expr":"$.foo )"}`), &unmarshalled))
+}
+
+func TestFieldText(t *testing.T) {
+	var field expressions.Field
+	assert.NoError(t, field.UnmarshalText([]byte(`$.a.b`)))
+	text, err := field.MarshalText()
+	assert.NoError(t, err)
+	assert.Equals(t, string(text), `$.a.b`)
+	assert.Error(t, field.UnmarshalText([]byte(`$.`)))
+}
@@ -0,0 +1,57 @@
+package expressions
+
+import (
+	"fmt"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// Metrics describe the complexity of an expression. Use them to enforce limits on the expressions users write, or to
+// flag overly complex workflow logic.
+type Metrics struct {
+	// Nodes is the number of nodes in the syntax tree, including identifiers and argument lists.
+	Nodes int
+	// MaxDepth is the number of nodes on the longest path from the root of the syntax tree to a leaf.
+	MaxDepth int
+	// FunctionCalls is the number of function calls.
+	FunctionCalls int
+	// ReferencedPaths is the number of distinct paths of the data the expression references, such as `$.foo.bar`.
+	// References with dynamic keys, such as `$.list[$.index]`, count as the path before the dynamic key.
+	ReferencedPaths int
+}
+
+func (e expression) Metrics() Metrics {
+	var result Metrics
+	depth := 0
+	ast.Walk(e.ast, ast.VisitorFuncs{
+		EnterFunc: func(node ast.Node) bool {
+			result.Nodes++
+			depth++
+			result.MaxDepth = max(result.MaxDepth, depth)
+			if _, isFunctionCall := node.(*ast.FunctionCall); isFunctionCall {
+				result.FunctionCalls++
+			}
+			return true
+		},
+		ExitFunc: func(node ast.Node) {
+			depth--
+		},
+	})
+	paths := map[string]bool{}
+	for _, ref := range findReferences(e.ast) {
+		paths[pathKey(ref.path())] = true
+	}
+	result.ReferencedPaths = len(paths)
+	return result
+}
+
+// pathKey returns a string that is the same for paths with the same items.
+func pathKey(path Path) string {
+	var result strings.Builder
+	for _, item := range path {
+		// The type is included, so items that are printed the same, like 1 and "1", are distinct.
+		_, _ = fmt.Fprintf(&result, "%T:%v;", item, item)
+	}
+	return result.String()
+}
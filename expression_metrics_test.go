@@ -0,0 +1,29 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestMetrics(t *testing.T) {
+	testCases := map[string]expressions.Metrics{
+		`1`:         {Nodes: 1, MaxDepth: 1},
+		`$.foo.bar`: {Nodes: 5, MaxDepth: 3, ReferencedPaths: 1},
+		`f($.a) + $.a + $["a"] + $.b[$.c]`: {
+			Nodes:           22,
+			MaxDepth:        7,
+			FunctionCalls:   1,
+			ReferencedPaths: 3,
+		},
+		`g(h(1), h(2))`: {Nodes: 11, MaxDepth: 5, FunctionCalls: 3},
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			assert.Equals(t, expr.Metrics(), expected)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestNamedRoot(t *testing.T) {
+	expr, err := expressions.New(`$foo.bar + $simple_str`)
+	assert.NoError(t, err)
+
+	resolvedType, err := expr.Type(testScope, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, string(resolvedType.TypeID()), "string")
+
+	paths, err := expr.Dependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	pathStrings := make([]string, len(paths))
+	for i, path := range paths {
+		pathStrings[i] = path.String()
+	}
+	assert.Equals(t, pathStrings, []string{"$.foo.bar", "$.simple_str"})
+
+	result, err := expr.Evaluate(map[string]any{
+		"foo":        map[string]any{"bar": "x"},
+		"simple_str": "y",
+	}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals[any](t, result, "xy")
+}
+
+func TestNamedRoot_WithoutAccess(t *testing.T) {
+	expr, err := expressions.New(`$simple_int * 2`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(map[string]any{"simple_int": int64(21)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals[any](t, result, int64(42))
+
+	_, err = expr.Evaluate(map[string]any{}, nil, nil)
+	assert.Error(t, err)
+
+	expr, err = expressions.New(`$missing.a`)
+	assert.NoError(t, err)
+	_, err = expr.Type(testScope, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+}
+
+func TestNamedRoot_Canonical(t *testing.T) {
+	expr, err := expressions.New(`$foo["bar"]`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.String(), `$foo["bar"]`)
+	assert.Equals(t, expr.AST().String(), `$foo["bar"]`)
+	assert.Equals(t, expr.Canonical(), `$.foo.bar`)
+	other, err := expressions.New(`$.foo.bar`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.Equivalent(other), true)
+}
+
+func TestNamedRoot_ParseErrors(t *testing.T) {
+	for _, expression := range []string{`$ foo.bar`, `$foo$bar`, `$.$foo`, `$1`} {
+		t.Run(expression, func(t *testing.T) {
+			_, err := expressions.New(expression)
+			assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeParse)
+		})
+	}
+}
+
+func TestNamedRoot_Tooling(t *testing.T) {
+	steps, err := traceSteps(t, `$foo.bar`)
+	assert.NoError(t, err)
+	assert.Equals(t, steps, []string{
+		"1:1: $foo: object root -> object foo",
+		"1:1: $foo.bar: object foo -> string",
+	})
+
+	expr, err := expressions.New(`$foo.int_list[0]`)
+	assert.NoError(t, err)
+	explanation, err := expr.Explain(testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, explanation, "item 0 of list int_list of root foo")
+
+	completions, err := expressions.Complete(`$fo`, 3, testScope, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(completions), 1)
+	assert.Equals(t, completions[0].Label, "foo")
+	assert.Equals(t, completions[0].Start, 1)
+}
@@ -0,0 +1,286 @@
+package expressions
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// defaultSourceName is the file name used in error messages if no file name is set in the options.
+const defaultSourceName = "workflow.yaml"
+
+// Options holds the settings for parsing an expression with NewWithOptions.
+type Options struct {
+	// Filename is the name of the file the expression is read from. It is used in error messages. Defaults to
+	// "workflow.yaml".
+	Filename string
+	// LineOffset is added to the line numbers of the positions in the expression. Set it to the line the expression
+	// starts on in the containing document minus one.
+	LineOffset int
+	// ColumnOffset is added to the column numbers of the positions on the first line of the expression. Set it to
+	// the column the expression starts on in the containing document minus one.
+	ColumnOffset int
+	// DisabledFeatures lists the language features the expression must not use. Parsing fails if a disabled
+	// feature is used.
+	DisabledFeatures []Feature
+	// DeprecatedFeatures marks language features as deprecated, such as features that are removed in a later
+	// LanguageVersion. Expressions that use them are parsed, but each use is reported as a DiagnosticDeprecatedSyntax
+	// warning, so workflows can be migrated before the features are disabled.
+	DeprecatedFeatures map[Feature]Deprecation
+	// LanguageVersion is the version of the language the expression is written in, such as the version a workflow
+	// declares. Parsing fails with ErrorCodeLanguageVersion if the expression uses a feature of a later version.
+	// Defaults to LanguageVersionLatest.
+	LanguageVersion LanguageVersion
+	// Policy restricts the functions and paths the expression may use. It is enforced when the expression is
+	// resolved or evaluated, not when it is parsed.
+	Policy *Policy
+	// StringComparison sets how the comparison operators, such as `==` and `<`, compare strings. Defaults to
+	// StringComparisonBinary.
+	StringComparison StringComparisonMode
+	// Functions are the functions whose calls are folded when parsing. A call to a function that implements
+	// PureFunction and is pure, with only literal arguments, is evaluated once when the expression is parsed and
+	// replaced with a literal of its result, if the result is an integer, float, string, or boolean. Parsing fails if
+	// such a call fails. Other calls are evaluated when the expression is evaluated.
+	Functions map[string]schema.CallableFunction
+	// RedactArgumentValues omits the values of the arguments from the FunctionCallError returned when a function
+	// fails, so values such as credentials do not end up in logs. The argument expressions are still included.
+	RedactArgumentValues bool
+	// ValidateFunctionResults validates the value returned by each function call against the output type the
+	// function declares when the expression is evaluated. A function returning a value that does not match fails with
+	// an InvalidFunctionResultError, instead of causing a confusing failure later in the evaluation.
+	ValidateFunctionResults bool
+	// MaxNestingDepth limits how deeply expressions can be nested within the expression, such as with parentheses,
+	// unary operators, and function arguments. Parsing fails if the limit is exceeded. Defaults to
+	// ast.DefaultMaxNestingDepth.
+	MaxNestingDepth int
+	// ExponentLiterals sets the type of numbers written with an exponent but without a fraction, such as `1e6`.
+	// Defaults to ExponentLiteralFloat.
+	ExponentLiterals ExponentLiteralMode
+	// AllowNonFiniteFloats allows float operations, such as `0.0 / 0.0`, to produce NaN and infinite values. By
+	// default, such an operation fails, since NaN is not equal to any value, including itself, and compares as
+	// neither less nor greater than any value.
+	AllowNonFiniteFloats bool
+	// EuclideanModulo makes the modulus operator `%` return a result that is never negative, so -1 modulo 3 is 2,
+	// which is useful to wrap indexes around. By default, the result has the sign of the left operand, like in Go, so
+	// -1 modulo 3 is -1.
+	EuclideanModulo bool
+	// SchemaDefaults makes an AccessPlan created by Compile evaluate a field that is missing from the record to the
+	// default value of its property in the schema, like the SDK does when it unserializes data. Evaluate does not
+	// know the schema, so it is not affected.
+	SchemaDefaults bool
+	// OnWarning is called by NewWithOptions with each warning found when parsing the expression, such as literals
+	// that are converted to a different type than they are written as, and uses of deprecated features and
+	// functions. The warnings are also included in the report of Validate.
+	OnWarning func(warning Diagnostic)
+	// OnTypeTrace is called with each step of the type resolution when Type, Dependencies, TypedDependencies,
+	// Validate, or Compile resolves the expression, such as the type each field access is applied to and resolves
+	// to. This is useful to understand why an expression resolves to an unexpected type or fails to resolve. The
+	// results of the resolution are not cached if it is set, so each call reports all steps.
+	OnTypeTrace func(step TypeTraceStep)
+	// Tracer creates a span for each evaluation of the expression and for each function call within it, such as
+	// OpenTelemetry spans, so slow expressions and functions show up in the traces of the engine. Use
+	// EvaluateWithContext to make the spans children of the span of the caller.
+	Tracer Tracer
+}
+
+// StringComparisonMode is the way the comparison operators compare strings.
+type StringComparisonMode string
+
+const (
+	// StringComparisonBinary compares strings byte by byte, so "B" < "a". This is the default.
+	StringComparisonBinary StringComparisonMode = "binary"
+	// StringComparisonIgnoreCase compares strings ignoring the case of letters, so "a" == "A" and "a" < "B".
+	StringComparisonIgnoreCase StringComparisonMode = "ignore-case"
+)
+
+// ExponentLiteralMode is the way the type of numbers written with an exponent but without a fraction, such as `1e6`,
+// is decided. Numbers with a fraction, such as `1.0e6`, are always floats.
+type ExponentLiteralMode string
+
+const (
+	// ExponentLiteralFloat makes numbers with an exponent floats, so `1e6` is the same as `1000000.0`. This is the
+	// default.
+	ExponentLiteralFloat ExponentLiteralMode = "float"
+	// ExponentLiteralInteger makes numbers with an exponent integers if their value is whole and fits in an integer,
+	// so `1e6` is the same as `1000000`, while `1e-3` and `1e30` are floats.
+	ExponentLiteralInteger ExponentLiteralMode = "integer"
+)
+
+// compareStrings compares the strings with the mode, and returns -1 if a is less than b, 0 if they are equal, and 1
+// if a is greater than b.
+func (m StringComparisonMode) compareStrings(a string, b string) int {
+	if m == StringComparisonIgnoreCase {
+		if strings.EqualFold(a, b) {
+			return 0
+		}
+		a, b = strings.ToLower(a), strings.ToLower(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// Feature is an optional language feature that can be disabled when parsing an expression.
+type Feature string
+
+const (
+	// FeatureFunctionCalls allows calling functions, such as `toString(5)`.
+	FeatureFunctionCalls Feature = "function-calls"
+	// FeatureOperators allows the use of unary and binary operators, such as `-`, `!`, `+`, `==`, and `&&`.
+	FeatureOperators Feature = "operators"
+	// FeatureUnitLiterals allows duration and byte size literals, such as `5m30s` and `2Gi`.
+	FeatureUnitLiterals Feature = "unit-literals"
+	// FeatureNamespacedFunctions allows calling functions in namespaces, such as `math.abs(-1)` and `str::upper("a")`.
+	FeatureNamespacedFunctions Feature = "namespaced-functions"
+	// FeatureNamedRoots allows named roots, such as `$steps` and `$vars`.
+	FeatureNamedRoots Feature = "named-roots"
+)
+
+// LanguageVersion is a version of the expression language. Each version adds features to the previous one, so
+// workflows that declare a version keep working with engines that support later versions, and engines can reject
+// expressions that use features the declared version does not have.
+type LanguageVersion int
+
+const (
+	// LanguageVersion1 is the original language, with literals, accesses of the root data, function calls, and
+	// operators.
+	LanguageVersion1 LanguageVersion = 1
+	// LanguageVersion2 adds duration and byte size literals, namespaced function names, and named roots. See
+	// FeatureUnitLiterals, FeatureNamespacedFunctions, and FeatureNamedRoots.
+	LanguageVersion2 LanguageVersion = 2
+	// LanguageVersionLatest is the latest version of the language, which is used if no version is set.
+	LanguageVersionLatest = LanguageVersion2
+)
+
+// featureVersions are the language versions that introduced the features.
+var featureVersions = map[Feature]LanguageVersion{
+	FeatureFunctionCalls:       LanguageVersion1,
+	FeatureOperators:           LanguageVersion1,
+	FeatureUnitLiterals:        LanguageVersion2,
+	FeatureNamespacedFunctions: LanguageVersion2,
+	FeatureNamedRoots:          LanguageVersion2,
+}
+
+// Features returns the features the language version has.
+func (v LanguageVersion) Features() []Feature {
+	var result []Feature
+	for _, feature := range []Feature{
+		FeatureFunctionCalls, FeatureOperators, FeatureUnitLiterals, FeatureNamespacedFunctions, FeatureNamedRoots,
+	} {
+		if featureVersions[feature] <= v {
+			result = append(result, feature)
+		}
+	}
+	return result
+}
+
+// featureUsedBy returns the feature the given node requires, or an empty string if the node is always allowed.
+func featureUsedBy(node ast.Node) Feature {
+	switch n := node.(type) {
+	case *ast.FunctionCall:
+		if strings.Contains(n.FuncIdentifier.IdentifierName, ".") ||
+			strings.Contains(n.FuncIdentifier.IdentifierName, "::") {
+			return FeatureNamespacedFunctions
+		}
+		return FeatureFunctionCalls
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		return FeatureOperators
+	case *ast.IntLiteral:
+		// Duration and byte size literals end with their unit, while exponent literals, such as 1e6, end with a
+		// digit.
+		if n.Literal != "" && !unicode.IsDigit(rune(n.Literal[len(n.Literal)-1])) {
+			return FeatureUnitLiterals
+		}
+		return ""
+	case *ast.Identifier:
+		if n.RootName() != "" {
+			return FeatureNamedRoots
+		}
+		return ""
+	default:
+		return ""
+	}
+}
+
+// validateFeatures returns an error for the first node in the tree that uses a disabled feature, or a feature of a
+// later language version than the one set.
+func (o Options) validateFeatures(root ast.Node) error {
+	version := o.languageVersion()
+	if len(o.DisabledFeatures) == 0 && version == LanguageVersionLatest {
+		return nil
+	}
+	var err error
+	ast.Inspect(root, func(node ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		feature := featureUsedBy(node)
+		switch {
+		case feature == "":
+		case slices.Contains(o.DisabledFeatures, feature):
+			err = newCodedError(ErrorCodeFeatureDisabled,
+				"%s are disabled, but used in %q at %s", feature, node.String(), node.Start())
+		case featureVersions[feature] > version:
+			err = newCodedError(ErrorCodeLanguageVersion,
+				"%s require language version %d, but version %d is used, in %q at %s",
+				feature, featureVersions[feature], version, node.String(), node.Start())
+		}
+		return err == nil
+	})
+	return err
+}
+
+// languageVersion returns the language version set, or the latest version if none is set.
+func (o Options) languageVersion() LanguageVersion {
+	if o.LanguageVersion == 0 {
+		return LanguageVersionLatest
+	}
+	return o.LanguageVersion
+}
+
+// validateLanguageVersion returns an error if the language version is not known.
+func (o Options) validateLanguageVersion() error {
+	if o.LanguageVersion < 0 || o.LanguageVersion > LanguageVersionLatest {
+		return newCodedError(ErrorCodeInvalidOptions,
+			"unknown language version %d, the latest version is %d", o.LanguageVersion, LanguageVersionLatest)
+	}
+	return nil
+}
+
+// validateStringComparison returns an error if the string comparison mode is not known.
+func (o Options) validateStringComparison() error {
+	switch o.StringComparison {
+	case "", StringComparisonBinary, StringComparisonIgnoreCase:
+		return nil
+	default:
+		return newCodedError(ErrorCodeInvalidOptions, "unknown string comparison mode %q", o.StringComparison)
+	}
+}
+
+// validateExponentLiterals returns an error if the exponent literal mode is not known.
+func (o Options) validateExponentLiterals() error {
+	switch o.ExponentLiterals {
+	case "", ExponentLiteralFloat, ExponentLiteralInteger:
+		return nil
+	default:
+		return newCodedError(ErrorCodeInvalidOptions, "unknown exponent literal mode %q", o.ExponentLiterals)
+	}
+}
+
+func (o Options) sourceName() string {
+	if o.Filename == "" {
+		return defaultSourceName
+	}
+	return o.Filename
+}
+
+// reportWarnings passes the warnings to the OnWarning function, if it is set.
+func (o Options) reportWarnings(warnings []Diagnostic) {
+	if o.OnWarning == nil {
+		return
+	}
+	for _, warning := range warnings {
+		o.OnWarning(warning)
+	}
+}
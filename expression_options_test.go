@@ -0,0 +1,215 @@
+package expressions_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func TestNewWithOptions_Filename(t *testing.T) {
+	_, err := expressions.NewWithOptions("$.foo )", expressions.Options{Filename: "test.yaml"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"test.yaml" at line 1:7`)
+
+	// The default file name is used when none is specified.
+	_, err = expressions.New("$.foo )")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"workflow.yaml" at line 1:7`)
+}
+
+func TestNewWithOptions_Offset(t *testing.T) {
+	options := expressions.Options{
+		LineOffset:   4,
+		ColumnOffset: 10,
+	}
+	_, err := expressions.NewWithOptions("$.foo )", options)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at line 5:17")
+
+	// The column offset only applies to the first line.
+	_, err = expressions.NewWithOptions("$.foo\n )", options)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "at line 6:2")
+
+	expr, err := expressions.NewWithOptions("$.foo", options)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.AST().Start().Line, 5)
+	assert.Equals(t, expr.AST().Start().Column, 11)
+}
+
+func TestNewWithOptions_DisabledFeatures(t *testing.T) {
+	options := expressions.Options{
+		DisabledFeatures: []expressions.Feature{expressions.FeatureFunctionCalls},
+	}
+	_, err := expressions.NewWithOptions("$.foo[f()]", options)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "function-calls are disabled")
+	assert.Contains(t, err.Error(), "1:7")
+	_, err = expressions.NewWithOptions("$.foo + 1", options)
+	assert.NoError(t, err)
+
+	options = expressions.Options{
+		DisabledFeatures: []expressions.Feature{expressions.FeatureOperators},
+	}
+	_, err = expressions.NewWithOptions("$.foo + 1", options)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "operators are disabled")
+	_, err = expressions.NewWithOptions("-1", options)
+	assert.Error(t, err)
+	_, err = expressions.NewWithOptions("f($.foo)", options)
+	assert.NoError(t, err)
+}
+
+func TestNewWithOptions_LanguageVersion(t *testing.T) {
+	options := expressions.Options{LanguageVersion: expressions.LanguageVersion1}
+	testCases := map[string]string{
+		"$.timeout < 5m":    "unit-literals require language version 2, but version 1 is used",
+		"$.size > 2Gi":      "unit-literals require language version 2",
+		"math::abs($.a)":    "namespaced-functions require language version 2",
+		"$steps.a.outputs":  "named-roots require language version 2",
+		"$.foo[$vars.item]": "named-roots require language version 2",
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			_, err := expressions.NewWithOptions(expression, options)
+			assert.Error(t, err)
+			assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeLanguageVersion)
+			assert.Contains(t, err.Error(), expected)
+		})
+	}
+	for _, expression := range []string{"f($.foo[0]) + 1e6", "-$.a * 300", `'a' + "b"`} {
+		_, err := expressions.NewWithOptions(expression, options)
+		assert.NoError(t, err)
+	}
+	_, err := expressions.NewWithOptions("$steps.a + 5m", expressions.Options{LanguageVersion: expressions.LanguageVersion2})
+	assert.NoError(t, err)
+	_, err = expressions.NewWithOptions("$steps.a + 5m", expressions.Options{})
+	assert.NoError(t, err)
+
+	_, err = expressions.NewWithOptions("1", expressions.Options{LanguageVersion: expressions.LanguageVersionLatest + 1})
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeInvalidOptions)
+
+	// Disabled features are reported before the version.
+	_, err = expressions.NewWithOptions("$steps.a", expressions.Options{
+		LanguageVersion:  expressions.LanguageVersion1,
+		DisabledFeatures: []expressions.Feature{expressions.FeatureNamedRoots},
+	})
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeFeatureDisabled)
+
+	assert.Equals(t, expressions.LanguageVersion1.Features(), []expressions.Feature{
+		expressions.FeatureFunctionCalls, expressions.FeatureOperators,
+	})
+}
+
+func TestNewWithOptions_StringComparison(t *testing.T) {
+	data := map[string]any{"name": "Alice"}
+	testCases := map[string]struct {
+		mode     expressions.StringComparisonMode
+		expected bool
+	}{
+		"default":     {"", false},
+		"binary":      {expressions.StringComparisonBinary, false},
+		"ignore-case": {expressions.StringComparisonIgnoreCase, true},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			options := expressions.Options{StringComparison: tc.mode}
+			expr, err := expressions.NewWithOptions(`$.name == "ALICE" && "alice" <= "Bob"`, options)
+			assert.NoError(t, err)
+			result, err := expr.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, any(tc.expected))
+		})
+	}
+
+	_, err := expressions.NewWithOptions(`$.name`, expressions.Options{StringComparison: "locale"})
+	assert.Error(t, err)
+}
+
+func TestNewWithOptions_ExponentLiterals(t *testing.T) {
+	testCases := map[string]struct {
+		mode     expressions.ExponentLiteralMode
+		expected []any
+	}{
+		"default": {"", []any{1000000.0, 0.002, 1e30, 1.5e3}},
+		"float":   {expressions.ExponentLiteralFloat, []any{1000000.0, 0.002, 1e30, 1.5e3}},
+		"integer": {expressions.ExponentLiteralInteger, []any{int64(1000000), 0.002, 1e30, 1.5e3}},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			options := expressions.Options{ExponentLiterals: tc.mode}
+			for i, literal := range []string{"1e6", "2E-3", "1e30", "1.5e3"} {
+				expr, err := expressions.NewWithOptions(literal, options)
+				assert.NoError(t, err)
+				result, err := expr.Evaluate(nil, nil, nil)
+				assert.NoError(t, err)
+				assert.Equals(t, result, tc.expected[i])
+			}
+		})
+	}
+
+	// The type of the literal decides the type of the result.
+	expr, err := expressions.NewWithOptions(`$.count < 1e3`, expressions.Options{ExponentLiterals: expressions.ExponentLiteralInteger})
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(map[string]any{"count": int64(5)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(true))
+
+	_, err = expressions.NewWithOptions(`1e6`, expressions.Options{ExponentLiterals: "decimal"})
+	assert.Error(t, err)
+}
+
+func TestNewWithOptions_EuclideanModulo(t *testing.T) {
+	testCases := map[string]struct {
+		left      any
+		right     any
+		truncated any
+		euclidean any
+	}{
+		"positive":           {int64(7), int64(3), int64(1), int64(1)},
+		"negative-left":      {int64(-7), int64(3), int64(-1), int64(2)},
+		"negative-right":     {int64(7), int64(-3), int64(1), int64(1)},
+		"negative-both":      {int64(-7), int64(-3), int64(-1), int64(2)},
+		"negative-multiple":  {int64(-6), int64(3), int64(0), int64(0)},
+		"float-negative":     {-7.5, 2.0, -1.5, 0.5},
+		"float-negative-all": {-7.5, -2.0, -1.5, 0.5},
+	}
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			data := map[string]any{"left": tc.left, "right": tc.right}
+			expr, err := expressions.New(`$.left % $.right`)
+			assert.NoError(t, err)
+			result, err := expr.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, tc.truncated)
+
+			expr, err = expressions.NewWithOptions(`$.left % $.right`, expressions.Options{EuclideanModulo: true})
+			assert.NoError(t, err)
+			result, err = expr.Evaluate(data, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, tc.euclidean)
+		})
+	}
+}
+
+func TestNewWithOptions_MaxNestingDepth(t *testing.T) {
+	expression := strings.Repeat("(", 10) + "1" + strings.Repeat(")", 10)
+	_, err := expressions.New(expression)
+	assert.NoError(t, err)
+
+	_, err = expressions.NewWithOptions(expression, expressions.Options{MaxNestingDepth: 10})
+	var depthErr *ast.NestingDepthError
+	assert.Equals(t, errors.As(err, &depthErr), true)
+	assert.Equals(t, depthErr.MaxDepth, 10)
+
+	_, err = expressions.New(strings.Repeat("-", 1000) + "1")
+	assert.Equals(t, errors.As(err, &depthErr), true)
+	assert.Equals(t, depthErr.MaxDepth, ast.DefaultMaxNestingDepth)
+}
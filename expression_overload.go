@@ -0,0 +1,192 @@
+package expressions
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// OverloadedFunction is a set of functions that are called by the same name, and differ in the number or the types of
+// their parameters, such as max(int, int) and max(float, float). The overload is resolved when the expression is type
+// checked, based on the types of the arguments, and again when it is evaluated, based on the values of the arguments.
+//
+// The ID and the display of the set are those of the first overload. Parameters returns the parameters of the first
+// overload, so use Overloads or Resolve to get the parameters of the other overloads.
+type OverloadedFunction struct {
+	schema.CallableFunction
+	overloads []schema.CallableFunction
+}
+
+// NewOverloadedFunction creates a set of overloads. It fails if no overloads are passed, or if two overloads have the
+// same number and types of parameters, because they could not be told apart.
+func NewOverloadedFunction(overloads ...schema.CallableFunction) (*OverloadedFunction, error) {
+	if len(overloads) == 0 {
+		return nil, Errorf("no overloads passed")
+	}
+	signatures := make(map[string]struct{}, len(overloads))
+	for _, overload := range overloads {
+		signature := overloadSignature(overload.Parameters())
+		if _, exists := signatures[signature]; exists {
+			return nil, Errorf(
+				"function '%s' has more than one overload with the parameters (%s)",
+				overload.ID(), signature)
+		}
+		signatures[signature] = struct{}{}
+	}
+	return &OverloadedFunction{
+		CallableFunction: overloads[0],
+		overloads:        overloads,
+	}, nil
+}
+
+// Overloads returns the overloads in the order they were passed.
+func (f *OverloadedFunction) Overloads() []schema.CallableFunction {
+	return f.overloads
+}
+
+// Resolve returns the overload that accepts arguments of the types. An overload whose parameter types are the same as
+// the argument types is preferred over one that only accepts them, such as one with a parameter of the any type. If
+// more than one overload matches equally well, the first one is returned.
+func (f *OverloadedFunction) Resolve(argumentTypes []schema.Type) (schema.CallableFunction, error) {
+	return f.resolve(len(argumentTypes), func(i int, paramType schema.Type) (bool, bool) {
+		if paramType.ValidateCompatibility(argumentTypes[i]) != nil {
+			return false, false
+		}
+		return true, paramType.TypeID() == argumentTypes[i].TypeID()
+	}, func() string {
+		typeIDs := make([]string, len(argumentTypes))
+		for i, argumentType := range argumentTypes {
+			typeIDs[i] = string(argumentType.TypeID())
+		}
+		return strings.Join(typeIDs, ", ")
+	})
+}
+
+// Call calls the overload that accepts the values of the arguments.
+func (f *OverloadedFunction) Call(arguments []any) (any, error) {
+	overload, err := f.resolveArguments(arguments)
+	if err != nil {
+		return nil, err
+	}
+	return overload.Call(arguments)
+}
+
+// resolveArguments returns the overload that accepts the values of the arguments.
+func (f *OverloadedFunction) resolveArguments(arguments []any) (schema.CallableFunction, error) {
+	return f.resolve(len(arguments), func(i int, paramType schema.Type) (bool, bool) {
+		return valueMatchesType(arguments[i], paramType)
+	}, func() string {
+		kinds := make([]string, len(arguments))
+		for i, argument := range arguments {
+			kinds[i] = fmt.Sprintf("%T", argument)
+		}
+		return strings.Join(kinds, ", ")
+	})
+}
+
+// Pure returns true if all overloads are pure, see PureFunction.
+func (f *OverloadedFunction) Pure() bool {
+	for _, overload := range f.overloads {
+		if !isPure(overload) {
+			return false
+		}
+	}
+	return true
+}
+
+// Deprecation returns the deprecation of the first overload if all overloads are deprecated, see DeprecatedFunction.
+func (f *OverloadedFunction) Deprecation() *Deprecation {
+	for _, overload := range f.overloads {
+		if functionDeprecation(overload) == nil {
+			return nil
+		}
+	}
+	return functionDeprecation(f.overloads[0])
+}
+
+// String returns the signatures of all overloads.
+func (f *OverloadedFunction) String() string {
+	signatures := make([]string, len(f.overloads))
+	for i, overload := range f.overloads {
+		signatures[i] = overload.String()
+	}
+	return strings.Join(signatures, "; ")
+}
+
+// resolve returns the best overload with the number of parameters. The match function reports if the argument at the
+// index matches the parameter type, and if it matches exactly.
+func (f *OverloadedFunction) resolve(
+	argumentCount int,
+	match func(i int, paramType schema.Type) (matches bool, exact bool),
+	describeArguments func() string,
+) (schema.CallableFunction, error) {
+	var best schema.CallableFunction
+	bestScore := -1
+	for _, overload := range f.overloads {
+		paramTypes := overload.Parameters()
+		if len(paramTypes) != argumentCount {
+			continue
+		}
+		score := 0
+		for i, paramType := range paramTypes {
+			matches, exact := match(i, paramType)
+			if !matches {
+				score = -1
+				break
+			}
+			if exact {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = overload, score
+		}
+	}
+	if best == nil {
+		return nil, newCodedError(
+			ErrorCodeInvalidArguments,
+			"no overload of function '%s' accepts the arguments (%s). Function schema: %s",
+			f.ID(), describeArguments(), f.String())
+	}
+	return best, nil
+}
+
+func overloadSignature(paramTypes []schema.Type) string {
+	typeIDs := make([]string, len(paramTypes))
+	for i, paramType := range paramTypes {
+		typeIDs[i] = string(paramType.TypeID())
+	}
+	return strings.Join(typeIDs, ", ")
+}
+
+// valueMatchesType reports if the value can be passed as a parameter of the type, and if the parameter is of the
+// same type as the value rather than of the any type.
+func valueMatchesType(value any, paramType schema.Type) (matches bool, exact bool) {
+	kind := reflect.Invalid
+	if value != nil {
+		kind = reflect.TypeOf(value).Kind()
+	}
+	switch paramType.TypeID() {
+	case schema.TypeIDAny:
+		return true, false
+	case schema.TypeIDInt, schema.TypeIDIntEnum:
+		matches = kind >= reflect.Int && kind <= reflect.Uint64
+	case schema.TypeIDFloat:
+		matches = kind == reflect.Float32 || kind == reflect.Float64
+	case schema.TypeIDString, schema.TypeIDStringEnum:
+		matches = kind == reflect.String
+	case schema.TypeIDBool:
+		matches = kind == reflect.Bool
+	case schema.TypeIDList:
+		matches = kind == reflect.Slice
+	case schema.TypeIDMap:
+		matches = kind == reflect.Map
+	case schema.TypeIDObject, schema.TypeIDRef, schema.TypeIDScope:
+		matches = kind == reflect.Map || kind == reflect.Struct || kind == reflect.Pointer
+	default:
+		matches = true
+	}
+	return matches, matches
+}
@@ -0,0 +1,104 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func newMaxOverloads(t *testing.T) *expressions.OverloadedFunction {
+	maxInts, err := schema.NewCallableFunction(
+		"max",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil), schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a int64, b int64) int64 {
+			return max(a, b)
+		},
+	)
+	assert.NoError(t, err)
+	maxFloats, err := schema.NewCallableFunction(
+		"max",
+		[]schema.Type{schema.NewFloatSchema(nil, nil, nil), schema.NewFloatSchema(nil, nil, nil)},
+		schema.NewFloatSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a float64, b float64) float64 {
+			return max(a, b)
+		},
+	)
+	assert.NoError(t, err)
+	maxOfThree, err := schema.NewCallableFunction(
+		"max",
+		[]schema.Type{
+			schema.NewIntSchema(nil, nil, nil),
+			schema.NewIntSchema(nil, nil, nil),
+			schema.NewIntSchema(nil, nil, nil),
+		},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(a int64, b int64, c int64) int64 {
+			return max(a, b, c)
+		},
+	)
+	assert.NoError(t, err)
+	overloaded, err := expressions.NewOverloadedFunction(maxInts, maxFloats, maxOfThree)
+	assert.NoError(t, err)
+	return overloaded
+}
+
+func TestOverloadedFunction(t *testing.T) {
+	overloaded := newMaxOverloads(t)
+	assert.Equals(t, len(overloaded.Overloads()), 3)
+	functions := map[string]schema.CallableFunction{"max": overloaded}
+	functionSchemas := map[string]schema.Function{"max": overloaded}
+
+	testCases := map[string]struct {
+		expression     string
+		expectedType   schema.TypeID
+		expectedResult any
+	}{
+		"ints":       {"max(1, $.simple_int)", schema.TypeIDInt, int64(1)},
+		"floats":     {"max(1.5, 0.5)", schema.TypeIDFloat, 1.5},
+		"three-ints": {"max(1, 3, 2)", schema.TypeIDInt, int64(3)},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			expr, err := expressions.New(testCase.expression)
+			assert.NoError(t, err)
+			resultType, err := expr.Type(testScope, functionSchemas, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, resultType.TypeID(), testCase.expectedType)
+			result, err := expr.Evaluate(map[string]any{"simple_int": int64(0)}, functions, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, testCase.expectedResult)
+		})
+	}
+}
+
+func TestOverloadedFunction_NoMatchingOverload(t *testing.T) {
+	overloaded := newMaxOverloads(t)
+	for _, expression := range []string{`max(1, 1.5)`, `max("a", "b")`, `max(1)`} {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Type(testScope, map[string]schema.Function{"max": overloaded}, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no overload of function 'max'")
+		_, err = expr.Evaluate(map[string]any{}, map[string]schema.CallableFunction{"max": overloaded}, nil)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no overload of function 'max'")
+	}
+}
+
+func TestOverloadedFunction_DuplicateSignature(t *testing.T) {
+	overloaded := newMaxOverloads(t)
+	_, err := expressions.NewOverloadedFunction(overloaded.Overloads()[0], overloaded.Overloads()[0])
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "more than one overload")
+	_, err = expressions.NewOverloadedFunction()
+	assert.Error(t, err)
+}
@@ -0,0 +1,271 @@
+package expressions
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// AccessPlan is an expression compiled for a root schema by Expression.Compile. The expression is type checked once,
+// and each access of the root data is compiled into steps that use the field names and list positions resolved from
+// the schema. Accesses of the same path share a slot, so each path is only followed once per record.
+//
+// An AccessPlan does not change after it is compiled, so it can evaluate records concurrently.
+type AccessPlan struct {
+	expression expression
+	resultType schema.Type
+	// slots holds the index of the slot of each access chain in the expression.
+	slots map[*ast.AccessChain]int
+	// accessors holds the compiled steps of each slot, indexed by the slot.
+	accessors [][]planStep
+}
+
+// planStepKind is the way a planStep accesses the data.
+type planStepKind int
+
+const (
+	// keyStep looks up the key in the data without knowing its type.
+	keyStep planStepKind = iota
+	// fieldStep looks up a field of an object, which is stored in a map with string keys.
+	fieldStep
+	// positionStep reads the item at a non-negative position of a list.
+	positionStep
+)
+
+// planStep is a single compiled access of an access chain.
+type planStep struct {
+	kind planStepKind
+	// key is the key of the access as written in the expression, used for keyStep, and for data that does not
+	// have the type expected by the schema.
+	key any
+	// field is the name of the field for fieldStep.
+	field string
+	// position is the list position for positionStep.
+	position int
+	// defaultValue is the default value of the field for fieldStep, which is used if the field is missing from the
+	// data and hasDefault is set.
+	defaultValue any
+	hasDefault   bool
+}
+
+// planSlot holds the value of an access chain for the record being evaluated.
+type planSlot struct {
+	value    any
+	resolved bool
+}
+
+// planSlotPool holds the slot slices of evaluations, so evaluating a record does not allocate them.
+var planSlotPool = sync.Pool{
+	New: func() any {
+		return new([]planSlot)
+	},
+}
+
+func (e expression) Compile(
+	scope schema.Scope,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) (plan *AccessPlan, err error) {
+	defer recoverInternalError("compiling the expression", &err)
+	dependencyResolutionResult, err := e.resolveDependencies(scope, functions, workflowContext)
+	if err != nil {
+		return nil, err
+	}
+	plan = &AccessPlan{
+		expression: e,
+		resultType: dependencyResolutionResult.resolvedType,
+		slots:      map[*ast.AccessChain]int{},
+	}
+	slotsByPath := map[string]int{}
+	ast.Inspect(e.ast, func(node ast.Node) bool {
+		var chain *ast.AccessChain
+		switch n := node.(type) {
+		case *ast.DotNotation:
+			chain = n.Chain
+		case *ast.BracketAccessor:
+			chain = n.Chain
+		}
+		if chain == nil {
+			return true
+		}
+		pathKey := accessChainKey(chain)
+		slot, found := slotsByPath[pathKey]
+		if !found {
+			var steps []planStep
+			steps, err = compileAccessChain(chain, scope, e.options.SchemaDefaults)
+			if err != nil {
+				return false
+			}
+			slot = len(plan.accessors)
+			slotsByPath[pathKey] = slot
+			plan.accessors = append(plan.accessors, steps)
+		}
+		plan.slots[chain] = slot
+		// The nodes inside the chain are only literals and shorter chains, which are not evaluated on their own.
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// accessChainKey returns a string that is the same for access chains with the same steps.
+func accessChainKey(chain *ast.AccessChain) string {
+	var result strings.Builder
+	for _, step := range chain.Steps {
+		// The type is included, so keys that are printed the same, like 1 and "1", are distinct.
+		_, _ = fmt.Fprintf(&result, "%t:%T:%v;", step.Bracket, step.Key, step.Key)
+	}
+	return result.String()
+}
+
+// compileAccessChain compiles the steps of the chain with the types of the schema. Once the type is not known, such
+// as after an any type, the remaining steps look up their keys without knowing the type. If useDefaults is set, the
+// fields with a default value in the schema use it when they are missing from the data.
+func compileAccessChain(chain *ast.AccessChain, scope schema.Scope, useDefaults bool) ([]planStep, error) {
+	result := make([]planStep, len(chain.Steps))
+	var currentType schema.Type = scope
+	for i, step := range chain.Steps {
+		result[i] = planStep{kind: keyStep, key: step.Key}
+		if currentType == nil {
+			continue
+		}
+		var nextType schema.Type
+		switch currentType.TypeID() {
+		case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+			field, isField := step.Key.(string)
+			if property, found := currentType.(schema.Object).Properties()[field]; isField && !step.Bracket && found {
+				result[i].kind = fieldStep
+				result[i].field = field
+				nextType = property.Type()
+				if useDefaults && property.Default() != nil {
+					defaultValue, err := unserializeDefault(currentType.(schema.Object), field, property)
+					if err != nil {
+						return nil, err
+					}
+					result[i].defaultValue = defaultValue
+					result[i].hasDefault = true
+				}
+			}
+		case schema.TypeIDList:
+			position, isPosition := step.Key.(int64)
+			if isPosition && position >= 0 && int64(int(position)) == position {
+				result[i].kind = positionStep
+				result[i].position = int(position)
+			}
+			nextType = currentType.(schema.UntypedList).Items()
+		case schema.TypeIDMap:
+			nextType = currentType.(schema.UntypedMap).Values()
+		}
+		currentType = nextType
+	}
+	return result, nil
+}
+
+// unserializeDefault returns the default value of the field of the object, decoded and unserialized like the SDK
+// does when the field is missing from the data it unserializes.
+func unserializeDefault(object schema.Object, field string, property *schema.PropertySchema) (any, error) {
+	var rawValue any
+	if err := json.Unmarshal([]byte(*property.Default()), &rawValue); err != nil {
+		if property.Type().TypeID() != schema.TypeIDString {
+			return nil, newCodedError(
+				ErrorCodeTypeMismatch, "invalid default value of field %s of object %s (%w)", field, object.ID(), err)
+		}
+		// Like the SDK, string defaults written without quotes, as in YAML, are used as they are.
+		rawValue = *property.Default()
+	}
+	defaultValue, err := property.Type().Unserialize(rawValue)
+	if err != nil {
+		return nil, newCodedError(
+			ErrorCodeTypeMismatch, "invalid default value of field %s of object %s (%w)", field, object.ID(), err)
+	}
+	return defaultValue, nil
+}
+
+// Type returns the type of the results of the expression.
+func (p *AccessPlan) Type() schema.Type {
+	return p.resultType
+}
+
+// Expression returns the expression the plan was compiled from.
+func (p *AccessPlan) Expression() Expression {
+	return p.expression
+}
+
+// Evaluate evaluates the expression on the record, which must match the schema the plan was compiled with. The
+// result is the same as the result of Expression.Evaluate for the record.
+func (p *AccessPlan) Evaluate(
+	record any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (result any, err error) {
+	defer recoverInternalError("evaluating the expression", &err)
+	slots := planSlotPool.Get().(*[]planSlot)
+	// The slots in the pool are cleared, so they are not resolved yet.
+	*slots = slices.Grow((*slots)[:0], len(p.accessors))[:len(p.accessors)]
+	context := p.expression.newEvaluateContext(record, functions, workflowContext)
+	context.plan = p
+	context.planSlots = *slots
+	result, err = context.evaluateTraced(p.expression.ast, record)
+	// Clear the values, so the pool does not keep the record alive.
+	clear(*slots)
+	planSlotPool.Put(slots)
+	return result, err
+}
+
+// access returns the value of the access chain for the record, following the chain if it is not resolved yet.
+func (p *AccessPlan) access(chain *ast.AccessChain, record any, slots []planSlot) (any, error) {
+	slot, found := p.slots[chain]
+	if !found {
+		// Only the outermost chains have a slot, so this is not expected, but the chain can still be followed.
+		steps, _ := compileAccessChain(chain, nil, false)
+		return followPlanSteps(record, steps)
+	}
+	if slots[slot].resolved {
+		return slots[slot].value, nil
+	}
+	value, err := followPlanSteps(record, p.accessors[slot])
+	if err != nil {
+		return nil, err
+	}
+	slots[slot] = planSlot{value: value, resolved: true}
+	return value, nil
+}
+
+// followPlanSteps applies the steps to the data. Data that does not have the type the step was compiled for is
+// accessed like Evaluate does, so the errors are the same.
+func followPlanSteps(data any, steps []planStep) (any, error) {
+	for _, step := range steps {
+		switch step.kind {
+		case fieldStep:
+			if object, isObject := data.(map[string]any); isObject {
+				value, found := object[step.field]
+				if found {
+					data = value
+					continue
+				}
+				if step.hasDefault {
+					data = step.defaultValue
+					continue
+				}
+			}
+		case positionStep:
+			if list, isList := data.([]any); isList && step.position < len(list) {
+				data = list[step.position]
+				continue
+			}
+		}
+		var err error
+		data, err = evaluateMapAccess(data, step.key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
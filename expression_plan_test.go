@@ -0,0 +1,174 @@
+package expressions_test
+
+import (
+	"sync"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestCompile(t *testing.T) {
+	expr, err := expressions.New(`$.foo.int_list[1] + $.simple_int * $.foo.int_list[1] + $.int_list[$.simple_int]`)
+	assert.NoError(t, err)
+	plan, err := expr.Compile(testScope, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, plan.Type().TypeID(), schema.TypeIDInt)
+	assert.Equals(t, plan.Expression().String(), expr.String())
+	for i := int64(0); i < 3; i++ {
+		record := map[string]any{
+			"foo":        map[string]any{"int_list": []any{int64(0), i}},
+			"simple_int": i,
+			"int_list":   []any{int64(10), int64(20), int64(30)},
+		}
+		expected, err := expr.Evaluate(record, nil, nil)
+		assert.NoError(t, err)
+		result, err := plan.Evaluate(record, nil, nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, expected)
+	}
+}
+
+func TestCompile_OtherDataTypes(t *testing.T) {
+	// Records that do not use the types the plan is compiled for are accessed like Evaluate does.
+	expr, err := expressions.New(`$.foo.int_list[-1] + $.foo.int_list[0]`)
+	assert.NoError(t, err)
+	plan, err := expr.Compile(testScope, nil, nil)
+	assert.NoError(t, err)
+	record := map[string]any{"foo": map[string][]int64{"int_list": {2, 3}}}
+	result, err := plan.Evaluate(record, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(5)))
+}
+
+func TestCompile_EvaluationErrors(t *testing.T) {
+	expr, err := expressions.New(`$.foo.int_list[2]`)
+	assert.NoError(t, err)
+	plan, err := expr.Compile(testScope, nil, nil)
+	assert.NoError(t, err)
+	records := []any{
+		map[string]any{"foo": map[string]any{"int_list": []any{int64(1)}}},
+		map[string]any{"foo": map[string]any{}},
+	}
+	for _, record := range records {
+		_, expectedErr := expr.Evaluate(record, nil, nil)
+		assert.Error(t, expectedErr)
+		_, err := plan.Evaluate(record, nil, nil)
+		assert.Error(t, err)
+		assert.Equals(t, err.Error(), expectedErr.Error())
+	}
+}
+
+func TestCompile_TypeError(t *testing.T) {
+	expr, err := expressions.New(`$.simple_str + 1`)
+	assert.NoError(t, err)
+	_, err = expr.Compile(testScope, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestCompile_Concurrent(t *testing.T) {
+	expr, err := expressions.New(`$.simple_int + $.simple_int`)
+	assert.NoError(t, err)
+	plan, err := expr.Compile(testScope, nil, nil)
+	assert.NoError(t, err)
+	results := make([]any, 20)
+	wg := &sync.WaitGroup{}
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], _ = plan.Evaluate(map[string]any{"simple_int": int64(i)}, nil, nil)
+		}()
+	}
+	wg.Wait()
+	for i, result := range results {
+		assert.Equals(t, result, any(int64(2*i)))
+	}
+}
+
+// newDefaultsScope returns a scope with a string field that has a default value, and one that has none.
+func newDefaultsScope(defaultValue string) schema.Scope {
+	return schema.NewScopeSchema(
+		schema.NewObjectSchema(
+			"root",
+			map[string]*schema.PropertySchema{
+				"with_default": schema.NewPropertySchema(
+					schema.NewStringSchema(nil, nil, nil), nil, false, nil, nil, nil, &defaultValue, nil,
+				),
+				"without_default": schema.NewPropertySchema(
+					schema.NewStringSchema(nil, nil, nil), nil, false, nil, nil, nil, nil, nil,
+				),
+			},
+		),
+	)
+}
+
+func TestCompile_SchemaDefaults(t *testing.T) {
+	scope := newDefaultsScope(`"hello"`)
+	expr, err := expressions.NewWithOptions(`$.with_default`, expressions.Options{SchemaDefaults: true})
+	assert.NoError(t, err)
+	plan, err := expr.Compile(scope, nil, nil)
+	assert.NoError(t, err)
+	result, err := plan.Evaluate(map[string]any{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("hello"))
+	// Values in the record are used over the default.
+	result, err = plan.Evaluate(map[string]any{"with_default": "world"}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("world"))
+
+	// Fields without a default still fail.
+	expr, err = expressions.NewWithOptions(`$.without_default`, expressions.Options{SchemaDefaults: true})
+	assert.NoError(t, err)
+	plan, err = expr.Compile(scope, nil, nil)
+	assert.NoError(t, err)
+	_, err = plan.Evaluate(map[string]any{}, nil, nil)
+	assert.Error(t, err)
+
+	// Without the option, the default is not used.
+	expr, err = expressions.New(`$.with_default`)
+	assert.NoError(t, err)
+	plan, err = expr.Compile(scope, nil, nil)
+	assert.NoError(t, err)
+	_, err = plan.Evaluate(map[string]any{}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestCompile_SchemaDefaultsUnquoted(t *testing.T) {
+	// Like the SDK, string defaults written as YAML are accepted without quotes.
+	expr, err := expressions.NewWithOptions(`$.with_default`, expressions.Options{SchemaDefaults: true})
+	assert.NoError(t, err)
+	plan, err := expr.Compile(newDefaultsScope(`hello`), nil, nil)
+	assert.NoError(t, err)
+	result, err := plan.Evaluate(map[string]any{}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("hello"))
+}
+
+func BenchmarkAccessPlan(b *testing.B) {
+	expr, err := expressions.New(`$.foo.int_list[1] > 5 && $.foo.int_list[1] < 10 && $.simple_str == "abc"`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	record := map[string]any{
+		"foo":        map[string]any{"int_list": []any{int64(1), int64(7)}},
+		"simple_str": "abc",
+	}
+	b.Run("evaluate", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = expr.Evaluate(record, nil, nil)
+		}
+	})
+	b.Run("plan", func(b *testing.B) {
+		plan, err := expr.Compile(testScope, nil, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = plan.Evaluate(record, nil, nil)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package expressions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// Policy restricts which functions an expression may call, and which data it may reference. This is useful when
+// the expressions are written by users that should not have access to all data or functions, such as in multi-tenant
+// deployments. Set it in the Options passed to NewWithOptions. The policy is enforced by Type, Dependencies,
+// TypedDependencies, Validate, and Evaluate.
+//
+// Paths are written as dot-separated items starting with the root, such as `$.steps.*`, where `*` matches any
+// single item. A path pattern matches the path itself and all values within it. References with dynamic keys, such
+// as `$.steps[$.name]`, are checked as if they referenced the value before the dynamic key.
+type Policy struct {
+	// AllowedFunctions lists the functions that may be called. If nil, all functions not in DeniedFunctions are
+	// allowed.
+	AllowedFunctions []string
+	// DeniedFunctions lists the functions that may not be called.
+	DeniedFunctions []string
+	// AllowedPaths lists the path patterns that may be referenced. If nil, all paths not matching DeniedPaths are
+	// allowed.
+	AllowedPaths []string
+	// DeniedPaths lists the path patterns that may not be referenced. A reference is also denied if it contains a
+	// denied path, so denying `$.secrets` also denies referencing `$`.
+	DeniedPaths []string
+}
+
+// PolicyViolationError is returned when an expression uses a function or references a path that the policy does
+// not allow.
+type PolicyViolationError struct {
+	// Function is the name of the function that is not allowed, if the violation is a function call.
+	Function string
+	// Path is the path that is not allowed, if the violation is a reference.
+	Path Path
+	// Position is the position of the violating code in the expression.
+	Position ast.Position
+}
+
+func (e *PolicyViolationError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *PolicyViolationError) LocalizedError(localize func(format string, args ...any) string) string {
+	if e.Function != "" {
+		return localize("calling the function %q is not allowed by the policy (at %s)", e.Function, e.Position)
+	}
+	return localize("referencing %s is not allowed by the policy (at %s)", e.Path.String(), e.Position)
+}
+
+func (e *PolicyViolationError) ErrorCode() ErrorCode {
+	return ErrorCodePolicyViolation
+}
+
+// CheckAccessPolicy statically checks that the expression only calls the functions and references the paths that the
+// policy allows, and returns a violation for each call and reference that it does not allow, ordered by their
+// position. Unlike setting the policy in the Options, this needs no data, scope, or functions, so expressions can be
+// checked when they are submitted, before any data flows. A nil policy allows everything.
+func CheckAccessPolicy(expr Expression, policy *Policy) []*PolicyViolationError {
+	violations := policy.violations(expr.AST())
+	slices.SortStableFunc(violations, func(a, b *PolicyViolationError) int {
+		if a.Position.Line != b.Position.Line {
+			return a.Position.Line - b.Position.Line
+		}
+		return a.Position.Column - b.Position.Column
+	})
+	return violations
+}
+
+// check returns a PolicyViolationError for the first function call or reference in the tree that the policy does
+// not allow. A nil policy allows everything.
+func (p *Policy) check(root ast.Node) error {
+	if violations := p.violations(root); len(violations) > 0 {
+		return violations[0]
+	}
+	return nil
+}
+
+// violations returns a PolicyViolationError for each function call in the tree that the policy does not allow,
+// followed by one for each reference that it does not allow.
+func (p *Policy) violations(root ast.Node) []*PolicyViolationError {
+	if p == nil {
+		return nil
+	}
+	var result []*PolicyViolationError
+	ast.Inspect(root, func(node ast.Node) bool {
+		if functionCall, isFunctionCall := node.(*ast.FunctionCall); isFunctionCall {
+			name := functionCall.FuncIdentifier.IdentifierName
+			if !p.functionAllowed(name) {
+				result = append(result, &PolicyViolationError{Function: name, Position: functionCall.Start()})
+			}
+		}
+		return true
+	})
+	for _, ref := range findReferences(root) {
+		path := ref.path()
+		if !p.pathAllowed(path) {
+			result = append(result, &PolicyViolationError{Path: path, Position: ref.node.Start()})
+		}
+	}
+	return result
+}
+
+func (p *Policy) functionAllowed(name string) bool {
+	if slices.Contains(p.DeniedFunctions, name) {
+		return false
+	}
+	return p.AllowedFunctions == nil || slices.Contains(p.AllowedFunctions, name)
+}
+
+func (p *Policy) pathAllowed(path Path) bool {
+	for _, pattern := range p.DeniedPaths {
+		// The path is denied if it is within the denied path, or if the denied path is within it.
+		if pathPatternMatches(pattern, path) || pathContainsPattern(path, pattern) {
+			return false
+		}
+	}
+	if p.AllowedPaths == nil {
+		return true
+	}
+	for _, pattern := range p.AllowedPaths {
+		if pathPatternMatches(pattern, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathPatternMatches returns true if the path is the value matched by the pattern, or a value within it.
+func pathPatternMatches(pattern string, path Path) bool {
+	patternItems := strings.Split(pattern, ".")
+	if len(patternItems) > len(path) {
+		return false
+	}
+	return pathItemsMatch(patternItems, path[:len(patternItems)])
+}
+
+// pathContainsPattern returns true if the value matched by the pattern is within the path.
+func pathContainsPattern(path Path, pattern string) bool {
+	patternItems := strings.Split(pattern, ".")
+	if len(path) > len(patternItems) {
+		return false
+	}
+	return pathItemsMatch(patternItems[:len(path)], path)
+}
+
+func pathItemsMatch(patternItems []string, path Path) bool {
+	for i, patternItem := range patternItems {
+		if patternItem != "*" && patternItem != fmt.Sprintf("%v", path[i]) {
+			return false
+		}
+	}
+	return true
+}
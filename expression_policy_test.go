@@ -0,0 +1,107 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestPolicy_Paths(t *testing.T) {
+	policy := &expressions.Policy{
+		AllowedPaths: []string{"$.steps.*"},
+		DeniedPaths:  []string{"$.steps.secret"},
+	}
+	testCases := map[string]bool{
+		`$.steps.a.outputs`:          true,
+		`steps.a`:                    true,
+		`$.steps["a"]`:               true,
+		`$.steps.a[$.steps.b.index]`: true,
+		`$.steps`:                    false,
+		`$.other`:                    false,
+		`$.steps.secret.value`:       false,
+		`$.steps[$.steps.a.name]`:    false,
+		`"literal"`:                  true,
+	}
+	for expression, allowed := range testCases {
+		expr, err := expressions.NewWithOptions(expression, expressions.Options{Policy: policy})
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(map[string]any{}, nil, nil)
+		var policyErr *expressions.PolicyViolationError
+		if errors.As(err, &policyErr) == allowed {
+			t.Fatalf("unexpected policy result for %s: %v", expression, err)
+		}
+	}
+}
+
+func TestPolicy_Functions(t *testing.T) {
+	policy := &expressions.Policy{
+		AllowedFunctions: []string{"intToString", "env"},
+		DeniedFunctions:  []string{"env"},
+	}
+	expr, err := expressions.NewWithOptions(`intToString(env("HOME"))`, expressions.Options{Policy: policy})
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, nil, nil)
+	var policyErr *expressions.PolicyViolationError
+	assert.Equals(t, errors.As(err, &policyErr), true)
+	assert.Equals(t, policyErr.Function, "env")
+	assert.Equals(t, policyErr.Position.String(), "1:13")
+	assert.Contains(t, err.Error(), `"env"`)
+
+	expr, err = expressions.NewWithOptions(`other()`, expressions.Options{Policy: policy})
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, nil, nil)
+	assert.Equals(t, errors.As(err, &policyErr), true)
+	assert.Equals(t, policyErr.Function, "other")
+}
+
+func TestPolicy_Dependencies(t *testing.T) {
+	policy := &expressions.Policy{DeniedPaths: []string{"$.foo"}}
+	expr, err := expressions.NewWithOptions(`$.simple_int + 1`, expressions.Options{Policy: policy})
+	assert.NoError(t, err)
+	paths, err := expr.Dependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(paths), 1)
+
+	expr, err = expressions.NewWithOptions(`$.foo.bar`, expressions.Options{Policy: policy})
+	assert.NoError(t, err)
+	_, err = expr.Dependencies(testScope, nil, nil, fullDataRequirements)
+	var policyErr *expressions.PolicyViolationError
+	assert.Equals(t, errors.As(err, &policyErr), true)
+	assert.Equals(t, policyErr.Path.String(), "$.foo.bar")
+	_, err = expr.Type(testScope, nil, nil)
+	assert.Error(t, err)
+	assert.Equals(t, expr.Validate(testScope, nil, nil).HasErrors(), true)
+
+	// The whole root contains the denied path.
+	expr, err = expressions.NewWithOptions(`$`, expressions.Options{Policy: policy})
+	assert.NoError(t, err)
+	_, err = expr.Dependencies(testScope, map[string]schema.Function{}, nil, fullDataRequirements)
+	assert.Error(t, err)
+}
+
+func TestCheckAccessPolicy(t *testing.T) {
+	policy := &expressions.Policy{
+		AllowedFunctions: []string{"toUpper"},
+		AllowedPaths:     []string{"$.steps.*.outputs", "$.input"},
+	}
+	expr, err := expressions.New(`$.secrets.token + toUpper($steps.a.outputs.x) + env($.input.name) + $steps.b`)
+	assert.NoError(t, err)
+	violations := expressions.CheckAccessPolicy(expr, policy)
+	assert.Equals(t, len(violations), 3)
+	assert.Equals(t, violations[0].Path, expressions.Path{"$", "secrets", "token"})
+	assert.Equals(t, violations[0].Position.String(), "1:1")
+	assert.Equals(t, violations[1].Function, "env")
+	assert.Equals(t, violations[1].Position.String(), "1:49")
+	assert.Equals(t, violations[2].Path, expressions.Path{"$", "steps", "b"})
+	assert.Equals(t, violations[2].Position.String(), "1:69")
+
+	assert.Equals(t, len(expressions.CheckAccessPolicy(expr, nil)), 0)
+
+	// The key k in $.input[k] is a key of $.input, not a reference to $.k.
+	expr, err = expressions.New(`$.input[k]`)
+	assert.NoError(t, err)
+	assert.Equals(t, len(expressions.CheckAccessPolicy(expr, policy)), 0)
+}
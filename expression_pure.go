@@ -0,0 +1,125 @@
+package expressions
+
+import (
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// PureFunction can be implemented by functions to declare whether they are pure. A pure function always returns the
+// same result for the same arguments, and has no side effects. Calls to pure functions with only literal arguments
+// are folded into literals when the expression is parsed with Options.Functions, and are not reported as dependencies.
+// Functions that do not implement PureFunction are treated as impure.
+type PureFunction interface {
+	// Pure returns true if the function is pure.
+	Pure() bool
+}
+
+// NewPureFunction declares the function as pure. The returned function keeps implementing
+// LiteralArgumentValidator and LiteralArgumentTyper if the passed function does. To declare an overloaded function as
+// pure, declare each overload as pure before creating the OverloadedFunction.
+func NewPureFunction(function schema.CallableFunction) schema.CallableFunction {
+	return pureFunction{function}
+}
+
+// pureFunction is a function that was declared as pure with NewPureFunction.
+type pureFunction struct {
+	schema.CallableFunction
+}
+
+// Pure returns true.
+func (f pureFunction) Pure() bool {
+	return true
+}
+
+// ValidateLiteralArguments validates the literal arguments with the wrapped function, if it implements
+// LiteralArgumentValidator.
+func (f pureFunction) ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error {
+	if validator, isValidator := f.CallableFunction.(LiteralArgumentValidator); isValidator {
+		return validator.ValidateLiteralArguments(literals, argumentTypes)
+	}
+	return nil
+}
+
+// OutputForLiteralArguments returns the output type from the wrapped function, using the literal arguments if it
+// implements LiteralArgumentTyper.
+func (f pureFunction) OutputForLiteralArguments(literals map[int]any, argumentTypes []schema.Type) (schema.Type, error) {
+	if typer, isTyper := f.CallableFunction.(LiteralArgumentTyper); isTyper {
+		return typer.OutputForLiteralArguments(literals, argumentTypes)
+	}
+	outputType, _, err := f.Output(argumentTypes)
+	return outputType, err
+}
+
+// isPure returns true if the function declares itself as pure.
+func isPure(function schema.Function) bool {
+	pure, declaresPurity := function.(PureFunction)
+	return declaresPurity && pure.Pure()
+}
+
+// foldPureCalls replaces the calls to pure functions in the tree whose arguments are all literals with the literal
+// of their result, and returns the new root node. Calls are folded from the innermost, so a call whose arguments are
+// folded calls is folded too. Calls to functions the policy does not allow are not folded, so the policy is still
+// enforced, and calls whose result is not an integer, float, string, or boolean are not folded, because there is no
+// literal for them.
+func foldPureCalls(node ast.Node, functions map[string]schema.CallableFunction, policy *Policy) (ast.Node, error) {
+	var err error
+	switch n := node.(type) {
+	case *ast.FunctionCall:
+		for i, argument := range n.ArgumentInputs.Arguments {
+			if n.ArgumentInputs.Arguments[i], err = foldPureCalls(argument, functions, policy); err != nil {
+				return nil, err
+			}
+		}
+		return foldPureCall(n, functions, policy)
+	case *ast.DotNotation:
+		n.LeftAccessibleNode, err = foldPureCalls(n.LeftAccessibleNode, functions, policy)
+	case *ast.BracketAccessor:
+		if n.LeftNode, err = foldPureCalls(n.LeftNode, functions, policy); err != nil {
+			return nil, err
+		}
+		n.RightExpression, err = foldPureCalls(n.RightExpression, functions, policy)
+	case *ast.BinaryOperation:
+		if n.LeftNode, err = foldPureCalls(n.LeftNode, functions, policy); err != nil {
+			return nil, err
+		}
+		n.RightNode, err = foldPureCalls(n.RightNode, functions, policy)
+	case *ast.UnaryOperation:
+		n.RightNode, err = foldPureCalls(n.RightNode, functions, policy)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// foldPureCall evaluates the call and returns the literal of the result if it can be folded, or the call otherwise.
+func foldPureCall(node *ast.FunctionCall, functions map[string]schema.CallableFunction, policy *Policy) (ast.Node, error) {
+	name := node.FuncIdentifier.IdentifierName
+	function, found := functions[name]
+	if !found || !isPure(function) || (policy != nil && !policy.functionAllowed(name)) {
+		return node, nil
+	}
+	for _, argument := range node.ArgumentInputs.Arguments {
+		if _, isLiteral := argument.(ast.ValueLiteral); !isLiteral {
+			return node, nil
+		}
+	}
+	// Deferred values are not awaited, so they are not folded.
+	context := &evaluateContext{functions: functions, deferredRoot: node}
+	result, err := context.evaluateFuncCall(node)
+	if err != nil {
+		return nil, err
+	}
+	switch value := result.(type) {
+	case int64:
+		return &ast.IntLiteral{NodeSpan: node.NodeSpan, IntValue: value}, nil
+	case float64:
+		return &ast.FloatLiteral{NodeSpan: node.NodeSpan, FloatValue: value}, nil
+	case string:
+		return &ast.StringLiteral{NodeSpan: node.NodeSpan, StrValue: value}, nil
+	case bool:
+		return &ast.BooleanLiteral{NodeSpan: node.NodeSpan, BooleanValue: value}, nil
+	default:
+		return node, nil
+	}
+}
@@ -0,0 +1,105 @@
+package expressions_test
+
+import (
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func newDoubleFunction(t *testing.T) schema.CallableFunction {
+	double, err := schema.NewCallableFunction(
+		"double",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		nil,
+		func(a int64) (int64, error) {
+			if a == 0 {
+				return 0, fmt.Errorf("zero input")
+			}
+			return a * 2, nil
+		},
+	)
+	assert.NoError(t, err)
+	return double
+}
+
+func TestPureFunction_Folding(t *testing.T) {
+	double := newDoubleFunction(t)
+	options := expressions.Options{
+		Functions: map[string]schema.CallableFunction{
+			"double": expressions.NewPureFunction(double),
+			"impure": double,
+		},
+	}
+
+	expr, err := expressions.NewWithOptions("double(double(1)) + $.simple_int", options)
+	assert.NoError(t, err)
+	left := expr.AST().(*ast.BinaryOperation).LeftNode
+	literal, isLiteral := left.(*ast.IntLiteral)
+	assert.Equals(t, isLiteral, true)
+	assert.Equals(t, literal.IntValue, int64(4))
+	assert.Equals(t, literal.Start(), ast.Position{Line: 1, Column: 1})
+	// The folded call does not need the function when evaluated.
+	result, err := expr.Evaluate(map[string]any{"simple_int": int64(1)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(5)))
+
+	for _, expression := range []string{"impure(1)", "double($.simple_int)"} {
+		expr, err = expressions.NewWithOptions(expression, options)
+		assert.NoError(t, err)
+		_, isFunctionCall := expr.AST().(*ast.FunctionCall)
+		assert.Equals(t, isFunctionCall, true)
+	}
+
+	// A call that is not allowed by the policy is not folded, so the policy still applies.
+	options.Policy = &expressions.Policy{DeniedFunctions: []string{"double"}}
+	expr, err = expressions.NewWithOptions("double(1)", options)
+	assert.NoError(t, err)
+	_, isFunctionCall := expr.AST().(*ast.FunctionCall)
+	assert.Equals(t, isFunctionCall, true)
+}
+
+func TestPureFunction_FoldingError(t *testing.T) {
+	options := expressions.Options{
+		Functions: map[string]schema.CallableFunction{
+			"double": expressions.NewPureFunction(newDoubleFunction(t)),
+		},
+	}
+	_, err := expressions.NewWithOptions("double(0)", options)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "zero input")
+}
+
+func TestPureFunction_Dependencies(t *testing.T) {
+	double := newDoubleFunction(t)
+	functions := map[string]schema.Function{
+		"double": expressions.NewPureFunction(double),
+		"impure": double,
+	}
+	testCases := map[string][]string{
+		"double(2)":                 {},
+		"double(double(2))":         {},
+		"impure(2)":                 {"impure"},
+		"double(impure(2))":         {"double", "impure"},
+		"double($.simple_int)":      {"$.simple_int", "double"},
+		"double(2) + $.simple_int":  {"$.simple_int"},
+		"double(1 + 2) + double(3)": {},
+	}
+	for expression, expectedDependencies := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			dependencies, err := expr.Dependencies(testScope, functions, nil, withFunctionsRequirements)
+			assert.NoError(t, err)
+			assert.Equals(t, len(dependencies), len(expectedDependencies))
+			for _, expectedDependency := range expectedDependencies {
+				assert.SliceContainsExtractor(t, pathStrExtractor, expectedDependency, dependencies)
+			}
+		})
+	}
+}
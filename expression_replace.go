@@ -0,0 +1,101 @@
+package expressions
+
+import (
+	"sort"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func (e expression) ReplaceSubexpression(
+	match func(node ast.Node) bool,
+	replacement func(node ast.Node) string,
+) (_ Expression, err error) {
+	defer recoverInternalError("replacing subexpressions of the expression", &err)
+	var replacements []textReplacement
+	if err := e.collectReplacements(e.ast, nil, match, replacement, &replacements); err != nil {
+		return nil, err
+	}
+	if len(replacements) == 0 {
+		return NewWithOptions(e.expression, e.options)
+	}
+	// Replace from the end, so the earlier indexes stay valid.
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	result := []rune(e.expression)
+	for _, r := range replacements {
+		result = append(result[:r.start], append([]rune(r.replacement), result[r.end:]...)...)
+	}
+	return NewWithOptions(string(result), e.options)
+}
+
+// collectReplacements adds the replacements of the node and of its descendants that match. The descendants of a
+// matching node are not visited, since the node is replaced as a whole.
+func (e expression) collectReplacements(
+	node ast.Node,
+	parent ast.Node,
+	match func(node ast.Node) bool,
+	replacement func(node ast.Node) string,
+	replacements *[]textReplacement,
+) error {
+	if !match(node) {
+		for _, child := range ast.Children(node) {
+			if err := e.collectReplacements(child, node, match, replacement, replacements); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	text := replacement(node)
+	if text == "" {
+		return Errorf("the replacement of %q is empty", node.String())
+	}
+	if needsParentheses(node, parent) {
+		parenthesize, err := isOperation(text)
+		if err != nil {
+			return Errorf("invalid replacement %q of %q (%w)", text, node.String(), err)
+		}
+		if parenthesize {
+			text = "(" + text + ")"
+		}
+	}
+	start, end, err := e.nodeRuneIndexes(node, node)
+	if err != nil {
+		return err
+	}
+	*replacements = append(*replacements, textReplacement{start: start, end: end, replacement: text})
+	return nil
+}
+
+// needsParentheses returns true if a replacement of the node that is an operation must be put in parentheses to keep
+// the structure of the expression, which is the case for operands and for the accessed value of accesses.
+func needsParentheses(node ast.Node, parent ast.Node) bool {
+	switch p := parent.(type) {
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		return true
+	case *ast.DotNotation:
+		return node == p.LeftAccessibleNode
+	case *ast.BracketAccessor:
+		return node == p.LeftNode
+	default:
+		return false
+	}
+}
+
+// isOperation returns true if the expression string is a binary or unary operation.
+func isOperation(expressionString string) (bool, error) {
+	parser, err := ast.InitParser(expressionString, "")
+	if err != nil {
+		return false, err
+	}
+	root, err := parser.ParseExpression()
+	if err != nil {
+		return false, err
+	}
+	switch root.(type) {
+	case *ast.BinaryOperation, *ast.UnaryOperation:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
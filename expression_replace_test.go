@@ -0,0 +1,100 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func TestReplaceSubexpression(t *testing.T) {
+	expr, err := expressions.New(`$.foo.bar  +  5 * 5`)
+	assert.NoError(t, err)
+
+	// Replace a literal with a reference. The formatting of the rest is kept.
+	replaced, err := expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			literal, isInt := node.(*ast.IntLiteral)
+			return isInt && literal.IntValue == 5
+		},
+		func(node ast.Node) string {
+			return "$.simple_int"
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, replaced.String(), `$.foo.bar  +  $.simple_int * $.simple_int`)
+
+	// Operations are put in parentheses where needed.
+	replaced, err = expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			_, isInt := node.(*ast.IntLiteral)
+			return isInt
+		},
+		func(node ast.Node) string {
+			return "1 + 2"
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, replaced.String(), `$.foo.bar  +  (1 + 2) * (1 + 2)`)
+	replaced, err = expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			_, isOperation := node.(*ast.BinaryOperation)
+			return isOperation
+		},
+		func(node ast.Node) string {
+			return "1 - 2"
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, replaced.String(), `1 - 2`)
+
+	// Expressions without matches are kept.
+	replaced, err = expr.ReplaceSubexpression(
+		func(node ast.Node) bool { return false },
+		func(node ast.Node) string { return "" },
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, replaced.String(), expr.String())
+}
+
+func TestReplaceSubexpression_RenamedFunction(t *testing.T) {
+	expr, err := expressions.New(`toUpper(old($.simple_str), old(1))`)
+	assert.NoError(t, err)
+	replaced, err := expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			identifier, isIdentifier := node.(*ast.Identifier)
+			return isIdentifier && identifier.IdentifierName == "old"
+		},
+		func(node ast.Node) string {
+			return "new"
+		},
+	)
+	assert.NoError(t, err)
+	assert.Equals(t, replaced.String(), `toUpper(new($.simple_str), new(1))`)
+}
+
+func TestReplaceSubexpression_Invalid(t *testing.T) {
+	expr, err := expressions.New(`$.a + 1`)
+	assert.NoError(t, err)
+	_, err = expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			_, isInt := node.(*ast.IntLiteral)
+			return isInt
+		},
+		func(node ast.Node) string {
+			return "1 +"
+		},
+	)
+	assert.Error(t, err)
+	_, err = expr.ReplaceSubexpression(
+		func(node ast.Node) bool {
+			_, isInt := node.(*ast.IntLiteral)
+			return isInt
+		},
+		func(node ast.Node) string {
+			return ""
+		},
+	)
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package expressions
+
+import (
+	"reflect"
+	"sync"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// maxResolutionCacheEntries limits how many distinct schema/function combinations are cached per expression.
+const maxResolutionCacheEntries = 16
+
+// resolutionCache caches the result of the dependency resolution of an expression for a given root type, function
+// set, and workflow context. This is useful so that calling Type and Dependencies on the same expression with the
+// same inputs doesn't traverse the AST multiple times.
+//
+// The functions are compared by their content, so maps that are created for each call, such as by
+// FunctionRegistry.Functions, hit the cache, and modified maps do not return stale results. The workflow context is
+// identified by the map and its number of files, since comparing the content of the files may cost more than the
+// resolution the cache saves. Added and removed files are detected, but the cache assumes that the content of a file
+// is not replaced in the same map. The root type is identified by its pointer identity, so the cache assumes that
+// schemas are not modified after they have been passed to the expression.
+type resolutionCache struct {
+	lock    sync.Mutex
+	entries []resolutionCacheEntry
+}
+
+type resolutionCacheEntry struct {
+	// The root type is stored to keep it from being garbage collected, which would allow its address to be reused
+	// while the entry is still in the cache.
+	rootType        schema.Type
+	rootTypePointer uintptr
+	// functions is a copy of the function map, so changes of the map passed by the caller are detected.
+	functions map[string]schema.Function
+	// The workflow context is stored for the same reason as the root type.
+	workflowContext        map[string][]byte
+	workflowContextPointer uintptr
+	workflowContextLength  int
+	result                 *dependencyResult
+}
+
+func newResolutionCache() *resolutionCache {
+	return &resolutionCache{}
+}
+
+// matches returns true if the entry holds the result for the inputs.
+func (e resolutionCacheEntry) matches(
+	rootTypePointer uintptr,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) bool {
+	if e.rootTypePointer != rootTypePointer ||
+		e.workflowContextPointer != reflect.ValueOf(workflowContext).Pointer() ||
+		e.workflowContextLength != len(workflowContext) {
+		return false
+	}
+	if len(e.functions) != len(functions) {
+		return false
+	}
+	for name, function := range functions {
+		cached, found := e.functions[name]
+		if !found || !sameFunction(cached, function) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameFunction returns true if both are the same function value. Functions whose values cannot be compared are
+// treated as different.
+func sameFunction(a schema.Function, b schema.Function) bool {
+	aValue := reflect.ValueOf(a)
+	bValue := reflect.ValueOf(b)
+	if !aValue.IsValid() || !bValue.IsValid() {
+		return aValue.IsValid() == bValue.IsValid()
+	}
+	if aValue.Type() != bValue.Type() || !aValue.Comparable() || !bValue.Comparable() {
+		return false
+	}
+	return aValue.Equal(bValue)
+}
+
+// resolve returns the cached dependency resolution result for the specified inputs, or runs the resolution with
+// resolver and caches the result if it succeeds. Root types that cannot be identified by their address are not
+// cached.
+func (r *resolutionCache) resolve(
+	rootType schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	resolver func() (*dependencyResult, error),
+) (*dependencyResult, error) {
+	if r == nil {
+		return resolver()
+	}
+	rootTypeValue := reflect.ValueOf(rootType)
+	if !rootTypeValue.IsValid() || rootTypeValue.Kind() != reflect.Pointer {
+		return resolver()
+	}
+	rootTypePointer := rootTypeValue.Pointer()
+	r.lock.Lock()
+	for _, entry := range r.entries {
+		if entry.matches(rootTypePointer, functions, workflowContext) {
+			r.lock.Unlock()
+			return entry.result, nil
+		}
+	}
+	r.lock.Unlock()
+	result, err := resolver()
+	if err != nil {
+		return nil, err
+	}
+	functionsCopy := make(map[string]schema.Function, len(functions))
+	for name, function := range functions {
+		functionsCopy[name] = function
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if len(r.entries) >= maxResolutionCacheEntries {
+		r.entries = nil
+	}
+	r.entries = append(r.entries, resolutionCacheEntry{
+		rootType:               rootType,
+		rootTypePointer:        rootTypePointer,
+		functions:              functionsCopy,
+		workflowContext:        workflowContext,
+		workflowContextPointer: reflect.ValueOf(workflowContext).Pointer(),
+		workflowContextLength:  len(workflowContext),
+		result:                 result,
+	})
+	return result, nil
+}
@@ -0,0 +1,98 @@
+package expressions
+
+import (
+	"fmt"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// InvalidFunctionResultError is the cause of the FunctionCallError returned by Evaluate when a function returns a
+// value that does not match its declared output type, and Options.ValidateFunctionResults is set.
+type InvalidFunctionResultError struct {
+	// Function is the name of the function as registered.
+	Function string
+	// OutputType is the type the function declares as its output.
+	OutputType schema.Type
+	// Cause is the error returned by the validation of the value.
+	Cause error
+}
+
+func (e *InvalidFunctionResultError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *InvalidFunctionResultError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize(
+		"function '%s' returned a value that does not match its declared output type %s (%v)",
+		e.Function, e.OutputType.TypeID(), e.Cause)
+}
+
+func (e *InvalidFunctionResultError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *InvalidFunctionResultError) ErrorCode() ErrorCode {
+	return ErrorCodeInvalidFunctionResult
+}
+
+// validateFunctionResult validates the value returned by the function against the output type the function
+// declares for its parameters. Results of functions whose output type cannot be determined without the types of the
+// arguments are not validated.
+func validateFunctionResult(function schema.Function, value any) error {
+	outputType, _, outputErr := function.Output(function.Parameters())
+	if outputErr == nil && outputType != nil {
+		if err := outputType.Validate(value); err != nil {
+			return &InvalidFunctionResultError{
+				Function:   function.ID(),
+				OutputType: outputType,
+				Cause:      err,
+			}
+		}
+	}
+	return nil
+}
+
+// InvalidResultError is returned by EvaluateAndValidate when the result of the expression does not match the expected
+// type.
+type InvalidResultError struct {
+	// Expression is the expression that was evaluated.
+	Expression string
+	// ExpectedType is the type the result was validated against.
+	ExpectedType schema.Type
+	// Cause is the error returned by the validation of the result.
+	Cause error
+}
+
+func (e *InvalidResultError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *InvalidResultError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize(
+		"the result of expression %s does not match the expected type %s (%v)",
+		e.Expression, e.ExpectedType.TypeID(), e.Cause)
+}
+
+func (e *InvalidResultError) Unwrap() error {
+	return e.Cause
+}
+
+func (e *InvalidResultError) ErrorCode() ErrorCode {
+	return ErrorCodeInvalidResult
+}
+
+func (e expression) EvaluateAndValidate(
+	data any,
+	expectedType schema.Type,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (any, error) {
+	result, err := e.Evaluate(data, functions, workflowContext)
+	if err != nil {
+		return nil, err
+	}
+	if err := expectedType.Validate(result); err != nil {
+		return nil, &InvalidResultError{Expression: e.expression, ExpectedType: expectedType, Cause: err}
+	}
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package expressions_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// misbehavingFunction returns a value of another type than its declared output type.
+type misbehavingFunction struct {
+	schema.CallableFunction
+	result any
+}
+
+func (f misbehavingFunction) Call(_ []any) (any, error) {
+	return f.result, nil
+}
+
+func TestValidateFunctionResults(t *testing.T) {
+	lowercase, err := schema.NewCallableFunction(
+		"lowercase",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, regexp.MustCompile(`^[a-z]*$`)),
+		false,
+		nil,
+		func(value string) string {
+			return value
+		},
+	)
+	assert.NoError(t, err)
+	functions := map[string]schema.CallableFunction{
+		"lowercase": lowercase,
+		"wrongType": misbehavingFunction{CallableFunction: lowercase, result: int64(1)},
+	}
+
+	for _, expression := range []string{`lowercase("ABC")`, `wrongType("abc")`} {
+		// Without validation, the results are returned as-is.
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(nil, functions, nil)
+		assert.NoError(t, err)
+
+		expr, err = expressions.NewWithOptions(expression, expressions.Options{ValidateFunctionResults: true})
+		assert.NoError(t, err)
+		_, err = expr.Evaluate(nil, functions, nil)
+		var resultErr *expressions.InvalidFunctionResultError
+		assert.Equals(t, errors.As(err, &resultErr), true)
+		assert.Equals(t, resultErr.Function, "lowercase")
+		var callErr *expressions.FunctionCallError
+		assert.Equals(t, errors.As(err, &callErr), true)
+		assert.Equals(t, callErr.Function, expression[:len(expression)-7])
+	}
+
+	expr, err := expressions.NewWithOptions(`lowercase("abc")`, expressions.Options{ValidateFunctionResults: true})
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(nil, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("abc"))
+}
+
+func TestEvaluateAndValidate(t *testing.T) {
+	expectedType := schema.NewStringSchema(nil, nil, regexp.MustCompile(`^[a-z]*$`))
+	expr, err := expressions.New(`$.simple_str`)
+	assert.NoError(t, err)
+	result, err := expr.EvaluateAndValidate(map[string]any{"simple_str": "abc"}, expectedType, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("abc"))
+
+	_, err = expr.EvaluateAndValidate(map[string]any{"simple_str": "ABC"}, expectedType, nil, nil)
+	var resultErr *expressions.InvalidResultError
+	assert.Equals(t, errors.As(err, &resultErr), true)
+	assert.Equals(t, resultErr.Expression, `$.simple_str`)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeInvalidResult)
+	assert.Contains(t, err.Error(), `$.simple_str`)
+
+	_, err = expr.EvaluateAndValidate(map[string]any{"simple_str": int64(1)}, expectedType, nil, nil)
+	assert.Equals(t, errors.As(err, &resultErr), true)
+
+	// Evaluation errors are returned as they are.
+	_, err = expr.EvaluateAndValidate(map[string]any{}, expectedType, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+}
@@ -0,0 +1,347 @@
+package expressions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+// referenceSegment is one item of a reference path, together with the node that ends with the item.
+type referenceSegment struct {
+	pathItem any
+	// node is the AST node that ends with this path item. It is nil for the implicit root.
+	node ast.Node
+}
+
+// reference is a path to a value referenced in the expression, built from the AST.
+type reference struct {
+	segments []referenceSegment
+	// node is the node of the whole reference.
+	node ast.Node
+}
+
+func (r reference) path() Path {
+	result := make(Path, len(r.segments))
+	for i, segment := range r.segments {
+		result[i] = segment.pathItem
+	}
+	return result
+}
+
+func (r reference) implicitRoot() bool {
+	return r.segments[0].node == nil
+}
+
+// startsWithKey returns true if the reference starts with an identifier without a root, such as k, which is a key of
+// the value it is evaluated on. Where it is evaluated on the root, the root is implicit.
+func (r reference) startsWithKey() bool {
+	return r.implicitRoot() && r.implicitRootPrefix() == ""
+}
+
+// implicitRootPrefix returns the text written before the first field name of a reference with an implicit root, which
+// is $ for named roots, such as $steps, and empty otherwise.
+func (r reference) implicitRootPrefix() string {
+	if identifier, isIdentifier := r.segments[1].node.(*ast.Identifier); isIdentifier && identifier.IsRoot() {
+		return "$"
+	}
+	return ""
+}
+
+// findReferences returns all references to the data in the expression, which are the longest chains of dot
+// notations and bracket accessors with literal keys that start at the root.
+func findReferences(node ast.Node) []reference {
+	var result []reference
+	collectReferences(node, false, &result)
+	return result
+}
+
+// collectReferences adds the references in the tree to the result. The relative parameter indicates that the node is
+// evaluated on the value accessed by a bracket accessor instead of the root data, as its key is, so an identifier
+// without a root, such as k in $.a[k], is a key of that value and not a reference to the root.
+func collectReferences(node ast.Node, relative bool, result *[]reference) {
+	if segments, isReference := referenceSegments(node); isReference {
+		ref := reference{segments: segments, node: node}
+		if !relative || !ref.startsWithKey() {
+			*result = append(*result, ref)
+		}
+		return
+	}
+	switch n := node.(type) {
+	case *ast.DotNotation:
+		// The right identifier is a field name, not a reference to the root.
+		collectReferences(n.LeftAccessibleNode, relative, result)
+	case *ast.BracketAccessor:
+		collectReferences(n.LeftNode, relative, result)
+		collectReferences(n.RightExpression, true, result)
+	case *ast.FunctionCall:
+		// The identifier is a function name, not a reference to the root. The arguments are evaluated on the root.
+		collectReferences(n.ArgumentInputs, false, result)
+	default:
+		// Operations are evaluated on the root.
+		for _, child := range ast.Children(node) {
+			collectReferences(child, false, result)
+		}
+	}
+}
+
+// referenceSegments returns the path segments of the node if the node is a reference to the root data.
+func referenceSegments(node ast.Node) ([]referenceSegment, bool) {
+	switch n := node.(type) {
+	case *ast.Identifier:
+		if n.IdentifierName == "$" {
+			return []referenceSegment{{pathItem: "$", node: n}}, true
+		}
+		if n.RootName() == ast.VariablesRootName {
+			// The variables are not part of the root data.
+			return nil, false
+		}
+		if rootName := n.RootName(); rootName != "" {
+			// A named root is the field of the root with its name, so the root is implicit.
+			return []referenceSegment{{pathItem: "$"}, {pathItem: rootName, node: n}}, true
+		}
+		// Implicit root access.
+		return []referenceSegment{{pathItem: "$"}, {pathItem: n.IdentifierName, node: n}}, true
+	case *ast.DotNotation:
+		leftSegments, isReference := referenceSegments(n.LeftAccessibleNode)
+		if !isReference {
+			return nil, false
+		}
+		identifier, isIdentifier := n.RightAccessIdentifier.(*ast.Identifier)
+		if !isIdentifier {
+			return nil, false
+		}
+		return append(leftSegments, referenceSegment{pathItem: identifier.IdentifierName, node: n}), true
+	case *ast.BracketAccessor:
+		leftSegments, isReference := referenceSegments(n.LeftNode)
+		if !isReference {
+			return nil, false
+		}
+		key, isLiteral := n.RightExpression.(ast.ValueLiteral)
+		if !isLiteral {
+			return nil, false
+		}
+		return append(leftSegments, referenceSegment{pathItem: key.Value(), node: n}), true
+	default:
+		return nil, false
+	}
+}
+
+type textReplacement struct {
+	start       int
+	end         int
+	replacement string
+}
+
+func (e expression) RewritePaths(rewrite func(path Path) (Path, bool)) (_ Expression, err error) {
+	defer recoverInternalError("rewriting the paths of the expression", &err)
+	var replacements []textReplacement
+	for _, ref := range findReferences(e.ast) {
+		oldPath := ref.path()
+		newPath, changed := rewrite(oldPath)
+		if !changed {
+			continue
+		}
+		if len(newPath) == 0 || newPath[0] != "$" {
+			return nil, Errorf("rewritten path %s for %s must start with the root ($)", newPath, oldPath)
+		}
+		referenceReplacements, err := e.renderReference(ref, newPath)
+		if err != nil {
+			return nil, err
+		}
+		replacements = append(replacements, referenceReplacements...)
+	}
+	if len(replacements) == 0 {
+		return NewWithOptions(e.expression, e.options)
+	}
+	// Replace from the end, so the earlier indexes stay valid.
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start > replacements[j].start
+	})
+	result := []rune(e.expression)
+	for _, replacement := range replacements {
+		result = append(result[:replacement.start], append([]rune(replacement.replacement), result[replacement.end:]...)...)
+	}
+	return NewWithOptions(string(result), e.options)
+}
+
+// renderReference creates the replacements for the text of the reference. The text of the parts of the path that
+// are not changed is kept as it is.
+func (e expression) renderReference(ref reference, newPath Path) ([]textReplacement, error) {
+	oldPath := ref.path()
+	if len(oldPath) != len(newPath) {
+		replacement, err := e.renderReferenceSuffix(ref, newPath)
+		if err != nil {
+			return nil, err
+		}
+		return []textReplacement{replacement}, nil
+	}
+	// Same length, so only replace the items that changed.
+	var result []textReplacement
+	for i := 1; i < len(oldPath); i++ {
+		if oldPath[i] == newPath[i] {
+			continue
+		}
+		if i == 1 && ref.implicitRoot() {
+			name, isString := newPath[1].(string)
+			if !isString || !identifierPattern.MatchString(name) || reservedWords[name] {
+				// The root cannot stay implicit, so render the whole reference.
+				replacement, err := e.renderReferenceSuffix(ref, newPath)
+				if err != nil {
+					return nil, err
+				}
+				return []textReplacement{replacement}, nil
+			}
+			start, end, err := e.nodeRuneIndexes(ref.segments[1].node, ref.segments[1].node)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, textReplacement{start: start, end: end, replacement: ref.implicitRootPrefix() + name})
+			continue
+		}
+		_, start, err := e.nodeRuneIndexes(ref.segments[i-1].node, ref.segments[i-1].node)
+		if err != nil {
+			return nil, err
+		}
+		_, end, err := e.nodeRuneIndexes(ref.segments[i].node, ref.segments[i].node)
+		if err != nil {
+			return nil, err
+		}
+		renderedItem, err := renderPathItems(newPath[i : i+1])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, textReplacement{start: start, end: end, replacement: renderedItem})
+	}
+	return result, nil
+}
+
+// nodeRuneIndexes returns the index of the start of the first node, and the index of the end of the second node
+// in the expression string.
+func (e expression) nodeRuneIndexes(startNode ast.Node, endNode ast.Node) (int, int, error) {
+	start, startFound := e.runeIndex(startNode.Start())
+	end, endFound := e.runeIndex(endNode.End())
+	if !startFound || !endFound {
+		return 0, 0, fmt.Errorf("bug: position of %s not found in expression", startNode.String())
+	}
+	return start, end, nil
+}
+
+// renderReferenceSuffix creates the replacement for the text of the whole reference. The text of the longest
+// common prefix of the old and the new path is kept as it is.
+func (e expression) renderReferenceSuffix(ref reference, newPath Path) (textReplacement, error) {
+	start, startFound := e.runeIndex(ref.node.Start())
+	end, endFound := e.runeIndex(ref.node.End())
+	if !startFound || !endFound {
+		return textReplacement{}, fmt.Errorf("bug: position of reference %s not found in expression", ref.node.String())
+	}
+	oldPath := ref.path()
+	commonItems := 0
+	for commonItems < len(oldPath) && commonItems < len(newPath) && oldPath[commonItems] == newPath[commonItems] {
+		commonItems++
+	}
+	var prefix string
+	switch {
+	case commonItems > 1 || (commonItems == 1 && !ref.implicitRoot()):
+		prefixEnd, found := e.runeIndex(ref.segments[commonItems-1].node.End())
+		if !found {
+			return textReplacement{}, fmt.Errorf("bug: position of reference %s not found in expression", ref.node.String())
+		}
+		prefix = string([]rune(e.expression)[start:prefixEnd])
+	case commonItems == 1 && len(newPath) > 1:
+		// Keep the root implicit, if possible.
+		if name, isString := newPath[1].(string); isString && identifierPattern.MatchString(name) && !reservedWords[name] {
+			suffix, err := renderPathItems(newPath[2:])
+			if err != nil {
+				return textReplacement{}, err
+			}
+			return textReplacement{start: start, end: end, replacement: ref.implicitRootPrefix() + name + suffix}, nil
+		}
+		prefix = "$"
+	default:
+		prefix = "$"
+		if commonItems == 0 {
+			commonItems = 1
+		}
+	}
+	suffix, err := renderPathItems(newPath[commonItems:])
+	if err != nil {
+		return textReplacement{}, err
+	}
+	return textReplacement{start: start, end: end, replacement: prefix + suffix}, nil
+}
+
+// runeIndex returns the index of the rune at the specified position of the expression string.
+func (e expression) runeIndex(position ast.Position) (int, bool) {
+	line := position.Line - e.options.LineOffset
+	column := position.Column
+	if line == 1 {
+		column -= e.options.ColumnOffset
+	}
+	currentLine := 1
+	currentColumn := 1
+	index := 0
+	for _, char := range e.expression {
+		if currentLine == line && currentColumn == column {
+			return index, true
+		}
+		if char == '\n' {
+			currentLine++
+			currentColumn = 1
+		} else {
+			currentColumn++
+		}
+		index++
+	}
+	if currentLine == line && currentColumn == column {
+		// The position directly after the last character.
+		return index, true
+	}
+	return 0, false
+}
+
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_]\w*$`)
+
+// reservedWords are valid identifiers that are parsed as literals, unless they follow a dot. They cannot be used as
+// references with an implicit root.
+var reservedWords = map[string]bool{"true": true, "false": true}
+
+// renderPathItems renders the path items as dot notations and bracket accessors.
+func renderPathItems(items []any) (string, error) {
+	var result strings.Builder
+	for _, item := range items {
+		switch i := item.(type) {
+		case string:
+			if identifierPattern.MatchString(i) {
+				result.WriteString("." + i)
+			} else {
+				result.WriteString("[" + quoteString(i) + "]")
+			}
+		case int:
+			result.WriteString(fmt.Sprintf("[%d]", i))
+		case int64:
+			result.WriteString(fmt.Sprintf("[%d]", i))
+		default:
+			return "", Errorf("unsupported path item type %T (%v); expected string or integer", item, item)
+		}
+	}
+	return result.String(), nil
+}
+
+// stringEscaper escapes the characters that are unescaped when parsing string literals.
+var stringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\t", `\t`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\b", `\b`,
+	"\000", `\0`,
+)
+
+// quoteString returns the string as a double-quoted string literal.
+func quoteString(value string) string {
+	return `"` + stringEscaper.Replace(value) + `"`
+}
@@ -0,0 +1,175 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+// renameStep returns a rewrite function that renames the step in $.steps.<from> references.
+func renameStep(from string, to string) func(path expressions.Path) (expressions.Path, bool) {
+	return func(path expressions.Path) (expressions.Path, bool) {
+		if len(path) < 3 || path[1] != "steps" || path[2] != from {
+			return nil, false
+		}
+		newPath := append(expressions.Path{}, path...)
+		newPath[2] = to
+		return newPath, true
+	}
+}
+
+func TestRewritePaths(t *testing.T) {
+	testCases := map[string]struct {
+		expression string
+		expected   string
+	}{
+		"simple": {
+			`$.steps.a.outputs.success`,
+			`$.steps.b.outputs.success`,
+		},
+		"not-matching": {
+			`$.steps.c.outputs.success`,
+			`$.steps.c.outputs.success`,
+		},
+		"preserve-formatting": {
+			`f( $.steps.a["outputs"] ,  $.steps.c["x"] ) +  1`,
+			`f( $.steps.b["outputs"] ,  $.steps.c["x"] ) +  1`,
+		},
+		"keep-unchanged-prefix-formatting": {
+			`$["steps"].a.outputs`,
+			`$["steps"].b.outputs`,
+		},
+		"multiple": {
+			`$.steps.a.x + $.steps.a.y`,
+			`$.steps.b.x + $.steps.b.y`,
+		},
+		"subexpression": {
+			`$.steps.c[$.steps.a.key].value`,
+			`$.steps.c[$.steps.b.key].value`,
+		},
+		"implicit-root": {
+			`steps.a.x`,
+			`steps.b.x`,
+		},
+		"named-root": {
+			`$steps.a.x + $steps["c"].y`,
+			`$steps.b.x + $steps["c"].y`,
+		},
+		"multi-line": {
+			"$.steps.c.x +\n  $.steps.a.y",
+			"$.steps.c.x +\n  $.steps.b.y",
+		},
+	}
+	for name, tc := range testCases {
+		testCase := tc
+		t.Run(name, func(t *testing.T) {
+			expr, err := expressions.New(testCase.expression)
+			assert.NoError(t, err)
+			rewritten, err := expr.RewritePaths(renameStep("a", "b"))
+			assert.NoError(t, err)
+			assert.Equals(t, rewritten.String(), testCase.expected)
+			// The original expression must not be changed.
+			assert.Equals(t, expr.String(), testCase.expression)
+		})
+	}
+}
+
+func TestRewritePaths_VisitedPaths(t *testing.T) {
+	expr, err := expressions.New(`f($.a[0], b.c)[$.d].e + $`)
+	assert.NoError(t, err)
+	var visited []string
+	_, err = expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		visited = append(visited, path.String())
+		return nil, false
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, visited, []string{"$.a.0", "$.b.c", "$.d", "$"})
+}
+
+func TestRewritePaths_RelativeKeys(t *testing.T) {
+	// The key k in $.a[k] is a key of $.a, not a reference to $.k, so it is not rewritten. Keys with a root, and
+	// the arguments of function calls in keys, are references.
+	expr, err := expressions.New(`$.a[k] + $.a[k.x[k]] + $.a[$.k] + $.a[f(k)]`)
+	assert.NoError(t, err)
+	var visited []string
+	rewritten, err := expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		visited = append(visited, path.String())
+		if path.String() == "$.k" {
+			return expressions.Path{"$", "kk"}, true
+		}
+		return nil, false
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, visited, []string{"$.a", "$.a", "$.a", "$.k", "$.a", "$.k"})
+	assert.Equals(t, rewritten.String(), `$.a[k] + $.a[k.x[k]] + $.a[$.kk] + $.a[f(kk)]`)
+}
+
+func TestRewritePaths_SpecialItems(t *testing.T) {
+	expr, err := expressions.New(`$.a.b`)
+	assert.NoError(t, err)
+	rewritten, err := expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		return expressions.Path{"$", "with space", "true", 1, `quote"`}, true
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, rewritten.String(), `$["with space"].true[1]["quote\""]`)
+	result, err := rewritten.Evaluate(map[string]any{
+		"with space": map[string]any{
+			"true": []any{nil, map[string]any{`quote"`: "found"}},
+		},
+	}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals[any](t, result, "found")
+}
+
+func TestRewritePaths_OffsetOptions(t *testing.T) {
+	expr, err := expressions.NewWithOptions("$.x +\n $.steps.a.y", expressions.Options{LineOffset: 3, ColumnOffset: 7})
+	assert.NoError(t, err)
+	rewritten, err := expr.RewritePaths(renameStep("a", "b"))
+	assert.NoError(t, err)
+	assert.Equals(t, rewritten.String(), "$.x +\n $.steps.b.y")
+	assert.Equals(t, rewritten.AST().Start().Line, 4)
+}
+
+func TestRewritePaths_Errors(t *testing.T) {
+	expr, err := expressions.New(`$.a`)
+	assert.NoError(t, err)
+	_, err = expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		return expressions.Path{"a"}, true
+	})
+	assert.Error(t, err)
+	_, err = expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		return expressions.Path{"$", 1.5}, true
+	})
+	assert.Error(t, err)
+}
+
+func TestRewritePaths_DifferentLength(t *testing.T) {
+	expr, err := expressions.New(`f( $["a"].b.c )`)
+	assert.NoError(t, err)
+	rewritten, err := expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		return expressions.Path{"$", "a", "d"}, true
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, rewritten.String(), `f( $["a"].d )`)
+
+	expr, err = expressions.New(`a.b`)
+	assert.NoError(t, err)
+	rewritten, err = expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		return expressions.Path{"$", "x", "y", "z"}, true
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, rewritten.String(), `x.y.z`)
+}
+
+func TestRewritePaths_NamedRoot(t *testing.T) {
+	expr, err := expressions.New(`$steps.a + $input`)
+	assert.NoError(t, err)
+	rewritten, err := expr.RewritePaths(func(path expressions.Path) (expressions.Path, bool) {
+		newPath := append(expressions.Path{}, path...)
+		newPath[1] = path[1].(string) + "_renamed"
+		return newPath, true
+	})
+	assert.NoError(t, err)
+	assert.Equals(t, rewritten.String(), `$steps_renamed.a + $input_renamed`)
+}
@@ -0,0 +1,99 @@
+package expressions
+
+import (
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// SensitiveFieldFunc decides whether the field of the object holds sensitive data, such as a credential. It is
+// usually decided from the metadata of the property, so the engine can mark fields in the schema.
+type SensitiveFieldFunc func(object schema.Object, field string, property *schema.PropertySchema) bool
+
+// SensitivityReport is the result of Expression.AnalyzeSensitivity.
+type SensitivityReport struct {
+	// Sensitive is true if the result of the expression may contain sensitive data.
+	Sensitive bool
+	// Sources are the paths referenced by the expression that are sensitive fields, are within sensitive fields, or
+	// contain sensitive fields.
+	Sources []Path
+}
+
+func (e expression) AnalyzeSensitivity(
+	scope schema.Scope,
+	functions map[string]schema.Function,
+	isSensitive SensitiveFieldFunc,
+) (SensitivityReport, error) {
+	// The keys are needed to follow the paths through lists and maps.
+	dependencies, err := e.TypedDependencies(scope, functions, nil, UnpackRequirements{
+		ExcludeFunctionRootPaths: true,
+		StopAtTerminals:          true,
+		IncludeKeys:              true,
+	})
+	if err != nil {
+		return SensitivityReport{}, err
+	}
+	// Operators and function calls are assumed to pass on the data of their operands and arguments, so the result is
+	// sensitive if any of the referenced data is.
+	report := SensitivityReport{Sources: []Path{}}
+	for _, dependency := range dependencies {
+		if pathIsSensitive(scope, dependency.Path, isSensitive) {
+			report.Sensitive = true
+			report.Sources = append(report.Sources, dependency.Path)
+		}
+	}
+	return report, nil
+}
+
+// pathIsSensitive follows the path from the root through the schema, and returns true if it passes a sensitive field,
+// or if the value at its end contains one.
+func pathIsSensitive(root schema.Type, path Path, isSensitive SensitiveFieldFunc) bool {
+	currentType := root
+	// The first item is the root ($).
+	for _, item := range path[1:] {
+		switch currentType.TypeID() {
+		case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+			object := currentType.(schema.Object)
+			field, isField := item.(string)
+			property, found := object.Properties()[field]
+			if !isField || !found {
+				return false
+			}
+			if isSensitive(object, field, property) {
+				return true
+			}
+			currentType = property.Type()
+		case schema.TypeIDList:
+			currentType = currentType.(schema.UntypedList).Items()
+		case schema.TypeIDMap:
+			currentType = currentType.(schema.UntypedMap).Values()
+		default:
+			// Values within other types, such as any, are not described by the schema.
+			return false
+		}
+	}
+	return containsSensitiveField(currentType, isSensitive, map[string]bool{})
+}
+
+// containsSensitiveField returns true if a value of the type can contain a sensitive field. The visited objects are
+// skipped, so recursive types end.
+func containsSensitiveField(t schema.Type, isSensitive SensitiveFieldFunc, visited map[string]bool) bool {
+	switch t.TypeID() {
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+		object := t.(schema.Object)
+		if visited[object.ID()] {
+			return false
+		}
+		visited[object.ID()] = true
+		for field, property := range object.Properties() {
+			if isSensitive(object, field, property) || containsSensitiveField(property.Type(), isSensitive, visited) {
+				return true
+			}
+		}
+		return false
+	case schema.TypeIDList:
+		return containsSensitiveField(t.(schema.UntypedList).Items(), isSensitive, visited)
+	case schema.TypeIDMap:
+		return containsSensitiveField(t.(schema.UntypedMap).Values(), isSensitive, visited)
+	default:
+		return false
+	}
+}
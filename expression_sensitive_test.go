@@ -0,0 +1,49 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// isSensitiveTestField marks the bar field of foo and the simple_str field of the root as sensitive.
+func isSensitiveTestField(object schema.Object, field string, _ *schema.PropertySchema) bool {
+	return (object.ID() == "foo" && field == "bar") || (object.ID() == "root" && field == "simple_str")
+}
+
+func TestAnalyzeSensitivity(t *testing.T) {
+	testCases := map[string][]string{
+		`$.foo.bar`:                            {"$.foo.bar"},
+		`$.simple_str + "x"`:                   {"$.simple_str"},
+		`$.foo`:                                {"$.foo"},
+		`$`:                                    {"$"},
+		`$.simple_int == 1`:                    {},
+		`$.foo.int_list[0] + 1`:                {},
+		`$.int_list[$.simple_int]`:             {},
+		`"literal"`:                            {},
+		`$.simple_int > 0 && $.foo.bar == "x"`: {"$.foo.bar"},
+	}
+	for expression, expectedSources := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			report, err := expr.AnalyzeSensitivity(testScope, nil, isSensitiveTestField)
+			assert.NoError(t, err)
+			assert.Equals(t, report.Sensitive, len(expectedSources) > 0)
+			sources := make([]string, len(report.Sources))
+			for i, source := range report.Sources {
+				sources[i] = source.String()
+			}
+			assert.Equals(t, sources, expectedSources)
+		})
+	}
+}
+
+func TestAnalyzeSensitivity_Invalid(t *testing.T) {
+	expr, err := expressions.New(`$.foo.nonexistent`)
+	assert.NoError(t, err)
+	_, err = expr.AnalyzeSensitivity(testScope, nil, isSensitiveTestField)
+	assert.Error(t, err)
+}
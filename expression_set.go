@@ -0,0 +1,210 @@
+package expressions
+
+import "go.flow.arcalot.io/pluginsdk/schema"
+
+// ExpressionSet holds multiple expressions that are analyzed and evaluated against the same data root, such as all
+// expressions in a workflow. Equivalent expressions in the set, which have the same canonical form and options, are
+// only resolved once against the schema.
+type ExpressionSet struct {
+	expressions []Expression
+}
+
+// NewExpressionSet creates a set of the specified expressions.
+func NewExpressionSet(expressions ...Expression) *ExpressionSet {
+	return &ExpressionSet{
+		expressions: append([]Expression{}, expressions...),
+	}
+}
+
+// Add adds an expression to the set and returns its index.
+func (s *ExpressionSet) Add(expression Expression) int {
+	s.expressions = append(s.expressions, expression)
+	return len(s.expressions) - 1
+}
+
+// Expressions returns the expressions in the set, in the order they were added.
+func (s *ExpressionSet) Expressions() []Expression {
+	return append([]Expression{}, s.expressions...)
+}
+
+// Len returns the number of expressions in the set.
+func (s *ExpressionSet) Len() int {
+	return len(s.expressions)
+}
+
+// setKey identifies the equivalent expressions of a set.
+type setKey struct {
+	expression expressionCacheKey
+	// index is the index of an expression that is not equivalent to any other, or -1.
+	index int
+}
+
+// newSetKey returns the key of the expression at the index. Expressions are equivalent if they have the same canonical
+// form and options, since options such as SchemaDefaults and Functions change their types and dependencies. The
+// position options are left out, since they only change the positions in the errors. Expressions of other types and
+// expressions whose options cannot be compared are not equivalent to any other expression.
+func newSetKey(expr Expression, index int) setKey {
+	impl, ok := expr.(*expression)
+	if !ok || impl.options.OnTypeTrace != nil || !tracerComparable(impl.options.Tracer) {
+		return setKey{index: index}
+	}
+	options := impl.options
+	options.Filename = ""
+	options.LineOffset = 0
+	options.ColumnOffset = 0
+	return setKey{expression: newExpressionCacheKey(impl.Canonical(), options), index: -1}
+}
+
+// uniqueIndexes returns the index of the first of each group of equivalent expressions, in order.
+func (s *ExpressionSet) uniqueIndexes() []int {
+	seen := make(map[setKey]bool, len(s.expressions))
+	result := make([]int, 0, len(s.expressions))
+	for i, expr := range s.expressions {
+		key := newSetKey(expr, i)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, i)
+	}
+	return result
+}
+
+// Types returns the type of each expression in the set, in the order of the expressions.
+func (s *ExpressionSet) Types(
+	scope schema.Scope,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) ([]schema.Type, error) {
+	typesByKey := make(map[setKey]schema.Type, len(s.expressions))
+	result := make([]schema.Type, len(s.expressions))
+	for i, expr := range s.expressions {
+		key := newSetKey(expr, i)
+		resolvedType, found := typesByKey[key]
+		if !found {
+			var err error
+			resolvedType, err = expr.Type(scope, functions, workflowContext)
+			if err != nil {
+				return nil, Errorf("failed to resolve the type of expression %d (%s): %w", i, expr.String(), err)
+			}
+			typesByKey[key] = resolvedType
+		}
+		result[i] = resolvedType
+	}
+	return result, nil
+}
+
+// Dependencies returns the combined dependencies of all expressions in the set. Each path is only returned once.
+func (s *ExpressionSet) Dependencies(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	unpackRequirements UnpackRequirements,
+) ([]Path, error) {
+	typedDependencies, err := s.TypedDependencies(scope, functions, workflowContext, unpackRequirements)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Path, len(typedDependencies))
+	for i, dependency := range typedDependencies {
+		result[i] = dependency.Path
+	}
+	return result, nil
+}
+
+// TypedDependencies is the same as Dependencies, but each returned path also contains the resolved schema type. A path
+// is only optional if it is optional for all expressions that depend on it.
+func (s *ExpressionSet) TypedDependencies(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	unpackRequirements UnpackRequirements,
+) ([]TypedPath, error) {
+	seen := make(map[string]int)
+	result := make([]TypedPath, 0)
+	for _, i := range s.uniqueIndexes() {
+		expr := s.expressions[i]
+		dependencies, err := expr.TypedDependencies(scope, functions, workflowContext, unpackRequirements)
+		if err != nil {
+			return nil, Errorf("failed to resolve the dependencies of expression %d (%s): %w", i, expr.String(), err)
+		}
+		for _, dependency := range dependencies {
+			asString := dependency.String()
+			if index, found := seen[asString]; found {
+				result[index].Optional = result[index].Optional && dependency.Optional
+				continue
+			}
+			seen[asString] = len(result)
+			result = append(result, dependency)
+		}
+	}
+	return result, nil
+}
+
+// PathTrees returns the dependency trees of all expressions in the set merged together. There is one tree for each
+// distinct root: the data root ($), and each function whose result is accessed.
+func (s *ExpressionSet) PathTrees(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) ([]*PathTree, error) {
+	var result []*PathTree
+	for _, i := range s.uniqueIndexes() {
+		expr := s.expressions[i]
+		impl, ok := expr.(*expression)
+		if !ok {
+			return nil, Errorf("expression %d (%s) has unsupported type %T", i, expr.String(), expr)
+		}
+		dependencyResult, err := impl.resolveDependencies(scope, functions, workflowContext)
+		if err != nil {
+			return nil, Errorf("failed to resolve the dependencies of expression %d (%s): %w", i, expr.String(), err)
+		}
+		for _, tree := range dependencyResult.completedPaths {
+			result = mergePathTree(result, tree)
+		}
+	}
+	return result, nil
+}
+
+// mergePathTree merges a copy of the tree into the list of trees, combining the nodes with the same node type and
+// path item.
+func mergePathTree(trees []*PathTree, tree *PathTree) []*PathTree {
+	for _, existing := range trees {
+		if existing.NodeType == tree.NodeType && existing.PathItem == tree.PathItem {
+			if existing.ResolvedType == nil {
+				existing.ResolvedType = tree.ResolvedType
+			}
+			for _, subtree := range tree.Subtrees {
+				existing.Subtrees = mergePathTree(existing.Subtrees, subtree)
+			}
+			return trees
+		}
+	}
+	treeCopy := &PathTree{
+		PathItem:     tree.PathItem,
+		NodeType:     tree.NodeType,
+		ResolvedType: tree.ResolvedType,
+	}
+	for _, subtree := range tree.Subtrees {
+		treeCopy.Subtrees = mergePathTree(treeCopy.Subtrees, subtree)
+	}
+	return append(trees, treeCopy)
+}
+
+// Evaluate evaluates all expressions in the set on the same data, and returns the results in the order of the
+// expressions.
+func (s *ExpressionSet) Evaluate(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) ([]any, error) {
+	result := make([]any, len(s.expressions))
+	for i, expr := range s.expressions {
+		value, err := expr.Evaluate(data, functions, workflowContext)
+		if err != nil {
+			return nil, Errorf("failed to evaluate expression %d (%s): %w", i, expr.String(), err)
+		}
+		result[i] = value
+	}
+	return result, nil
+}
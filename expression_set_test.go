@@ -0,0 +1,136 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func newExpressionSet(t *testing.T, expressionStrings ...string) *expressions.ExpressionSet {
+	set := expressions.NewExpressionSet()
+	for i, expressionString := range expressionStrings {
+		expr, err := expressions.New(expressionString)
+		assert.NoError(t, err)
+		assert.Equals(t, set.Add(expr), i)
+	}
+	return set
+}
+
+func TestExpressionSet_Dependencies(t *testing.T) {
+	set := newExpressionSet(t, "$.foo.bar", "$.simple_int + $.simple_int_2", `$["foo"].bar`, "$.simple_int")
+	assert.Equals(t, set.Len(), 4)
+	paths, err := set.Dependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(paths), 3)
+	assert.Equals(t, paths[0].String(), "$.foo.bar")
+	assert.Equals(t, paths[1].String(), "$.simple_int")
+	assert.Equals(t, paths[2].String(), "$.simple_int_2")
+
+	typedPaths, err := set.TypedDependencies(testScope, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 3)
+	assert.Equals(t, typedPaths[0].Type.TypeID(), schema.TypeIDString)
+}
+
+func TestExpressionSet_OptionalDependencies(t *testing.T) {
+	set := expressions.NewExpressionSet()
+	optional, err := expressions.NewWithOptions(`$.with_default`, expressions.Options{SchemaDefaults: true})
+	assert.NoError(t, err)
+	set.Add(optional)
+	typedPaths, err := set.TypedDependencies(newDefaultsScope(`"hello"`), nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].Optional, true)
+
+	// The path is required once an expression needs it.
+	required, err := expressions.New(`$.with_default + "!"`)
+	assert.NoError(t, err)
+	set.Add(required)
+	typedPaths, err = set.TypedDependencies(newDefaultsScope(`"hello"`), nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].Optional, false)
+}
+
+func TestExpressionSet_DifferentOptions(t *testing.T) {
+	set := expressions.NewExpressionSet()
+	optional, err := expressions.NewWithOptions(`$.with_default`, expressions.Options{SchemaDefaults: true})
+	assert.NoError(t, err)
+	set.Add(optional)
+	// The same expression without the option needs the path, so it is not merged with the first one.
+	required, err := expressions.New(`$.with_default`)
+	assert.NoError(t, err)
+	set.Add(required)
+	typedPaths, err := set.TypedDependencies(newDefaultsScope(`"hello"`), nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].Optional, false)
+
+	// Expressions that only differ in their position are still merged.
+	positioned, err := expressions.NewWithOptions(
+		`$.with_default`, expressions.Options{SchemaDefaults: true, LineOffset: 10},
+	)
+	assert.NoError(t, err)
+	set = expressions.NewExpressionSet(optional, positioned)
+	typedPaths, err = set.TypedDependencies(newDefaultsScope(`"hello"`), nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPaths), 1)
+	assert.Equals(t, typedPaths[0].Optional, true)
+}
+
+func TestExpressionSet_Dependencies_Error(t *testing.T) {
+	set := newExpressionSet(t, "$.foo.bar", "$.nonexistent")
+	_, err := set.Dependencies(testScope, nil, nil, fullDataRequirements)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expression 1 ($.nonexistent)")
+}
+
+func TestExpressionSet_PathTrees(t *testing.T) {
+	set := newExpressionSet(t, "$.foo.bar", "$.foo.int_list[0]", "$.simple_str")
+	trees, err := set.PathTrees(testScope, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(trees), 1)
+	root := trees[0]
+	assert.Equals(t, root.PathItem, any("$"))
+	assert.Equals(t, len(root.Subtrees), 2)
+	assert.Equals(t, root.Subtrees[0].PathItem, any("foo"))
+	assert.Equals(t, len(root.Subtrees[0].Subtrees), 2)
+	assert.Equals(t, root.Subtrees[1].PathItem, any("simple_str"))
+
+	paths := root.Unpack(fullDataRequirements)
+	assert.Equals(t, len(paths), 3)
+	assert.SliceContainsExtractor(t, pathStrExtractor, "$.foo.bar", paths)
+	assert.SliceContainsExtractor(t, pathStrExtractor, "$.foo.int_list.0", paths)
+	assert.SliceContainsExtractor(t, pathStrExtractor, "$.simple_str", paths)
+}
+
+func TestExpressionSet_Types(t *testing.T) {
+	set := newExpressionSet(t, "$.foo.bar", "$.simple_int", `$.foo["bar"]`)
+	types, err := set.Types(testScope, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, len(types), 3)
+	assert.Equals(t, types[0].TypeID(), schema.TypeIDString)
+	assert.Equals(t, types[1].TypeID(), schema.TypeIDInt)
+	assert.Equals(t, types[2].TypeID(), schema.TypeIDString)
+}
+
+func TestExpressionSet_Evaluate(t *testing.T) {
+	set := newExpressionSet(t, "$.a", "$.b + 1", `"literal"`)
+	results, err := set.Evaluate(map[string]any{"a": "x", "b": int64(1)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, results, []any{"x", int64(2), "literal"})
+
+	_, err = set.Evaluate(map[string]any{"a": "x"}, nil, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "expression 1")
+}
+
+func TestExpressionSet_UnaryOperands(t *testing.T) {
+	// A unary operator applies to the rest of the expression, so these are different expressions.
+	set := newExpressionSet(t, "(-1) + $.b", "-(1 + $.b)", "-1 + $.b")
+	results, err := set.Evaluate(map[string]any{"b": int64(1)}, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, results, []any{int64(0), int64(-2), int64(-2)})
+}
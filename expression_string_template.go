@@ -0,0 +1,179 @@
+package expressions
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Template is a string with embedded expressions, such as `Hello ${$.input.name}!`, which is rendered by replacing
+// each `${...}` segment with the result of its expression. Write `$${` for a literal `${`. This is useful for
+// generating file contents and commands from the data of a workflow.
+type Template struct {
+	template string
+	segments []templateSegment
+}
+
+// templateSegment is a part of a Template, which is either literal text or an expression.
+type templateSegment struct {
+	text       string
+	expression Expression
+}
+
+// NewTemplate parses the template string and the expressions embedded in it.
+func NewTemplate(templateString string) (*Template, error) {
+	return NewTemplateWithOptions(templateString, Options{})
+}
+
+// NewTemplateWithOptions parses the template string with the specified options. The expressions are parsed with the
+// options, and the positions in their errors are the positions in the template string.
+func NewTemplateWithOptions(templateString string, options Options) (*Template, error) {
+	t := &Template{template: templateString}
+	var text strings.Builder
+	for i := 0; i < len(templateString); {
+		switch {
+		case strings.HasPrefix(templateString[i:], "$${"):
+			text.WriteString("${")
+			i += 3
+		case strings.HasPrefix(templateString[i:], "${"):
+			start := i + 2
+			end := findExpressionEnd(templateString, start)
+			if end < 0 {
+				line, column := templatePosition(templateString, i, options)
+				return nil, newCodedError(
+					ErrorCodeParse, "unclosed expression in template at line %d:%d; expected a closing }", line, column)
+			}
+			expressionOptions := options
+			line, column := templatePosition(templateString, start, options)
+			expressionOptions.LineOffset = line - 1
+			expressionOptions.ColumnOffset = column - 1
+			expr, err := NewWithOptions(templateString[start:end], expressionOptions)
+			if err != nil {
+				return nil, err
+			}
+			if text.Len() > 0 {
+				t.segments = append(t.segments, templateSegment{text: text.String()})
+				text.Reset()
+			}
+			t.segments = append(t.segments, templateSegment{expression: expr})
+			i = end + 1
+		default:
+			text.WriteByte(templateString[i])
+			i++
+		}
+	}
+	if text.Len() > 0 {
+		t.segments = append(t.segments, templateSegment{text: text.String()})
+	}
+	return t, nil
+}
+
+// findExpressionEnd returns the index of the } that closes the expression starting at the index, or -1 if the
+// expression is not closed. Braces in string literals do not close the expression.
+func findExpressionEnd(templateString string, start int) int {
+	var quote byte
+	for i := start; i < len(templateString); i++ {
+		c := templateString[i]
+		switch {
+		case quote == 0 && c == '}':
+			return i
+		case quote == 0 && (c == '"' || c == '\'' || c == '`'):
+			quote = c
+		case quote != 0 && quote != '`' && c == '\\':
+			// Skip the escaped character.
+			i++
+		case c == quote:
+			quote = 0
+		}
+	}
+	return -1
+}
+
+// templatePosition returns the line and column of the byte offset in the template string, with the position offsets
+// of the options applied.
+func templatePosition(templateString string, offset int, options Options) (int, int) {
+	before := templateString[:offset]
+	line := strings.Count(before, "\n") + 1
+	lineStart := strings.LastIndex(before, "\n") + 1
+	column := offset - lineStart + 1
+	if line == 1 {
+		column += options.ColumnOffset
+	}
+	return line + options.LineOffset, column
+}
+
+// String returns the original template string.
+func (t *Template) String() string {
+	return t.template
+}
+
+// Expressions returns the expressions embedded in the template, in the order they appear.
+func (t *Template) Expressions() []Expression {
+	var result []Expression
+	for _, segment := range t.segments {
+		if segment.expression != nil {
+			result = append(result, segment.expression)
+		}
+	}
+	return result
+}
+
+// Dependencies returns the combined dependencies of the expressions in the template. Each path is only returned
+// once.
+func (t *Template) Dependencies(
+	scope schema.Type,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+	unpackRequirements UnpackRequirements,
+) ([]Path, error) {
+	return NewExpressionSet(t.Expressions()...).Dependencies(scope, functions, workflowContext, unpackRequirements)
+}
+
+// Render evaluates the expressions in the template on the data, and returns the template with each expression
+// replaced by its result. Strings are inserted as they are, numbers and booleans in their literal form, and other
+// values, such as lists and maps, are encoded as JSON.
+func (t *Template) Render(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) (string, error) {
+	var result strings.Builder
+	for _, segment := range t.segments {
+		if segment.expression == nil {
+			result.WriteString(segment.text)
+			continue
+		}
+		value, err := segment.expression.Evaluate(data, functions, workflowContext)
+		if err != nil {
+			return "", Errorf("failed to render ${%s} in the template (%w)", segment.expression.String(), err)
+		}
+		rendered, err := renderTemplateValue(value)
+		if err != nil {
+			return "", Errorf("failed to render ${%s} in the template (%w)", segment.expression.String(), err)
+		}
+		result.WriteString(rendered)
+	}
+	return result.String(), nil
+}
+
+// renderTemplateValue returns the text the value is inserted into a template as.
+func renderTemplateValue(value any) (string, error) {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue, nil
+	case int64:
+		return strconv.FormatInt(typedValue, 10), nil
+	case float64:
+		return strconv.FormatFloat(typedValue, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(typedValue), nil
+	default:
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", newCodedError(ErrorCodeTypeMismatch, "cannot insert a %T into a template (%w)", value, err)
+		}
+		return string(encoded), nil
+	}
+}
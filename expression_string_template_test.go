@@ -0,0 +1,49 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := expressions.NewTemplate(
+		`echo ${$.foo.bar} ${$.simple_int + 1} ${$.foo.int_list} ${"}"} $${literal}`)
+	assert.NoError(t, err)
+	assert.Equals(t, len(tmpl.Expressions()), 4)
+	data := map[string]any{
+		"foo":        map[string]any{"bar": "hello", "int_list": []any{int64(1), int64(2)}},
+		"simple_int": int64(2),
+	}
+	result, err := tmpl.Render(data, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, `echo hello 3 [1,2] } ${literal}`)
+
+	dependencies, err := tmpl.Dependencies(testScope, nil, nil, expressions.UnpackRequirements{})
+	assert.NoError(t, err)
+	paths := make([]string, len(dependencies))
+	for i, dependency := range dependencies {
+		paths[i] = dependency.String()
+	}
+	assert.Equals(t, paths, []string{"$.foo.bar", "$.simple_int", "$.foo.int_list"})
+
+	_, err = tmpl.Render(map[string]any{}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+	assert.Contains(t, err.Error(), "${$.foo.bar}")
+}
+
+func TestTemplate_Errors(t *testing.T) {
+	_, err := expressions.NewTemplate("a ${$.foo")
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeParse)
+	assert.Contains(t, err.Error(), "1:3")
+
+	// The positions of the expressions are the positions in the template.
+	_, err = expressions.NewTemplate("line\nsecond ${1 + )}")
+	var grammarErr *ast.InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	assert.Equals(t, grammarErr.FoundToken.Line, 2)
+	assert.Equals(t, grammarErr.FoundToken.Column, 14)
+}
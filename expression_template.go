@@ -0,0 +1,121 @@
+//go:build !tinygo
+
+// text/template calls the template functions with reflect.Value.Call, which TinyGo does not support.
+
+package expressions
+
+import (
+	"text/template"
+	templateparse "text/template/parse"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// TemplateFunctionName is the name of the template function returned by TemplateFuncs.
+const TemplateFunctionName = "expr"
+
+// TemplateFuncs returns the functions for a text/template or html/template that evaluate expressions on the data,
+// such as `{{ expr "$.foo.bar" }}`. The functions must be added before the template is parsed. Since they hold the
+// data, add them again with the data of each rendering before executing the template:
+//
+//	tmpl, err := template.New("message").Funcs(expressions.TemplateFuncs(nil, nil, nil)).Parse(text)
+//	// ...
+//	err = tmpl.Funcs(expressions.TemplateFuncs(data, functions, nil)).Execute(writer, nil)
+//
+// An expression that fails to parse or evaluate stops the execution of the template with its error.
+func TemplateFuncs(
+	data any,
+	functions map[string]schema.CallableFunction,
+	workflowContext map[string][]byte,
+) template.FuncMap {
+	return template.FuncMap{
+		TemplateFunctionName: func(expressionString string) (any, error) {
+			expr, err := New(expressionString)
+			if err != nil {
+				return nil, err
+			}
+			return expr.Evaluate(data, functions, workflowContext)
+		},
+	}
+}
+
+// TemplateExpressions returns the expressions used with the expr function of TemplateFuncs in the template and the
+// templates associated with it, so their dependencies can be resolved before the template is executed. Only
+// expressions that are written as string literals, such as `{{ expr "$.foo.bar" }}`, can be found.
+func TemplateExpressions(tmpl *template.Template) (*ExpressionSet, error) {
+	set := NewExpressionSet()
+	for _, associated := range tmpl.Templates() {
+		if associated.Tree == nil {
+			continue
+		}
+		if err := addTemplateExpressions(set, associated.Tree.Root); err != nil {
+			return nil, Errorf("failed to parse the expressions of template %s (%w)", associated.Name(), err)
+		}
+	}
+	return set, nil
+}
+
+// addTemplateExpressions adds the expressions of the calls of the expr function in the template node to the set.
+func addTemplateExpressions(set *ExpressionSet, node templateparse.Node) error {
+	switch n := node.(type) {
+	case *templateparse.ListNode:
+		if n == nil {
+			return nil
+		}
+		for _, child := range n.Nodes {
+			if err := addTemplateExpressions(set, child); err != nil {
+				return err
+			}
+		}
+	case *templateparse.ActionNode:
+		return addTemplateExpressions(set, n.Pipe)
+	case *templateparse.TemplateNode:
+		return addTemplateExpressions(set, n.Pipe)
+	case *templateparse.IfNode:
+		return addTemplateBranchExpressions(set, &n.BranchNode)
+	case *templateparse.RangeNode:
+		return addTemplateBranchExpressions(set, &n.BranchNode)
+	case *templateparse.WithNode:
+		return addTemplateBranchExpressions(set, &n.BranchNode)
+	case *templateparse.PipeNode:
+		if n == nil {
+			return nil
+		}
+		for _, command := range n.Cmds {
+			if err := addTemplateExpressions(set, command); err != nil {
+				return err
+			}
+		}
+	case *templateparse.ChainNode:
+		return addTemplateExpressions(set, n.Node)
+	case *templateparse.CommandNode:
+		if len(n.Args) == 2 {
+			identifier, isIdentifier := n.Args[0].(*templateparse.IdentifierNode)
+			literal, isLiteral := n.Args[1].(*templateparse.StringNode)
+			if isIdentifier && isLiteral && identifier.Ident == TemplateFunctionName {
+				expr, err := New(literal.Text)
+				if err != nil {
+					return err
+				}
+				set.Add(expr)
+				return nil
+			}
+		}
+		for _, argument := range n.Args {
+			if err := addTemplateExpressions(set, argument); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// addTemplateBranchExpressions adds the expressions of the pipeline and the lists of an if, range, or with node.
+func addTemplateBranchExpressions(set *ExpressionSet, branch *templateparse.BranchNode) error {
+	for _, child := range []templateparse.Node{branch.Pipe, branch.List, branch.ElseList} {
+		if err := addTemplateExpressions(set, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
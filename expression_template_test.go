@@ -0,0 +1,47 @@
+//go:build !tinygo
+
+package expressions_test
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	tmpl, err := template.New("message").
+		Funcs(expressions.TemplateFuncs(nil, nil, nil)).
+		Parse(`{{ expr "$.foo.bar" }} has {{ expr "$.simple_int + 1" | printf "%d" }} items`)
+	assert.NoError(t, err)
+	data := map[string]any{"foo": map[string]any{"bar": "list"}, "simple_int": int64(2)}
+	var result strings.Builder
+	assert.NoError(t, tmpl.Funcs(expressions.TemplateFuncs(data, nil, nil)).Execute(&result, nil))
+	assert.Equals(t, result.String(), "list has 3 items")
+
+	result.Reset()
+	assert.Error(t, tmpl.Funcs(expressions.TemplateFuncs(map[string]any{}, nil, nil)).Execute(&result, nil))
+}
+
+func TestTemplateExpressions(t *testing.T) {
+	tmpl, err := template.New("message").Funcs(expressions.TemplateFuncs(nil, nil, nil)).Parse(
+		`{{ if expr "$.simple_bool" }}{{ expr "$.foo.bar" }}{{ else }}{{ template "other" }}{{ end }}` +
+			`{{ define "other" }}{{ printf "%d" (expr "$.simple_int") }}{{ end }}`)
+	assert.NoError(t, err)
+	set, err := expressions.TemplateExpressions(tmpl)
+	assert.NoError(t, err)
+	dependencies, err := set.Dependencies(testScope, nil, nil, expressions.UnpackRequirements{})
+	assert.NoError(t, err)
+	paths := make(map[string]bool)
+	for _, dependency := range dependencies {
+		paths[dependency.String()] = true
+	}
+	assert.Equals(t, paths, map[string]bool{"$.simple_bool": true, "$.foo.bar": true, "$.simple_int": true})
+
+	tmpl, err = template.New("invalid").Funcs(expressions.TemplateFuncs(nil, nil, nil)).Parse(`{{ expr "$.foo." }}`)
+	assert.NoError(t, err)
+	_, err = expressions.TemplateExpressions(tmpl)
+	assert.Error(t, err)
+}
@@ -0,0 +1,78 @@
+package expressions
+
+import (
+	"context"
+	"reflect"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+const (
+	// TraceSpanEvaluate is the name of the span of an evaluation of an expression.
+	TraceSpanEvaluate = "expressions.evaluate"
+	// TraceSpanFunctionCall is the name of the span of a function call.
+	TraceSpanFunctionCall = "expressions.call"
+	// TraceAttributeExpression is the attribute that holds the evaluated expression.
+	TraceAttributeExpression = "expression"
+	// TraceAttributeFunction is the attribute of a function call span that holds the name of the function.
+	TraceAttributeFunction = "expression.function"
+)
+
+// Tracer creates the spans of evaluations for Options.Tracer. It has the shape of an OpenTelemetry tracer, and
+// oteltracing.NewTracer in the separate oteltracing module adapts an OpenTelemetry tracer to it, so this module does
+// not depend on OpenTelemetry.
+//
+// Each evaluation has a TraceSpanEvaluate span, with the expression as the TraceAttributeExpression attribute. Each
+// function call within it has a TraceSpanFunctionCall child span, with the expression and the name of the function
+// as the TraceAttributeFunction attribute.
+type Tracer interface {
+	// StartSpan starts a span with the name and attributes as a child of the span in the context, if any, and
+	// returns the context holding the new span.
+	StartSpan(ctx context.Context, name string, attributes map[string]string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// End ends the span. The error is the error the evaluation or function call failed with, or nil.
+	End(err error)
+}
+
+// evaluateTraced evaluates the root node of the expression in a TraceSpanEvaluate span if there is a tracer.
+func (c *evaluateContext) evaluateTraced(root ast.Node, data any) (any, error) {
+	if c.tracer == nil {
+		return c.evaluate(root, data)
+	}
+	ctx := c.traceContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, span := c.tracer.StartSpan(ctx, TraceSpanEvaluate, map[string]string{TraceAttributeExpression: c.expression})
+	c.traceContext = ctx
+	result, err := c.evaluate(root, data)
+	span.End(err)
+	return result, err
+}
+
+// callTraced calls the function in a TraceSpanFunctionCall span if there is a tracer.
+func (c evaluateContext) callTraced(name string, function schema.CallableFunction, arguments []any) (any, error) {
+	if c.tracer == nil {
+		return callFunction(function, arguments)
+	}
+	ctx := c.traceContext
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	_, span := c.tracer.StartSpan(ctx, TraceSpanFunctionCall, map[string]string{
+		TraceAttributeExpression: c.expression,
+		TraceAttributeFunction:   name,
+	})
+	result, err := callFunction(function, arguments)
+	span.End(err)
+	return result, err
+}
+
+// tracerComparable returns true if the tracer can be part of the key of the parsed expression cache.
+func tracerComparable(tracer Tracer) bool {
+	return tracer == nil || reflect.TypeOf(tracer).Comparable()
+}
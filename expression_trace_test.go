@@ -0,0 +1,90 @@
+package expressions_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+type traceParentKey struct{}
+
+// recordingTracer records the spans it starts, with their parents and the errors they ended with.
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+type recordedSpan struct {
+	name       string
+	parent     string
+	attributes map[string]string
+	ended      bool
+	err        error
+}
+
+func (t *recordingTracer) StartSpan(
+	ctx context.Context,
+	name string,
+	attributes map[string]string,
+) (context.Context, expressions.Span) {
+	parent, _ := ctx.Value(traceParentKey{}).(string)
+	span := &recordedSpan{name: name, parent: parent, attributes: attributes}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, traceParentKey{}, name), span
+}
+
+func (s *recordedSpan) End(err error) {
+	s.ended = true
+	s.err = err
+}
+
+func TestTracer(t *testing.T) {
+	failing, err := schema.NewCallableFunction(
+		"failing",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		nil,
+		func(value string) (string, error) {
+			if value == "fail" {
+				return "", fmt.Errorf("failed")
+			}
+			return value, nil
+		},
+	)
+	assert.NoError(t, err)
+	functions := map[string]schema.CallableFunction{"failing": failing}
+	tracer := &recordingTracer{}
+	expr, err := expressions.NewWithOptions(`failing($.foo.bar)`, expressions.Options{Tracer: tracer})
+	assert.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), traceParentKey{}, "engine")
+	result, err := expr.EvaluateWithContext(ctx, map[string]any{"foo": map[string]any{"bar": "ok"}}, functions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("ok"))
+	assert.Equals(t, len(tracer.spans), 2)
+	assert.Equals(t, tracer.spans[0].name, expressions.TraceSpanEvaluate)
+	assert.Equals(t, tracer.spans[0].parent, "engine")
+	assert.Equals(t, tracer.spans[0].attributes, map[string]string{
+		expressions.TraceAttributeExpression: `failing($.foo.bar)`,
+	})
+	assert.Equals(t, tracer.spans[1].name, expressions.TraceSpanFunctionCall)
+	assert.Equals(t, tracer.spans[1].parent, expressions.TraceSpanEvaluate)
+	assert.Equals(t, tracer.spans[1].attributes[expressions.TraceAttributeFunction], "failing")
+	for _, span := range tracer.spans {
+		assert.Equals(t, span.ended, true)
+		assert.NoError(t, span.err)
+	}
+
+	// The spans of failed calls end with the error.
+	tracer.spans = nil
+	_, err = expr.Evaluate(map[string]any{"foo": map[string]any{"bar": "fail"}}, functions, nil)
+	assert.Error(t, err)
+	assert.Equals(t, len(tracer.spans), 2)
+	assert.Equals(t, tracer.spans[0].parent, "")
+	assert.Error(t, tracer.spans[0].err)
+	assert.Error(t, tracer.spans[1].err)
+}
@@ -243,6 +243,14 @@ func TestTypeResolution_BinaryConcatenateStrings(t *testing.T) {
 	assert.Equals[schema.Type](t, typeResult, schema.NewStringSchema(nil, nil, nil))
 }
 
+func TestTypeResolution_RawString(t *testing.T) {
+	expr, err := expressions.New("`a\\b` + \"c\"")
+	assert.NoError(t, err)
+	typeResult, err := expr.Type(nil, nil, nil)
+	assert.NoError(t, err)
+	assert.Equals[schema.Type](t, typeResult, schema.NewStringSchema(nil, nil, nil))
+}
+
 func TestTypeResolution_WithStrictSchemas(t *testing.T) {
 	// In this example, we're going to reference schemas that have regular expressions that
 	// no longer apply when appended together.
@@ -454,3 +462,59 @@ func TestDependencyResolution_Error_TestAddingLists(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "invalid type")
 }
+
+func TestTypeResolution_Cached(t *testing.T) {
+	typeResolutions := 0
+	countingFunc, err := schema.NewDynamicCallableFunction(
+		"count",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		nil,
+		func(a int64) (any, error) {
+			return a, nil
+		},
+		func(inputType []schema.Type) (schema.Type, error) {
+			typeResolutions++
+			return inputType[0], nil
+		},
+	)
+	assert.NoError(t, err)
+	funcMap := map[string]schema.Function{"count": countingFunc}
+	expr, err := expressions.New("count($.simple_int)")
+	assert.NoError(t, err)
+
+	resultType, err := expr.Type(testScope, funcMap, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, resultType.TypeID(), schema.TypeIDInt)
+	dependencies, err := expr.Dependencies(testScope, funcMap, nil, noKeyOrPastTerminalRequirements)
+	assert.NoError(t, err)
+	assert.Equals(t, len(dependencies), 1)
+	_, err = expr.Type(testScope, funcMap, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 1)
+
+	// A new map with the same functions re-uses the cached result, like the maps returned by a function registry.
+	otherFuncMap := map[string]schema.Function{"count": countingFunc}
+	_, err = expr.Type(testScope, otherFuncMap, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 1)
+
+	// Modified maps and workflow contexts must not re-use the cached result.
+	otherFuncMap["other"] = countingFunc
+	_, err = expr.Type(testScope, otherFuncMap, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 2)
+	workflowContext := map[string][]byte{"a.txt": []byte("a")}
+	_, err = expr.Type(testScope, funcMap, workflowContext)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 3)
+	_, err = expr.Type(testScope, funcMap, workflowContext)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 3)
+	workflowContext["b.txt"] = []byte("b")
+	_, err = expr.Type(testScope, funcMap, workflowContext)
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 4)
+	_, err = expr.Type(testScope, funcMap, map[string][]byte{"a.txt": []byte("a")})
+	assert.NoError(t, err)
+	assert.Equals(t, typeResolutions, 5)
+}
@@ -0,0 +1,82 @@
+package expressions
+
+import (
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// TypeTraceStep is a single step of the type resolution of an expression, which is reported to Options.OnTypeTrace.
+// The steps are reported in the order they are resolved, so the operands of an operation are reported before the
+// operation, and each access of a chain, such as `$.foo.bar`, is reported on its own.
+type TypeTraceStep struct {
+	// Expression is the part of the expression the step resolves, such as `$.foo` or `$.foo.bar + 1`.
+	Expression string
+	// Start is the position of the start of the part in the expression.
+	Start ast.Position
+	// InputType is the type of the value the step accesses, such as the object a field is read from. It is nil for
+	// steps that don't access a value, such as literals and operations.
+	InputType schema.Type
+	// ResolvedType is the type the part of the expression resolves to. It is nil if the step failed.
+	ResolvedType schema.Type
+	// Err is the error of the step, if it failed.
+	Err error
+}
+
+// String returns the position, the part of the expression, and the input and resolved types of the step, or the
+// error if the step failed.
+func (s TypeTraceStep) String() string {
+	result := s.Start.String() + ": " + s.Expression + ": "
+	if s.InputType != nil {
+		result += describeType(s.InputType) + " -> "
+	}
+	if s.Err != nil {
+		return result + "failed: " + s.Err.Error()
+	}
+	return result + describeType(s.ResolvedType)
+}
+
+// describeType returns a short description of the type, such as "object foo" or "list of int".
+func describeType(t schema.Type) string {
+	switch t.TypeID() {
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+		return "object " + t.(schema.Object).ID()
+	case schema.TypeIDList:
+		return "list of " + describeType(t.(schema.UntypedList).Items())
+	case schema.TypeIDMap:
+		untypedMap := t.(schema.UntypedMap)
+		return "map of " + describeType(untypedMap.Keys()) + " to " + describeType(untypedMap.Values())
+	default:
+		return string(t.TypeID())
+	}
+}
+
+// traceExpression returns the part of the expression the node stands for in its canonical form. Identifiers are
+// returned as they are, since they are also resolved as the fields on the right of dot notations.
+func traceExpression(node ast.Node) string {
+	if identifier, isIdentifier := node.(*ast.Identifier); isIdentifier {
+		return identifier.IdentifierName
+	}
+	var result strings.Builder
+	writeCanonical(&result, node, true)
+	return result.String()
+}
+
+// traceStep reports the step to the trace function, if one is set.
+func (c *dependencyContext) traceStep(
+	expression string,
+	start ast.Position,
+	inputType schema.Type,
+	result *dependencyResult,
+	err error,
+) {
+	if c.trace == nil {
+		return
+	}
+	step := TypeTraceStep{Expression: expression, Start: start, InputType: inputType, Err: err}
+	if err == nil {
+		step.ResolvedType = result.resolvedType
+	}
+	c.trace(step)
+}
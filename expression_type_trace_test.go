@@ -0,0 +1,60 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+// traceSteps parses the expression with a type trace, and returns the steps reported while resolving its type.
+func traceSteps(t *testing.T, expression string) ([]string, error) {
+	var steps []string
+	expr, err := expressions.NewWithOptions(expression, expressions.Options{
+		OnTypeTrace: func(step expressions.TypeTraceStep) {
+			steps = append(steps, step.String())
+		},
+	})
+	assert.NoError(t, err)
+	_, err = expr.Type(testScope, nil, nil)
+	return steps, err
+}
+
+func TestTypeTrace(t *testing.T) {
+	steps, err := traceSteps(t, `$.foo.int_list[0] + 1`)
+	assert.NoError(t, err)
+	assert.Equals(t, steps, []string{
+		"1:1: $.foo: object root -> object foo",
+		"1:1: $.foo.int_list: object foo -> list of integer",
+		"1:1: $.foo.int_list[0]: list of integer -> integer",
+		"1:21: 1: integer",
+		"1:1: $.foo.int_list[0] + 1: integer",
+	})
+}
+
+func TestTypeTrace_Failure(t *testing.T) {
+	steps, err := traceSteps(t, `$.simple_int + $.foo.bar`)
+	assert.Error(t, err)
+	assert.Equals(t, steps[len(steps)-2], "1:16: $.foo.bar: object foo -> string")
+	assert.Contains(t, steps[len(steps)-1], "1:1: $.simple_int + $.foo.bar: failed: ")
+
+	steps, err = traceSteps(t, `$.foo.missing`)
+	assert.Error(t, err)
+	assert.Equals(t, len(steps), 2)
+	assert.Contains(t, steps[1], "1:1: $.foo.missing: object foo -> failed: ")
+}
+
+func TestTypeTrace_NotCached(t *testing.T) {
+	var count int
+	expr, err := expressions.NewWithOptions(`$.simple_int`, expressions.Options{
+		OnTypeTrace: func(step expressions.TypeTraceStep) {
+			count++
+		},
+	})
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = expr.Dependencies(testScope, nil, nil, expressions.UnpackRequirements{})
+		assert.NoError(t, err)
+	}
+	assert.Equals(t, count, 2)
+}
@@ -0,0 +1,219 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// DiagnosticSeverity describes how severe a diagnostic is.
+type DiagnosticSeverity string
+
+const (
+	// SeverityError means that the expression cannot be used with the specified inputs.
+	SeverityError DiagnosticSeverity = "error"
+	// SeverityWarning means that the expression can be used, but it may not behave as expected.
+	SeverityWarning DiagnosticSeverity = "warning"
+)
+
+// DiagnosticKind identifies the kind of issue a warning reports.
+type DiagnosticKind string
+
+const (
+	// DiagnosticPastTerminal reports accesses within values of the any type, whose existence and type cannot be
+	// checked before the expression is evaluated.
+	DiagnosticPastTerminal DiagnosticKind = "past-terminal"
+	// DiagnosticDeprecatedField reports references to fields whose description marks them as deprecated.
+	DiagnosticDeprecatedField DiagnosticKind = "deprecated-field"
+	// DiagnosticImplicitConversion reports literals whose value has a different type than they are written as, such
+	// as duration literals, which are integers of seconds.
+	DiagnosticImplicitConversion DiagnosticKind = "implicit-conversion"
+	// DiagnosticDeprecatedFunction reports calls of functions that are deprecated, see DeprecatedFunction.
+	DiagnosticDeprecatedFunction DiagnosticKind = "deprecated-function"
+	// DiagnosticDeprecatedSyntax reports uses of language features that are deprecated in
+	// Options.DeprecatedFeatures.
+	DiagnosticDeprecatedSyntax DiagnosticKind = "deprecated-syntax"
+)
+
+// Diagnostic is a single problem found when validating an expression.
+type Diagnostic struct {
+	Severity DiagnosticSeverity
+	// Kind is the kind of issue a warning reports. It is empty for errors; use ErrorCodeOf on the error returned by
+	// the failing call to get the code of an error.
+	Kind    DiagnosticKind
+	Message string
+	// Path is the dependency the diagnostic is about, if any.
+	Path Path
+	// Start is the position of the start of the code the diagnostic is about. It is not valid if the diagnostic is
+	// not about a specific part of the expression.
+	Start ast.Position
+	// End is the position directly after the code the diagnostic is about.
+	End ast.Position
+	// format and args are the format and the arguments of the message, to translate it.
+	format string
+	args   []any
+}
+
+// withMessage returns the diagnostic with the message formatted like fmt.Sprintf does.
+func (d Diagnostic) withMessage(format string, args ...any) Diagnostic {
+	d.Message = fmt.Sprintf(format, args...)
+	d.format = format
+	d.args = args
+	return d
+}
+
+// Localize returns the message of the diagnostic translated by the localizer.
+func (d Diagnostic) Localize(localizer Localizer) string {
+	return localizeMessage(localizer, d.Message, d.format, d.args)
+}
+
+// String returns the position, if known, the severity, and the message of the diagnostic.
+func (d Diagnostic) String() string {
+	if d.Start.IsValid() {
+		return d.Start.String() + ": " + string(d.Severity) + ": " + d.Message
+	}
+	return string(d.Severity) + ": " + d.Message
+}
+
+// ValidationReport holds the diagnostics found when validating an expression.
+type ValidationReport struct {
+	Errors   []Diagnostic
+	Warnings []Diagnostic
+}
+
+// HasErrors returns true if the report contains at least one error.
+func (r ValidationReport) HasErrors() bool {
+	return len(r.Errors) > 0
+}
+
+// Err returns an error combining all errors in the report, or nil if there are no errors. Warnings are not included.
+func (r ValidationReport) Err() error {
+	if !r.HasErrors() {
+		return nil
+	}
+	messages := make([]string, len(r.Errors))
+	for i, diagnostic := range r.Errors {
+		messages[i] = diagnostic.Message
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func (r *ValidationReport) addError(err error) {
+	diagnostic := Diagnostic{Severity: SeverityError}.withMessage("%v", err)
+	var codedErr *Error
+	if errors.As(err, &codedErr) {
+		diagnostic.Start = codedErr.Position
+		diagnostic.End = codedErr.End
+	}
+	r.Errors = append(r.Errors, diagnostic)
+}
+
+func (r *ValidationReport) addWarning(kind DiagnosticKind, path Path, format string, args ...any) {
+	warning := Diagnostic{Severity: SeverityWarning, Kind: kind, Path: path}
+	r.Warnings = append(r.Warnings, warning.withMessage(format, args...))
+}
+
+func (e expression) Validate(
+	scope schema.Scope,
+	functions map[string]schema.Function,
+	workflowContext map[string][]byte,
+) ValidationReport {
+	report := ValidationReport{}
+	report.Warnings = append(report.Warnings, e.warnings...)
+	dependencyResolutionResult, err := e.resolveDependencies(scope, functions, workflowContext)
+	if err != nil {
+		report.addError(err)
+		return report
+	}
+	for _, tree := range dependencyResolutionResult.completedPaths {
+		addPastTerminalWarnings(&report, tree, nil)
+	}
+	for _, ref := range findReferences(e.ast) {
+		addDeprecatedFieldWarnings(&report, scope, ref)
+	}
+	addDeprecatedFunctionWarnings(&report, e.ast, functions)
+	return report
+}
+
+// addDeprecatedFunctionWarnings adds a warning for each call of a deprecated function that was not already reported
+// when the expression was parsed.
+func addDeprecatedFunctionWarnings(report *ValidationReport, root ast.Node, functions map[string]schema.Function) {
+	warnings := deprecatedFunctionWarnings(root, func(name string) (schema.Function, bool) {
+		function, found := functions[name]
+		return function, found
+	})
+	for _, warning := range warnings {
+		if !slices.ContainsFunc(report.Warnings, func(reported Diagnostic) bool {
+			return reported.Kind == warning.Kind && reported.Start == warning.Start
+		}) {
+			report.Warnings = append(report.Warnings, warning)
+		}
+	}
+}
+
+// addPastTerminalWarnings adds a warning for each access within a value of the any type, since the existence and
+// the type of these values cannot be checked before the expression is evaluated.
+func addPastTerminalWarnings(report *ValidationReport, tree *PathTree, parent Path) {
+	path := append(append(Path{}, parent...), tree.PathItem)
+	if tree.NodeType == PastTerminalNode {
+		report.addWarning(
+			DiagnosticPastTerminal,
+			path,
+			"%s accesses a value within an untyped (any) value, which cannot be checked before evaluation",
+			path.String(),
+		)
+		return
+	}
+	for _, subtree := range tree.Subtrees {
+		addPastTerminalWarnings(report, subtree, path)
+	}
+}
+
+// addDeprecatedFieldWarnings adds a warning for each field in the reference that is marked as deprecated in its
+// description.
+func addDeprecatedFieldWarnings(report *ValidationReport, scope schema.Scope, ref reference) {
+	forEachDeprecatedField(scope, ref, func(segment int, fieldName string, description string) {
+		itemNode := referenceItemNode(ref.segments[segment].node)
+		report.Warnings = append(report.Warnings, Diagnostic{
+			Severity: SeverityWarning,
+			Kind:     DiagnosticDeprecatedField,
+			Path:     ref.path()[:segment+1],
+			Start:    itemNode.Start(),
+			End:      itemNode.End(),
+		}.withMessage("the field %q is deprecated: %s", fieldName, description))
+	})
+}
+
+// literalWarnings returns a warning for each literal whose value has a different type than it is written as.
+// Duration, byte size, and integer exponent literals are the only literals the parser converts to integers.
+func literalWarnings(root ast.Node) []Diagnostic {
+	var result []Diagnostic
+	ast.Inspect(root, func(node ast.Node) bool {
+		literal, isInt := node.(*ast.IntLiteral)
+		if !isInt || literal.Literal == "" {
+			return true
+		}
+		var format string
+		switch {
+		case strings.ContainsAny(literal.Literal, "eE"):
+			// No duration or byte size unit contains an e.
+			format = "the number %s is converted to the integer %d"
+		case strings.ContainsAny(literal.Literal, "dhms"):
+			format = "the duration %s is converted to the integer %d, in seconds"
+		default:
+			format = "the byte size %s is converted to the integer %d, in bytes"
+		}
+		result = append(result, Diagnostic{
+			Severity: SeverityWarning,
+			Kind:     DiagnosticImplicitConversion,
+			Start:    literal.Start(),
+			End:      literal.End(),
+		}.withMessage(format, literal.Literal, literal.IntValue))
+		return true
+	})
+	return result
+}
@@ -0,0 +1,105 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestValidate_Valid(t *testing.T) {
+	expr, err := expressions.New("$.foo.bar")
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, nil, nil)
+	assert.Equals(t, report.HasErrors(), false)
+	assert.NoError(t, report.Err())
+	assert.Equals(t, len(report.Warnings), 0)
+}
+
+func TestValidate_Errors(t *testing.T) {
+	expr, err := expressions.New("$.foo.nonexistent")
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, nil, nil)
+	assert.Equals(t, report.HasErrors(), true)
+	assert.Equals(t, len(report.Errors), 1)
+	assert.Equals(t, report.Errors[0].Severity, expressions.SeverityError)
+	assert.Error(t, report.Err())
+	assert.Contains(t, report.Errors[0].String(), "error: ")
+	assert.Equals(t, report.Errors[0].Start.String(), "1:1")
+	assert.Equals(t, report.Errors[0].End.Offset, len("$.foo.nonexistent"))
+}
+
+func TestValidate_UnknownFunction(t *testing.T) {
+	expr, err := expressions.New("unknown($.foo.bar)")
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, nil, nil)
+	assert.Equals(t, report.HasErrors(), true)
+}
+
+func TestValidate_PastTerminalWarning(t *testing.T) {
+	expr, err := expressions.New("$.simple_any.a.b")
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, nil, nil)
+	assert.NoError(t, report.Err())
+	assert.Equals(t, len(report.Warnings), 1)
+	assert.Equals(t, report.Warnings[0].Severity, expressions.SeverityWarning)
+	assert.Equals(t, report.Warnings[0].Path.String(), "$.simple_any.a")
+	assert.Equals(t, report.Warnings[0].Kind, expressions.DiagnosticPastTerminal)
+}
+
+func TestValidate_DeprecatedFieldWarning(t *testing.T) {
+	description := "Deprecated: use new_name instead."
+	scope := schema.NewScopeSchema(
+		schema.NewObjectSchema(
+			"root",
+			map[string]*schema.PropertySchema{
+				"old_name": schema.NewPropertySchema(
+					schema.NewStringSchema(nil, nil, nil),
+					schema.NewDisplayValue(nil, &description, nil),
+					false,
+					nil,
+					nil,
+					nil,
+					nil,
+					nil,
+				),
+			},
+		),
+	)
+	expr, err := expressions.New(`"a" + $.old_name`)
+	assert.NoError(t, err)
+	report := expr.Validate(scope, nil, nil)
+	assert.NoError(t, report.Err())
+	assert.Equals(t, len(report.Warnings), 1)
+	assert.Equals(t, report.Warnings[0].Kind, expressions.DiagnosticDeprecatedField)
+	assert.Equals(t, report.Warnings[0].Path.String(), "$.old_name")
+	assert.Equals(t, report.Warnings[0].String(), `1:9: warning: the field "old_name" is deprecated: `+description)
+}
+
+func TestValidate_ImplicitConversionWarnings(t *testing.T) {
+	var reported []expressions.Diagnostic
+	expr, err := expressions.NewWithOptions(
+		"$.simple_int < 5m + 2Ki + 1e3",
+		expressions.Options{
+			ExponentLiterals: expressions.ExponentLiteralInteger,
+			OnWarning: func(warning expressions.Diagnostic) {
+				reported = append(reported, warning)
+			},
+		},
+	)
+	assert.NoError(t, err)
+	messages := make([]string, len(reported))
+	for i, warning := range reported {
+		assert.Equals(t, warning.Kind, expressions.DiagnosticImplicitConversion)
+		messages[i] = warning.String()
+	}
+	assert.Equals(t, messages, []string{
+		"1:16: warning: the duration 5m is converted to the integer 300, in seconds",
+		"1:21: warning: the byte size 2Ki is converted to the integer 2048, in bytes",
+		"1:27: warning: the number 1e3 is converted to the integer 1000",
+	})
+	report := expr.Validate(testScope, nil, nil)
+	assert.NoError(t, report.Err())
+	assert.Equals(t, report.Warnings, reported)
+}
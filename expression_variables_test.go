@@ -0,0 +1,93 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestEvaluateWithVariables(t *testing.T) {
+	data := map[string]any{
+		"item":     "root item",
+		"int_list": []any{int64(10), int64(20)},
+		"foo":      map[string]any{"item": "field item"},
+	}
+	variables := map[string]any{"item": "loop item", "index": int64(1)}
+	testCases := map[string]any{
+		`item`:                  "loop item",
+		`$vars.item`:            "loop item",
+		`$.item`:                "root item",
+		`$item`:                 "root item",
+		`$.foo.item`:            "field item",
+		`foo.item`:              "field item",
+		`$.int_list[index]`:     int64(20),
+		`int_list[$vars.index]`: int64(20),
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			result, err := expr.EvaluateWithVariables(data, variables, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, result, expected)
+		})
+	}
+}
+
+func TestEvaluateWithVariables_Undefined(t *testing.T) {
+	expr, err := expressions.New(`$vars.missing`)
+	assert.NoError(t, err)
+	_, err = expr.EvaluateWithVariables(map[string]any{}, map[string]any{"item": 1}, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+	_, err = expr.Evaluate(map[string]any{"vars": map[string]any{"missing": 1}}, nil, nil)
+	assert.Error(t, err)
+}
+
+func TestTypeWithVariables(t *testing.T) {
+	variables := map[string]schema.Type{
+		"simple_int": schema.NewStringSchema(nil, nil, nil),
+		"index":      schema.NewIntSchema(nil, nil, nil),
+	}
+	testCases := map[string]schema.TypeID{
+		`simple_int`:                  schema.TypeIDString,
+		`$.simple_int`:                schema.TypeIDInt,
+		`$vars.index + 1`:             schema.TypeIDInt,
+		`$.foo.int_list[index]`:       schema.TypeIDInt,
+		`$.foo.int_list[$vars.index]`: schema.TypeIDInt,
+	}
+	for expression, expected := range testCases {
+		t.Run(expression, func(t *testing.T) {
+			expr, err := expressions.New(expression)
+			assert.NoError(t, err)
+			resolvedType, err := expr.TypeWithVariables(testScope, variables, nil, nil)
+			assert.NoError(t, err)
+			assert.Equals(t, resolvedType.TypeID(), expected)
+		})
+	}
+
+	expr, err := expressions.New(`$vars.missing`)
+	assert.NoError(t, err)
+	_, err = expr.TypeWithVariables(testScope, variables, nil, nil)
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+}
+
+func TestDependenciesWithVariables(t *testing.T) {
+	variables := map[string]schema.Type{"index": schema.NewIntSchema(nil, nil, nil)}
+	expr, err := expressions.New(`$.foo.int_list[index] + $vars.index + $.simple_int`)
+	assert.NoError(t, err)
+	paths, err := expr.DependenciesWithVariables(testScope, variables, nil, nil, fullDataRequirements)
+	assert.NoError(t, err)
+	pathStrings := make([]string, len(paths))
+	for i, path := range paths {
+		pathStrings[i] = path.String()
+	}
+	assert.Equals(t, pathStrings, []string{"$.foo.int_list", "$.simple_int"})
+}
+
+func TestVariables_Canonical(t *testing.T) {
+	expr, err := expressions.New(`$vars["item"]`)
+	assert.NoError(t, err)
+	assert.Equals(t, expr.Canonical(), `$vars.item`)
+}
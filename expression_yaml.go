@@ -0,0 +1,108 @@
+package expressions
+
+import "gopkg.in/yaml.v3"
+
+// YAMLTag is the YAML tag that marks a scalar as an expression, such as `input: !expr $.steps.a.outputs.success`.
+const YAMLTag = "!expr"
+
+// MarshalYAML encodes the expression as a scalar with the !expr tag.
+func (e expression) MarshalYAML() (any, error) {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: YAMLTag, Value: e.expression}, nil
+}
+
+// MarshalYAML encodes the expression as a scalar with the !expr tag, or null if there is no expression.
+func (f Field) MarshalYAML() (any, error) {
+	if f.Expression == nil {
+		return nil, nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: YAMLTag, Value: f.Expression.String()}, nil
+}
+
+// UnmarshalYAML parses the expression from a string scalar, with or without the !expr tag. The positions in the
+// errors of the expression are the positions in the YAML document. YAML null results in an empty Field.
+func (f *Field) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+		f.Expression = nil
+		return nil
+	}
+	if node.Kind != yaml.ScalarNode || (node.Tag != YAMLTag && node.Tag != "!!str") {
+		return Errorf("expressions must be encoded as YAML strings, found %s at line %d", node.Tag, node.Line)
+	}
+	expr, err := parseYAMLScalar(node, Options{})
+	if err != nil {
+		return err
+	}
+	f.Expression = expr
+	return nil
+}
+
+// DecodeYAML decodes the YAML node into Go values like yaml.Node.Decode does into an any value, but parses the
+// scalars tagged with !expr into Expression values. Mappings are decoded into map[string]any, and sequences into
+// []any. The expressions are parsed with the options, and the positions in their errors are the positions in the YAML
+// document. Encoding the result with yaml.Marshal writes the expressions with the !expr tag again.
+func DecodeYAML(node *yaml.Node, options Options) (any, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return DecodeYAML(node.Content[0], options)
+	case yaml.AliasNode:
+		return DecodeYAML(node.Alias, options)
+	case yaml.MappingNode:
+		result := make(map[string]any, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			keyNode := node.Content[i]
+			if keyNode.Kind != yaml.ScalarNode {
+				return nil, Errorf("unsupported non-scalar mapping key at line %d", keyNode.Line)
+			}
+			value, err := DecodeYAML(node.Content[i+1], options)
+			if err != nil {
+				return nil, err
+			}
+			result[keyNode.Value] = value
+		}
+		return result, nil
+	case yaml.SequenceNode:
+		result := make([]any, len(node.Content))
+		for i, item := range node.Content {
+			value, err := DecodeYAML(item, options)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = value
+		}
+		return result, nil
+	default:
+		if node.Tag == YAMLTag {
+			return parseYAMLScalar(node, options)
+		}
+		var value any
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
+
+// parseYAMLScalar parses the value of the scalar node as an expression, with the position offsets set to the position
+// of the value in the document. The position of a node is the position of its tag, if it has one, so the tag is
+// skipped. Block scalars start on the next line, and their indentation is not known, so only their lines are exact.
+func parseYAMLScalar(node *yaml.Node, options Options) (Expression, error) {
+	line := node.Line
+	column := node.Column
+	if node.Style&yaml.TaggedStyle != 0 {
+		// The tag is followed by a space.
+		column += len(node.Tag) + 1
+	}
+	switch {
+	case node.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+		line++
+		column = 1
+	case node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0:
+		column++
+	}
+	options.LineOffset += line - 1
+	options.ColumnOffset += column - 1
+	return NewWithOptions(node.Value, options)
+}
@@ -0,0 +1,62 @@
+package expressions_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+	"gopkg.in/yaml.v3"
+)
+
+func TestField_YAML(t *testing.T) {
+	type step struct {
+		Input expressions.Field `yaml:"input"`
+		Skip  expressions.Field `yaml:"skip"`
+	}
+	var decoded step
+	assert.NoError(t, yaml.Unmarshal([]byte("input: !expr $.foo.bar\nskip: null\n"), &decoded))
+	assert.Equals(t, decoded.Input.String(), "$.foo.bar")
+	assert.Nil(t, decoded.Skip.Expression)
+
+	encoded, err := yaml.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.Equals(t, string(encoded), "input: !expr $.foo.bar\nskip: null\n")
+
+	// Untagged strings are parsed too, like in JSON.
+	assert.NoError(t, yaml.Unmarshal([]byte("input: $.foo.bar\n"), &decoded))
+	assert.Equals(t, decoded.Input.String(), "$.foo.bar")
+
+	assert.Error(t, yaml.Unmarshal([]byte("input: 5\n"), &decoded))
+}
+
+func TestDecodeYAML(t *testing.T) {
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("steps:\n  - name: a\n    input: !expr $.foo.bar\ncount: 1\n"), &node))
+	decoded, err := expressions.DecodeYAML(&node, expressions.Options{})
+	assert.NoError(t, err)
+	root := decoded.(map[string]any)
+	assert.Equals(t, root["count"], any(1))
+	step := root["steps"].([]any)[0].(map[string]any)
+	assert.Equals(t, step["name"], any("a"))
+	expr := step["input"].(expressions.Expression)
+	assert.Equals(t, expr.String(), "$.foo.bar")
+	// The position of the expression is its position in the document.
+	assert.Equals(t, expr.AST().Start().Line, 3)
+	assert.Equals(t, expr.AST().Start().Column, 18)
+
+	encoded, err := yaml.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.Equals(t, string(encoded), "count: 1\nsteps:\n    - input: !expr $.foo.bar\n      name: a\n")
+}
+
+func TestDecodeYAML_Errors(t *testing.T) {
+	var node yaml.Node
+	assert.NoError(t, yaml.Unmarshal([]byte("a: 1\nb: !expr '1 + )'\n"), &node))
+	_, err := expressions.DecodeYAML(&node, expressions.Options{})
+	var grammarErr *ast.InvalidGrammarError
+	assert.Equals(t, errors.As(err, &grammarErr), true)
+	assert.Equals(t, grammarErr.FoundToken.Line, 2)
+	assert.Equals(t, grammarErr.FoundToken.Column, 15)
+}
@@ -0,0 +1,222 @@
+package functions
+
+import (
+	"cmp"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Aggregate returns the built-in functions that reduce lists of numbers: sum, avg, min, max, and count. The sum,
+// min, and max of a list of integers are integers, and of a list of floats are floats. The average is always a float.
+// Lists of the any type may contain both integers and floats, in which case the result is a float.
+func Aggregate() map[string]schema.CallableFunction {
+	return toMap(
+		sumFunction(),
+		avgFunction(),
+		minFunction(),
+		maxFunction(),
+		countFunction(),
+	)
+}
+
+// numbers holds the items of a list of numbers, as integers if all items are integers, and as floats otherwise.
+type numbers struct {
+	ints    []int64
+	floats  []float64
+	allInts bool
+}
+
+func (n numbers) len() int {
+	return len(n.floats)
+}
+
+// numberValues converts the items of the list to numbers, or returns an error if an item is not a number.
+func numberValues(name string, list any) (numbers, error) {
+	value, err := listValue(list)
+	if err != nil {
+		return numbers{}, expressions.Errorf("%s: %w", name, err)
+	}
+	result := numbers{
+		ints:    make([]int64, value.Len()),
+		floats:  make([]float64, value.Len()),
+		allInts: true,
+	}
+	for i := 0; i < value.Len(); i++ {
+		item := unwrapInterface(value.Index(i))
+		switch {
+		case item.CanInt():
+			result.ints[i] = item.Int()
+			result.floats[i] = float64(item.Int())
+		case item.CanFloat():
+			result.floats[i] = item.Float()
+			result.allInts = false
+		default:
+			return numbers{}, expressions.Errorf("%s: item %d is not a number (got %s)", name, i, item.Kind())
+		}
+	}
+	return result, nil
+}
+
+// numberListType validates that the type is a list of numbers, and returns the item type.
+func numberListType(name string, listType schema.Type) (schema.Type, error) {
+	itemType, err := listItemType(listType)
+	if err != nil {
+		return nil, expressions.Errorf("%s: %w", name, err)
+	}
+	switch itemType.TypeID() {
+	case schema.TypeIDInt, schema.TypeIDFloat, schema.TypeIDAny:
+		return itemType, nil
+	default:
+		return nil, expressions.Errorf("%s expects a list of integers or floats, got a list of %s items", name, itemType.TypeID())
+	}
+}
+
+// numberResultType returns the type of the result of sum, min, and max, which is the item type of the list, or the
+// any type if the items may be integers or floats.
+func numberResultType(name string) func(inputTypes []schema.Type) (schema.Type, error) {
+	return func(inputTypes []schema.Type) (schema.Type, error) {
+		itemType, err := numberListType(name, inputTypes[0])
+		if err != nil {
+			return nil, err
+		}
+		switch itemType.TypeID() {
+		case schema.TypeIDInt:
+			return schema.NewIntSchema(nil, nil, nil), nil
+		case schema.TypeIDFloat:
+			return schema.NewFloatSchema(nil, nil, nil), nil
+		default:
+			return schema.NewAnySchema(), nil
+		}
+	}
+}
+
+func sumFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"sum",
+		[]schema.Type{schema.NewAnySchema()},
+		display("sum", "Returns the sum of the numbers in the list, or 0 if the list is empty."),
+		func(list any) (any, error) {
+			values, err := numberValues("sum", list)
+			if err != nil {
+				return nil, err
+			}
+			if values.allInts {
+				var result int64
+				for _, value := range values.ints {
+					result += value
+				}
+				return result, nil
+			}
+			var result float64
+			for _, value := range values.floats {
+				result += value
+			}
+			return result, nil
+		},
+		numberResultType("sum"),
+	))
+}
+
+func avgFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"avg",
+		[]schema.Type{schema.NewAnySchema()},
+		display("avg", "Returns the average of the numbers in the list as a float. Fails if the list is empty."),
+		func(list any) (any, error) {
+			values, err := numberValues("avg", list)
+			if err != nil {
+				return nil, err
+			}
+			if values.len() == 0 {
+				return nil, expressions.Errorf("avg called on an empty list")
+			}
+			var sum float64
+			for _, value := range values.floats {
+				sum += value
+			}
+			return sum / float64(values.len()), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if _, err := numberListType("avg", inputTypes[0]); err != nil {
+				return nil, err
+			}
+			return schema.NewFloatSchema(nil, nil, nil), nil
+		},
+	))
+}
+
+// compare compares the numbers at the two indexes, as integers if all numbers are integers.
+func (n numbers) compare(i int, j int) int {
+	if n.allInts {
+		return cmp.Compare(n.ints[i], n.ints[j])
+	}
+	return cmp.Compare(n.floats[i], n.floats[j])
+}
+
+// extremeFunction creates a function that returns the smallest number in the list if the sign is -1, or the largest
+// number if the sign is 1.
+func extremeFunction(name string, description string, sign int) schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		name,
+		[]schema.Type{schema.NewAnySchema()},
+		display(name, description),
+		func(list any) (any, error) {
+			values, err := numberValues(name, list)
+			if err != nil {
+				return nil, err
+			}
+			if values.len() == 0 {
+				return nil, expressions.Errorf("%s called on an empty list", name)
+			}
+			index := 0
+			for i := 1; i < values.len(); i++ {
+				if values.compare(i, index) == sign {
+					index = i
+				}
+			}
+			if values.allInts {
+				return values.ints[index], nil
+			}
+			return values.floats[index], nil
+		},
+		numberResultType(name),
+	))
+}
+
+func minFunction() schema.CallableFunction {
+	return extremeFunction(
+		"min",
+		"Returns the smallest number in the list. Fails if the list is empty.",
+		-1,
+	)
+}
+
+func maxFunction() schema.CallableFunction {
+	return extremeFunction(
+		"max",
+		"Returns the largest number in the list. Fails if the list is empty.",
+		1,
+	)
+}
+
+func countFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"count",
+		[]schema.Type{schema.NewAnySchema()},
+		display("count", "Returns the number of items in the list."),
+		func(list any) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, expressions.Errorf("count: %w", err)
+			}
+			return int64(value.Len()), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if _, err := listItemType(inputTypes[0]); err != nil {
+				return nil, expressions.Errorf("count: %w", err)
+			}
+			return schema.NewIntSchema(nil, nil, nil), nil
+		},
+	))
+}
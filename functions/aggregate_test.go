@@ -0,0 +1,30 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestAggregate(t *testing.T) {
+	runFunctionTests(t, functions.Aggregate(), map[string]functionTestCase{
+		"sum":              {`sum($.ints)`, schema.TypeIDInt, int64(6), false, false},
+		"sum-empty":        {`sum($.empty)`, schema.TypeIDInt, int64(0), false, false},
+		"sum-floats":       {`sum($.floats)`, schema.TypeIDFloat, 4.0, false, false},
+		"sum-strings":      {`sum($.strings)`, "", nil, true, false},
+		"sum-not-list":     {`sum($.int)`, "", nil, true, false},
+		"sum-untyped":      {`sum($.untyped)`, schema.TypeIDAny, nil, false, true},
+		"sum-mixed":        {`sum($.numbers)`, schema.TypeIDAny, 3.5, false, false},
+		"avg":              {`avg($.ints)`, schema.TypeIDFloat, 2.0, false, false},
+		"avg-empty":        {`avg($.empty)`, schema.TypeIDFloat, nil, false, true},
+		"min":              {`min($.ints)`, schema.TypeIDInt, int64(1), false, false},
+		"min-floats":       {`min($.floats)`, schema.TypeIDFloat, 0.5, false, false},
+		"min-empty":        {`min($.empty)`, schema.TypeIDInt, nil, false, true},
+		"max":              {`max($.ints)`, schema.TypeIDInt, int64(3), false, false},
+		"max-mixed":        {`max($.numbers)`, schema.TypeIDAny, 2.5, false, false},
+		"count":            {`count($.strings)`, schema.TypeIDInt, int64(2), false, false},
+		"count-empty":      {`count($.empty)`, schema.TypeIDInt, int64(0), false, false},
+		"count-not-a-list": {`count($.map)`, "", nil, true, false},
+	})
+}
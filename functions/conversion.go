@@ -0,0 +1,207 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Conversion returns the built-in functions that convert between the primitive types: toInt, toFloat, toString,
+// and toBool. They accept integers, floats, strings, and booleans, and fail if the value cannot be converted.
+// The toIntOr, toFloatOr, and toBoolOr variants take a default value as the second argument, which is returned
+// instead of failing.
+//
+// The conversions are:
+//   - toInt: floats are truncated towards zero, strings are parsed as base 10 integers, and booleans are 1 or 0.
+//   - toFloat: strings are parsed as floats, and booleans are 1 or 0.
+//   - toString: floats are formatted without an exponent, and booleans are "true" or "false".
+//   - toBool: numbers are true if they are not zero, and strings are parsed with strconv.ParseBool, which accepts
+//     1, t, T, TRUE, true, True, 0, f, F, FALSE, false, and False.
+func Conversion() map[string]schema.CallableFunction {
+	return toMap(
+		conversionFunction("toInt", "Converts the value to an integer.", schema.NewIntSchema(nil, nil, nil), convertToInt),
+		conversionFunction("toFloat", "Converts the value to a float.", schema.NewFloatSchema(nil, nil, nil), convertToFloat),
+		conversionFunction("toString", "Converts the value to a string.", schema.NewStringSchema(nil, nil, nil), convertToString),
+		conversionFunction("toBool", "Converts the value to a boolean.", schema.NewBoolSchema(), convertToBool),
+		conversionOrFunction("toIntOr", schema.NewIntSchema(nil, nil, nil), convertToInt),
+		conversionOrFunction("toFloatOr", schema.NewFloatSchema(nil, nil, nil), convertToFloat),
+		conversionOrFunction("toBoolOr", schema.NewBoolSchema(), convertToBool),
+	)
+}
+
+// validateConvertible validates that the type is a primitive type that can be converted.
+func validateConvertible(name string, inputType schema.Type) error {
+	switch inputType.TypeID() {
+	case schema.TypeIDInt, schema.TypeIDFloat, schema.TypeIDString, schema.TypeIDBool, schema.TypeIDAny:
+		return nil
+	default:
+		return expressions.Errorf("%s expects an integer, float, string, or boolean, got %s", name, inputType.TypeID())
+	}
+}
+
+func conversionFunction(
+	name string,
+	description string,
+	outputType schema.Type,
+	convert func(value any) (any, error),
+) schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		name,
+		[]schema.Type{schema.NewAnySchema()},
+		display(name, description+" Fails if the value cannot be converted."),
+		func(value any) (any, error) {
+			result, err := convert(value)
+			if err != nil {
+				return nil, expressions.Errorf("%s: %w", name, err)
+			}
+			return result, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if err := validateConvertible(name, inputTypes[0]); err != nil {
+				return nil, err
+			}
+			return outputType, nil
+		},
+	))
+}
+
+func conversionOrFunction(
+	name string,
+	outputType schema.Type,
+	convert func(value any) (any, error),
+) schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		name,
+		// The default value is declared as any, since the handler receives it as any. Its type is checked when the
+		// output type is resolved.
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display(
+			name,
+			fmt.Sprintf(
+				"Converts the value in the first argument to %s, or returns the default value in the second "+
+					"argument if the value cannot be converted.",
+				outputType.TypeID(),
+			),
+		),
+		func(value any, defaultValue any) (any, error) {
+			result, err := convert(value)
+			if err != nil {
+				return defaultValue, nil //nolint:nilerr // Returning the default value is the purpose of the function.
+			}
+			return result, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if err := validateConvertible(name, inputTypes[0]); err != nil {
+				return nil, err
+			}
+			if !typesCompatible(outputType, inputTypes[1]) {
+				return nil, expressions.Errorf(
+					"%s expects a %s default value, got %s", name, outputType.TypeID(), inputTypes[1].TypeID(),
+				)
+			}
+			return outputType, nil
+		},
+	))
+}
+
+func convertToInt(value any) (any, error) {
+	reflectedValue := reflect.ValueOf(value)
+	switch {
+	case reflectedValue.CanInt():
+		return reflectedValue.Int(), nil
+	case reflectedValue.CanUint():
+		if reflectedValue.Uint() > math.MaxInt64 {
+			return nil, expressions.Errorf("%d is out of the integer range", reflectedValue.Uint())
+		}
+		return int64(reflectedValue.Uint()), nil
+	case reflectedValue.CanFloat():
+		floatValue := math.Trunc(reflectedValue.Float())
+		if math.IsNaN(floatValue) || floatValue < math.MinInt64 || floatValue >= math.MaxInt64 {
+			return nil, expressions.Errorf("%v is out of the integer range", reflectedValue.Float())
+		}
+		return int64(floatValue), nil
+	case reflectedValue.Kind() == reflect.String:
+		result, err := strconv.ParseInt(strings.TrimSpace(reflectedValue.String()), 10, 64)
+		if err != nil {
+			return nil, expressions.Errorf("%q is not a valid integer", reflectedValue.String())
+		}
+		return result, nil
+	case reflectedValue.Kind() == reflect.Bool:
+		if reflectedValue.Bool() {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	default:
+		return nil, expressions.Errorf("cannot convert %T to an integer", value)
+	}
+}
+
+func convertToFloat(value any) (any, error) {
+	reflectedValue := reflect.ValueOf(value)
+	switch {
+	case reflectedValue.CanInt():
+		return float64(reflectedValue.Int()), nil
+	case reflectedValue.CanUint():
+		return float64(reflectedValue.Uint()), nil
+	case reflectedValue.CanFloat():
+		return reflectedValue.Float(), nil
+	case reflectedValue.Kind() == reflect.String:
+		result, err := strconv.ParseFloat(strings.TrimSpace(reflectedValue.String()), 64)
+		if err != nil {
+			return nil, expressions.Errorf("%q is not a valid float", reflectedValue.String())
+		}
+		return result, nil
+	case reflectedValue.Kind() == reflect.Bool:
+		if reflectedValue.Bool() {
+			return 1.0, nil
+		}
+		return 0.0, nil
+	default:
+		return nil, expressions.Errorf("cannot convert %T to a float", value)
+	}
+}
+
+func convertToString(value any) (any, error) {
+	reflectedValue := reflect.ValueOf(value)
+	switch {
+	case reflectedValue.CanInt():
+		return strconv.FormatInt(reflectedValue.Int(), 10), nil
+	case reflectedValue.CanUint():
+		return strconv.FormatUint(reflectedValue.Uint(), 10), nil
+	case reflectedValue.CanFloat():
+		return strconv.FormatFloat(reflectedValue.Float(), 'f', -1, 64), nil
+	case reflectedValue.Kind() == reflect.String:
+		return reflectedValue.String(), nil
+	case reflectedValue.Kind() == reflect.Bool:
+		return strconv.FormatBool(reflectedValue.Bool()), nil
+	default:
+		return nil, expressions.Errorf("cannot convert %T to a string", value)
+	}
+}
+
+func convertToBool(value any) (any, error) {
+	reflectedValue := reflect.ValueOf(value)
+	switch {
+	case reflectedValue.CanInt():
+		return reflectedValue.Int() != 0, nil
+	case reflectedValue.CanUint():
+		return reflectedValue.Uint() != 0, nil
+	case reflectedValue.CanFloat():
+		return reflectedValue.Float() != 0, nil
+	case reflectedValue.Kind() == reflect.String:
+		result, err := strconv.ParseBool(strings.TrimSpace(reflectedValue.String()))
+		if err != nil {
+			return nil, expressions.Errorf("%q is not a valid boolean", reflectedValue.String())
+		}
+		return result, nil
+	case reflectedValue.Kind() == reflect.Bool:
+		return reflectedValue.Bool(), nil
+	default:
+		return nil, expressions.Errorf("cannot convert %T to a boolean", value)
+	}
+}
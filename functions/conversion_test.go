@@ -0,0 +1,34 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestConversion(t *testing.T) {
+	runFunctionTests(t, functions.Conversion(), map[string]functionTestCase{
+		"to-int-from-float":     {`toInt($.float)`, schema.TypeIDInt, int64(1), false, false},
+		"to-int-from-string":    {`toInt(" 42 ")`, schema.TypeIDInt, int64(42), false, false},
+		"to-int-from-bool":      {`toInt($.bool)`, schema.TypeIDInt, int64(1), false, false},
+		"to-int-invalid-string": {`toInt($.str)`, schema.TypeIDInt, nil, false, true},
+		"to-int-from-list":      {`toInt($.ints)`, "", nil, true, false},
+		"to-float-from-int":     {`toFloat($.int)`, schema.TypeIDFloat, 5.0, false, false},
+		"to-float-from-string":  {`toFloat("2.5")`, schema.TypeIDFloat, 2.5, false, false},
+		"to-float-invalid":      {`toFloat("abc")`, schema.TypeIDFloat, nil, false, true},
+		"to-string-from-int":    {`toString($.int)`, schema.TypeIDString, "5", false, false},
+		"to-string-from-float":  {`toString($.float)`, schema.TypeIDString, "1.5", false, false},
+		"to-string-from-bool":   {`toString($.bool)`, schema.TypeIDString, "true", false, false},
+		"to-string-from-map":    {`toString($.map)`, "", nil, true, false},
+		"to-bool-from-string":   {`toBool("false")`, schema.TypeIDBool, false, false, false},
+		"to-bool-from-int":      {`toBool($.int)`, schema.TypeIDBool, true, false, false},
+		"to-bool-invalid":       {`toBool("maybe")`, schema.TypeIDBool, nil, false, true},
+		"to-bool-untyped":       {`toBool($.untyped)`, schema.TypeIDBool, nil, false, true},
+		"to-int-or":             {`toIntOr("7", 0)`, schema.TypeIDInt, int64(7), false, false},
+		"to-int-or-default":     {`toIntOr($.str, -1)`, schema.TypeIDInt, int64(-1), false, false},
+		"to-int-or-bad-default": {`toIntOr($.str, "x")`, "", nil, true, false},
+		"to-float-or-default":   {`toFloatOr("x", 0.5)`, schema.TypeIDFloat, 0.5, false, false},
+		"to-bool-or-default":    {`toBoolOr("maybe", true)`, schema.TypeIDBool, true, false, false},
+	})
+}
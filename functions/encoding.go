@@ -0,0 +1,165 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"gopkg.in/yaml.v3"
+)
+
+// Encoding returns the built-in functions that encode and decode strings: base64Encode, base64Decode, jsonEncode,
+// jsonDecode, yamlDecode, and urlEncode. The decoded JSON and YAML values are of the any type, so accessing their
+// items is not type checked, and the dependencies of the items end at the decode function's result.
+func Encoding() map[string]schema.CallableFunction {
+	return toMap(
+		base64EncodeFunction(),
+		base64DecodeFunction(),
+		jsonEncodeFunction(),
+		jsonDecodeFunction(),
+		yamlDecodeFunction(),
+		urlEncodeFunction(),
+	)
+}
+
+func base64EncodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"base64Encode",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		display("base64Encode", "Encodes the string with the standard base64 encoding."),
+		func(value string) string {
+			return base64.StdEncoding.EncodeToString([]byte(value))
+		},
+	))
+}
+
+func base64DecodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"base64Decode",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		display("base64Decode", "Decodes the string with the standard base64 encoding. Fails if it is not valid base64."),
+		func(value string) (string, error) {
+			result, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return "", expressions.Errorf("base64Decode: invalid base64 input (%w)", err)
+			}
+			return string(result), nil
+		},
+	))
+}
+
+func jsonEncodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"jsonEncode",
+		[]schema.Type{schema.NewAnySchema()},
+		display("jsonEncode", "Encodes the value as a JSON string. Map keys are sorted."),
+		func(value any) (any, error) {
+			result, err := json.Marshal(value)
+			if err != nil {
+				return nil, expressions.Errorf("jsonEncode: cannot encode %T (%w)", value, err)
+			}
+			return string(result), nil
+		},
+		func(_ []schema.Type) (schema.Type, error) {
+			return schema.NewStringSchema(nil, nil, nil), nil
+		},
+	))
+}
+
+func jsonDecodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"jsonDecode",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewAnySchema(),
+		true,
+		display(
+			"jsonDecode",
+			"Decodes the JSON string. Integers are decoded as integers and other numbers as floats. Fails if it is "+
+				"not valid JSON.",
+		),
+		func(value string) (any, error) {
+			decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+			decoder.UseNumber()
+			var result any
+			if err := decoder.Decode(&result); err != nil {
+				return nil, expressions.Errorf("jsonDecode: invalid JSON input (%w)", err)
+			}
+			if decoder.More() {
+				return nil, expressions.Errorf("jsonDecode: invalid JSON input (unexpected data after the value)")
+			}
+			return normalizeDecoded(result), nil
+		},
+	))
+}
+
+func yamlDecodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"yamlDecode",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewAnySchema(),
+		true,
+		display("yamlDecode", "Decodes the YAML string. Fails if it is not valid YAML."),
+		func(value string) (any, error) {
+			var result any
+			if err := yaml.Unmarshal([]byte(value), &result); err != nil {
+				return nil, expressions.Errorf("yamlDecode: invalid YAML input (%w)", err)
+			}
+			return normalizeDecoded(result), nil
+		},
+	))
+}
+
+func urlEncodeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"urlEncode",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		display("urlEncode", "Escapes the string so it can be used in a URL query."),
+		func(value string) string {
+			return url.QueryEscape(value)
+		},
+	))
+}
+
+// normalizeDecoded converts the numbers decoded from JSON or YAML to int64 and float64, which are the number types
+// used by expressions.
+func normalizeDecoded(data any) any {
+	switch value := data.(type) {
+	case int:
+		return int64(value)
+	case uint64:
+		// YAML decodes integers above the int64 range as uint64.
+		return float64(value)
+	case json.Number:
+		if intValue, err := value.Int64(); err == nil {
+			return intValue
+		}
+		floatValue, _ := value.Float64()
+		return floatValue
+	case map[string]any:
+		for key, item := range value {
+			value[key] = normalizeDecoded(item)
+		}
+		return value
+	case map[any]any:
+		for key, item := range value {
+			value[key] = normalizeDecoded(item)
+		}
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = normalizeDecoded(item)
+		}
+		return value
+	default:
+		return data
+	}
+}
@@ -0,0 +1,56 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestEncoding(t *testing.T) {
+	runFunctionTests(t, functions.Encoding(), map[string]functionTestCase{
+		"base64-encode":         {`base64Encode($.str)`, schema.TypeIDString, "aMOpbGxv", false, false},
+		"base64-decode":         {`base64Decode("aMOpbGxv")`, schema.TypeIDString, "héllo", false, false},
+		"base64-decode-invalid": {`base64Decode("!")`, schema.TypeIDString, nil, false, true},
+		"base64-encode-int":     {`base64Encode($.int)`, "", nil, true, false},
+		"json-encode":           {`jsonEncode($.map)`, schema.TypeIDString, `{"a":1,"b":2}`, false, false},
+		"json-decode": {
+			`jsonDecode("{\"a\": [1, 2.5]}")`,
+			schema.TypeIDAny,
+			map[string]any{"a": []any{int64(1), 2.5}},
+			false,
+			false,
+		},
+		"json-decode-item":     {`jsonDecode("{\"a\": [1, 2.5]}").a[0]`, schema.TypeIDAny, int64(1), false, false},
+		"json-decode-invalid":  {`jsonDecode("{")`, schema.TypeIDAny, nil, false, true},
+		"json-decode-trailing": {`jsonDecode("1 2")`, schema.TypeIDAny, nil, false, true},
+		"yaml-decode": {
+			`yamlDecode("a: 1\nb: [x]")`,
+			schema.TypeIDAny,
+			map[string]any{"a": int64(1), "b": []any{"x"}},
+			false,
+			false,
+		},
+		"yaml-decode-invalid": {`yamlDecode("a: [")`, schema.TypeIDAny, nil, false, true},
+		"url-encode":          {`urlEncode("a b&c")`, schema.TypeIDString, "a+b%26c", false, false},
+	})
+}
+
+func TestJSONDecodeDependencies(t *testing.T) {
+	expr, err := expressions.New(`jsonDecode($.str).a.b`)
+	assert.NoError(t, err)
+	dependencies, err := expr.Dependencies(
+		testScope,
+		toFunctionSchemas(functions.Encoding()),
+		nil,
+		expressions.UnpackRequirements{StopAtTerminals: false, IncludeKeys: true},
+	)
+	assert.NoError(t, err)
+	paths := make([]string, len(dependencies))
+	for i, dependency := range dependencies {
+		paths[i] = dependency.String()
+	}
+	assert.Equals(t, paths, []string{"$.str", "jsonDecode.a.b"})
+}
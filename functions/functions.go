@@ -0,0 +1,112 @@
+// Package functions provides built-in functions that can be passed to expressions. Each group of functions is
+// returned as a map of function names to functions, which can be passed to the expression functions directly, or
+// merged with other functions:
+//
+//	result, err := expr.Evaluate(data, functions.List(), nil)
+//
+// All built-in functions except the ones returned by Random are pure, see expressions.PureFunction.
+package functions
+
+import (
+	"fmt"
+	"reflect"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// display creates the display value of a function.
+func display(name string, description string) schema.Display {
+	return schema.NewDisplayValue(&name, &description, nil)
+}
+
+// mustFunction returns the function, and panics if it could not be created. Creating the built-in functions only
+// fails if their definitions are invalid, which is a bug.
+func mustFunction(function schema.CallableFunction, err error) schema.CallableFunction {
+	if err != nil {
+		panic(fmt.Errorf("bug: invalid built-in function (%w)", err))
+	}
+	return function
+}
+
+// toMap creates a map of the functions by their IDs. The built-in functions are pure unless they declare otherwise,
+// so functions that do not implement expressions.PureFunction are declared as pure.
+func toMap(functions ...schema.CallableFunction) map[string]schema.CallableFunction {
+	result := make(map[string]schema.CallableFunction, len(functions))
+	for _, function := range functions {
+		if _, declaresPurity := function.(expressions.PureFunction); !declaresPurity {
+			function = expressions.NewPureFunction(function)
+		}
+		result[function.ID()] = function
+	}
+	return result
+}
+
+// listValue returns the reflected value of a list, or an error if the value is not a list.
+func listValue(list any) (reflect.Value, error) {
+	value := reflect.ValueOf(list)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return reflect.Value{}, expressions.Errorf("expected a list, got %T", list)
+	}
+	return value, nil
+}
+
+// listItemType returns the type of the items of a list type. For the any type, the items are also of the any type.
+func listItemType(listType schema.Type) (schema.Type, error) {
+	switch listType.TypeID() {
+	case schema.TypeIDList:
+		return listType.(schema.UntypedList).Items(), nil
+	case schema.TypeIDAny:
+		return schema.NewAnySchema(), nil
+	default:
+		return nil, expressions.Errorf("expected a list, got %s", listType.TypeID())
+	}
+}
+
+// typesCompatible returns true if values of both types can be compared with each other. The any type is compatible
+// with all types.
+func typesCompatible(a schema.Type, b schema.Type) bool {
+	return a.TypeID() == schema.TypeIDAny || b.TypeID() == schema.TypeIDAny || a.TypeID() == b.TypeID()
+}
+
+// fieldType returns the type of the field at the path within the type.
+func fieldType(fieldParentType schema.Type, path []string) (schema.Type, error) {
+	currentType := fieldParentType
+	for _, field := range path {
+		switch currentType.TypeID() {
+		case schema.TypeIDAny:
+			return currentType, nil
+		case schema.TypeIDMap:
+			currentType = currentType.(schema.UntypedMap).Values()
+		case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+			property, found := currentType.(schema.Object).Properties()[field]
+			if !found {
+				return nil, expressions.Errorf("object %s does not have a property named %q", currentType.(schema.Object).ID(), field)
+			}
+			currentType = property.Type()
+		default:
+			return nil, expressions.Errorf("cannot access %q in a %s", field, currentType.TypeID())
+		}
+	}
+	return currentType, nil
+}
+
+// fieldValue returns the value of the field at the path within the map.
+func fieldValue(item reflect.Value, path []string) (reflect.Value, error) {
+	current := item
+	for _, field := range path {
+		current = unwrapInterface(current)
+		if current.Kind() != reflect.Map {
+			return reflect.Value{}, expressions.Errorf("cannot access %q in a %s", field, current.Kind())
+		}
+		key, ok := mapKey(current, field)
+		if !ok {
+			return reflect.Value{}, expressions.Errorf("cannot access %q in a map with %s keys", field, current.Type().Key())
+		}
+		current = current.MapIndex(key)
+		if !current.IsValid() {
+			return reflect.Value{}, expressions.Errorf("the field %q does not exist", field)
+		}
+	}
+	return current, nil
+}
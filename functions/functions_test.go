@@ -0,0 +1,130 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// testScope is the scope the test expressions are resolved against. It matches testData.
+var testScope = schema.NewScopeSchema(
+	schema.NewObjectSchema(
+		"root",
+		map[string]*schema.PropertySchema{
+			"ints":    property(schema.NewListSchema(schema.NewIntSchema(nil, nil, nil), nil, nil)),
+			"strings": property(schema.NewListSchema(schema.NewStringSchema(nil, nil, nil), nil, nil)),
+			"empty":   property(schema.NewListSchema(schema.NewIntSchema(nil, nil, nil), nil, nil)),
+			"map": property(schema.NewMapSchema(
+				schema.NewStringSchema(nil, nil, nil),
+				schema.NewIntSchema(nil, nil, nil),
+				nil,
+				nil,
+			)),
+			"otherMap": property(schema.NewMapSchema(
+				schema.NewStringSchema(nil, nil, nil),
+				schema.NewIntSchema(nil, nil, nil),
+				nil,
+				nil,
+			)),
+			"str":     property(schema.NewStringSchema(nil, nil, nil)),
+			"int":     property(schema.NewIntSchema(nil, nil, nil)),
+			"float":   property(schema.NewFloatSchema(nil, nil, nil)),
+			"bool":    property(schema.NewBoolSchema()),
+			"untyped": property(schema.NewAnySchema()),
+			"floats":  property(schema.NewListSchema(schema.NewFloatSchema(nil, nil, nil), nil, nil)),
+			"numbers": property(schema.NewAnySchema()),
+			"objects": property(schema.NewListSchema(
+				schema.NewObjectSchema(
+					"item",
+					map[string]*schema.PropertySchema{
+						"name": property(schema.NewStringSchema(nil, nil, nil)),
+						"size": property(schema.NewFloatSchema(nil, nil, nil)),
+						"tags": property(schema.NewListSchema(schema.NewStringSchema(nil, nil, nil), nil, nil)),
+						"meta": property(schema.NewMapSchema(
+							schema.NewStringSchema(nil, nil, nil),
+							schema.NewIntSchema(nil, nil, nil),
+							nil,
+							nil,
+						)),
+					},
+				),
+				nil,
+				nil,
+			)),
+		},
+	),
+)
+
+var testData = map[string]any{
+	"ints":     []int64{1, 2, 3},
+	"strings":  []string{"a", "b"},
+	"empty":    []int64{},
+	"map":      map[string]int64{"b": 2, "a": 1},
+	"otherMap": map[string]int64{"b": 3, "c": 4},
+	"str":      "héllo",
+	"int":      int64(5),
+	"float":    1.5,
+	"bool":     true,
+	"untyped":  []any{"x", int64(1)},
+	"floats":   []float64{2.5, 0.5, 1.0},
+	"numbers":  []any{int64(1), 2.5},
+	"objects": []any{
+		map[string]any{"name": "b", "size": 2.5, "tags": []string{"x"}, "meta": map[string]int64{"rank": 1}},
+		map[string]any{"name": "a", "size": 2.5, "tags": []string{}, "meta": map[string]int64{"rank": 3}},
+		map[string]any{"name": "c", "size": 0.5, "tags": []string{"y"}, "meta": map[string]int64{"rank": 2}},
+	},
+}
+
+func property(t schema.Type) *schema.PropertySchema {
+	return schema.NewPropertySchema(t, nil, true, nil, nil, nil, nil, nil)
+}
+
+// toFunctionSchemas converts the callable functions to the function schemas used for type resolution.
+func toFunctionSchemas(callableFunctions map[string]schema.CallableFunction) map[string]schema.Function {
+	result := make(map[string]schema.Function, len(callableFunctions))
+	for name, function := range callableFunctions {
+		result[name] = function
+	}
+	return result
+}
+
+type functionTestCase struct {
+	expression     string
+	expectedType   schema.TypeID
+	expectedResult any
+	// typeError is set if the type resolution is expected to fail.
+	typeError bool
+	// evalError is set if the evaluation is expected to fail.
+	evalError bool
+}
+
+func runFunctionTests(
+	t *testing.T,
+	callableFunctions map[string]schema.CallableFunction,
+	testCases map[string]functionTestCase,
+) {
+	functionSchemas := toFunctionSchemas(callableFunctions)
+	for name, testCase := range testCases {
+		tc := testCase
+		t.Run(name, func(t *testing.T) {
+			expr, err := expressions.New(tc.expression)
+			assert.NoError(t, err)
+			resultType, err := expr.Type(testScope, functionSchemas, nil)
+			if tc.typeError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equals(t, resultType.TypeID(), tc.expectedType)
+			result, err := expr.Evaluate(testData, callableFunctions, nil)
+			if tc.evalError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equals(t, result, tc.expectedResult)
+		})
+	}
+}
@@ -0,0 +1,187 @@
+// Package functionstest provides helpers for testing expressions that call functions, without the side effects of
+// the real functions:
+//
+//	registry := functions.NewFunctionRegistry()
+//	_ = registry.RegisterBuiltins("")
+//	mocks := functionstest.NewMockRegistry(registry)
+//	lookup := mocks.Mock(t, "lookup").Returns("value")
+//	result, err := expr.Evaluate(data, mocks.CallableFunctions(), nil)
+//	lookup.AssertCalledWith(t, "key")
+package functionstest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// MockRegistry wraps a function registry, and replaces the calls to the mocked functions with canned results. The
+// mocked functions keep the parameters and output types of the registered functions, so expressions are type checked
+// as with the real functions. Functions that are not mocked are called as registered.
+type MockRegistry struct {
+	registry *functions.FunctionRegistry
+	mocks    map[string]schema.CallableFunction
+}
+
+// NewMockRegistry creates a mock registry that wraps the registry.
+func NewMockRegistry(registry *functions.FunctionRegistry) *MockRegistry {
+	return &MockRegistry{
+		registry: registry,
+		mocks:    map[string]schema.CallableFunction{},
+	}
+}
+
+// Mock replaces the calls to the function registered by the qualified name with the returned mock. By default, the
+// mock returns nil. If the function is overloaded, all overloads call the same mock. The test fails if no function is
+// registered by the name.
+func (m *MockRegistry) Mock(t testing.TB, name string) *MockFunction {
+	t.Helper()
+	function, found := m.registry.Lookup(name)
+	if !found {
+		t.Fatalf("cannot mock %q, no function is registered by the name", name)
+	}
+	mock := &MockFunction{name: name}
+	overloaded, isOverloaded := function.(*expressions.OverloadedFunction)
+	if !isOverloaded {
+		m.mocks[name] = mockedFunction{CallableFunction: function, mock: mock}
+		return mock
+	}
+	overloads := make([]schema.CallableFunction, len(overloaded.Overloads()))
+	for i, overload := range overloaded.Overloads() {
+		overloads[i] = mockedFunction{CallableFunction: overload, mock: mock}
+	}
+	mockedOverloads, err := expressions.NewOverloadedFunction(overloads...)
+	if err != nil {
+		t.Fatalf("cannot mock %q (%v)", name, err)
+	}
+	m.mocks[name] = mockedOverloads
+	return mock
+}
+
+// Functions returns the registered functions with the mocked ones replaced, to be passed to Type, Dependencies, and
+// Validate.
+func (m *MockRegistry) Functions() map[string]schema.Function {
+	result := m.registry.Functions()
+	for name, mock := range m.mocks {
+		result[name] = mock
+	}
+	return result
+}
+
+// CallableFunctions returns the registered functions with the mocked ones replaced, to be passed to Evaluate.
+func (m *MockRegistry) CallableFunctions() map[string]schema.CallableFunction {
+	result := m.registry.CallableFunctions()
+	for name, mock := range m.mocks {
+		result[name] = mock
+	}
+	return result
+}
+
+// MockFunction records the calls to a mocked function and returns the canned result. It is safe for concurrent use.
+type MockFunction struct {
+	name   string
+	lock   sync.Mutex
+	result func(arguments []any) (any, error)
+	calls  [][]any
+}
+
+// Returns sets the value returned by the calls.
+func (f *MockFunction) Returns(value any) *MockFunction {
+	return f.ReturnsFunc(func(_ []any) (any, error) {
+		return value, nil
+	})
+}
+
+// ReturnsError sets the error returned by the calls.
+func (f *MockFunction) ReturnsError(err error) *MockFunction {
+	return f.ReturnsFunc(func(_ []any) (any, error) {
+		return nil, err
+	})
+}
+
+// ReturnsFunc sets the function called to get the result of each call from its arguments.
+func (f *MockFunction) ReturnsFunc(result func(arguments []any) (any, error)) *MockFunction {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.result = result
+	return f
+}
+
+// Calls returns the arguments of all calls, in the order of the calls.
+func (f *MockFunction) Calls() [][]any {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([][]any{}, f.calls...)
+}
+
+// AssertCalled fails the test if the function was not called the number of times.
+func (f *MockFunction) AssertCalled(t testing.TB, times int) {
+	t.Helper()
+	if calls := len(f.Calls()); calls != times {
+		t.Errorf("expected %q to be called %d times, but it was called %d times", f.name, times, calls)
+	}
+}
+
+// AssertCalledWith fails the test if the function was not called with the arguments.
+func (f *MockFunction) AssertCalledWith(t testing.TB, arguments ...any) {
+	t.Helper()
+	calls := f.Calls()
+	for _, call := range calls {
+		if reflect.DeepEqual(call, arguments) {
+			return
+		}
+	}
+	t.Errorf("expected %q to be called with the arguments %v, but it was called with %v", f.name, arguments, calls)
+}
+
+func (f *MockFunction) call(arguments []any) (any, error) {
+	f.lock.Lock()
+	f.calls = append(f.calls, append([]any{}, arguments...))
+	result := f.result
+	f.lock.Unlock()
+	if result == nil {
+		return nil, nil
+	}
+	return result(arguments)
+}
+
+// mockedFunction is a registered function whose calls are replaced by a mock.
+type mockedFunction struct {
+	schema.CallableFunction
+	mock *MockFunction
+}
+
+func (f mockedFunction) Call(arguments []any) (any, error) {
+	return f.mock.call(arguments)
+}
+
+// Pure returns false, so calls to the mock are not folded, and are always recorded.
+func (f mockedFunction) Pure() bool {
+	return false
+}
+
+// ValidateLiteralArguments validates the literal arguments with the registered function, if it implements
+// expressions.LiteralArgumentValidator.
+func (f mockedFunction) ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error {
+	if validator, isValidator := f.CallableFunction.(expressions.LiteralArgumentValidator); isValidator {
+		return validator.ValidateLiteralArguments(literals, argumentTypes)
+	}
+	return nil
+}
+
+// OutputForLiteralArguments returns the output type of the registered function, using the literal arguments if it
+// implements expressions.LiteralArgumentTyper.
+func (f mockedFunction) OutputForLiteralArguments(
+	literals map[int]any,
+	argumentTypes []schema.Type,
+) (schema.Type, error) {
+	if typer, isTyper := f.CallableFunction.(expressions.LiteralArgumentTyper); isTyper {
+		return typer.OutputForLiteralArguments(literals, argumentTypes)
+	}
+	outputType, _, err := f.Output(argumentTypes)
+	return outputType, err
+}
@@ -0,0 +1,123 @@
+package functionstest_test
+
+import (
+	"errors"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/expressions/functions/functionstest"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+var testScope = schema.NewScopeSchema(
+	schema.NewObjectSchema(
+		"root",
+		map[string]*schema.PropertySchema{
+			"key": schema.NewPropertySchema(
+				schema.NewStringSchema(nil, nil, nil),
+				nil, true, nil, nil, nil, nil, nil,
+			),
+		},
+	),
+)
+
+func newMockRegistry(t *testing.T) *functionstest.MockRegistry {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.RegisterBuiltins(""))
+	assert.NoError(t, registry.Register("hash", functions.Hash()))
+	return functionstest.NewMockRegistry(registry)
+}
+
+func TestMockRegistry(t *testing.T) {
+	mocks := newMockRegistry(t)
+	uuid := mocks.Mock(t, "uuid").Returns("00000000-0000-4000-8000-000000000000")
+	md5 := mocks.Mock(t, "hash.md5").ReturnsFunc(func(arguments []any) (any, error) {
+		return "md5 of " + arguments[0].(string), nil
+	})
+
+	expr, err := expressions.New(`uuid() + " " + hash.md5($.key) + " " + hash.crc32("a")`)
+	assert.NoError(t, err)
+	// The mocks keep the types of the registered functions.
+	resultType, err := expr.Type(testScope, mocks.Functions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, resultType.TypeID(), schema.TypeIDString)
+	result, err := expr.Evaluate(map[string]any{"key": "b"}, mocks.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("00000000-0000-4000-8000-000000000000 md5 of b e8b7be43"))
+
+	uuid.AssertCalled(t, 1)
+	md5.AssertCalled(t, 1)
+	md5.AssertCalledWith(t, "b")
+	assert.Equals(t, md5.Calls(), [][]any{{"b"}})
+}
+
+func TestMockRegistryError(t *testing.T) {
+	mocks := newMockRegistry(t)
+	failure := errors.New("unavailable")
+	mocks.Mock(t, "uuid").ReturnsError(failure)
+
+	expr, err := expressions.New(`uuid()`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, mocks.CallableFunctions(), nil)
+	assert.Equals(t, errors.Is(err, failure), true)
+}
+
+func TestMockRegistryOptionsFunctions(t *testing.T) {
+	mocks := newMockRegistry(t)
+	md5 := mocks.Mock(t, "hash.md5").Returns("mocked")
+
+	// Mocks are not pure, so their calls are not folded when parsing.
+	expr, err := expressions.NewWithOptions(`hash.md5("a")`, expressions.Options{
+		Functions: mocks.CallableFunctions(),
+	})
+	assert.NoError(t, err)
+	md5.AssertCalled(t, 0)
+	result, err := expr.Evaluate(nil, mocks.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("mocked"))
+	md5.AssertCalledWith(t, "a")
+}
+
+func TestMockRegistryOverloaded(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	describeInt, err := schema.NewCallableFunction(
+		"describe",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(_ int64) string {
+			return "real"
+		},
+	)
+	assert.NoError(t, err)
+	describeString, err := schema.NewCallableFunction(
+		"describe",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(_ string) string {
+			return "real"
+		},
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterFunction("", describeInt))
+	assert.NoError(t, registry.RegisterFunction("", describeString))
+	mocks := functionstest.NewMockRegistry(registry)
+	describe := mocks.Mock(t, "describe").Returns("mocked")
+
+	for _, expression := range []string{`describe(1)`, `describe("a")`} {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		result, err := expr.Evaluate(nil, mocks.CallableFunctions(), nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any("mocked"))
+	}
+	assert.Equals(t, describe.Calls(), [][]any{{int64(1)}, {"a"}})
+
+	_, isOverloaded := mocks.CallableFunctions()["describe"].(*expressions.OverloadedFunction)
+	assert.Equals(t, isOverloaded, true)
+}
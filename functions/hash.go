@@ -0,0 +1,43 @@
+package functions
+
+import (
+	"crypto/md5" //nolint:gosec // MD5 is provided for checksums, not for security.
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Hash returns the built-in functions that hash strings: sha256, md5, and crc32. The hashes are returned as
+// lowercase hex strings. md5 and crc32 are not secure, and should only be used for checksums and cache keys.
+func Hash() map[string]schema.CallableFunction {
+	return toMap(
+		hashFunction("sha256", "Returns the SHA-256 hash of the string as a hex string.", sha256.New),
+		hashFunction("md5", "Returns the MD5 hash of the string as a hex string. Not suitable for security.", md5.New),
+		hashFunction(
+			"crc32",
+			"Returns the CRC-32 checksum of the string with the IEEE polynomial as a hex string.",
+			func() hash.Hash { return crc32.NewIEEE() },
+		),
+	)
+}
+
+func hashFunction(name string, description string, newHash func() hash.Hash) schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		name,
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		display(name, description),
+		func(value string) (string, error) {
+			hasher := newHash()
+			if _, err := hasher.Write([]byte(value)); err != nil {
+				return "", expressions.Errorf("%s: failed to hash the value (%w)", name, err)
+			}
+			return hex.EncodeToString(hasher.Sum(nil)), nil
+		},
+	))
+}
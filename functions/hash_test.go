@@ -0,0 +1,24 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestHash(t *testing.T) {
+	runFunctionTests(t, functions.Hash(), map[string]functionTestCase{
+		"sha256": {
+			`sha256("hello")`,
+			schema.TypeIDString,
+			"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+			false,
+			false,
+		},
+		"md5":        {`md5("hello")`, schema.TypeIDString, "5d41402abc4b2a76b9719d911017c592", false, false},
+		"crc32":      {`crc32("hello")`, schema.TypeIDString, "3610a686", false, false},
+		"crc32-data": {`crc32($.strings[0])`, schema.TypeIDString, "e8b7be43", false, false},
+		"not-string": {`sha256($.int)`, "", nil, true, false},
+	})
+}
@@ -0,0 +1,503 @@
+package functions
+
+import (
+	"reflect"
+	"strings"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// List returns the built-in functions that operate on lists: length, first, last, contains, indexOf, reverse, slice,
+// concat, groupBy, flatten, zip, and unique. The functions accept lists with any item type, and their output types
+// are derived from the types of the lists passed.
+func List() map[string]schema.CallableFunction {
+	return toMap(
+		lengthFunction(),
+		firstFunction(),
+		lastFunction(),
+		containsFunction(),
+		indexOfFunction(),
+		reverseFunction(),
+		sliceFunction(),
+		concatFunction(),
+		groupByFunction(),
+		flattenFunction(),
+		zipFunction(),
+		uniqueFunction(),
+	)
+}
+
+func lengthFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"length",
+		[]schema.Type{schema.NewAnySchema()},
+		display("length", "Returns the number of items in a list or map, or the number of characters in a string."),
+		func(value any) (any, error) {
+			if str, isString := value.(string); isString {
+				return int64(len([]rune(str))), nil
+			}
+			reflectedValue := reflect.ValueOf(value)
+			switch reflectedValue.Kind() {
+			case reflect.Slice, reflect.Array, reflect.Map:
+				return int64(reflectedValue.Len()), nil
+			default:
+				return nil, expressions.Errorf("length expects a list, map, or string, got %T", value)
+			}
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			switch inputTypes[0].TypeID() {
+			case schema.TypeIDList, schema.TypeIDMap, schema.TypeIDString, schema.TypeIDAny:
+				return schema.NewIntSchema(nil, nil, nil), nil
+			default:
+				return nil, expressions.Errorf("length expects a list, map, or string, got %s", inputTypes[0].TypeID())
+			}
+		},
+	))
+}
+
+// itemFunction creates a function that returns a single item of a non-empty list.
+func itemFunction(name string, description string, index func(length int) int) schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		name,
+		[]schema.Type{schema.NewAnySchema()},
+		display(name, description),
+		func(list any) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, err
+			}
+			if value.Len() == 0 {
+				return nil, expressions.Errorf("%s called on an empty list", name)
+			}
+			return value.Index(index(value.Len())).Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			return listItemType(inputTypes[0])
+		},
+	))
+}
+
+func firstFunction() schema.CallableFunction {
+	return itemFunction(
+		"first",
+		"Returns the first item of a list. Fails if the list is empty.",
+		func(int) int { return 0 },
+	)
+}
+
+func lastFunction() schema.CallableFunction {
+	return itemFunction(
+		"last",
+		"Returns the last item of a list. Fails if the list is empty.",
+		func(length int) int { return length - 1 },
+	)
+}
+
+// indexOf returns the index of the first item in the list equal to the item, or -1 if there is no such item.
+func indexOf(list any, item any) (int64, error) {
+	value, err := listValue(list)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < value.Len(); i++ {
+		if reflect.DeepEqual(value.Index(i).Interface(), item) {
+			return int64(i), nil
+		}
+	}
+	return -1, nil
+}
+
+// validateListItemTypes validates that the item can be compared with the items of the list.
+func validateListItemTypes(name string, inputTypes []schema.Type) error {
+	itemType, err := listItemType(inputTypes[0])
+	if err != nil {
+		return err
+	}
+	if !typesCompatible(itemType, inputTypes[1]) {
+		return expressions.Errorf("%s: cannot search for a %s in a list of %s items", name, inputTypes[1].TypeID(), itemType.TypeID())
+	}
+	return nil
+}
+
+func containsFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"contains",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display("contains", "Returns true if the list in the first argument contains the item in the second argument."),
+		func(list any, item any) (any, error) {
+			index, err := indexOf(list, item)
+			if err != nil {
+				return nil, err
+			}
+			return index >= 0, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if err := validateListItemTypes("contains", inputTypes); err != nil {
+				return nil, err
+			}
+			return schema.NewBoolSchema(), nil
+		},
+	))
+}
+
+func indexOfFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"indexOf",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display(
+			"indexOf",
+			"Returns the index of the first occurrence of the item in the second argument in the list in the "+
+				"first argument, or -1 if the list does not contain the item.",
+		),
+		func(list any, item any) (any, error) {
+			return indexOf(list, item)
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if err := validateListItemTypes("indexOf", inputTypes); err != nil {
+				return nil, err
+			}
+			return schema.NewIntSchema(nil, nil, nil), nil
+		},
+	))
+}
+
+// listTypeHandler returns the type of the list passed as the first argument, so the function returns a list of the
+// same type.
+func listTypeHandler(inputTypes []schema.Type) (schema.Type, error) {
+	itemType, err := listItemType(inputTypes[0])
+	if err != nil {
+		return nil, err
+	}
+	return schema.NewListSchema(itemType, nil, nil), nil
+}
+
+func reverseFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"reverse",
+		[]schema.Type{schema.NewAnySchema()},
+		display("reverse", "Returns a copy of the list with the items in reverse order."),
+		func(list any) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, err
+			}
+			length := value.Len()
+			result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), length, length)
+			for i := 0; i < length; i++ {
+				result.Index(i).Set(value.Index(length - 1 - i))
+			}
+			return result.Interface(), nil
+		},
+		listTypeHandler,
+	))
+}
+
+func sliceFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"slice",
+		[]schema.Type{schema.NewAnySchema(), schema.NewIntSchema(nil, nil, nil), schema.NewIntSchema(nil, nil, nil)},
+		display(
+			"slice",
+			"Returns the items of the list from the start index (inclusive) in the second argument to the end "+
+				"index (exclusive) in the third argument. Negative indexes count from the end of the list.",
+		),
+		func(list any, start int64, end int64) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, err
+			}
+			length := int64(value.Len())
+			if start < 0 {
+				start += length
+			}
+			if end < 0 {
+				end += length
+			}
+			if start < 0 || end > length || start > end {
+				return nil, expressions.Errorf("slice indexes out of range for a list of length %d", length)
+			}
+			result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), int(end-start), int(end-start))
+			reflect.Copy(result, value.Slice(int(start), int(end)))
+			return result.Interface(), nil
+		},
+		listTypeHandler,
+	))
+}
+
+func concatFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"concat",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display("concat", "Returns a list with the items of the first list followed by the items of the second list."),
+		func(a any, b any) (any, error) {
+			aValue, err := listValue(a)
+			if err != nil {
+				return nil, err
+			}
+			bValue, err := listValue(b)
+			if err != nil {
+				return nil, err
+			}
+			itemType := aValue.Type().Elem()
+			if bValue.Type().Elem() != itemType {
+				// The lists hold different Go types, so fall back to a list of any values.
+				itemType = reflect.TypeOf((*any)(nil)).Elem()
+			}
+			result := reflect.MakeSlice(reflect.SliceOf(itemType), 0, aValue.Len()+bValue.Len())
+			for _, value := range []reflect.Value{aValue, bValue} {
+				for i := 0; i < value.Len(); i++ {
+					result = reflect.Append(result, value.Index(i))
+				}
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			aItemType, err := listItemType(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			bItemType, err := listItemType(inputTypes[1])
+			if err != nil {
+				return nil, err
+			}
+			if !typesCompatible(aItemType, bItemType) {
+				return nil, expressions.Errorf(
+					"concat: cannot concatenate a list of %s items with a list of %s items",
+					aItemType.TypeID(), bItemType.TypeID(),
+				)
+			}
+			if bItemType.TypeID() == schema.TypeIDAny {
+				// The items of the second list may be of any type, so the items of the result are too.
+				return schema.NewListSchema(bItemType, nil, nil), nil
+			}
+			return schema.NewListSchema(aItemType, nil, nil), nil
+		},
+	))
+}
+
+// groupByKeyIndex is the index of the key argument of groupBy.
+const groupByKeyIndex = 1
+
+// groupByKeyFunction is the groupBy function, which derives the key type of the result from the key if it is a
+// literal.
+type groupByKeyFunction struct {
+	schema.CallableFunction
+}
+
+// OutputForLiteralArguments returns a map from the type of the field referenced by the key to lists of the items. If
+// the key is not a literal, the field is not known, so the result is of the any type.
+func (f groupByKeyFunction) OutputForLiteralArguments(
+	literals map[int]any,
+	argumentTypes []schema.Type,
+) (schema.Type, error) {
+	itemType, err := listItemType(argumentTypes[0])
+	if err != nil {
+		return nil, err
+	}
+	key, isString := literals[groupByKeyIndex].(string)
+	if !isString {
+		return schema.NewAnySchema(), nil
+	}
+	keyType, err := fieldType(itemType, strings.Split(key, "."))
+	if err != nil {
+		return nil, expressions.Errorf("groupBy: invalid key %q (%w)", key, err)
+	}
+	switch keyType.TypeID() {
+	case schema.TypeIDString, schema.TypeIDStringEnum, schema.TypeIDInt, schema.TypeIDIntEnum:
+		return schema.NewMapSchema(keyType, schema.NewListSchema(itemType, nil, nil), nil, nil), nil
+	case schema.TypeIDAny:
+		return schema.NewAnySchema(), nil
+	default:
+		return nil, expressions.Errorf("groupBy can only group by integers or strings, got %s", keyType.TypeID())
+	}
+}
+
+func groupByFunction() schema.CallableFunction {
+	return groupByKeyFunction{mustFunction(schema.NewDynamicCallableFunction(
+		"groupBy",
+		[]schema.Type{schema.NewAnySchema(), schema.NewStringSchema(nil, nil, nil)},
+		display(
+			"groupBy",
+			"Returns a map from the values of the key in the second argument to lists of the items of the list in "+
+				"the first argument with that value, in the order of the list. The key is a dot-separated path to a "+
+				"field of the items, such as \"metadata.name\".",
+		),
+		func(list any, key string) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, expressions.Errorf("groupBy: %w", err)
+			}
+			path := strings.Split(key, ".")
+			keys := make([]reflect.Value, value.Len())
+			var keyType reflect.Type
+			for i := 0; i < value.Len(); i++ {
+				keyValue, err := fieldValue(value.Index(i), path)
+				if err != nil {
+					return nil, expressions.Errorf("groupBy: item %d: %w", i, err)
+				}
+				keyValue = unwrapInterface(keyValue)
+				if !keyValue.CanInt() && keyValue.Kind() != reflect.String {
+					return nil, expressions.Errorf("groupBy: item %d: can only group by integers or strings, got %s", i, keyValue.Kind())
+				}
+				switch {
+				case keyType == nil:
+					keyType = keyValue.Type()
+				case keyType != keyValue.Type():
+					// The keys have different Go types, so fall back to a map with keys of any type.
+					keyType = reflect.TypeOf((*any)(nil)).Elem()
+				}
+				keys[i] = keyValue
+			}
+			if keyType == nil {
+				keyType = reflect.TypeOf("")
+			}
+			groupType := reflect.SliceOf(value.Type().Elem())
+			result := reflect.MakeMap(reflect.MapOf(keyType, groupType))
+			for i, keyValue := range keys {
+				mapKeyValue := reflect.New(keyType).Elem()
+				mapKeyValue.Set(keyValue)
+				group := result.MapIndex(mapKeyValue)
+				if !group.IsValid() {
+					group = reflect.MakeSlice(groupType, 0, 1)
+				}
+				result.SetMapIndex(mapKeyValue, reflect.Append(group, value.Index(i)))
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			if _, err := listItemType(inputTypes[0]); err != nil {
+				return nil, err
+			}
+			return schema.NewAnySchema(), nil
+		},
+	))}
+}
+
+func flattenFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"flatten",
+		[]schema.Type{schema.NewAnySchema()},
+		display(
+			"flatten",
+			"Returns a list with the items of all lists in the list of lists, in order. Only one level is flattened.",
+		),
+		func(list any) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, expressions.Errorf("flatten: %w", err)
+			}
+			var itemType reflect.Type
+			sublists := make([]reflect.Value, value.Len())
+			for i := 0; i < value.Len(); i++ {
+				sublist, err := listValue(unwrapInterface(value.Index(i)).Interface())
+				if err != nil {
+					return nil, expressions.Errorf("flatten: item %d: %w", i, err)
+				}
+				switch {
+				case itemType == nil:
+					itemType = sublist.Type().Elem()
+				case itemType != sublist.Type().Elem():
+					// The lists hold different Go types, so fall back to a list of any values.
+					itemType = reflect.TypeOf((*any)(nil)).Elem()
+				}
+				sublists[i] = sublist
+			}
+			if itemType == nil {
+				itemType = reflect.TypeOf((*any)(nil)).Elem()
+			}
+			result := reflect.MakeSlice(reflect.SliceOf(itemType), 0, 0)
+			for _, sublist := range sublists {
+				for i := 0; i < sublist.Len(); i++ {
+					result = reflect.Append(result, sublist.Index(i))
+				}
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			sublistType, err := listItemType(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			itemType, err := listItemType(sublistType)
+			if err != nil {
+				return nil, expressions.Errorf("flatten expects a list of lists (%w)", err)
+			}
+			return schema.NewListSchema(itemType, nil, nil), nil
+		},
+	))
+}
+
+func zipFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"zip",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display(
+			"zip",
+			"Returns a list of pairs, where each pair is a list of the items at the same index in both lists. The "+
+				"result has the length of the shorter list.",
+		),
+		func(a any, b any) (any, error) {
+			aValue, err := listValue(a)
+			if err != nil {
+				return nil, expressions.Errorf("zip: %w", err)
+			}
+			bValue, err := listValue(b)
+			if err != nil {
+				return nil, expressions.Errorf("zip: %w", err)
+			}
+			length := min(aValue.Len(), bValue.Len())
+			result := make([]any, length)
+			for i := 0; i < length; i++ {
+				result[i] = []any{aValue.Index(i).Interface(), bValue.Index(i).Interface()}
+			}
+			return result, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			aItemType, err := listItemType(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			bItemType, err := listItemType(inputTypes[1])
+			if err != nil {
+				return nil, err
+			}
+			pairItemType := aItemType
+			if aItemType.TypeID() != bItemType.TypeID() {
+				// The items of a pair have different types, which a list cannot express.
+				pairItemType = schema.NewAnySchema()
+			}
+			return schema.NewListSchema(schema.NewListSchema(pairItemType, nil, nil), nil, nil), nil
+		},
+	))
+}
+
+func uniqueFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"unique",
+		[]schema.Type{schema.NewAnySchema()},
+		display("unique", "Returns a copy of the list without duplicate items, keeping the first occurrence of each item."),
+		func(list any) (any, error) {
+			value, err := listValue(list)
+			if err != nil {
+				return nil, expressions.Errorf("unique: %w", err)
+			}
+			result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), 0, value.Len())
+			for i := 0; i < value.Len(); i++ {
+				item := value.Index(i)
+				duplicate := false
+				for j := 0; j < result.Len(); j++ {
+					if reflect.DeepEqual(result.Index(j).Interface(), item.Interface()) {
+						duplicate = true
+						break
+					}
+				}
+				if !duplicate {
+					result = reflect.Append(result, item)
+				}
+			}
+			return result.Interface(), nil
+		},
+		listTypeHandler,
+	))
+}
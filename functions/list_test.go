@@ -0,0 +1,94 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestList(t *testing.T) {
+	runFunctionTests(t, functions.List(), map[string]functionTestCase{
+		"length-list":       {`length($.ints)`, schema.TypeIDInt, int64(3), false, false},
+		"length-map":        {`length($.map)`, schema.TypeIDInt, int64(2), false, false},
+		"length-string":     {`length($.str)`, schema.TypeIDInt, int64(5), false, false},
+		"length-int":        {`length($.int)`, "", nil, true, false},
+		"first":             {`first($.ints)`, schema.TypeIDInt, int64(1), false, false},
+		"first-empty":       {`first($.empty)`, schema.TypeIDInt, nil, false, true},
+		"first-untyped":     {`first($.untyped)`, schema.TypeIDAny, "x", false, false},
+		"first-not-list":    {`first($.str)`, "", nil, true, false},
+		"last":              {`last($.strings)`, schema.TypeIDString, "b", false, false},
+		"contains":          {`contains($.ints, 2)`, schema.TypeIDBool, true, false, false},
+		"contains-missing":  {`contains($.strings, "c")`, schema.TypeIDBool, false, false, false},
+		"contains-mismatch": {`contains($.ints, "a")`, "", nil, true, false},
+		"index-of":          {`indexOf($.strings, "b")`, schema.TypeIDInt, int64(1), false, false},
+		"index-of-missing":  {`indexOf($.ints, 5)`, schema.TypeIDInt, int64(-1), false, false},
+		"reverse":           {`reverse($.ints)`, schema.TypeIDList, []int64{3, 2, 1}, false, false},
+		"reverse-index":     {`reverse($.ints)[0]`, schema.TypeIDInt, int64(3), false, false},
+		"slice":             {`slice($.ints, 1, 3)`, schema.TypeIDList, []int64{2, 3}, false, false},
+		"slice-negative":    {`slice($.ints, 0, -1)`, schema.TypeIDList, []int64{1, 2}, false, false},
+		"slice-range":       {`slice($.ints, 2, 5)`, schema.TypeIDList, nil, false, true},
+		"concat":            {`concat($.ints, reverse($.ints))`, schema.TypeIDList, []int64{1, 2, 3, 3, 2, 1}, false, false},
+		"concat-mixed":      {`concat($.strings, $.untyped)`, schema.TypeIDList, []any{"a", "b", "x", int64(1)}, false, false},
+		"concat-mismatch":   {`concat($.ints, $.strings)`, "", nil, true, false},
+	})
+}
+
+func TestGroupBy(t *testing.T) {
+	objects := testData["objects"].([]any)
+	runFunctionTests(t, functions.List(), map[string]functionTestCase{
+		"group-by": {
+			`groupBy($.objects, "size")`,
+			"",
+			nil,
+			true,
+			false,
+		},
+		"group-by-name": {
+			`groupBy($.objects, "name")`,
+			schema.TypeIDMap,
+			map[string][]any{"a": {objects[1]}, "b": {objects[0]}, "c": {objects[2]}},
+			false,
+			false,
+		},
+		"group-by-nested": {
+			`groupBy($.objects, "meta.rank")[3]`,
+			schema.TypeIDList,
+			[]any{objects[1]},
+			false,
+			false,
+		},
+		"group-by-dynamic-key": {`groupBy($.objects, $.strings[1])`, schema.TypeIDAny, nil, false, true},
+		"group-by-missing":     {`groupBy($.objects, "missing")`, "", nil, true, false},
+		"group-by-not-list":    {`groupBy($.map, "a")`, "", nil, true, false},
+		"group-by-empty":       {`groupBy($.empty, "a")`, "", nil, true, false},
+	})
+}
+
+func TestListUtilities(t *testing.T) {
+	runFunctionTests(t, functions.List(), map[string]functionTestCase{
+		"flatten": {
+			`flatten(zip($.ints, $.ints))`,
+			schema.TypeIDList,
+			[]any{int64(1), int64(1), int64(2), int64(2), int64(3), int64(3)},
+			false,
+			false,
+		},
+		"flatten-item-type":  {`flatten(zip($.ints, $.ints))[0]`, schema.TypeIDInt, int64(1), false, false},
+		"flatten-not-nested": {`flatten($.ints)`, "", nil, true, false},
+		"flatten-untyped":    {`flatten($.untyped)`, schema.TypeIDList, nil, false, true},
+		"zip": {
+			`zip($.strings, $.ints)`,
+			schema.TypeIDList,
+			[]any{[]any{"a", int64(1)}, []any{"b", int64(2)}},
+			false,
+			false,
+		},
+		"zip-pair-type":   {`zip($.ints, $.ints)[0][1]`, schema.TypeIDInt, int64(1), false, false},
+		"zip-mixed-type":  {`zip($.strings, $.ints)[0][1]`, schema.TypeIDAny, int64(1), false, false},
+		"zip-not-list":    {`zip($.ints, $.int)`, "", nil, true, false},
+		"unique":          {`unique(concat($.ints, reverse($.ints)))`, schema.TypeIDList, []int64{1, 2, 3}, false, false},
+		"unique-item":     {`unique($.strings)[1]`, schema.TypeIDString, "b", false, false},
+		"unique-not-list": {`unique($.str)`, "", nil, true, false},
+	})
+}
@@ -0,0 +1,283 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Map returns the built-in functions that operate on maps: keys, values, hasKey, merge, and pick. The functions
+// accept maps with any key and value types, and their output types are derived from the types of the maps passed.
+// The keys and values are returned in the order of the sorted keys, so the results are deterministic.
+func Map() map[string]schema.CallableFunction {
+	return toMap(
+		keysFunction(),
+		valuesFunction(),
+		hasKeyFunction(),
+		mergeFunction(),
+		pickFunction(),
+	)
+}
+
+// mapValue returns the reflected value of a map, or an error if the value is not a map.
+func mapValue(m any) (reflect.Value, error) {
+	value := reflect.ValueOf(m)
+	if value.Kind() != reflect.Map {
+		return reflect.Value{}, expressions.Errorf("expected a map, got %T", m)
+	}
+	return value, nil
+}
+
+// mapKeyValueTypes returns the key and value types of a map type. For the any type, the keys and values are also of
+// the any type.
+func mapKeyValueTypes(mapType schema.Type) (schema.Type, schema.Type, error) {
+	switch mapType.TypeID() {
+	case schema.TypeIDMap:
+		untypedMap := mapType.(schema.UntypedMap)
+		return untypedMap.Keys(), untypedMap.Values(), nil
+	case schema.TypeIDAny:
+		return schema.NewAnySchema(), schema.NewAnySchema(), nil
+	default:
+		return nil, nil, expressions.Errorf("expected a map, got %s", mapType.TypeID())
+	}
+}
+
+// sortedKeys returns the keys of the map, sorted by their values.
+func sortedKeys(value reflect.Value) []reflect.Value {
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return lessValue(keys[i], keys[j])
+	})
+	return keys
+}
+
+// lessValue compares integers, floats, and strings by their values, and other values by their string form.
+func lessValue(a reflect.Value, b reflect.Value) bool {
+	a, b = unwrapInterface(a), unwrapInterface(b)
+	switch {
+	case a.CanInt() && b.CanInt():
+		return a.Int() < b.Int()
+	case a.CanFloat() && b.CanFloat():
+		return a.Float() < b.Float()
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return a.String() < b.String()
+	default:
+		return fmt.Sprintf("%v", a.Interface()) < fmt.Sprintf("%v", b.Interface())
+	}
+}
+
+func unwrapInterface(value reflect.Value) reflect.Value {
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		return value.Elem()
+	}
+	return value
+}
+
+func keysFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"keys",
+		[]schema.Type{schema.NewAnySchema()},
+		display("keys", "Returns the sorted keys of a map as a list."),
+		func(m any) (any, error) {
+			value, err := mapValue(m)
+			if err != nil {
+				return nil, err
+			}
+			keys := sortedKeys(value)
+			result := reflect.MakeSlice(reflect.SliceOf(value.Type().Key()), len(keys), len(keys))
+			for i, key := range keys {
+				result.Index(i).Set(key)
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			keyType, _, err := mapKeyValueTypes(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			return schema.NewListSchema(keyType, nil, nil), nil
+		},
+	))
+}
+
+func valuesFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"values",
+		[]schema.Type{schema.NewAnySchema()},
+		display("values", "Returns the values of a map as a list, in the order of the sorted keys."),
+		func(m any) (any, error) {
+			value, err := mapValue(m)
+			if err != nil {
+				return nil, err
+			}
+			keys := sortedKeys(value)
+			result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), len(keys), len(keys))
+			for i, key := range keys {
+				result.Index(i).Set(value.MapIndex(key))
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			_, valueType, err := mapKeyValueTypes(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			return schema.NewListSchema(valueType, nil, nil), nil
+		},
+	))
+}
+
+// mapKey converts the key to the key type of the map. Returns false if it cannot be converted.
+func mapKey(value reflect.Value, key any) (reflect.Value, bool) {
+	keyValue := reflect.ValueOf(key)
+	if !keyValue.IsValid() {
+		return reflect.Value{}, false
+	}
+	keyType := value.Type().Key()
+	switch {
+	case keyValue.Type().AssignableTo(keyType):
+		return keyValue, true
+	case keyValue.Type().ConvertibleTo(keyType) && keyValue.Kind() == keyType.Kind():
+		return keyValue.Convert(keyType), true
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+func hasKeyFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"hasKey",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display("hasKey", "Returns true if the map in the first argument has the key in the second argument."),
+		func(m any, key any) (any, error) {
+			value, err := mapValue(m)
+			if err != nil {
+				return nil, err
+			}
+			keyValue, ok := mapKey(value, key)
+			if !ok {
+				return false, nil
+			}
+			return value.MapIndex(keyValue).IsValid(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			keyType, _, err := mapKeyValueTypes(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			if !typesCompatible(keyType, inputTypes[1]) {
+				return nil, expressions.Errorf(
+					"hasKey: cannot look up a %s key in a map with %s keys", inputTypes[1].TypeID(), keyType.TypeID(),
+				)
+			}
+			return schema.NewBoolSchema(), nil
+		},
+	))
+}
+
+func mergeFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"merge",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display(
+			"merge",
+			"Returns a map with the items of both maps. If both maps have the same key, the value of the second map "+
+				"is used.",
+		),
+		func(a any, b any) (any, error) {
+			aValue, err := mapValue(a)
+			if err != nil {
+				return nil, err
+			}
+			bValue, err := mapValue(b)
+			if err != nil {
+				return nil, err
+			}
+			mapType := aValue.Type()
+			if bValue.Type() != mapType {
+				// The maps hold different Go types, so fall back to a map of any values.
+				mapType = reflect.TypeOf(map[any]any{})
+			}
+			result := reflect.MakeMapWithSize(mapType, aValue.Len()+bValue.Len())
+			for _, value := range []reflect.Value{aValue, bValue} {
+				iterator := value.MapRange()
+				for iterator.Next() {
+					result.SetMapIndex(iterator.Key(), iterator.Value())
+				}
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			aKeyType, aValueType, err := mapKeyValueTypes(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			bKeyType, bValueType, err := mapKeyValueTypes(inputTypes[1])
+			if err != nil {
+				return nil, err
+			}
+			if !typesCompatible(aKeyType, bKeyType) || !typesCompatible(aValueType, bValueType) {
+				return nil, expressions.Errorf(
+					"merge: cannot merge a map of %s to %s with a map of %s to %s",
+					aKeyType.TypeID(), aValueType.TypeID(), bKeyType.TypeID(), bValueType.TypeID(),
+				)
+			}
+			if inputTypes[1].TypeID() == schema.TypeIDAny {
+				return inputTypes[1], nil
+			}
+			return inputTypes[0], nil
+		},
+	))
+}
+
+func pickFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"pick",
+		[]schema.Type{schema.NewAnySchema(), schema.NewAnySchema()},
+		display(
+			"pick",
+			"Returns a map with only the items of the map in the first argument whose keys are in the list in the "+
+				"second argument. Keys that are not in the map are ignored.",
+		),
+		func(m any, keys any) (any, error) {
+			value, err := mapValue(m)
+			if err != nil {
+				return nil, err
+			}
+			keysValue, err := listValue(keys)
+			if err != nil {
+				return nil, err
+			}
+			result := reflect.MakeMap(value.Type())
+			for i := 0; i < keysValue.Len(); i++ {
+				keyValue, ok := mapKey(value, keysValue.Index(i).Interface())
+				if !ok {
+					continue
+				}
+				if item := value.MapIndex(keyValue); item.IsValid() {
+					result.SetMapIndex(keyValue, item)
+				}
+			}
+			return result.Interface(), nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			keyType, _, err := mapKeyValueTypes(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			pickedKeyType, err := listItemType(inputTypes[1])
+			if err != nil {
+				return nil, err
+			}
+			if !typesCompatible(keyType, pickedKeyType) {
+				return nil, expressions.Errorf(
+					"pick: cannot pick %s keys from a map with %s keys", pickedKeyType.TypeID(), keyType.TypeID(),
+				)
+			}
+			return inputTypes[0], nil
+		},
+	))
+}
@@ -0,0 +1,31 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestMap(t *testing.T) {
+	runFunctionTests(t, functions.Map(), map[string]functionTestCase{
+		"keys":             {`keys($.map)`, schema.TypeIDList, []string{"a", "b"}, false, false},
+		"keys-item":        {`keys($.map)[1]`, schema.TypeIDString, "b", false, false},
+		"keys-not-map":     {`keys($.ints)`, "", nil, true, false},
+		"values":           {`values($.map)`, schema.TypeIDList, []int64{1, 2}, false, false},
+		"has-key":          {`hasKey($.map, "a")`, schema.TypeIDBool, true, false, false},
+		"has-key-missing":  {`hasKey($.map, "z")`, schema.TypeIDBool, false, false, false},
+		"has-key-mismatch": {`hasKey($.map, 1)`, "", nil, true, false},
+		"merge": {
+			`merge($.map, $.otherMap)`,
+			schema.TypeIDMap,
+			map[string]int64{"a": 1, "b": 3, "c": 4},
+			false,
+			false,
+		},
+		"merge-mismatch": {`merge($.map, $.ints)`, "", nil, true, false},
+		"pick":           {`pick($.map, $.strings)`, schema.TypeIDMap, map[string]int64{"a": 1, "b": 2}, false, false},
+		"pick-missing":   {`pick($.otherMap, $.strings)`, schema.TypeIDMap, map[string]int64{"b": 3}, false, false},
+		"pick-mismatch":  {`pick($.map, $.ints)`, "", nil, true, false},
+	})
+}
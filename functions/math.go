@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"math"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Math returns the built-in functions that inspect floats: isNaN and isInf. Float operations only produce NaN and
+// infinite values if expressions.Options.AllowNonFiniteFloats is set, but such values can also come from the data.
+func Math() map[string]schema.CallableFunction {
+	return toMap(
+		floatCheckFunction("isNaN", "Returns true if the float is NaN (not a number).", math.IsNaN),
+		floatCheckFunction("isInf", "Returns true if the float is positive or negative infinity.", func(value float64) bool {
+			return math.IsInf(value, 0)
+		}),
+	)
+}
+
+func floatCheckFunction(name string, description string, check func(value float64) bool) schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		name,
+		[]schema.Type{schema.NewFloatSchema(nil, nil, nil)},
+		schema.NewBoolSchema(),
+		false,
+		display(name, description),
+		check,
+	))
+}
@@ -0,0 +1,39 @@
+package functions_test
+
+import (
+	"math"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestMath(t *testing.T) {
+	runFunctionTests(t, functions.Math(), map[string]functionTestCase{
+		"is-nan":           {`isNaN($.float)`, schema.TypeIDBool, false, false, false},
+		"is-inf":           {`isInf($.float)`, schema.TypeIDBool, false, false, false},
+		"is-nan-not-float": {`isNaN($.str)`, "", nil, true, false},
+	})
+}
+
+func TestMath_NonFiniteValues(t *testing.T) {
+	options := expressions.Options{AllowNonFiniteFloats: true}
+	testCases := map[string]bool{
+		`isNaN(0.0 / 0.0)`:             true,
+		`isInf(1.0 / 0.0)`:             true,
+		`isInf(-1.0 / 0.0)`:            true,
+		`isNaN(1.0 / 0.0)`:             false,
+		`isInf($.nan) || isNaN($.inf)`: false,
+		`isNaN($.nan) && isInf($.inf)`: true,
+	}
+	data := map[string]any{"nan": math.NaN(), "inf": math.Inf(1)}
+	for expression, expected := range testCases {
+		expr, err := expressions.NewWithOptions(expression, options)
+		assert.NoError(t, err)
+		result, err := expr.Evaluate(data, functions.Math(), nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any(expected))
+	}
+}
@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"regexp"
+	"strings"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// PluginFunctionCaller calls a function of a plugin by its ID, such as over the connection to the plugin.
+type PluginFunctionCaller func(functionID string, arguments []any) (any, error)
+
+var invalidNamespaceCharacters = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// PluginNamespace returns the namespace the functions of the plugin are registered in by RegisterPlugin. It is the
+// plugin name with the characters that are not allowed in identifiers replaced by underscores, so the functions of
+// the plugin "example-plugin" are called as example_plugin.name().
+func PluginNamespace(pluginName string) string {
+	namespace := invalidNamespaceCharacters.ReplaceAllString(pluginName, "_")
+	if namespace == "" || (namespace[0] >= '0' && namespace[0] <= '9') {
+		namespace = "_" + namespace
+	}
+	return namespace
+}
+
+// RegisterPlugin registers the functions defined in the schema of a plugin in the namespace of the plugin, see
+// PluginNamespace, and returns the namespace. The functions are type checked with their definitions, and their calls
+// are forwarded to the caller when expressions are evaluated.
+//
+// Registering fails without registering any of the functions if the namespace is already in use, such as when two
+// plugins have names that result in the same namespace, or if the plugin defines a function more than once.
+func (r *FunctionRegistry) RegisterPlugin(
+	pluginName string,
+	definitions []schema.Function,
+	call PluginFunctionCaller,
+) (string, error) {
+	namespace := PluginNamespace(pluginName)
+	for name := range r.functions {
+		if strings.HasPrefix(name, namespace+namespaceSeparator) {
+			return "", expressions.Errorf(
+				"cannot register the functions of the plugin %q, the namespace %q is already in use",
+				pluginName, namespace)
+		}
+	}
+	pluginFunctions, err := NewRemoteFunctions(pluginFunctionClient{definitions: definitions, call: call})
+	if err != nil {
+		return "", expressions.Errorf("cannot register the functions of the plugin %q (%w)", pluginName, err)
+	}
+	if err := r.Register(namespace, pluginFunctions); err != nil {
+		return "", expressions.Errorf("cannot register the functions of the plugin %q (%w)", pluginName, err)
+	}
+	return namespace, nil
+}
+
+// pluginFunctionClient adapts the function definitions of a plugin schema to a RemoteFunctionClient.
+type pluginFunctionClient struct {
+	definitions []schema.Function
+	call        PluginFunctionCaller
+}
+
+func (c pluginFunctionClient) Functions() ([]RemoteFunctionDefinition, error) {
+	result := make([]RemoteFunctionDefinition, len(c.definitions))
+	for i, definition := range c.definitions {
+		output, _, err := definition.Output(definition.Parameters())
+		if err != nil {
+			return nil, expressions.Errorf("cannot get the output type of the function %q (%w)", definition.ID(), err)
+		}
+		var description string
+		if definition.Display() != nil && definition.Display().Description() != nil {
+			description = *definition.Display().Description()
+		}
+		result[i] = RemoteFunctionDefinition{
+			Name:        definition.ID(),
+			Description: description,
+			Parameters:  definition.Parameters(),
+			Output:      output,
+		}
+	}
+	return result, nil
+}
+
+func (c pluginFunctionClient) Call(name string, arguments []any) (any, error) {
+	return c.call(name, arguments)
+}
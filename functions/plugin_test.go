@@ -0,0 +1,61 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func newPluginDefinitions(t *testing.T) []schema.Function {
+	description := "Adds one to the number."
+	increment, err := schema.NewCallableFunction(
+		"increment",
+		[]schema.Type{schema.NewIntSchema(nil, nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		schema.NewDisplayValue(nil, &description, nil),
+		func(a int64) int64 { return a + 1 },
+	)
+	assert.NoError(t, err)
+	return []schema.Function{increment}
+}
+
+func TestRegisterPlugin(t *testing.T) {
+	var calls []string
+	caller := func(functionID string, arguments []any) (any, error) {
+		calls = append(calls, functionID)
+		return arguments[0].(int64) + 1, nil
+	}
+	registry := functions.NewFunctionRegistry()
+	namespace, err := registry.RegisterPlugin("example-plugin", newPluginDefinitions(t), caller)
+	assert.NoError(t, err)
+	assert.Equals(t, namespace, "example_plugin")
+	assert.Equals(t, registry.Names(), []string{"example_plugin.increment"})
+	function, _ := registry.Lookup("example_plugin.increment")
+	assert.Equals(t, *function.Display().Description(), "Adds one to the number.")
+
+	expr, err := expressions.New(`example_plugin.increment($.int)`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(6)))
+	assert.Equals(t, calls, []string{"increment"})
+
+	// A plugin whose namespace is already in use is rejected.
+	_, err = registry.RegisterPlugin("example_plugin", newPluginDefinitions(t), caller)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already in use")
+	_, err = registry.RegisterPlugin("other", append(newPluginDefinitions(t), newPluginDefinitions(t)...), caller)
+	assert.Error(t, err)
+	assert.Equals(t, len(registry.Names()), 1)
+}
+
+func TestPluginNamespace(t *testing.T) {
+	assert.Equals(t, functions.PluginNamespace("example"), "example")
+	assert.Equals(t, functions.PluginNamespace("arcaflow-plugin.v2"), "arcaflow_plugin_v2")
+	assert.Equals(t, functions.PluginNamespace("3d"), "_3d")
+	assert.Equals(t, functions.PluginNamespace(""), "_")
+}
@@ -0,0 +1,87 @@
+package functions
+
+import (
+	cryptorand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Random returns the built-in functions that generate random values: uuid and random. The functions are impure,
+// as they return a different result on every call, so their calls are never evaluated ahead of time or cached.
+func Random() map[string]schema.CallableFunction {
+	return toMap(
+		uuidFunction(),
+		randomFunction(),
+	)
+}
+
+// impureFunction is a function that may return a different result for the same arguments.
+type impureFunction struct {
+	schema.CallableFunction
+}
+
+// Pure returns false, so the calls of the function are never evaluated ahead of time or cached.
+func (f impureFunction) Pure() bool {
+	return false
+}
+
+func uuidFunction() schema.CallableFunction {
+	return impureFunction{mustFunction(schema.NewCallableFunction(
+		"uuid",
+		[]schema.Type{},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		display("uuid", "Returns a random version 4 UUID."),
+		func() (string, error) {
+			var uuid [16]byte
+			if _, err := cryptorand.Read(uuid[:]); err != nil {
+				return "", expressions.Errorf("uuid: failed to read random data (%w)", err)
+			}
+			// Set the version to 4 and the variant to RFC 4122.
+			uuid[6] = (uuid[6] & 0x0f) | 0x40
+			uuid[8] = (uuid[8] & 0x3f) | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:16]), nil
+		},
+	))}
+}
+
+func randomFunction() schema.CallableFunction {
+	return impureFunction{mustFunction(schema.NewCallableFunction(
+		"random",
+		[]schema.Type{
+			schema.NewIntSchema(nil, nil, nil),
+			schema.NewIntSchema(nil, nil, nil),
+			schema.NewIntSchema(nil, nil, nil),
+		},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		display(
+			"random",
+			"Returns a random integer between the minimum in the first argument and the maximum in the second "+
+				"argument, inclusive. If the seed in the third argument is not 0, the same seed always returns the "+
+				"same number, so workflows can be re-run deterministically. Not suitable for security.",
+		),
+		func(minValue int64, maxValue int64, seed int64) (int64, error) {
+			if minValue > maxValue {
+				return 0, expressions.Errorf("random: the minimum %d is larger than the maximum %d", minValue, maxValue)
+			}
+			if seed == 0 {
+				var seedBytes [8]byte
+				if _, err := cryptorand.Read(seedBytes[:]); err != nil {
+					return 0, expressions.Errorf("random: failed to read random data (%w)", err)
+				}
+				seed = int64(binary.LittleEndian.Uint64(seedBytes[:])) //nolint:gosec // Any bit pattern is a valid seed.
+			}
+			generator := rand.New(rand.NewSource(seed)) //nolint:gosec // The numbers are not used for security.
+			span := uint64(maxValue - minValue)
+			if span == ^uint64(0) {
+				return int64(generator.Uint64()), nil //nolint:gosec // The full int64 range is requested.
+			}
+			return minValue + int64(generator.Uint64()%(span+1)), nil //nolint:gosec // The offset is within the span.
+		},
+	))}
+}
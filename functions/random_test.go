@@ -0,0 +1,68 @@
+package functions_test
+
+import (
+	"regexp"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func evaluateRandom(t *testing.T, expression string) any {
+	expr, err := expressions.New(expression)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(testData, functions.Random(), nil)
+	assert.NoError(t, err)
+	return result
+}
+
+func TestUUID(t *testing.T) {
+	runFunctionTests(t, functions.Random(), map[string]functionTestCase{
+		"uuid-args": {`uuid($.str)`, "", nil, true, false},
+	})
+	first := evaluateRandom(t, `uuid()`).(string)
+	second := evaluateRandom(t, `uuid()`).(string)
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	assert.Equals(t, uuidPattern.MatchString(first), true)
+	assert.Equals(t, first != second, true)
+}
+
+func TestRandom(t *testing.T) {
+	runFunctionTests(t, functions.Random(), map[string]functionTestCase{
+		"random-single":  {`random(5, 5, 0)`, schema.TypeIDInt, int64(5), false, false},
+		"random-invalid": {`random(5, 1, 0)`, schema.TypeIDInt, nil, false, true},
+		"random-float":   {`random(1.5, 2, 0)`, "", nil, true, false},
+	})
+	for i := 0; i < 20; i++ {
+		result := evaluateRandom(t, `random(-2, 2, 0)`).(int64)
+		assert.Equals(t, result >= -2 && result <= 2, true)
+	}
+	assert.Equals(t, evaluateRandom(t, `random(1, 1000000, 42)`), evaluateRandom(t, `random(1, 1000000, 42)`))
+}
+
+func TestRandomImpure(t *testing.T) {
+	for _, function := range functions.Random() {
+		pureFunction, ok := function.(expressions.PureFunction)
+		assert.Equals(t, ok, true)
+		assert.Equals(t, pureFunction.Pure(), false)
+	}
+}
+
+func TestBuiltinsPure(t *testing.T) {
+	for name, function := range functions.Strings() {
+		pureFunction, ok := function.(expressions.PureFunction)
+		assert.Equals(t, ok, true)
+		assert.Equals(t, pureFunction.Pure(), true)
+		// Declaring the function as pure keeps its literal argument validation.
+		if name == "compare" {
+			_, isValidator := function.(expressions.LiteralArgumentValidator)
+			assert.Equals(t, isValidator, true)
+		}
+	}
+	// Calls to pure functions with literal arguments are folded when parsing.
+	expr, err := expressions.NewWithOptions(`md5("a") + $.str`, expressions.Options{Functions: functions.Hash()})
+	assert.NoError(t, err)
+	assert.Equals(t, expr.AST().String(), `"0cc175b9c0f1b6a831c399e269772661" + $.str`)
+}
@@ -0,0 +1,134 @@
+package functions
+
+import (
+	"regexp"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Regex returns the built-in functions that use regular expressions: regexMatch, regexFind, regexFindAll, and
+// regexReplace. The patterns use the RE2 syntax of the regexp package. If the pattern is a literal, it is validated
+// when the expression is type checked, so an invalid pattern fails Type and Validate instead of failing at runtime.
+func Regex() map[string]schema.CallableFunction {
+	return toMap(
+		regexMatchFunction(),
+		regexFindFunction(),
+		regexFindAllFunction(),
+		regexReplaceFunction(),
+	)
+}
+
+// regexPatternIndex is the index of the pattern argument of all regex functions.
+const regexPatternIndex = 1
+
+// regexFunction is a function with a pattern argument, which validates the pattern if it is a literal.
+type regexFunction struct {
+	schema.CallableFunction
+}
+
+// ValidateLiteralArguments validates the pattern if it is passed as a literal.
+func (f regexFunction) ValidateLiteralArguments(literals map[int]any, _ []schema.Type) error {
+	pattern, isString := literals[regexPatternIndex].(string)
+	if !isString {
+		return nil
+	}
+	_, err := compilePattern(f.ID(), pattern)
+	return err
+}
+
+func compilePattern(name string, pattern string) (*regexp.Regexp, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, expressions.Errorf("%s: invalid pattern %q (%w)", name, pattern, err)
+	}
+	return compiled, nil
+}
+
+func regexMatchFunction() schema.CallableFunction {
+	return regexFunction{mustFunction(schema.NewCallableFunction(
+		"regexMatch",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil), schema.NewStringSchema(nil, nil, nil)},
+		schema.NewBoolSchema(),
+		true,
+		display("regexMatch", "Returns true if the string in the first argument matches the pattern in the second argument."),
+		func(value string, pattern string) (bool, error) {
+			compiled, err := compilePattern("regexMatch", pattern)
+			if err != nil {
+				return false, err
+			}
+			return compiled.MatchString(value), nil
+		},
+	))}
+}
+
+func regexFindFunction() schema.CallableFunction {
+	return regexFunction{mustFunction(schema.NewCallableFunction(
+		"regexFind",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil), schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		display(
+			"regexFind",
+			"Returns the first match of the pattern in the second argument in the string in the first argument, or "+
+				"an empty string if there is no match.",
+		),
+		func(value string, pattern string) (string, error) {
+			compiled, err := compilePattern("regexFind", pattern)
+			if err != nil {
+				return "", err
+			}
+			return compiled.FindString(value), nil
+		},
+	))}
+}
+
+func regexFindAllFunction() schema.CallableFunction {
+	return regexFunction{mustFunction(schema.NewCallableFunction(
+		"regexFindAll",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil), schema.NewStringSchema(nil, nil, nil)},
+		schema.NewListSchema(schema.NewStringSchema(nil, nil, nil), nil, nil),
+		true,
+		display(
+			"regexFindAll",
+			"Returns all non-overlapping matches of the pattern in the second argument in the string in the first "+
+				"argument.",
+		),
+		func(value string, pattern string) ([]string, error) {
+			compiled, err := compilePattern("regexFindAll", pattern)
+			if err != nil {
+				return nil, err
+			}
+			matches := compiled.FindAllString(value, -1)
+			if matches == nil {
+				return []string{}, nil
+			}
+			return matches, nil
+		},
+	))}
+}
+
+func regexReplaceFunction() schema.CallableFunction {
+	return regexFunction{mustFunction(schema.NewCallableFunction(
+		"regexReplace",
+		[]schema.Type{
+			schema.NewStringSchema(nil, nil, nil),
+			schema.NewStringSchema(nil, nil, nil),
+			schema.NewStringSchema(nil, nil, nil),
+		},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		display(
+			"regexReplace",
+			"Replaces all matches of the pattern in the second argument in the string in the first argument with "+
+				"the replacement in the third argument. The replacement may reference capture groups as $1 or ${name}.",
+		),
+		func(value string, pattern string, replacement string) (string, error) {
+			compiled, err := compilePattern("regexReplace", pattern)
+			if err != nil {
+				return "", err
+			}
+			return compiled.ReplaceAllString(value, replacement), nil
+		},
+	))}
+}
@@ -0,0 +1,33 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestRegex(t *testing.T) {
+	runFunctionTests(t, functions.Regex(), map[string]functionTestCase{
+		"match":             {`regexMatch("abc123", "^[a-z]+[0-9]+$")`, schema.TypeIDBool, true, false, false},
+		"match-no-match":    {`regexMatch($.str, "[0-9]")`, schema.TypeIDBool, false, false, false},
+		"match-bad-literal": {`regexMatch($.str, "[")`, "", nil, true, false},
+		"match-bad-dynamic": {`regexMatch("a", $.strings[0] + "(")`, schema.TypeIDBool, nil, false, true},
+		"find":              {`regexFind("a1b22", "[0-9]+")`, schema.TypeIDString, "1", false, false},
+		"find-no-match":     {`regexFind("ab", "[0-9]+")`, schema.TypeIDString, "", false, false},
+		"find-all":          {`regexFindAll("a1b22", "[0-9]+")`, schema.TypeIDList, []string{"1", "22"}, false, false},
+		"find-all-none":     {`regexFindAll("ab", "[0-9]+")`, schema.TypeIDList, []string{}, false, false},
+		"replace":           {`regexReplace("a-b", "([a-z])", "<$1>")`, schema.TypeIDString, "<a>-<b>", false, false},
+		"replace-bad":       {`regexReplace("a", "(", "")`, "", nil, true, false},
+		"not-string":        {`regexMatch($.int, "a")`, "", nil, true, false},
+	})
+}
+
+func TestRegexValidate(t *testing.T) {
+	expr, err := expressions.New(`regexMatch($.str, "(")`)
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, toFunctionSchemas(functions.Regex()), nil)
+	assert.Equals(t, report.HasErrors(), true)
+}
@@ -0,0 +1,240 @@
+package functions
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// namespaceSeparator separates the namespace from the function name in qualified names, such as math.abs.
+const namespaceSeparator = "."
+
+var namespacePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// DuplicateFunctionError is returned when a function is registered with a name that is already registered for a
+// function with the same parameters.
+type DuplicateFunctionError struct {
+	// Name is the qualified name of the function.
+	Name string
+}
+
+func (e *DuplicateFunctionError) Error() string {
+	return e.LocalizedError(fmt.Sprintf)
+}
+
+func (e *DuplicateFunctionError) LocalizedError(localize func(format string, args ...any) string) string {
+	return localize("a function named %q is already registered", e.Name)
+}
+
+func (e *DuplicateFunctionError) ErrorCode() expressions.ErrorCode {
+	return expressions.ErrorCodeDuplicateFunction
+}
+
+// FunctionRegistry holds a set of functions, optionally grouped in namespaces. Functions in a namespace are
+// registered by their qualified name, such as math.abs, and functions without a namespace by their name alone.
+// Registering a name that is already registered adds an overload if the number or the types of the parameters differ,
+// such as max(int, int) and max(float, float), and fails otherwise, so functions from different sources cannot
+// silently replace each other.
+//
+// Pass the result of Functions to Type and Dependencies, and the result of CallableFunctions to Evaluate:
+//
+//	registry := functions.NewFunctionRegistry()
+//	if err := registry.RegisterBuiltins(""); err != nil {
+//	    return err
+//	}
+//	result, err := expr.Evaluate(data, registry.CallableFunctions(), nil)
+//
+// A registry is not safe for concurrent registration, so register all functions before using it.
+type FunctionRegistry struct {
+	functions map[string]schema.CallableFunction
+}
+
+// NewFunctionRegistry creates an empty function registry.
+func NewFunctionRegistry() *FunctionRegistry {
+	return &FunctionRegistry{
+		functions: map[string]schema.CallableFunction{},
+	}
+}
+
+// Register registers the functions in the namespace, by the names they have in the map. An empty namespace
+// registers the functions without a namespace. A function whose name is already registered is added as an overload,
+// see expressions.OverloadedFunction. If any of the functions has the same parameters as a function that is already
+// registered by its name, none of the functions are registered.
+func (r *FunctionRegistry) Register(namespace string, functions map[string]schema.CallableFunction) error {
+	if namespace != "" && !namespacePattern.MatchString(namespace) {
+		return expressions.Errorf("invalid namespace %q, namespaces must be identifiers", namespace)
+	}
+	registered := make(map[string]schema.CallableFunction, len(functions))
+	for name, function := range functions {
+		name = qualifiedName(namespace, name)
+		existing, exists := r.functions[name]
+		if !exists {
+			registered[name] = function
+			continue
+		}
+		overloaded, err := expressions.NewOverloadedFunction(append(overloads(existing), overloads(function)...)...)
+		if err != nil {
+			return &DuplicateFunctionError{Name: name}
+		}
+		registered[name] = overloaded
+	}
+	for name, function := range registered {
+		r.functions[name] = function
+	}
+	return nil
+}
+
+// overloads returns the overloads of the function, or the function itself if it is not overloaded.
+func overloads(function schema.CallableFunction) []schema.CallableFunction {
+	if overloaded, isOverloaded := function.(*expressions.OverloadedFunction); isOverloaded {
+		return overloaded.Overloads()
+	}
+	return []schema.CallableFunction{function}
+}
+
+// RegisterFunction registers a single function in the namespace by its ID.
+func (r *FunctionRegistry) RegisterFunction(namespace string, function schema.CallableFunction) error {
+	return r.Register(namespace, map[string]schema.CallableFunction{function.ID(): function})
+}
+
+// RegisterBuiltins registers all built-in functions of this package in the namespace.
+func (r *FunctionRegistry) RegisterBuiltins(namespace string) error {
+	builtins := map[string]schema.CallableFunction{}
+	for _, group := range []map[string]schema.CallableFunction{
+		List(),
+		Map(),
+		Conversion(),
+		Encoding(),
+		Regex(),
+		Hash(),
+		Aggregate(),
+		Sort(),
+		Random(),
+		Units(),
+		Strings(),
+		Math(),
+	} {
+		for name, function := range group {
+			builtins[name] = function
+		}
+	}
+	return r.Register(namespace, builtins)
+}
+
+// SetCallPolicy attaches the timeout and retry policy to the function registered by the qualified name, replacing
+// any policy set before. If the function is overloaded, the policy is attached to all overloads. The policy is
+// enforced when the function is called by Evaluate.
+func (r *FunctionRegistry) SetCallPolicy(name string, policy expressions.CallPolicy) error {
+	return r.wrapOverloads(name, "set the call policy of", func(overload schema.CallableFunction) (
+		schema.CallableFunction,
+		error,
+	) {
+		return expressions.NewFunctionWithCallPolicy(overload, policy)
+	})
+}
+
+// Deprecate marks the function registered by the qualified name as deprecated. If the function is overloaded, all
+// overloads are deprecated. The function keeps working, but its calls are reported as warnings by Validate, so
+// workflows can be migrated before it is removed:
+//
+//	err := registry.Deprecate("upper", expressions.Deprecation{Replacement: "toUpper()"})
+func (r *FunctionRegistry) Deprecate(name string, deprecation expressions.Deprecation) error {
+	return r.wrapOverloads(name, "deprecate", func(overload schema.CallableFunction) (schema.CallableFunction, error) {
+		return expressions.NewDeprecatedFunction(overload, deprecation), nil
+	})
+}
+
+// wrapOverloads replaces each overload of the function registered by the qualified name with the result of the wrap
+// function. The action describes the wrapping in errors.
+func (r *FunctionRegistry) wrapOverloads(
+	name string,
+	action string,
+	wrap func(overload schema.CallableFunction) (schema.CallableFunction, error),
+) error {
+	function, found := r.functions[name]
+	if !found {
+		return expressions.Errorf("no function named %q is registered", name)
+	}
+	functionOverloads := overloads(function)
+	wrapped := make([]schema.CallableFunction, len(functionOverloads))
+	for i, overload := range functionOverloads {
+		var err error
+		wrapped[i], err = wrap(overload)
+		if err != nil {
+			return expressions.Errorf("cannot %s %q (%w)", action, name, err)
+		}
+	}
+	if len(wrapped) == 1 {
+		r.functions[name] = wrapped[0]
+		return nil
+	}
+	overloaded, err := expressions.NewOverloadedFunction(wrapped...)
+	if err != nil {
+		return expressions.Errorf("cannot %s %q (%w)", action, name, err)
+	}
+	r.functions[name] = overloaded
+	return nil
+}
+
+// Lookup returns the function registered by the qualified name.
+func (r *FunctionRegistry) Lookup(name string) (schema.CallableFunction, bool) {
+	function, found := r.functions[name]
+	return function, found
+}
+
+// Names returns the sorted qualified names of all registered functions.
+func (r *FunctionRegistry) Names() []string {
+	names := make([]string, 0, len(r.functions))
+	for name := range r.functions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Namespaces returns the sorted namespaces that have functions registered. Functions without a namespace are not
+// included.
+func (r *FunctionRegistry) Namespaces() []string {
+	namespaces := map[string]struct{}{}
+	for name := range r.functions {
+		if namespace, _, hasNamespace := strings.Cut(name, namespaceSeparator); hasNamespace {
+			namespaces[namespace] = struct{}{}
+		}
+	}
+	result := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		result = append(result, namespace)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Functions returns the registered functions by their qualified names, to be passed to Type, Dependencies, and
+// Validate.
+func (r *FunctionRegistry) Functions() map[string]schema.Function {
+	result := make(map[string]schema.Function, len(r.functions))
+	for name, function := range r.functions {
+		result[name] = function
+	}
+	return result
+}
+
+// CallableFunctions returns the registered functions by their qualified names, to be passed to Evaluate.
+func (r *FunctionRegistry) CallableFunctions() map[string]schema.CallableFunction {
+	result := make(map[string]schema.CallableFunction, len(r.functions))
+	for name, function := range r.functions {
+		result[name] = function
+	}
+	return result
+}
+
+func qualifiedName(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + namespaceSeparator + name
+}
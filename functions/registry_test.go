@@ -0,0 +1,186 @@
+package functions_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestFunctionRegistry(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.RegisterBuiltins(""))
+	assert.NoError(t, registry.Register("hash", functions.Hash()))
+
+	_, found := registry.Lookup("length")
+	assert.Equals(t, found, true)
+	_, found = registry.Lookup("hash.md5")
+	assert.Equals(t, found, true)
+	_, found = registry.Lookup("hash.length")
+	assert.Equals(t, found, false)
+	assert.Equals(t, registry.Namespaces(), []string{"hash"})
+	assert.Equals(t, len(registry.Names()), len(registry.CallableFunctions()))
+
+	expr, err := expressions.New(`length($.ints)`)
+	assert.NoError(t, err)
+	resultType, err := expr.Type(testScope, registry.Functions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, resultType.TypeID(), schema.TypeIDInt)
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(3)))
+}
+
+func TestFunctionRegistryNamespacedCall(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("hash", functions.Hash()))
+	for _, expression := range []string{`hash.md5("a")`, `hash::md5("a")`} {
+		expr, err := expressions.New(expression)
+		assert.NoError(t, err)
+		resultType, err := expr.Type(testScope, registry.Functions(), nil)
+		assert.NoError(t, err)
+		assert.Equals(t, resultType.TypeID(), schema.TypeIDString)
+		result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+		assert.NoError(t, err)
+		assert.Equals(t, result, any("0cc175b9c0f1b6a831c399e269772661"))
+	}
+	// Without the namespace, the function is not found.
+	expr, err := expressions.New(`md5("a")`)
+	assert.NoError(t, err)
+	_, err = expr.Type(testScope, registry.Functions(), nil)
+	assert.Error(t, err)
+}
+
+func TestFunctionRegistryDuplicate(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("", functions.List()))
+	err := registry.Register("", functions.Map())
+	assert.NoError(t, err)
+	err = registry.RegisterBuiltins("")
+	var duplicateErr *functions.DuplicateFunctionError
+	assert.Equals(t, errors.As(err, &duplicateErr), true)
+	// A failed registration does not register any of the functions.
+	_, found := registry.Lookup("toInt")
+	assert.Equals(t, found, false)
+	// The same functions can be registered in a different namespace.
+	assert.NoError(t, registry.RegisterBuiltins("builtin"))
+}
+
+func TestFunctionRegistryInvalidNamespace(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.Error(t, registry.Register("a.b", functions.List()))
+	assert.Error(t, registry.Register("1a", functions.List()))
+}
+
+func TestFunctionRegistryOverload(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("", functions.Hash()))
+	md5WithFlag, err := schema.NewCallableFunction(
+		"md5",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil), schema.NewBoolSchema()},
+		schema.NewStringSchema(nil, nil, nil),
+		false,
+		nil,
+		func(value string, upper bool) string {
+			return value
+		},
+	)
+	assert.NoError(t, err)
+	assert.NoError(t, registry.RegisterFunction("", md5WithFlag))
+	function, found := registry.Lookup("md5")
+	assert.Equals(t, found, true)
+	overloaded, isOverloaded := function.(*expressions.OverloadedFunction)
+	assert.Equals(t, isOverloaded, true)
+	assert.Equals(t, len(overloaded.Overloads()), 2)
+
+	expr, err := expressions.New(`md5("a", true)`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("a"))
+	expr, err = expressions.New(`md5("a")`)
+	assert.NoError(t, err)
+	result, err = expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("0cc175b9c0f1b6a831c399e269772661"))
+
+	// An overload with the same parameters as a registered one is a duplicate.
+	var duplicateErr *functions.DuplicateFunctionError
+	assert.Equals(t, errors.As(registry.RegisterFunction("", md5WithFlag), &duplicateErr), true)
+}
+
+func TestFunctionRegistryCallPolicy(t *testing.T) {
+	attempts := 0
+	flaky, err := schema.NewCallableFunction(
+		"flaky",
+		[]schema.Type{},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		nil,
+		func() (int64, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, fmt.Errorf("attempt %d failed", attempts)
+			}
+			return int64(attempts), nil
+		},
+	)
+	assert.NoError(t, err)
+	slow, err := schema.NewCallableFunction(
+		"slow",
+		[]schema.Type{},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func() int64 {
+			time.Sleep(time.Second)
+			return 1
+		},
+	)
+	assert.NoError(t, err)
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.RegisterFunction("", flaky))
+	assert.NoError(t, registry.RegisterFunction("", slow))
+	assert.NoError(t, registry.SetCallPolicy("flaky", expressions.CallPolicy{Retries: 2}))
+	assert.NoError(t, registry.SetCallPolicy("slow", expressions.CallPolicy{Timeout: 10 * time.Millisecond}))
+	assert.Error(t, registry.SetCallPolicy("missing", expressions.CallPolicy{}))
+	assert.Error(t, registry.SetCallPolicy("slow", expressions.CallPolicy{Retries: -1}))
+
+	expr, err := expressions.New(`flaky()`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(3)))
+
+	expr, err = expressions.New(`slow()`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	var timeoutErr *expressions.FunctionTimeoutError
+	assert.Equals(t, errors.As(err, &timeoutErr), true)
+	assert.Equals(t, timeoutErr.Function, "slow")
+}
+
+func TestFunctionRegistry_Deprecate(t *testing.T) {
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.RegisterBuiltins(""))
+	assert.NoError(t, registry.Deprecate("toIntOr", expressions.Deprecation{Reason: "it hides errors", Replacement: "toInt()"}))
+	assert.Error(t, registry.Deprecate("missing", expressions.Deprecation{}))
+
+	expr, err := expressions.New(`toIntOr("x", 2) + toInt("1")`)
+	assert.NoError(t, err)
+	report := expr.Validate(testScope, registry.Functions(), nil)
+	assert.Equals(t, report.HasErrors(), false)
+	assert.Equals(t, len(report.Warnings), 1)
+	assert.Equals(t, report.Warnings[0].Kind, expressions.DiagnosticDeprecatedFunction)
+	assert.Contains(t, report.Warnings[0].Message, `the function "toIntOr" is deprecated: it hides errors; use toInt() instead`)
+
+	// Deprecated functions keep working.
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(3)))
+}
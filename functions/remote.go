@@ -0,0 +1,143 @@
+package functions
+
+import (
+	"reflect"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// RemoteFunctionClient is the transport to a service that provides functions, such as a gRPC or HTTP client
+// generated for the service. It lets engines offer organization-wide custom functions without recompiling them.
+type RemoteFunctionClient interface {
+	// Functions returns the definitions of the functions the service provides.
+	Functions() ([]RemoteFunctionDefinition, error)
+	// Call calls the function of the service with the arguments, and returns its result. The result must be of the
+	// output type of the function.
+	Call(name string, arguments []any) (any, error)
+}
+
+// RemoteFunctionDefinition is the schema of a function provided by a remote service.
+type RemoteFunctionDefinition struct {
+	// Name is the name the function is called by.
+	Name string
+	// Description is shown in the documentation of the function.
+	Description string
+	// Parameters are the types of the parameters of the function.
+	Parameters []schema.Type
+	// Output is the type of the result of the function, or nil if it has no result.
+	Output schema.Type
+	// Pure is true if the function always returns the same result for the same arguments, see
+	// expressions.PureFunction.
+	Pure bool
+}
+
+// NewRemoteFunctions fetches the function definitions from the client once, and returns functions that forward
+// their calls to the client. The functions are type checked with the fetched definitions, so calls to the service are
+// only made when the expressions are evaluated. Register them in a FunctionRegistry, usually in their own namespace.
+//
+// Errors returned by the client are returned by the calls, and can be retried with FunctionRegistry.SetCallPolicy.
+func NewRemoteFunctions(client RemoteFunctionClient) (map[string]schema.CallableFunction, error) {
+	definitions, err := client.Functions()
+	if err != nil {
+		return nil, expressions.Errorf("failed to fetch the remote function definitions (%w)", err)
+	}
+	result := make(map[string]schema.CallableFunction, len(definitions))
+	for _, definition := range definitions {
+		if _, exists := result[definition.Name]; exists {
+			return nil, expressions.Errorf("the remote service defines the function %q more than once", definition.Name)
+		}
+		function, err := newRemoteFunction(client, definition)
+		if err != nil {
+			return nil, expressions.Errorf("invalid remote function %q (%w)", definition.Name, err)
+		}
+		result[definition.Name] = function
+	}
+	return result, nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// newRemoteFunction creates a function with a handler of the Go types of the definition, which forwards its
+// arguments to the client.
+func newRemoteFunction(client RemoteFunctionClient, definition RemoteFunctionDefinition) (schema.CallableFunction, error) {
+	if !namespacePattern.MatchString(definition.Name) {
+		return nil, expressions.Errorf("function names must be identifiers")
+	}
+	parameterTypes := make([]reflect.Type, len(definition.Parameters))
+	for i, parameter := range definition.Parameters {
+		parameterTypes[i] = reflectedType(parameter)
+	}
+	outputTypes := []reflect.Type{errorType}
+	var outputType reflect.Type
+	if definition.Output != nil {
+		outputType = reflectedType(definition.Output)
+		outputTypes = []reflect.Type{outputType, errorType}
+	}
+	handler := reflect.MakeFunc(
+		reflect.FuncOf(parameterTypes, outputTypes, false),
+		func(arguments []reflect.Value) []reflect.Value {
+			values := make([]any, len(arguments))
+			for i, argument := range arguments {
+				values[i] = argument.Interface()
+			}
+			result, err := client.Call(definition.Name, values)
+			if err == nil && outputType != nil {
+				var resultValue reflect.Value
+				resultValue, err = remoteResultValue(result, outputType)
+				if err == nil {
+					return []reflect.Value{resultValue, reflect.Zero(errorType)}
+				}
+			}
+			errValue := reflect.Zero(errorType)
+			if err != nil {
+				errValue = reflect.ValueOf(expressions.Errorf("remote function %q failed (%w)", definition.Name, err))
+			}
+			if outputType == nil {
+				return []reflect.Value{errValue}
+			}
+			return []reflect.Value{reflect.Zero(outputType), errValue}
+		},
+	)
+	function, err := schema.NewCallableFunction(
+		definition.Name,
+		definition.Parameters,
+		definition.Output,
+		true,
+		display(definition.Name, definition.Description),
+		handler.Interface(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if definition.Pure {
+		return expressions.NewPureFunction(function), nil
+	}
+	return function, nil
+}
+
+// reflectedType returns the Go type of the values of the schema type, or the any type if it has none.
+func reflectedType(schemaType schema.Type) reflect.Type {
+	if reflected := schemaType.ReflectedType(); reflected != nil {
+		return reflected
+	}
+	return reflect.TypeOf((*any)(nil)).Elem()
+}
+
+// remoteResultValue converts the result returned by the client to the Go type of the output. Clients often decode
+// numbers to other Go types, such as int or float64, so integers are converted to other integer and float types.
+func remoteResultValue(result any, outputType reflect.Type) (reflect.Value, error) {
+	if result == nil {
+		return reflect.Zero(outputType), nil
+	}
+	value := reflect.ValueOf(result)
+	switch {
+	case value.Type().AssignableTo(outputType):
+		return value, nil
+	case value.CanInt() && (reflect.Zero(outputType).CanInt() || reflect.Zero(outputType).CanFloat()),
+		value.CanFloat() && reflect.Zero(outputType).CanFloat():
+		return value.Convert(outputType), nil
+	default:
+		return reflect.Value{}, expressions.Errorf("returned %T, expected %s", result, outputType)
+	}
+}
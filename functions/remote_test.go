@@ -0,0 +1,102 @@
+package functions_test
+
+import (
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// fakeRemoteClient provides a greet function that greets its argument, and a broken function that returns a value
+// of the wrong type.
+type fakeRemoteClient struct {
+	definitions []functions.RemoteFunctionDefinition
+	calls       []string
+}
+
+func newFakeRemoteClient() *fakeRemoteClient {
+	return &fakeRemoteClient{
+		definitions: []functions.RemoteFunctionDefinition{
+			{
+				Name:        "greet",
+				Description: "Greets the name.",
+				Parameters:  []schema.Type{schema.NewStringSchema(nil, nil, nil)},
+				Output:      schema.NewStringSchema(nil, nil, nil),
+				Pure:        true,
+			},
+			{
+				Name:       "broken",
+				Parameters: []schema.Type{},
+				Output:     schema.NewIntSchema(nil, nil, nil),
+			},
+		},
+	}
+}
+
+func (c *fakeRemoteClient) Functions() ([]functions.RemoteFunctionDefinition, error) {
+	return c.definitions, nil
+}
+
+func (c *fakeRemoteClient) Call(name string, arguments []any) (any, error) {
+	c.calls = append(c.calls, name)
+	switch name {
+	case "greet":
+		if arguments[0] == "" {
+			return nil, fmt.Errorf("empty name")
+		}
+		return "hello " + arguments[0].(string), nil
+	default:
+		return "not an int", nil
+	}
+}
+
+func TestRemoteFunctions(t *testing.T) {
+	client := newFakeRemoteClient()
+	remoteFunctions, err := functions.NewRemoteFunctions(client)
+	assert.NoError(t, err)
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("org", remoteFunctions))
+
+	expr, err := expressions.New(`org.greet($.str)`)
+	assert.NoError(t, err)
+	resultType, err := expr.Type(testScope, registry.Functions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, resultType.TypeID(), schema.TypeIDString)
+	// Type checking does not call the service.
+	assert.Equals(t, len(client.calls), 0)
+	result, err := expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("hello héllo"))
+	assert.Equals(t, client.calls, []string{"greet"})
+
+	pure, isPure := remoteFunctions["greet"].(expressions.PureFunction)
+	assert.Equals(t, isPure, true)
+	assert.Equals(t, pure.Pure(), true)
+
+	expr, err = expressions.New(`org.greet("")`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "empty name")
+
+	expr, err = expressions.New(`org.broken()`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(testData, registry.CallableFunctions(), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "returned string, expected int64")
+}
+
+func TestRemoteFunctionsInvalidDefinitions(t *testing.T) {
+	client := newFakeRemoteClient()
+	client.definitions = append(client.definitions, client.definitions[0])
+	_, err := functions.NewRemoteFunctions(client)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "more than once")
+
+	client.definitions = []functions.RemoteFunctionDefinition{{Name: "not-an-identifier"}}
+	_, err = functions.NewRemoteFunctions(client)
+	assert.Error(t, err)
+}
@@ -0,0 +1,165 @@
+package functions
+
+import (
+	"cmp"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Sort returns the built-in functions that sort lists: sort and sortBy. sort sorts a list of integers, floats, or
+// strings. sortBy sorts a list of objects by a key, which is a dot-separated path to a field of the items, such as
+// "metadata.name". If the key is a literal, it is validated when the expression is type checked, so a field that
+// does not exist or cannot be compared fails Type and Validate instead of failing at runtime. Both functions return
+// a sorted copy of the list, and keep the order of equal items.
+func Sort() map[string]schema.CallableFunction {
+	return toMap(
+		sortFunction(),
+		sortByFunction(),
+	)
+}
+
+// validateSortableType validates that values of the type can be compared with each other.
+func validateSortableType(name string, sortType schema.Type) error {
+	switch sortType.TypeID() {
+	case schema.TypeIDInt, schema.TypeIDIntEnum, schema.TypeIDFloat, schema.TypeIDString, schema.TypeIDStringEnum,
+		schema.TypeIDAny:
+		return nil
+	default:
+		return expressions.Errorf("%s can only sort by integers, floats, or strings, got %s", name, sortType.TypeID())
+	}
+}
+
+// compareSortValues compares two integers, floats, or strings. Integers and floats can be compared with each other.
+func compareSortValues(a reflect.Value, b reflect.Value) (int, error) {
+	a, b = unwrapInterface(a), unwrapInterface(b)
+	switch {
+	case a.CanInt() && b.CanInt():
+		return cmp.Compare(a.Int(), b.Int()), nil
+	case (a.CanInt() || a.CanFloat()) && (b.CanInt() || b.CanFloat()):
+		return cmp.Compare(floatValue(a), floatValue(b)), nil
+	case a.Kind() == reflect.String && b.Kind() == reflect.String:
+		return cmp.Compare(a.String(), b.String()), nil
+	default:
+		return 0, expressions.Errorf("cannot compare %s with %s", a.Kind(), b.Kind())
+	}
+}
+
+func floatValue(value reflect.Value) float64 {
+	if value.CanInt() {
+		return float64(value.Int())
+	}
+	return value.Float()
+}
+
+// sortList returns a sorted copy of the list, using the sort keys returned for the items.
+func sortList(list any, sortKey func(item reflect.Value) (reflect.Value, error)) (any, error) {
+	value, err := listValue(list)
+	if err != nil {
+		return nil, err
+	}
+	length := value.Len()
+	keys := make([]reflect.Value, length)
+	indexes := make([]int, length)
+	for i := 0; i < length; i++ {
+		keys[i], err = sortKey(value.Index(i))
+		if err != nil {
+			return nil, expressions.Errorf("item %d: %w", i, err)
+		}
+		indexes[i] = i
+	}
+	var compareErr error
+	sort.SliceStable(indexes, func(i, j int) bool {
+		result, err := compareSortValues(keys[indexes[i]], keys[indexes[j]])
+		if err != nil && compareErr == nil {
+			compareErr = err
+		}
+		return result < 0
+	})
+	if compareErr != nil {
+		return nil, compareErr
+	}
+	result := reflect.MakeSlice(reflect.SliceOf(value.Type().Elem()), length, length)
+	for i, index := range indexes {
+		result.Index(i).Set(value.Index(index))
+	}
+	return result.Interface(), nil
+}
+
+func sortFunction() schema.CallableFunction {
+	return mustFunction(schema.NewDynamicCallableFunction(
+		"sort",
+		[]schema.Type{schema.NewAnySchema()},
+		display("sort", "Returns a copy of the list of integers, floats, or strings in ascending order."),
+		func(list any) (any, error) {
+			result, err := sortList(list, func(item reflect.Value) (reflect.Value, error) {
+				return item, nil
+			})
+			if err != nil {
+				return nil, expressions.Errorf("sort: %w", err)
+			}
+			return result, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			itemType, err := listItemType(inputTypes[0])
+			if err != nil {
+				return nil, err
+			}
+			if err := validateSortableType("sort", itemType); err != nil {
+				return nil, err
+			}
+			return schema.NewListSchema(itemType, nil, nil), nil
+		},
+	))
+}
+
+// sortByKeyIndex is the index of the key argument of sortBy.
+const sortByKeyIndex = 1
+
+// sortByKeyFunction is the sortBy function, which validates the key if it is a literal.
+type sortByKeyFunction struct {
+	schema.CallableFunction
+}
+
+// ValidateLiteralArguments validates that the key refers to a field of the items of the list that can be compared.
+func (f sortByKeyFunction) ValidateLiteralArguments(literals map[int]any, argumentTypes []schema.Type) error {
+	key, isString := literals[sortByKeyIndex].(string)
+	if !isString {
+		return nil
+	}
+	itemType, err := listItemType(argumentTypes[0])
+	if err != nil {
+		return err
+	}
+	keyType, err := fieldType(itemType, strings.Split(key, "."))
+	if err != nil {
+		return expressions.Errorf("sortBy: invalid key %q (%w)", key, err)
+	}
+	return validateSortableType("sortBy", keyType)
+}
+
+func sortByFunction() schema.CallableFunction {
+	return sortByKeyFunction{mustFunction(schema.NewDynamicCallableFunction(
+		"sortBy",
+		[]schema.Type{schema.NewAnySchema(), schema.NewStringSchema(nil, nil, nil)},
+		display(
+			"sortBy",
+			"Returns a copy of the list of objects in the first argument in ascending order of the key in the "+
+				"second argument. The key is a dot-separated path to a field of the items, such as \"metadata.name\".",
+		),
+		func(list any, key string) (any, error) {
+			path := strings.Split(key, ".")
+			result, err := sortList(list, func(item reflect.Value) (reflect.Value, error) {
+				return fieldValue(item, path)
+			})
+			if err != nil {
+				return nil, expressions.Errorf("sortBy: %w", err)
+			}
+			return result, nil
+		},
+		listTypeHandler,
+	))}
+}
@@ -0,0 +1,34 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestSort(t *testing.T) {
+	objects := testData["objects"].([]any)
+	runFunctionTests(t, functions.Sort(), map[string]functionTestCase{
+		"sort-ints":      {`sort($.ints)`, schema.TypeIDList, []int64{1, 2, 3}, false, false},
+		"sort-floats":    {`sort($.floats)`, schema.TypeIDList, []float64{0.5, 1.0, 2.5}, false, false},
+		"sort-strings":   {`sort($.strings)`, schema.TypeIDList, []string{"a", "b"}, false, false},
+		"sort-mixed":     {`sort($.numbers)`, schema.TypeIDList, []any{int64(1), 2.5}, false, false},
+		"sort-untyped":   {`sort($.untyped)`, schema.TypeIDList, nil, false, true},
+		"sort-objects":   {`sort($.objects)`, "", nil, true, false},
+		"sort-not-list":  {`sort($.map)`, "", nil, true, false},
+		"sort-by":        {`sortBy($.objects, "name")`, schema.TypeIDList, []any{objects[1], objects[0], objects[2]}, false, false},
+		"sort-by-stable": {`sortBy($.objects, "size")`, schema.TypeIDList, []any{objects[2], objects[0], objects[1]}, false, false},
+		"sort-by-nested": {`sortBy($.objects, "meta.rank")`, schema.TypeIDList, []any{objects[0], objects[2], objects[1]}, false, false},
+		"sort-by-dynamic-key": {
+			`sortBy($.objects, $.strings[0])`,
+			schema.TypeIDList,
+			nil,
+			false,
+			true,
+		},
+		"sort-by-missing-field": {`sortBy($.objects, "missing")`, "", nil, true, false},
+		"sort-by-list-field":    {`sortBy($.objects, "tags")`, "", nil, true, false},
+		"sort-by-untyped":       {`sortBy($.untyped, "name")`, schema.TypeIDList, nil, false, true},
+	})
+}
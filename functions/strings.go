@@ -0,0 +1,115 @@
+package functions
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Strings returns the built-in functions that compare strings: equalsIgnoreCase and compare.
+func Strings() map[string]schema.CallableFunction {
+	return toMap(
+		equalsIgnoreCaseFunction(),
+		compareFunction(),
+	)
+}
+
+// localePattern matches BCP 47 language tags, such as en, tr, or pt-BR.
+var localePattern = regexp.MustCompile(`^[a-zA-Z]{2,3}(?:-[a-zA-Z0-9]{2,8})*$`)
+
+// localeCase returns the special case rules of the language of the locale, or nil if the language uses the default
+// Unicode case rules. Returns an error if the locale is not a valid language tag.
+func localeCase(locale string) (unicode.SpecialCase, error) {
+	if !localePattern.MatchString(locale) {
+		return nil, expressions.Errorf("invalid locale %q, expected a language tag such as en or pt-BR", locale)
+	}
+	language, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	switch language {
+	case "tr", "az":
+		return unicode.TurkishCase, nil
+	default:
+		return nil, nil
+	}
+}
+
+// compareWithLocale compares the strings case-insensitively with the case rules of the locale, and breaks ties by
+// comparing them byte by byte. An empty locale compares the strings byte by byte only.
+func compareWithLocale(a string, b string, locale string) (int64, error) {
+	if locale == "" {
+		return int64(strings.Compare(a, b)), nil
+	}
+	specialCase, err := localeCase(locale)
+	if err != nil {
+		return 0, err
+	}
+	foldedA, foldedB := strings.ToLower(a), strings.ToLower(b)
+	if specialCase != nil {
+		foldedA, foldedB = strings.ToLowerSpecial(specialCase, a), strings.ToLowerSpecial(specialCase, b)
+	}
+	if result := strings.Compare(foldedA, foldedB); result != 0 {
+		return int64(result), nil
+	}
+	return int64(strings.Compare(a, b)), nil
+}
+
+func equalsIgnoreCaseFunction() schema.CallableFunction {
+	return mustFunction(schema.NewCallableFunction(
+		"equalsIgnoreCase",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil), schema.NewStringSchema(nil, nil, nil)},
+		schema.NewBoolSchema(),
+		false,
+		display("equalsIgnoreCase", "Returns true if the strings are equal when ignoring the case of letters."),
+		func(a string, b string) bool {
+			return strings.EqualFold(a, b)
+		},
+	))
+}
+
+// compareLocaleIndex is the index of the locale argument of compare.
+const compareLocaleIndex = 2
+
+// compareLocaleFunction is the compare function, which validates the locale if it is a literal.
+type compareLocaleFunction struct {
+	schema.CallableFunction
+}
+
+// ValidateLiteralArguments validates the locale if it is passed as a literal.
+func (f compareLocaleFunction) ValidateLiteralArguments(literals map[int]any, _ []schema.Type) error {
+	locale, isString := literals[compareLocaleIndex].(string)
+	if !isString || locale == "" {
+		return nil
+	}
+	_, err := localeCase(locale)
+	return err
+}
+
+func compareFunction() schema.CallableFunction {
+	return compareLocaleFunction{mustFunction(schema.NewCallableFunction(
+		"compare",
+		[]schema.Type{
+			schema.NewStringSchema(nil, nil, nil),
+			schema.NewStringSchema(nil, nil, nil),
+			schema.NewStringSchema(nil, nil, nil),
+		},
+		schema.NewIntSchema(nil, nil, nil),
+		true,
+		display(
+			"compare",
+			"Compares the strings in the first two arguments, and returns -1 if the first is less than the second, "+
+				"0 if they are equal, and 1 if the first is greater than the second. If the locale in the third "+
+				"argument is empty, the strings are compared byte by byte. Otherwise, they are compared ignoring "+
+				"case with the case rules of the locale, such as the dotted and dotless i in Turkish, and ties are "+
+				"broken byte by byte. Other collation rules, such as ignoring accents, are not applied.",
+		),
+		func(a string, b string, locale string) (int64, error) {
+			result, err := compareWithLocale(a, b, locale)
+			if err != nil {
+				return 0, expressions.Errorf("compare: %w", err)
+			}
+			return result, nil
+		},
+	))}
+}
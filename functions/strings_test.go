@@ -0,0 +1,24 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestStrings(t *testing.T) {
+	runFunctionTests(t, functions.Strings(), map[string]functionTestCase{
+		"equals-ignore-case":           {`equalsIgnoreCase("Héllo", $.str)`, schema.TypeIDBool, true, false, false},
+		"equals-ignore-case-different": {`equalsIgnoreCase("hello", $.str)`, schema.TypeIDBool, false, false, false},
+		"equals-ignore-case-not-str":   {`equalsIgnoreCase($.int, $.str)`, "", nil, true, false},
+		"compare-binary":               {`compare("a", "B", "")`, schema.TypeIDInt, int64(1), false, false},
+		"compare-locale":               {`compare("a", "B", "en")`, schema.TypeIDInt, int64(-1), false, false},
+		"compare-locale-tie":           {`compare("a", "A", "en-US")`, schema.TypeIDInt, int64(1), false, false},
+		"compare-equal":                {`compare("a", "a", "en")`, schema.TypeIDInt, int64(0), false, false},
+		"compare-turkish":              {`compare("I", "i", "tr")`, schema.TypeIDInt, int64(1), false, false},
+		"compare-english-dotted-i":     {`compare("I", "i", "en")`, schema.TypeIDInt, int64(-1), false, false},
+		"compare-invalid-locale":       {`compare("a", "b", "not a locale")`, "", nil, true, false},
+		"compare-dynamic-locale":       {`compare("a", "b", $.str)`, schema.TypeIDInt, nil, false, true},
+	})
+}
@@ -0,0 +1,72 @@
+package functions
+
+import (
+	"math"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/ast"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+// Units returns the built-in functions that convert durations and byte sizes to integers: toSeconds, toMillis, and
+// toBytes. They accept the same formats as the duration and byte size literals, such as "5m30s" and "2Gi", but as
+// strings, so the values can come from the data. If the argument is a literal, it is validated when the expression is
+// type checked.
+func Units() map[string]schema.CallableFunction {
+	return toMap(
+		unitFunction("toSeconds", "Converts a duration such as \"5m30s\" to the number of seconds.", toSeconds),
+		unitFunction("toMillis", "Converts a duration such as \"5m30s\" to the number of milliseconds.", toMillis),
+		unitFunction("toBytes", "Converts a byte size such as \"2Gi\" or \"512MB\" to the number of bytes.", ast.ParseByteSizeLiteral),
+	)
+}
+
+// unitConversionFunction is a unit conversion function, which validates the value if it is a literal.
+type unitConversionFunction struct {
+	schema.CallableFunction
+	convert func(value string) (int64, error)
+}
+
+// ValidateLiteralArguments validates that the value can be converted if it is passed as a literal.
+func (f unitConversionFunction) ValidateLiteralArguments(literals map[int]any, _ []schema.Type) error {
+	value, isString := literals[0].(string)
+	if !isString {
+		return nil
+	}
+	_, err := f.convert(value)
+	return err
+}
+
+func unitFunction(name string, description string, convert func(value string) (int64, error)) schema.CallableFunction {
+	return unitConversionFunction{
+		CallableFunction: mustFunction(schema.NewCallableFunction(
+			name,
+			[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+			schema.NewIntSchema(nil, nil, nil),
+			true,
+			display(name, description+" Fails if the value is not valid."),
+			func(value string) (int64, error) {
+				result, err := convert(value)
+				if err != nil {
+					return 0, expressions.Errorf("%s: %w", name, err)
+				}
+				return result, nil
+			},
+		)),
+		convert: convert,
+	}
+}
+
+func toSeconds(value string) (int64, error) {
+	return ast.ParseDurationLiteral(value)
+}
+
+func toMillis(value string) (int64, error) {
+	seconds, err := ast.ParseDurationLiteral(value)
+	if err != nil {
+		return 0, err
+	}
+	if seconds > math.MaxInt64/1000 {
+		return 0, expressions.Errorf("duration %q is too large", value)
+	}
+	return seconds * 1000, nil
+}
@@ -0,0 +1,22 @@
+package functions_test
+
+import (
+	"testing"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+)
+
+func TestUnits(t *testing.T) {
+	runFunctionTests(t, functions.Units(), map[string]functionTestCase{
+		"to-seconds":          {`toSeconds("5m30s")`, schema.TypeIDInt, int64(330), false, false},
+		"to-seconds-invalid":  {`toSeconds("5 minutes")`, "", nil, true, false},
+		"to-seconds-data":     {`toSeconds($.str)`, schema.TypeIDInt, nil, false, true},
+		"to-seconds-not-str":  {`toSeconds($.int)`, "", nil, true, false},
+		"to-millis":           {`toMillis("1h")`, schema.TypeIDInt, int64(3600000), false, false},
+		"to-millis-too-large": {`toMillis("106751991168d")`, "", nil, true, false},
+		"to-bytes":            {`toBytes("2Gi")`, schema.TypeIDInt, int64(2 << 30), false, false},
+		"to-bytes-decimal":    {`toBytes("512MB")`, schema.TypeIDInt, int64(512000000), false, false},
+		"to-bytes-invalid":    {`toBytes("2gb")`, "", nil, true, false},
+	})
+}
@@ -0,0 +1,110 @@
+package grpcfunctions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.flow.arcalot.io/expressions/functions"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// NewClient returns the client of the function service on the connection. The definitions are fetched when
+// Functions is called, so functions.NewRemoteFunctions fetches them once at startup, and the calls are only made when
+// the expressions are evaluated.
+//
+// The calls are not interrupted when they time out, see expressions.CallPolicy. To stop the calls that take too long
+// on the service, add a deadline with a unary interceptor of the connection.
+func NewClient(connection grpc.ClientConnInterface) functions.RemoteFunctionClient {
+	return &client{connection: connection}
+}
+
+type client struct {
+	connection grpc.ClientConnInterface
+	lock       sync.Mutex
+	// definitions are the definitions fetched by the last call of Functions, by name. They are used to serialize the
+	// arguments and unserialize the results of the calls.
+	definitions map[string]functions.RemoteFunctionDefinition
+}
+
+func (c *client) Functions() ([]functions.RemoteFunctionDefinition, error) {
+	response := &structpb.ListValue{}
+	if err := c.connection.Invoke(context.Background(), listFunctionsMethod, &emptypb.Empty{}, response); err != nil {
+		return nil, err
+	}
+	result := make([]functions.RemoteFunctionDefinition, len(response.Values))
+	definitions := make(map[string]functions.RemoteFunctionDefinition, len(response.Values))
+	for i, value := range response.Values {
+		definition, err := decodeDefinition(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid function definition %d (%w)", i, err)
+		}
+		result[i] = definition
+		definitions[definition.Name] = definition
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.definitions = definitions
+	return result, nil
+}
+
+func (c *client) Call(name string, arguments []any) (any, error) {
+	c.lock.Lock()
+	definition, found := c.definitions[name]
+	c.lock.Unlock()
+	if !found {
+		return nil, fmt.Errorf("the function %q is not in the fetched definitions", name)
+	}
+	if len(arguments) != len(definition.Parameters) {
+		return nil, fmt.Errorf("expected %d arguments, got %d", len(definition.Parameters), len(arguments))
+	}
+	encodedArguments := make([]*structpb.Value, len(arguments))
+	for i, argument := range arguments {
+		encodedArgument, err := encodeValue(definition.Parameters[i], argument)
+		if err != nil {
+			return nil, fmt.Errorf("cannot pass argument %d (%w)", i, err)
+		}
+		encodedArguments[i] = encodedArgument
+	}
+	request := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name":      structpb.NewStringValue(name),
+		"arguments": structpb.NewListValue(&structpb.ListValue{Values: encodedArguments}),
+	}}
+	response := &structpb.Value{}
+	if err := c.connection.Invoke(context.Background(), callMethod, request, response); err != nil {
+		return nil, err
+	}
+	if definition.Output == nil {
+		return nil, nil
+	}
+	result, err := decodeValue(definition.Output, response)
+	if err != nil {
+		return nil, fmt.Errorf("invalid result (%w)", err)
+	}
+	return result, nil
+}
+
+// decodeDefinition returns the function definition in the list of ListFunctions.
+func decodeDefinition(value *structpb.Value) (functions.RemoteFunctionDefinition, error) {
+	fields := value.GetStructValue().GetFields()
+	if fields == nil {
+		return functions.RemoteFunctionDefinition{}, fmt.Errorf("expected a struct")
+	}
+	definition := functions.RemoteFunctionDefinition{
+		Name:        fields["name"].GetStringValue(),
+		Description: fields["description"].GetStringValue(),
+		Pure:        fields["pure"].GetBoolValue(),
+	}
+	if definition.Name == "" {
+		return definition, fmt.Errorf("the name is missing")
+	}
+	parameters, output, err := decodeSignature(fields["signature"].AsInterface())
+	if err != nil {
+		return definition, fmt.Errorf("invalid signature of function %q (%w)", definition.Name, err)
+	}
+	definition.Parameters = parameters
+	definition.Output = output
+	return definition, nil
+}
@@ -0,0 +1,24 @@
+module go.flow.arcalot.io/expressions/grpcfunctions
+
+go 1.23.0
+
+toolchain go1.23.5
+
+require (
+	go.arcalot.io/assert v1.8.0
+	go.flow.arcalot.io/expressions v0.0.0-00010101000000-000000000000
+	go.flow.arcalot.io/pluginsdk v0.14.2
+	google.golang.org/grpc v1.65.0
+	google.golang.org/protobuf v1.34.1
+)
+
+require (
+	golang.org/x/net v0.25.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.15.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240528184218-531527333157 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+// The adapter is developed together with the expressions module, so it builds against the code in this repository.
+replace go.flow.arcalot.io/expressions => ../
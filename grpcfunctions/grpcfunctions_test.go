@@ -0,0 +1,163 @@
+package grpcfunctions_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/functions"
+	"go.flow.arcalot.io/expressions/grpcfunctions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newTestFunctions(t *testing.T) map[string]schema.CallableFunction {
+	greet, err := schema.NewCallableFunction(
+		"greet",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		schema.NewDisplayValue(schema.PointerTo("greet"), schema.PointerTo("Greets the name."), nil),
+		func(name string) (string, error) {
+			if name == "" {
+				return "", fmt.Errorf("empty name")
+			}
+			return "hello " + name, nil
+		},
+	)
+	assert.NoError(t, err)
+	sum, err := schema.NewCallableFunction(
+		"sum",
+		[]schema.Type{schema.NewListSchema(schema.NewIntSchema(nil, nil, nil), nil, nil)},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func(numbers []int64) int64 {
+			var result int64
+			for _, number := range numbers {
+				result += number
+			}
+			return result
+		},
+	)
+	assert.NoError(t, err)
+	crash, err := schema.NewCallableFunction(
+		"crash",
+		[]schema.Type{},
+		schema.NewIntSchema(nil, nil, nil),
+		false,
+		nil,
+		func() int64 {
+			panic("crashed")
+		},
+	)
+	assert.NoError(t, err)
+	return map[string]schema.CallableFunction{
+		"greet": expressions.NewPureFunction(greet),
+		"sum":   sum,
+		"crash": crash,
+	}
+}
+
+// newTestClient serves the functions over an in-memory connection, and returns a client connected to it.
+func newTestClient(t *testing.T, served map[string]schema.CallableFunction) functions.RemoteFunctionClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	assert.NoError(t, grpcfunctions.RegisterServer(server, served))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	connection, err := grpc.NewClient(
+		"passthrough:///functions",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = connection.Close()
+	})
+	return grpcfunctions.NewClient(connection)
+}
+
+func TestRemoteFunctions(t *testing.T) {
+	remoteFunctions, err := functions.NewRemoteFunctions(newTestClient(t, newTestFunctions(t)))
+	assert.NoError(t, err)
+	assert.Equals(t, len(remoteFunctions), 3)
+	registry := functions.NewFunctionRegistry()
+	assert.NoError(t, registry.Register("org", remoteFunctions))
+
+	expr, err := expressions.New(`org.greet("world")`)
+	assert.NoError(t, err)
+	resultType, err := expr.Type(nil, registry.Functions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, resultType.TypeID(), schema.TypeIDString)
+	result, err := expr.Evaluate(nil, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("hello world"))
+
+	description := remoteFunctions["greet"].Display().Description()
+	assert.NotNil(t, description)
+	assert.Equals(t, *description, "Greets the name.")
+	pure, isPure := remoteFunctions["greet"].(expressions.PureFunction)
+	assert.Equals(t, isPure, true)
+	assert.Equals(t, pure.Pure(), true)
+	_, isPure = remoteFunctions["sum"].(expressions.PureFunction)
+	assert.Equals(t, isPure, false)
+
+	// Integers that a double cannot hold exactly keep their value.
+	expr, err = expressions.New(`org.sum($.numbers)`)
+	assert.NoError(t, err)
+	data := map[string]any{"numbers": []int64{1, 2, 9007199254740993}}
+	result, err = expr.Evaluate(data, registry.CallableFunctions(), nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(9007199254740996)))
+}
+
+func TestRemoteFunctions_Errors(t *testing.T) {
+	remoteFunctions, err := functions.NewRemoteFunctions(newTestClient(t, newTestFunctions(t)))
+	assert.NoError(t, err)
+
+	expr, err := expressions.New(`greet("")`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, remoteFunctions, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "empty name")
+
+	// A panic of a function is returned as an error, and does not crash the server.
+	expr, err = expressions.New(`crash()`)
+	assert.NoError(t, err)
+	_, err = expr.Evaluate(nil, remoteFunctions, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "panicked: crashed")
+	expr, err = expressions.New(`greet("again")`)
+	assert.NoError(t, err)
+	result, err := expr.Evaluate(nil, remoteFunctions, nil)
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("hello again"))
+}
+
+func TestRegisterServer_DynamicOutput(t *testing.T) {
+	identity, err := schema.NewDynamicCallableFunction(
+		"identity",
+		[]schema.Type{schema.NewAnySchema()},
+		nil,
+		func(value any) (any, error) {
+			return value, nil
+		},
+		func(inputTypes []schema.Type) (schema.Type, error) {
+			return inputTypes[0], nil
+		},
+	)
+	assert.NoError(t, err)
+	err = grpcfunctions.RegisterServer(grpc.NewServer(), map[string]schema.CallableFunction{"identity": identity})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "identity")
+}
@@ -0,0 +1,180 @@
+package grpcfunctions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// RegisterServer registers the function service with the functions on the server. The functions are called by their
+// names in the map. Functions with an output type that depends on the argument types cannot be described to the
+// clients, so they are rejected. Functions that implement expressions.PureFunction are described as pure.
+func RegisterServer(server grpc.ServiceRegistrar, functions map[string]schema.CallableFunction) error {
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	// Sort the names, so the clients get the definitions in the same order each time.
+	sort.Strings(names)
+	service := &functionService{
+		functions:   functions,
+		definitions: &structpb.ListValue{Values: make([]*structpb.Value, len(names))},
+	}
+	for i, name := range names {
+		definition, err := encodeDefinition(name, functions[name])
+		if err != nil {
+			return expressions.Errorf("cannot serve the function %q (%w)", name, err)
+		}
+		service.definitions.Values[i] = definition
+	}
+	server.RegisterService(&serviceDescription, service)
+	return nil
+}
+
+// encodeDefinition returns the definition of the function in the list of ListFunctions.
+func encodeDefinition(name string, function schema.CallableFunction) (*structpb.Value, error) {
+	functionSchema, err := function.ToFunctionSchema()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := encodeSignature(functionSchema.Parameters(), functionSchema.OutputValue)
+	if err != nil {
+		return nil, err
+	}
+	plainSignature, err := plainValue(signature)
+	if err != nil {
+		return nil, err
+	}
+	var description string
+	if display := function.Display(); display != nil && display.Description() != nil {
+		description = *display.Description()
+	}
+	pure, declaresPurity := function.(expressions.PureFunction)
+	return structpb.NewValue(map[string]any{
+		"name":        name,
+		"description": description,
+		"pure":        declaresPurity && pure.Pure(),
+		"signature":   plainSignature,
+	})
+}
+
+// functionService serves the functions. It implements functionServiceServer.
+type functionService struct {
+	functions   map[string]schema.CallableFunction
+	definitions *structpb.ListValue
+}
+
+// functionServiceServer is the interface of the handler of the service, which gRPC checks when it is registered.
+type functionServiceServer interface {
+	listFunctions(ctx context.Context, request *emptypb.Empty) (*structpb.ListValue, error)
+	call(ctx context.Context, request *structpb.Struct) (*structpb.Value, error)
+}
+
+func (s *functionService) listFunctions(_ context.Context, _ *emptypb.Empty) (*structpb.ListValue, error) {
+	return s.definitions, nil
+}
+
+func (s *functionService) call(_ context.Context, request *structpb.Struct) (*structpb.Value, error) {
+	name := request.GetFields()["name"].GetStringValue()
+	function, found := s.functions[name]
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "unknown function %q", name)
+	}
+	encodedArguments := request.GetFields()["arguments"].GetListValue().GetValues()
+	parameters := function.Parameters()
+	if len(encodedArguments) != len(parameters) {
+		return nil, status.Errorf(codes.InvalidArgument, "the function %q expects %d arguments, got %d",
+			name, len(parameters), len(encodedArguments))
+	}
+	arguments := make([]any, len(encodedArguments))
+	for i, encodedArgument := range encodedArguments {
+		argument, err := decodeValue(parameters[i], encodedArgument)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid argument %d of the function %q (%v)", i, name, err)
+		}
+		arguments[i] = argument
+	}
+	result, err := callFunction(function, arguments)
+	if err != nil {
+		return nil, status.Error(codes.Unknown, err.Error())
+	}
+	output, _, err := function.Output(parameters)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if output == nil {
+		return structpb.NewNullValue(), nil
+	}
+	encodedResult, err := encodeValue(output, result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot return the result of the function %q (%v)", name, err)
+	}
+	return encodedResult, nil
+}
+
+// callFunction calls the function, and returns a panic of the function as an error, so it cannot crash the server.
+func callFunction(function schema.CallableFunction, arguments []any) (result any, err error) {
+	defer func() {
+		if value := recover(); value != nil {
+			err = fmt.Errorf("the function %q panicked: %v", function.ID(), value)
+		}
+	}()
+	return function.Call(arguments)
+}
+
+// serviceDescription describes the service in functions.proto to gRPC, like the code generated by protoc would.
+var serviceDescription = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*functionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "ListFunctions", Handler: listFunctionsHandler},
+		{MethodName: "Call", Handler: callHandler},
+	},
+	Metadata: "functions.proto",
+}
+
+func listFunctionsHandler(
+	server any,
+	ctx context.Context,
+	decode func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	request := &emptypb.Empty{}
+	if err := decode(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return server.(functionServiceServer).listFunctions(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{Server: server, FullMethod: listFunctionsMethod}
+	return interceptor(ctx, request, info, func(ctx context.Context, request any) (any, error) {
+		return server.(functionServiceServer).listFunctions(ctx, request.(*emptypb.Empty))
+	})
+}
+
+func callHandler(
+	server any,
+	ctx context.Context,
+	decode func(any) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (any, error) {
+	request := &structpb.Struct{}
+	if err := decode(request); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return server.(functionServiceServer).call(ctx, request)
+	}
+	info := &grpc.UnaryServerInfo{Server: server, FullMethod: callMethod}
+	return interceptor(ctx, request, info, func(ctx context.Context, request any) (any, error) {
+		return server.(functionServiceServer).call(ctx, request.(*structpb.Struct))
+	})
+}
@@ -0,0 +1,169 @@
+// Package grpcfunctions provides functions over gRPC, so engines can offer organization-wide custom functions
+// without recompiling them. NewClient returns the transport for functions.NewRemoteFunctions, and RegisterServer
+// serves functions written in Go to such clients:
+//
+//	connection, err := grpc.NewClient(address, grpc.WithTransportCredentials(credentials))
+//	remoteFunctions, err := functions.NewRemoteFunctions(grpcfunctions.NewClient(connection))
+//	err = registry.Register("custom", remoteFunctions)
+//
+// The service is described in functions.proto. Its messages are the well-known protobuf types, so it can be
+// implemented in any language without generated code from this package. The values are passed as
+// google.protobuf.Value in the serialized form of the plugin SDK, like in JSON, and the types of the functions as a
+// serialized scope schema.
+//
+// The package is a separate module, so the expressions module does not depend on gRPC.
+package grpcfunctions
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	serviceName         = "arcaflow.expressions.v1.FunctionService"
+	listFunctionsMethod = "/" + serviceName + "/ListFunctions"
+	callMethod          = "/" + serviceName + "/Call"
+)
+
+// signatureObjectID is the ID of the root object of the scope that describes the types of a function. The object
+// has a property for each parameter, named by parameterName, and the output property if the function has a result.
+const signatureObjectID = "signature"
+
+const outputProperty = "output"
+
+// parameterName returns the name of the property of the signature object that holds the type of the parameter.
+func parameterName(index int) string {
+	return "parameter_" + strconv.Itoa(index)
+}
+
+// encodeSignature returns the serialized scope that describes the parameter and output types.
+func encodeSignature(parameters []schema.Type, output schema.Type) (any, error) {
+	properties := make(map[string]*schema.PropertySchema, len(parameters)+1)
+	for i, parameter := range parameters {
+		properties[parameterName(i)] = schema.NewPropertySchema(parameter, nil, true, nil, nil, nil, nil, nil)
+	}
+	if output != nil {
+		properties[outputProperty] = schema.NewPropertySchema(output, nil, true, nil, nil, nil, nil, nil)
+	}
+	return schema.DescribeScope().Serialize(
+		schema.NewScopeSchema(schema.NewObjectSchema(signatureObjectID, properties)),
+	)
+}
+
+// decodeSignature returns the parameter and output types described by the serialized scope. The output is nil if
+// the function has no result.
+func decodeSignature(data any) ([]schema.Type, schema.Type, error) {
+	scope, err := schema.UnserializeScope(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	properties := scope.Properties()
+	var output schema.Type
+	if property, hasOutput := properties[outputProperty]; hasOutput {
+		output = property.Type()
+	}
+	parameters := make([]schema.Type, 0, len(properties))
+	for i := 0; ; i++ {
+		property, found := properties[parameterName(i)]
+		if !found {
+			break
+		}
+		parameters = append(parameters, property.Type())
+	}
+	expectedProperties := len(parameters)
+	if output != nil {
+		expectedProperties++
+	}
+	if len(properties) != expectedProperties {
+		return nil, nil, fmt.Errorf("the signature has properties other than the numbered parameters and the output")
+	}
+	return parameters, output, nil
+}
+
+// maxSafeInteger is the largest integer that a float64, which google.protobuf.Value stores numbers as, holds exactly.
+const maxSafeInteger = 1 << 53
+
+// encodeValue serializes the value with the type, and converts it to a google.protobuf.Value.
+func encodeValue(valueType schema.Type, value any) (*structpb.Value, error) {
+	serialized, err := valueType.Serialize(value)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := plainValue(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return structpb.NewValue(plain)
+}
+
+// decodeValue unserializes the google.protobuf.Value with the type.
+func decodeValue(valueType schema.Type, value *structpb.Value) (any, error) {
+	return valueType.Unserialize(value.AsInterface())
+}
+
+// plainValue converts the serialized data to the types that structpb supports. Serialized maps have keys of any type,
+// so their keys are converted to strings, with integers in decimal like in JSON. Integers that a float64 cannot hold
+// exactly are written as decimal strings, like in the JSON mapping of protobuf, which the SDK unserializes to
+// integers.
+func plainValue(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Map:
+		result := make(map[string]any, reflected.Len())
+		entries := reflected.MapRange()
+		for entries.Next() {
+			key := entries.Key()
+			if key.Kind() == reflect.Interface {
+				key = key.Elem()
+			}
+			var name string
+			switch {
+			case key.Kind() == reflect.String:
+				name = key.String()
+			case key.CanInt():
+				name = strconv.FormatInt(key.Int(), 10)
+			default:
+				return nil, fmt.Errorf("unsupported map key type %s", key.Type())
+			}
+			item, err := plainValue(entries.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			result[name] = item
+		}
+		return result, nil
+	case reflect.Slice, reflect.Array:
+		if reflected.Type().Elem().Kind() == reflect.Uint8 {
+			// structpb writes bytes as a base64 string.
+			return value, nil
+		}
+		result := make([]any, reflected.Len())
+		for i := range result {
+			item, err := plainValue(reflected.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			result[i] = item
+		}
+		return result, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if integer := reflected.Int(); integer > maxSafeInteger || integer < -maxSafeInteger {
+			return strconv.FormatInt(integer, 10), nil
+		}
+		return value, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if integer := reflected.Uint(); integer > maxSafeInteger {
+			return strconv.FormatUint(integer, 10), nil
+		}
+		return value, nil
+	default:
+		return value, nil
+	}
+}
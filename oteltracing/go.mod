@@ -0,0 +1,18 @@
+module go.flow.arcalot.io/expressions/oteltracing
+
+go 1.23.0
+
+toolchain go1.23.5
+
+require (
+	go.arcalot.io/assert v1.8.0
+	go.flow.arcalot.io/expressions v0.0.0-00010101000000-000000000000
+	go.flow.arcalot.io/pluginsdk v0.14.2
+	go.opentelemetry.io/otel v1.7.0
+	go.opentelemetry.io/otel/trace v1.7.0
+)
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+// The adapter is developed together with the expressions module, so it builds against the code in this repository.
+replace go.flow.arcalot.io/expressions => ../
@@ -0,0 +1,62 @@
+// Package oteltracing adapts OpenTelemetry tracers to the Tracer interface of the expressions package, so the
+// evaluations of expressions and their function calls show up in the traces of an engine:
+//
+//	expr, err := expressions.NewWithOptions(expression, expressions.Options{
+//	    Tracer: oteltracing.NewTracer(otel.Tracer("workflow-engine")),
+//	})
+//
+// The spans are children of the span in the context passed to EvaluateWithContext. The package is a separate module,
+// so the expressions module does not depend on OpenTelemetry.
+package oteltracing
+
+import (
+	"context"
+	"sort"
+
+	"go.flow.arcalot.io/expressions"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// NewTracer returns a tracer that creates the spans of the expressions with the OpenTelemetry tracer. The attributes
+// of the spans, such as the expression and the name of the called function, are set as string attributes. Spans
+// that end with an error record the error and have the error status.
+func NewTracer(tracer trace.Tracer) expressions.Tracer {
+	return otelTracer{tracer: tracer}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) StartSpan(
+	ctx context.Context,
+	name string,
+	attributes map[string]string,
+) (context.Context, expressions.Span) {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	// Sort the keys, so the attributes are in the same order for each span.
+	sort.Strings(keys)
+	keyValues := make([]attribute.KeyValue, len(keys))
+	for i, key := range keys {
+		keyValues[i] = attribute.String(key, attributes[key])
+	}
+	ctx, span := t.tracer.Start(ctx, name, trace.WithAttributes(keyValues...))
+	return ctx, otelSpan{span: span}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
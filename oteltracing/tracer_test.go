@@ -0,0 +1,119 @@
+package oteltracing_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/expressions/oteltracing"
+	"go.flow.arcalot.io/pluginsdk/schema"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingTracer records the spans it starts. The spans embed a no-op span for the methods that are not recorded.
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	trace.Span
+	name       string
+	parent     string
+	attributes []attribute.KeyValue
+	errors     []error
+	status     codes.Code
+	ended      bool
+}
+
+func (t *recordingTracer) Start(
+	ctx context.Context,
+	name string,
+	options ...trace.SpanStartOption,
+) (context.Context, trace.Span) {
+	config := trace.NewSpanStartConfig(options...)
+	span := &recordingSpan{
+		Span:       trace.SpanFromContext(context.Background()),
+		name:       name,
+		attributes: config.Attributes(),
+	}
+	if parent, isRecording := trace.SpanFromContext(ctx).(*recordingSpan); isRecording {
+		span.parent = parent.name
+	}
+	t.spans = append(t.spans, span)
+	return trace.ContextWithSpan(ctx, span), span
+}
+
+func (s *recordingSpan) RecordError(err error, _ ...trace.EventOption) {
+	s.errors = append(s.errors, err)
+}
+
+func (s *recordingSpan) SetStatus(code codes.Code, _ string) {
+	s.status = code
+}
+
+func (s *recordingSpan) End(_ ...trace.SpanEndOption) {
+	s.ended = true
+}
+
+func TestNewTracer(t *testing.T) {
+	failing, err := schema.NewCallableFunction(
+		"failing",
+		[]schema.Type{schema.NewStringSchema(nil, nil, nil)},
+		schema.NewStringSchema(nil, nil, nil),
+		true,
+		nil,
+		func(value string) (string, error) {
+			if value == "fail" {
+				return "", fmt.Errorf("failed")
+			}
+			return value, nil
+		},
+	)
+	assert.NoError(t, err)
+	functions := map[string]schema.CallableFunction{"failing": failing}
+	recorder := &recordingTracer{}
+	expr, err := expressions.NewWithOptions(`failing($.value)`, expressions.Options{
+		Tracer: oteltracing.NewTracer(recorder),
+	})
+	assert.NoError(t, err)
+
+	ctx, engineSpan := recorder.Start(context.Background(), "engine")
+	result, err := expr.EvaluateWithContext(ctx, map[string]any{"value": "ok"}, functions, nil)
+	engineSpan.End()
+	assert.NoError(t, err)
+	assert.Equals(t, result, any("ok"))
+	assert.Equals(t, len(recorder.spans), 3)
+	evaluateSpan := recorder.spans[1]
+	assert.Equals(t, evaluateSpan.name, expressions.TraceSpanEvaluate)
+	assert.Equals(t, evaluateSpan.parent, "engine")
+	assert.Equals(t, evaluateSpan.attributes, []attribute.KeyValue{
+		attribute.String(expressions.TraceAttributeExpression, `failing($.value)`),
+	})
+	callSpan := recorder.spans[2]
+	assert.Equals(t, callSpan.name, expressions.TraceSpanFunctionCall)
+	assert.Equals(t, callSpan.parent, expressions.TraceSpanEvaluate)
+	assert.Equals(t, callSpan.attributes, []attribute.KeyValue{
+		attribute.String(expressions.TraceAttributeExpression, `failing($.value)`),
+		attribute.String(expressions.TraceAttributeFunction, "failing"),
+	})
+	for _, span := range recorder.spans {
+		assert.Equals(t, span.ended, true)
+		assert.Equals(t, len(span.errors), 0)
+		assert.Equals(t, span.status, codes.Unset)
+	}
+
+	// The spans of failed evaluations and calls record the error.
+	recorder.spans = nil
+	_, err = expr.Evaluate(map[string]any{"value": "fail"}, functions, nil)
+	assert.Error(t, err)
+	assert.Equals(t, len(recorder.spans), 2)
+	for _, span := range recorder.spans {
+		assert.Equals(t, span.ended, true)
+		assert.Equals(t, len(span.errors), 1)
+		assert.Equals(t, span.status, codes.Error)
+	}
+}
@@ -2,7 +2,11 @@ package expressions
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
+
+	"go.flow.arcalot.io/pluginsdk/schema"
 )
 
 // Path describes the path needed to take to reach an item. Items can either be strings or integers.
@@ -33,12 +37,112 @@ const (
 
 // String returns the dot-concatenated string version of the path as an Arcaflow-expression.
 func (p Path) String() string {
-	items := make([]string, len(p))
+	var result strings.Builder
+	// Most path items are short field names or indexes.
+	result.Grow(len(p) * 8)
 	for i, item := range p {
-		items[i] = fmt.Sprintf("%v", item)
+		if i > 0 {
+			result.WriteByte('.')
+		}
+		// Paths almost only contain strings and integers, which are formatted without fmt.
+		switch typedItem := item.(type) {
+		case string:
+			result.WriteString(typedItem)
+		case int64:
+			result.WriteString(strconv.FormatInt(typedItem, 10))
+		case int:
+			result.WriteString(strconv.Itoa(typedItem))
+		default:
+			_, _ = fmt.Fprintf(&result, "%v", typedItem)
+		}
+	}
+	return result.String()
+}
+
+// ToJSONPath returns the path as a JSONPath (RFC 9535) query, such as `$.foo.bar[0]`, for systems that consume
+// JSONPath. Integers are written as indexes, field names that are valid identifiers with a dot, and other keys in
+// quoted brackets, such as `$['with space']`. The leading $ item of paths that start at the root data is the root of
+// the JSONPath. JSONPath has no function roots, so the items of other paths are written as if they started at the
+// root; exclude function paths when unpacking the dependencies to avoid them.
+func (p Path) ToJSONPath() string {
+	var result strings.Builder
+	result.Grow(len(p) * 8)
+	result.WriteByte('$')
+	items := p
+	if len(items) > 0 && items[0] == "$" {
+		items = items[1:]
+	}
+	for _, item := range items {
+		switch typedItem := item.(type) {
+		case int64:
+			result.WriteString("[" + strconv.FormatInt(typedItem, 10) + "]")
+		case int:
+			result.WriteString("[" + strconv.Itoa(typedItem) + "]")
+		case string:
+			if jsonPathNamePattern.MatchString(typedItem) {
+				result.WriteString("." + typedItem)
+			} else {
+				result.WriteString("[" + quoteJSONPathName(typedItem) + "]")
+			}
+		default:
+			result.WriteString("[" + quoteJSONPathName(fmt.Sprintf("%v", typedItem)) + "]")
+		}
+	}
+	return result.String()
+}
+
+// jsonPathNamePattern matches the names that can be written with a dot in JSONPath.
+var jsonPathNamePattern = regexp.MustCompile(`^[A-Za-z_\x{80}-\x{10FFFF}][A-Za-z0-9_\x{80}-\x{10FFFF}]*$`)
+
+// quoteJSONPathName returns the name in single quotes, escaped as in a JSONPath string literal.
+func quoteJSONPathName(name string) string {
+	var result strings.Builder
+	result.Grow(len(name) + 2)
+	result.WriteByte('\'')
+	for _, c := range name {
+		switch c {
+		case '\'':
+			result.WriteString(`\'`)
+		case '\\':
+			result.WriteString(`\\`)
+		case '\b':
+			result.WriteString(`\b`)
+		case '\f':
+			result.WriteString(`\f`)
+		case '\n':
+			result.WriteString(`\n`)
+		case '\r':
+			result.WriteString(`\r`)
+		case '\t':
+			result.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				_, _ = fmt.Fprintf(&result, `\u%04x`, c)
+			} else {
+				result.WriteRune(c)
+			}
+		}
 	}
+	result.WriteByte('\'')
+	return result.String()
+}
 
-	return strings.Join(items, ".")
+// TypedPath is a Path together with the schema type resolved for the value at the end of the path.
+type TypedPath struct {
+	Path Path
+	// Type is the type of the value the path points to. It may be nil if the path tree was constructed without
+	// type information.
+	Type schema.Type
+	// Optional is true if the expression can be evaluated without the value the path points to. This is the case
+	// when a field on the path has a default value in the schema, and the expression was created with
+	// Options.SchemaDefaults, since an AccessPlan evaluates the missing field to its default. A scheduler can use this
+	// to avoid waiting for values that are not needed. Paths unpacked with PathTree.UnpackTyped are never optional.
+	Optional bool
+}
+
+// String returns the string version of the path, without the type.
+func (p TypedPath) String() string {
+	return p.Path.String()
 }
 
 // PathTree holds multiple paths in a branching fashion.
@@ -47,37 +151,134 @@ type PathTree struct {
 	PathItem any
 	NodeType PathNodeType
 	Subtrees []*PathTree
+	// ResolvedType is the schema type of the value at this part of the tree, if known.
+	ResolvedType schema.Type
 }
 
-// Unpack unpacks the path tree into a list of paths.
+// Unpack unpacks the path tree into a list of paths. It panics if the tree contains a node of an unknown type, which
+// trees returned by this package never do. Use TryUnpack for trees built elsewhere.
+//
+// Unlike the rest of the API, Unpack panics instead of returning an error, because adding an error result would break
+// the callers of this exported method, which predates the error handling of this package.
 func (p PathTree) Unpack(requirements UnpackRequirements) []Path {
-	if requirements.shouldStop(p.NodeType) {
-		return []Path{}
+	result, err := p.TryUnpack(requirements)
+	if err != nil {
+		panic(err)
 	}
-	var result []Path
+	return result
+}
+
+// TryUnpack is the same as Unpack, but returns an error instead of panicking if the tree contains a node of an unknown
+// type.
+func (p PathTree) TryUnpack(requirements UnpackRequirements) ([]Path, error) {
+	typedPaths, err := p.UnpackTyped(requirements)
+	if err != nil || typedPaths == nil {
+		return nil, err
+	}
+	result := make([]Path, len(typedPaths))
+	for i, typedPath := range typedPaths {
+		result[i] = typedPath.Path
+	}
+	return result, nil
+}
+
+// UnpackTyped unpacks the path tree into a list of paths, each with the type resolved at the leaf of the path.
+// Returns an error if the tree contains a node of an unknown type.
+func (p PathTree) UnpackTyped(requirements UnpackRequirements) ([]TypedPath, error) {
+	return p.unpackTypedPaths(requirements, false)
+}
 
+// unpackTypedPaths is the same as UnpackTyped, but if schemaDefaults is set, the paths through fields with a default
+// value in the schema are marked as optional.
+func (p PathTree) unpackTypedPaths(
+	requirements UnpackRequirements,
+	schemaDefaults bool,
+) (result []TypedPath, err error) {
+	defer recoverInternalError("unpacking the path tree", &err)
+	stop, err := requirements.shouldStop(p.NodeType)
+	if err != nil {
+		return nil, err
+	}
+	if stop {
+		return []TypedPath{}, nil
+	}
+	unpacker := pathUnpacker{requirements: &requirements, schemaDefaults: schemaDefaults, result: &result}
+	if err := unpacker.unpack(&p, make([]any, 0, p.depth()), false); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// pathUnpacker collects the typed paths of a path tree.
+type pathUnpacker struct {
+	requirements   *UnpackRequirements
+	schemaDefaults bool
+	result         *[]TypedPath
+}
+
+// unpack appends the paths of the tree to the result. The prefix holds the path items of the parent nodes, and is
+// shared by all paths of the tree, so each path only needs to allocate its own copy once it is complete. The optional
+// parameter indicates that a parent node is a field with a default value.
+func (u pathUnpacker) unpack(p *PathTree, prefix []any, optional bool) error {
+	skipped := u.requirements.shouldSkip(p.NodeType)
+	// First, this path item, if not skipping it
+	if !skipped {
+		prefix = append(prefix, p.PathItem)
+	}
+	// Second, add the subtrees
+	resultsBefore := len(*u.result)
 	for _, subtree := range p.Subtrees {
-		for _, subtreeResult := range subtree.Unpack(requirements) {
-			currentPathNodes := make([]any, 0)
-			// First, this path item, if not skipping it
-			if !requirements.shouldSkip(p.NodeType) {
-				currentPathNodes = append(currentPathNodes, p.PathItem)
-			}
-			// Second, add the subtrees
-			currentPathNodes = append(currentPathNodes, subtreeResult...)
-			result = append(result, currentPathNodes)
+		stop, err := u.requirements.shouldStop(subtree.NodeType)
+		if err != nil {
+			return err
+		}
+		if stop {
+			continue
+		}
+		subtreeOptional := optional || (u.schemaDefaults && p.hasDefault(subtree))
+		if err := u.unpack(subtree, prefix, subtreeOptional); err != nil {
+			return err
 		}
 	}
 
-	// An empty result happens when either there are zero subtrees, or the
+	// No paths are added when either there are zero subtrees, or the
 	// subtrees are excluded based on the current requirements.
-	// Return the current path if the current path node should be an included
+	// Add the current path if the current path node should be an included
 	// leaf node. Skipped nodes should not.
-	if len(result) == 0 && !requirements.shouldSkip(p.NodeType) {
-		return []Path{[]any{p.PathItem}}
+	if len(*u.result) == resultsBefore && !skipped {
+		path := make(Path, len(prefix))
+		copy(path, prefix)
+		*u.result = append(*u.result, TypedPath{Path: path, Type: p.ResolvedType, Optional: optional})
 	}
+	return nil
+}
 
-	return result
+// hasDefault returns true if the subtree accesses a field of the object at this node that has a default value in the
+// schema.
+func (p *PathTree) hasDefault(subtree *PathTree) bool {
+	if subtree.NodeType != AccessNode || p.ResolvedType == nil {
+		return false
+	}
+	switch p.ResolvedType.TypeID() {
+	case schema.TypeIDScope, schema.TypeIDRef, schema.TypeIDObject:
+	default:
+		return false
+	}
+	field, isField := subtree.PathItem.(string)
+	if !isField {
+		return false
+	}
+	property, found := p.ResolvedType.(schema.Object).Properties()[field]
+	return found && property.Default() != nil
+}
+
+// depth returns the number of nodes in the longest path of the tree.
+func (p *PathTree) depth() int {
+	maxSubtreeDepth := 0
+	for _, subtree := range p.Subtrees {
+		maxSubtreeDepth = max(maxSubtreeDepth, subtree.depth())
+	}
+	return maxSubtreeDepth + 1
 }
 
 type UnpackRequirements struct {
@@ -87,18 +288,18 @@ type UnpackRequirements struct {
 	IncludeKeys              bool // Whether to include the keys in the path. // Example, the 0 in `$ -> list -> 0 -> a`
 }
 
-func (r *UnpackRequirements) shouldStop(nodeType PathNodeType) bool {
+func (r *UnpackRequirements) shouldStop(nodeType PathNodeType) (bool, error) {
 	switch nodeType {
 	case DataRootNode:
-		return r.ExcludeDataRootPaths
+		return r.ExcludeDataRootPaths, nil
 	case FunctionNode:
-		return r.ExcludeFunctionRootPaths
+		return r.ExcludeFunctionRootPaths, nil
 	case PastTerminalNode:
-		return r.StopAtTerminals
+		return r.StopAtTerminals, nil
 	case AccessNode, KeyNode:
-		return false
+		return false, nil
 	default:
-		panic(fmt.Errorf("node type %q missing in shouldStop in path", nodeType))
+		return false, Errorf("unknown path node type %q", nodeType)
 	}
 }
 
@@ -0,0 +1,169 @@
+package expressions
+
+import "reflect"
+
+// GetByPath returns the value at the path in the data, such as a path returned by Dependencies. The items of the path
+// are accessed like expressions access them: strings are field names and map keys, and integers are map keys and list
+// indexes, where negative indexes count from the end of the list. A leading $ item refers to the data itself. If the
+// data is a DataProvider, the value is requested from it with the full path.
+//
+// The errors have the same codes as the errors of evaluating the path as an expression, such as ErrorCodeUnknownField
+// if a map key does not exist.
+func GetByPath(data any, path Path) (any, error) {
+	if provider, isProvider := data.(DataProvider); isProvider {
+		if len(path) == 0 || path[0] != "$" {
+			path = append(Path{"$"}, path...)
+		}
+		return getProvidedValue(provider, path)
+	}
+	for i, item := range path {
+		if i == 0 && item == "$" {
+			continue
+		}
+		var err error
+		data, err = evaluateMapAccess(data, pathItemKey(item))
+		if err != nil {
+			return nil, newCodedError(ErrorCodeOf(err), "failed to get %s (%w)", path[:i+1], err)
+		}
+	}
+	return data, nil
+}
+
+// SetByPath stores the value at the path in the data, such as the result of an expression in the state of a workflow.
+// The items of the path are accessed like GetByPath accesses them, and a leading $ item refers to the data itself.
+// Missing map keys are created, and an index equal to the length of a list appends to it. Missing containers on the
+// way are created as map[string]any for string items and as []any for integer items.
+//
+// The data must be a map, a slice, or a pointer to either. Slices can only grow if they are held by a map, a slice, or
+// a pointer, so pass a pointer to a slice to append to it. The value is converted to the type of the items of the
+// container like Bind converts results. Values that cannot be stored fail with ErrorCodeTypeMismatch, and indexes past
+// the end of a list with ErrorCodeIndexOutOfRange.
+func SetByPath(data any, path Path, value any) error {
+	start := 0
+	if len(path) > 0 && path[0] == "$" {
+		start = 1
+	}
+	if len(path) == start {
+		return newCodedError(ErrorCodeTypeMismatch, "cannot replace the data itself, the path must have an item")
+	}
+	container := reflect.ValueOf(data)
+	if container.Kind() == reflect.Pointer && !container.IsNil() {
+		container = container.Elem()
+	}
+	result, err := setInContainer(container, path, start, value)
+	if err != nil {
+		return err
+	}
+	if result.Kind() == reflect.Slice && (result.Len() != container.Len() || result.Pointer() != container.Pointer()) {
+		if !container.CanSet() {
+			return newCodedError(ErrorCodeTypeMismatch, "cannot append to a slice that is not passed as a pointer")
+		}
+		container.Set(result)
+	}
+	return nil
+}
+
+// setInContainer stores the value at the rest of the path, starting at the item with the index, in the container. It
+// returns the container, which is a new slice value if a slice had to grow.
+func setInContainer(container reflect.Value, path Path, index int, value any) (reflect.Value, error) {
+	if container.Kind() == reflect.Interface {
+		container = container.Elem()
+	}
+	item := path[index]
+	key := reflect.ValueOf(pathItemKey(item))
+	switch container.Kind() {
+	case reflect.Map:
+		if container.IsNil() {
+			return container, setError(path, index, newCodedError(ErrorCodeTypeMismatch, "cannot set a key in a nil map"))
+		}
+		mapType := container.Type()
+		if !key.Type().ConvertibleTo(mapType.Key()) {
+			return container, setError(path, index, newCodedError(
+				ErrorCodeTypeMismatch, "cannot use a %s as a key of a %s", key.Type(), mapType))
+		}
+		key = key.Convert(mapType.Key())
+		child, err := setChild(container.MapIndex(key), mapType.Elem(), path, index, value)
+		if err != nil {
+			return container, err
+		}
+		container.SetMapIndex(key, child)
+		return container, nil
+	case reflect.Slice:
+		sliceIndex, isInt := key.Interface().(int64)
+		if !isInt {
+			return container, setError(path, index, newCodedError(
+				ErrorCodeTypeMismatch, "unsupported slice index type '%T', expected int64", item))
+		}
+		if sliceIndex == int64(container.Len()) {
+			container = reflect.Append(container, reflect.Zero(container.Type().Elem()))
+		}
+		resolvedIndex, err := resolveSliceIndex(sliceIndex, container.Len())
+		if err != nil {
+			return container, setError(path, index, err)
+		}
+		child, err := setChild(container.Index(resolvedIndex), container.Type().Elem(), path, index, value)
+		if err != nil {
+			return container, err
+		}
+		container.Index(resolvedIndex).Set(child)
+		return container, nil
+	default:
+		return container, setError(path, index, newCodedError(
+			ErrorCodeTypeMismatch, "cannot set %v in a %s", item, container.Kind()))
+	}
+}
+
+// setChild returns the new value of the item at the index of the path in its container, whose items have the item
+// type. The current value of the item is invalid if it does not exist.
+func setChild(current reflect.Value, itemType reflect.Type, path Path, index int, value any) (reflect.Value, error) {
+	if index == len(path)-1 {
+		result := reflect.New(itemType).Elem()
+		if err := bindValue(result, value); err != nil {
+			return result, setError(path, index, err)
+		}
+		return result, nil
+	}
+	if current.IsValid() && current.Kind() == reflect.Interface {
+		current = current.Elem()
+	}
+	if !current.IsValid() || ((current.Kind() == reflect.Map || current.Kind() == reflect.Slice) && current.IsNil()) {
+		var err error
+		current, err = newPathContainer(itemType, path, index+1)
+		if err != nil {
+			return current, err
+		}
+	}
+	return setInContainer(current, path, index+1, value)
+}
+
+// newPathContainer creates an empty container of the item type for the item at the index of the path.
+func newPathContainer(itemType reflect.Type, path Path, index int) (reflect.Value, error) {
+	switch itemType.Kind() {
+	case reflect.Map:
+		return reflect.MakeMap(itemType), nil
+	case reflect.Slice:
+		return reflect.MakeSlice(itemType, 0, 0), nil
+	case reflect.Interface:
+		if _, isString := path[index].(string); isString {
+			return reflect.ValueOf(map[string]any{}), nil
+		}
+		return reflect.ValueOf([]any{}), nil
+	default:
+		return reflect.Value{}, setError(path, index-1, newCodedError(
+			ErrorCodeTypeMismatch, "cannot create a container in a %s", itemType))
+	}
+}
+
+// setError wraps the error of setting the item at the index of the path.
+func setError(path Path, index int, err error) error {
+	return newCodedError(ErrorCodeOf(err), "failed to set %s (%w)", path[:index+1], err)
+}
+
+// pathItemKey converts the path item to the key used to access it in the data. Paths may hold int items, but
+// expressions use int64 for all integers.
+func pathItemKey(item any) any {
+	if intItem, isInt := item.(int); isInt {
+		return int64(intItem)
+	}
+	return item
+}
@@ -0,0 +1,91 @@
+package expressions_test
+
+import (
+	"testing"
+
+	"go.arcalot.io/assert"
+	"go.flow.arcalot.io/expressions"
+)
+
+func TestGetByPath(t *testing.T) {
+	data := map[string]any{
+		"foo": map[string]any{"int_list": []any{int64(1), int64(2)}},
+		"faz": map[int64]string{5: "five"},
+	}
+	testCases := map[string]struct {
+		path     expressions.Path
+		expected any
+	}{
+		"root":           {expressions.Path{"$"}, any(data)},
+		"field":          {expressions.Path{"$", "foo", "int_list"}, any([]any{int64(1), int64(2)})},
+		"index":          {expressions.Path{"$", "foo", "int_list", 1}, any(int64(2))},
+		"negative index": {expressions.Path{"foo", "int_list", int64(-2)}, any(int64(1))},
+		"map key":        {expressions.Path{"$", "faz", int64(5)}, any("five")},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			result, err := expressions.GetByPath(data, testCase.path)
+			assert.NoError(t, err)
+			assert.Equals(t, result, testCase.expected)
+		})
+	}
+
+	_, err := expressions.GetByPath(data, expressions.Path{"$", "foo", "missing", "x"})
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeUnknownField)
+	assert.Contains(t, err.Error(), "$.foo.missing")
+	_, err = expressions.GetByPath(data, expressions.Path{"$", "foo", "int_list", 2})
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeIndexOutOfRange)
+
+	// The paths of the dependencies can be looked up directly.
+	expr, err := expressions.New(`$.foo.int_list[0]`)
+	assert.NoError(t, err)
+	paths, err := expr.Dependencies(testScope, nil, nil, expressions.UnpackRequirements{IncludeKeys: true})
+	assert.NoError(t, err)
+	result, err := expressions.GetByPath(data, paths[0])
+	assert.NoError(t, err)
+	assert.Equals(t, result, any(int64(1)))
+
+	provider := &recordingProvider{data: data}
+	result, err = expressions.GetByPath(provider, expressions.Path{"foo"})
+	assert.NoError(t, err)
+	assert.Equals(t, result, data["foo"])
+	assert.Equals(t, provider.requested, []string{"$.foo"})
+}
+
+func TestSetByPath(t *testing.T) {
+	data := map[string]any{
+		"foo": map[string]any{"int_list": []any{int64(1), int64(2)}},
+	}
+	assert.NoError(t, expressions.SetByPath(data, expressions.Path{"$", "foo", "bar"}, "hello"))
+	assert.NoError(t, expressions.SetByPath(data, expressions.Path{"$", "foo", "int_list", -1}, int64(3)))
+	assert.NoError(t, expressions.SetByPath(data, expressions.Path{"foo", "int_list", int64(2)}, int64(4)))
+	assert.NoError(t, expressions.SetByPath(data, expressions.Path{"$", "steps", "a", "outputs", 0, "x"}, true))
+	assert.Equals(t, data, map[string]any{
+		"foo": map[string]any{"bar": "hello", "int_list": []any{int64(1), int64(3), int64(4)}},
+		"steps": map[string]any{
+			"a": map[string]any{"outputs": []any{map[string]any{"x": true}}},
+		},
+	})
+
+	// The results are converted to the types of the containers.
+	typed := map[string][]int{"a": {1}}
+	assert.NoError(t, expressions.SetByPath(typed, expressions.Path{"a", 1}, int64(2)))
+	assert.NoError(t, expressions.SetByPath(typed, expressions.Path{"b", 0}, int64(3)))
+	assert.Equals(t, typed, map[string][]int{"a": {1, 2}, "b": {3}})
+
+	list := []any{int64(1)}
+	assert.NoError(t, expressions.SetByPath(&list, expressions.Path{"$", 1}, int64(2)))
+	assert.Equals(t, list, []any{int64(1), int64(2)})
+	err := expressions.SetByPath(list, expressions.Path{"$", 2}, int64(3))
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+
+	err = expressions.SetByPath(data, expressions.Path{"$", "foo", "int_list", 5}, int64(1))
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeIndexOutOfRange)
+	assert.Contains(t, err.Error(), "$.foo.int_list.5")
+	err = expressions.SetByPath(data, expressions.Path{"$", "foo", "bar", "x"}, int64(1))
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+	err = expressions.SetByPath(typed, expressions.Path{"a", 0}, "not an int")
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+	err = expressions.SetByPath(data, expressions.Path{"$"}, int64(1))
+	assert.Equals(t, expressions.ErrorCodeOf(err), expressions.ErrorCodeTypeMismatch)
+}
@@ -1,12 +1,18 @@
 package expressions_test
 
 import (
+	"fmt"
 	"testing"
 
 	"go.arcalot.io/assert"
 	"go.flow.arcalot.io/expressions"
+	"go.flow.arcalot.io/pluginsdk/schema"
 )
 
+func typedPathStrExtractor(value expressions.TypedPath) string {
+	return value.String()
+}
+
 func pathStrExtractor(value expressions.Path) string {
 	return value.String()
 }
@@ -20,6 +26,7 @@ func TestPathTree_UnpackDataPaths(t *testing.T) {
 				"bar",
 				expressions.AccessNode,
 				nil,
+				nil,
 			},
 			{
 				"baz",
@@ -33,10 +40,13 @@ func TestPathTree_UnpackDataPaths(t *testing.T) {
 								"a",
 								expressions.AccessNode,
 								nil,
+								nil,
 							},
 						},
+						nil,
 					},
 				},
+				nil,
 			},
 		},
 	}
@@ -77,6 +87,7 @@ func TestPathTree_UnpackFuncPaths(t *testing.T) {
 				"foo",
 				expressions.AccessNode,
 				nil,
+				nil,
 			},
 		},
 	}
@@ -340,4 +351,124 @@ func TestPathTree_ErrorUnpackInvalidType(t *testing.T) {
 	assert.Panics(t, func() {
 		_ = pathTree.Unpack(defaultRequirements)
 	})
+	_, err := pathTree.TryUnpack(defaultRequirements)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown path node type "abc-invalid"`)
+}
+
+func TestPathTree_UnpackTyped(t *testing.T) {
+	pathTree := expressions.PathTree{
+		PathItem:     "$",
+		NodeType:     expressions.DataRootNode,
+		ResolvedType: schema.NewAnySchema(),
+		Subtrees: []*expressions.PathTree{
+			{
+				PathItem:     "list",
+				NodeType:     expressions.AccessNode,
+				ResolvedType: schema.NewListSchema(schema.NewStringSchema(nil, nil, nil), nil, nil),
+				Subtrees: []*expressions.PathTree{
+					{
+						PathItem:     0,
+						NodeType:     expressions.KeyNode,
+						ResolvedType: schema.NewStringSchema(nil, nil, nil),
+					},
+				},
+			},
+		},
+	}
+	typedPathsWithKeys, err := pathTree.UnpackTyped(expressions.UnpackRequirements{IncludeKeys: true})
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPathsWithKeys), 1)
+	assert.Equals(t, typedPathsWithKeys[0].String(), "$.list.0")
+	assert.Equals(t, typedPathsWithKeys[0].Type.TypeID(), schema.TypeIDString)
+
+	typedPathsWithoutKeys, err := pathTree.UnpackTyped(expressions.UnpackRequirements{IncludeKeys: false})
+	assert.NoError(t, err)
+	assert.Equals(t, len(typedPathsWithoutKeys), 1)
+	assert.Equals(t, typedPathsWithoutKeys[0].String(), "$.list")
+	assert.Equals(t, typedPathsWithoutKeys[0].Type.TypeID(), schema.TypeIDList)
+}
+
+// newDeepPathTree creates a path tree with the given depth, in which each node has the given number of subtrees.
+// Every other level is a key, so the requirements decide whether they are included.
+func newDeepPathTree(depth int, branches int) *expressions.PathTree {
+	tree := &expressions.PathTree{PathItem: "$", NodeType: expressions.DataRootNode}
+	level := []*expressions.PathTree{tree}
+	for i := 1; i < depth; i++ {
+		var nextLevel []*expressions.PathTree
+		for _, parent := range level {
+			for j := 0; j < branches; j++ {
+				subtree := &expressions.PathTree{PathItem: fmt.Sprintf("field_%d", j), NodeType: expressions.AccessNode}
+				if i%2 == 0 {
+					subtree = &expressions.PathTree{PathItem: int64(j), NodeType: expressions.KeyNode}
+				}
+				parent.Subtrees = append(parent.Subtrees, subtree)
+				nextLevel = append(nextLevel, subtree)
+			}
+		}
+		level = nextLevel
+	}
+	return tree
+}
+
+func TestPathTree_UnpackDeep(t *testing.T) {
+	pathTree := newDeepPathTree(4, 2)
+	withKeys := pathTree.Unpack(expressions.UnpackRequirements{IncludeKeys: true})
+	assert.Equals(t, len(withKeys), 8)
+	assert.Equals(t, withKeys[0].String(), "$.field_0.0.field_0")
+	assert.Equals(t, withKeys[7].String(), "$.field_1.1.field_1")
+	// Without keys, the paths through different keys are the same.
+	withoutKeys := pathTree.Unpack(expressions.UnpackRequirements{})
+	assert.Equals(t, len(withoutKeys), 8)
+	assert.Equals(t, withoutKeys[1].String(), "$.field_0.field_1")
+	// The paths must not share their items.
+	withKeys[0][1] = "changed"
+	assert.Equals(t, withKeys[1].String(), "$.field_0.0.field_1")
+}
+
+func BenchmarkPathTree_UnpackTyped(b *testing.B) {
+	pathTree := newDeepPathTree(8, 3)
+	requirements := expressions.UnpackRequirements{IncludeKeys: true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = pathTree.UnpackTyped(requirements)
+	}
+}
+
+func BenchmarkPath_String(b *testing.B) {
+	path := expressions.Path{"$"}
+	for i := 0; i < 10; i++ {
+		path = append(path, fmt.Sprintf("field_%d", i), int64(i))
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = path.String()
+	}
+}
+
+func TestPath_ToJSONPath(t *testing.T) {
+	testCases := map[string]struct {
+		path     expressions.Path
+		expected string
+	}{
+		"root":       {expressions.Path{"$"}, "$"},
+		"fields":     {expressions.Path{"$", "foo", "bar_2"}, "$.foo.bar_2"},
+		"indexes":    {expressions.Path{"$", "list", int64(0), 1}, "$.list[0][1]"},
+		"quoted":     {expressions.Path{"$", "with space", "0a", "é"}, `$['with space']['0a'].é`},
+		"escaped":    {expressions.Path{"$", "it's", "a\\b", "line\n", "\x01"}, `$['it\'s']['a\\b']['line\n']['\u0001']`},
+		"other keys": {expressions.Path{"$", "map", true}, "$.map['true']"},
+		"no root":    {expressions.Path{"foo", "bar"}, "$.foo.bar"},
+	}
+	for name, testCase := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equals(t, testCase.path.ToJSONPath(), testCase.expected)
+		})
+	}
+
+	expr, err := expressions.New(`$.faz["with space"]`)
+	assert.NoError(t, err)
+	paths, err := expr.Dependencies(testScope, nil, nil, expressions.UnpackRequirements{IncludeKeys: true})
+	assert.NoError(t, err)
+	assert.Equals(t, len(paths), 1)
+	assert.Equals(t, paths[0].ToJSONPath(), "$.faz['with space']")
 }